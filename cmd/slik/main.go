@@ -0,0 +1,76 @@
+// Downloads monthly credit reporting file for the credit bureau from running loans server
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	previousMonth := time.Now().AddDate(0, -1, 0).Format("2006-01")
+	server := flag.String("server", "http://localhost:8080", "URL of the loans server")
+	month := flag.String("month", previousMonth, "reported month formatted as YYYY-MM")
+	out := flag.String("out", "", "file where the credit report is written, SLIK_YYYYMM.txt when empty")
+	officer := flag.String("officer", os.Getenv("USER"), "officer downloading the report, sent in X-Officer-ID header")
+	flag.Parse()
+	reportedMonth, err := time.Parse("2006-01", *month)
+	if err != nil {
+		log.Fatalf("invalid month %s, use YYYY-MM", *month)
+	}
+	path := *out
+	if path == "" {
+		path = "SLIK_" + reportedMonth.Format("200601") + ".txt"
+	}
+	request, err := http.NewRequest("GET", *server+"/reports/slik?month="+*month, nil)
+	if err != nil {
+		log.Fatalf("downloading credit report: %v", err)
+	}
+	request.Header.Set("X-Officer-ID", *officer)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatalf("downloading credit report: %v", err)
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case 200:
+	case 422:
+		log.Fatalf("credit report has invalid records:\n%s", problems(response.Body))
+	default:
+		body, _ := io.ReadAll(response.Body)
+		log.Fatalf("downloading credit report: %s %s", response.Status, body)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("creating credit report file: %v", err)
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, response.Body); err != nil {
+		log.Fatalf("writing credit report file: %v", err)
+	}
+	log.Printf("credit report of %s written to %s", *month, path)
+}
+
+// problems formats missing or malformed fields of records one per line, so they can be fixed in client profiles
+func problems(body io.Reader) string {
+	var response struct {
+		Problems []struct {
+			KTPNumber string `json:"ktpNumber"`
+			LoanID    string `json:"loanId"`
+			Field     string `json:"field"`
+		} `json:"problems"`
+	}
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return err.Error()
+	}
+	var lines string
+	for _, problem := range response.Problems {
+		lines += fmt.Sprintf("client %s loan %s: %s is missing or malformed\n", problem.KTPNumber, problem.LoanID, problem.Field)
+	}
+	return lines
+}
@@ -0,0 +1,95 @@
+// Package job runs lms use cases in the background of the server
+package job
+
+import (
+	"log"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+)
+
+// EndOfDay accrues late charges and then runs dunning once per business day, so notices include the charges. Loan
+// applications which were not decided in time are expired and pending refunds are transferred again afterwards. Days
+// skipped over weekends are caught up by the next run.
+type EndOfDay struct {
+	lms lms.Lms
+	// at is the time of day of the run
+	at   time.Duration
+	now  func() time.Time
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewEndOfDay initialize EndOfDay running at a given time of day, e.g. 1*time.Hour for 01:00
+func NewEndOfDay(lms lms.Lms, at time.Duration) *EndOfDay {
+	return &EndOfDay{lms: lms, at: at, now: time.Now}
+}
+
+// Start runs the job in a new goroutine
+func (job *EndOfDay) Start() {
+	job.stop = make(chan struct{})
+	job.done = make(chan struct{})
+	go func() {
+		defer close(job.done)
+		for {
+			timer := time.NewTimer(time.Until(nextRun(job.now(), job.at)))
+			select {
+			case <-job.stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+				job.run()
+			}
+		}
+	}()
+}
+
+// Stop waits until the running job is finished
+func (job *EndOfDay) Stop() {
+	close(job.stop)
+	<-job.done
+}
+
+func (job *EndOfDay) run() {
+	asOf := job.now()
+	accrual, err := job.lms.AccrueLateCharges(asOf)
+	if err != nil {
+		log.Printf("[ERROR] Accrual of late charges failed, it will be finished by the next run: %v", err)
+		return
+	}
+	log.Printf("[INFO] Accrued %d late charges of %d loans as of %s", accrual.Accruals, accrual.Loans, asOf.Format(time.RFC3339))
+	dunning, err := job.lms.RunDunning(asOf)
+	if err != nil {
+		log.Printf("[ERROR] Dunning failed, it will be finished by the next run: %v", err)
+		return
+	}
+	log.Printf("[INFO] Sent %d dunning notices and defaulted %d loans as of %s", dunning.Notices, dunning.Defaulted, asOf.Format(time.RFC3339))
+	expired, err := job.lms.ExpireApplications(asOf)
+	if err != nil {
+		log.Printf("[ERROR] Expiring applications failed, it will be finished by the next run: %v", err)
+		return
+	}
+	log.Printf("[INFO] Expired %d loan applications as of %s", expired, asOf.Format(time.RFC3339))
+	refunds, err := job.lms.RetryRefunds(asOf)
+	if err != nil {
+		log.Printf("[ERROR] Retrying refunds failed, it will be finished by the next run: %v", err)
+		return
+	}
+	log.Printf("[INFO] Completed %d, failed %d and kept %d pending refunds as of %s", refunds.Completed, refunds.Failed, refunds.Pending, asOf.Format(time.RFC3339))
+}
+
+// nextRun returns the first business day after now at a given time of day
+func nextRun(now time.Time, at time.Duration) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for {
+		if run := day.Add(at); run.After(now) && IsBusinessDay(day) {
+			return run
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+// IsBusinessDay tells whether the day is a working day. Public holidays are not taken into account.
+func IsBusinessDay(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
@@ -0,0 +1,17 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextRun(t *testing.T) {
+	at := 2 * time.Hour
+	// 2026-01-05 is Monday
+	assert.Equal(t, time.Date(2026, 1, 5, 2, 0, 0, 0, time.UTC), nextRun(time.Date(2026, 1, 5, 1, 0, 0, 0, time.UTC), at))
+	assert.Equal(t, time.Date(2026, 1, 6, 2, 0, 0, 0, time.UTC), nextRun(time.Date(2026, 1, 5, 2, 0, 0, 0, time.UTC), at))
+	assert.Equal(t, time.Date(2026, 1, 12, 2, 0, 0, 0, time.UTC), nextRun(time.Date(2026, 1, 9, 3, 0, 0, 0, time.UTC), at))
+	assert.Equal(t, time.Date(2026, 1, 12, 2, 0, 0, 0, time.UTC), nextRun(time.Date(2026, 1, 10, 1, 0, 0, 0, time.UTC), at))
+}
@@ -0,0 +1,60 @@
+package job
+
+import (
+	"log"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+)
+
+// NotificationRetry delivers queued notifications and those whose delivery failed, so clients are notified once their
+// channel is back
+type NotificationRetry struct {
+	lms      lms.Lms
+	interval time.Duration
+	now      func() time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewNotificationRetry initialize NotificationRetry running every interval
+func NewNotificationRetry(lms lms.Lms, interval time.Duration) *NotificationRetry {
+	return &NotificationRetry{lms: lms, interval: interval, now: time.Now}
+}
+
+// Start runs the job in a new goroutine
+func (job *NotificationRetry) Start() {
+	job.stop = make(chan struct{})
+	job.done = make(chan struct{})
+	go func() {
+		defer close(job.done)
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-job.stop:
+				return
+			case <-ticker.C:
+				job.run()
+			}
+		}
+	}()
+}
+
+// Stop waits until the running job is finished
+func (job *NotificationRetry) Stop() {
+	close(job.stop)
+	<-job.done
+}
+
+func (job *NotificationRetry) run() {
+	asOf := job.now()
+	run, err := job.lms.RetryNotifications(asOf)
+	if err != nil {
+		log.Printf("[ERROR] Delivering notifications failed, they will be retried by the next run: %v", err)
+		return
+	}
+	if run.Delivered+run.Failed+run.Pending > 0 {
+		log.Printf("[INFO] Delivered notifications as of %s: %d delivered, %d failed, %d pending", asOf.Format(time.RFC3339), run.Delivered, run.Failed, run.Pending)
+	}
+}
@@ -0,0 +1,60 @@
+package job
+
+import (
+	"log"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+)
+
+// WebhookRetry posts queued webhook payloads and those whose previous attempt failed, the attempts back off
+// exponentially
+type WebhookRetry struct {
+	lms      lms.Lms
+	interval time.Duration
+	now      func() time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewWebhookRetry initialize WebhookRetry running every interval
+func NewWebhookRetry(lms lms.Lms, interval time.Duration) *WebhookRetry {
+	return &WebhookRetry{lms: lms, interval: interval, now: time.Now}
+}
+
+// Start runs the job in a new goroutine
+func (job *WebhookRetry) Start() {
+	job.stop = make(chan struct{})
+	job.done = make(chan struct{})
+	go func() {
+		defer close(job.done)
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-job.stop:
+				return
+			case <-ticker.C:
+				job.run()
+			}
+		}
+	}()
+}
+
+// Stop waits until the running job is finished
+func (job *WebhookRetry) Stop() {
+	close(job.stop)
+	<-job.done
+}
+
+func (job *WebhookRetry) run() {
+	asOf := job.now()
+	run, err := job.lms.RetryWebhookDeliveries(asOf)
+	if err != nil {
+		log.Printf("[ERROR] Posting webhook deliveries failed, they will be retried by the next run: %v", err)
+		return
+	}
+	if run.Delivered+run.Failed+run.Pending > 0 {
+		log.Printf("[INFO] Posted webhook deliveries as of %s: %d delivered, %d failed, %d pending", asOf.Format(time.RFC3339), run.Delivered, run.Failed, run.Pending)
+	}
+}
@@ -0,0 +1,115 @@
+package lms
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// agreementDateFormat is used for dates printed in the agreement
+const agreementDateFormat = "2006-01-02"
+
+var agreementFuncs = map[string]interface{}{
+	"date": func(date interface{ Format(string) string }) string {
+		return date.Format(agreementDateFormat)
+	},
+	"percent": func(basisPoints uint) string {
+		return fmt.Sprintf("%d.%02d%%", basisPoints/100, basisPoints%100)
+	},
+}
+
+// agreementHTML is the document the client accepts, it must not change for an active loan or its hash won't match
+var agreementHTML = htmltemplate.Must(htmltemplate.New("agreement").Funcs(agreementFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Loan agreement {{.LoanID}}</title>
+</head>
+<body>
+<h1>Loan agreement {{.LoanID}}</h1>
+<p>This agreement is made on {{date .StartDate}} between goLoans (the lender) and the borrower below.</p>
+<h2>Borrower</h2>
+<table>
+<tr><th>Name</th><td>{{.Name}}</td></tr>
+<tr><th>KTP number</th><td>{{.KTPNumber}}</td></tr>
+<tr><th>Birth date</th><td>{{.BirthDate}}</td></tr>
+<tr><th>Address</th><td>{{.Address}}</td></tr>
+<tr><th>Bank account</th><td>{{.BankCode}} {{.BankAccountNumber}} ({{.BankAccountHolder}})</td></tr>
+</table>
+<h2>Loan</h2>
+<table>
+<tr><th>Product</th><td>{{.ProductName}}</td></tr>
+<tr><th>Amount</th><td>{{.Amount}}</td></tr>
+<tr><th>Term</th><td>{{.Term}} days</td></tr>
+<tr><th>Fee</th><td>{{.Fee}} ({{percent .FeeBasisPoints}} of the amount)</td></tr>
+<tr><th>Interest</th><td>{{.Interest}} ({{percent .DailyInterestBasisPoints}} per day)</td></tr>
+<tr><th>Total payable</th><td>{{.TotalPayable}}</td></tr>
+</table>
+<h2>Repayment schedule</h2>
+<table>
+<tr><th>Due date</th><th>Amount</th></tr>
+{{range .Instalments}}<tr><td>{{date .DueDate}}</td><td>{{.Amount}}</td></tr>
+{{end}}</table>
+<h2>Terms</h2>
+<ol>
+<li>The lender transfers the amount to the bank account of the borrower after the borrower accepts this agreement.</li>
+<li>The borrower repays the instalments of the repayment schedule by their due dates.</li>
+<li>Instalments not repaid by their due date are charged late fees and penalty interest.</li>
+<li>The borrower accepts this agreement electronically. The lender records the time and IP address of the acceptance together with the hash of this document.</li>
+</ol>
+</body>
+</html>
+`))
+
+// agreementText is agreementHTML without markup, it is printed into PDF
+var agreementText = texttemplate.Must(texttemplate.New("agreement").Funcs(agreementFuncs).Parse(`LOAN AGREEMENT {{.LoanID}}
+
+This agreement is made on {{date .StartDate}} between goLoans (the lender) and the borrower below.
+
+BORROWER
+Name: {{.Name}}
+KTP number: {{.KTPNumber}}
+Birth date: {{.BirthDate}}
+Address: {{.Address}}
+Bank account: {{.BankCode}} {{.BankAccountNumber}} ({{.BankAccountHolder}})
+
+LOAN
+Product: {{.ProductName}}
+Amount: {{.Amount}}
+Term: {{.Term}} days
+Fee: {{.Fee}} ({{percent .FeeBasisPoints}} of the amount)
+Interest: {{.Interest}} ({{percent .DailyInterestBasisPoints}} per day)
+Total payable: {{.TotalPayable}}
+
+REPAYMENT SCHEDULE
+{{range .Instalments}}{{date .DueDate}}  {{.Amount}}
+{{end}}
+TERMS
+1. The lender transfers the amount to the bank account of the borrower after the borrower accepts this agreement.
+2. The borrower repays the instalments of the repayment schedule by their due dates.
+3. Instalments not repaid by their due date are charged late fees and penalty interest.
+4. The borrower accepts this agreement electronically. The lender records the time and IP address of the acceptance together with the hash of this document.
+`))
+
+// RenderAgreement returns the HTML agreement with its SHA-256 hash, the client accepts the agreement by sending the hash
+// back
+func RenderAgreement(agreement LoanAgreement) (document []byte, hash string, err error) {
+	var html bytes.Buffer
+	if err = agreementHTML.Execute(&html, agreement); err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(html.Bytes())
+	return html.Bytes(), hex.EncodeToString(sum[:]), nil
+}
+
+// RenderAgreementText returns the agreement without markup, one line of the document per line of text
+func RenderAgreementText(agreement LoanAgreement) (string, error) {
+	var text bytes.Buffer
+	if err := agreementText.Execute(&text, agreement); err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
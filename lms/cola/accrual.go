@@ -0,0 +1,81 @@
+package cola
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// batchSize is a number of clients loaded from ClientRepo at once by jobs going through all active loans
+const batchSize = 100
+
+// WithPenaltyPolicy configures late charges of overdue loans. Without it domain.DefaultPenaltyPolicy is used.
+func WithPenaltyPolicy(policy domain.PenaltyPolicy) Option {
+	return func(cola *cola) {
+		cola.penaltyPolicy = policy
+	}
+}
+
+// AccrueLateCharges saves accruals of every client before the next one is processed, so a failed run can be repeated
+// without charging anything twice
+func (cola *cola) AccrueLateCharges(asOf time.Time) (lms.AccrualRun, error) {
+	run := lms.AccrualRun{AsOf: asOf}
+	err := cola.forEachClientWithActiveLoan(func(client domain.Client) error {
+		accruals := client.AccrueLateCharges(cola.penaltyPolicy, asOf)
+		if len(accruals) == 0 {
+			return nil
+		}
+		if err := cola.ClientRepo.Save(client); err != nil {
+			return fmt.Errorf("client %s: %w", client.KTPNumber(), err)
+		}
+		run.Loans++
+		run.Accruals += len(accruals)
+		return nil
+	})
+	if err != nil {
+		return run, fmt.Errorf("accruing late charges as of %s: %v", asOf.Format("2006-01-02"), err)
+	}
+	return run, nil
+}
+
+func (cola *cola) forEachClientWithActiveLoan(process func(client domain.Client) error) error {
+	hasActiveLoan := true
+	return cola.forEachClient(ClientQuery{HasActiveLoan: &hasActiveLoan}, process)
+}
+
+// forEachClient pages through clients matching the query ordered by KTP number and stops on the first error. Client
+// which was saved by a use case running at the same time is loaded again and processed again.
+func (cola *cola) forEachClient(query ClientQuery, process func(client domain.Client) error) error {
+	query.Limit = batchSize
+	for {
+		page, err := cola.ClientRepo.Search(query)
+		if err != nil {
+			return err
+		}
+		for _, client := range page.Clients {
+			if err := cola.processClient(client, process); err != nil {
+				return err
+			}
+		}
+		if !page.HasNext {
+			return nil
+		}
+		query.After = page.Clients[len(page.Clients)-1].KTPNumber()
+	}
+}
+
+// processClient loads the client again and processes it again when it was saved by a use case running at the same time
+func (cola *cola) processClient(client domain.Client, process func(client domain.Client) error) error {
+	err := process(client)
+	for attempt := 1; errors.Is(err, ErrClientChanged) && attempt < maxAttempts; attempt++ {
+		var found bool
+		if client, found, err = cola.ClientRepo.ByKTPNumber(client.KTPNumber()); err != nil || !found {
+			return err
+		}
+		err = process(client)
+	}
+	return err
+}
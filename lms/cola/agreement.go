@@ -0,0 +1,118 @@
+package cola
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// WithLoanAgreements holds approved loans until the client accepts the loan agreement. Without it loans are disbursed
+// right after approval.
+func WithLoanAgreements() Option {
+	return func(cola *cola) {
+		cola.agreements = true
+	}
+}
+
+func (cola *cola) LoanAgreement(ktpNumber string) (lms.LoanAgreement, error) {
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return lms.LoanAgreement{}, fmt.Errorf("loading loan agreement of client %s: %v", ktpNumber, err)
+	}
+	if !found {
+		return lms.LoanAgreement{}, lms.ErrClientDoesNotExist
+	}
+	if !client.HasActiveLoan() {
+		return lms.LoanAgreement{}, lms.ErrNoActiveLoan
+	}
+	return loanAgreement(client, cola.now()), nil
+}
+
+// loanAgreement is the agreement of the active loan. The schedule of a pending agreement starts on asOf, which is when
+// the client would accept it.
+func loanAgreement(client domain.Client, asOf time.Time) lms.LoanAgreement {
+	loan := client.ActiveLoan()
+	profile := client.Profile()
+	agreement := loan.Agreement()
+	dto := lms.LoanAgreement{
+		LoanID:                   loan.ID(),
+		KTPNumber:                client.KTPNumber(),
+		Name:                     profile.Name,
+		BirthDate:                profile.BirthDate,
+		Address:                  profile.Address,
+		BankCode:                 profile.BankAccount.BankCode,
+		BankAccountNumber:        profile.BankAccount.Number,
+		BankAccountHolder:        profile.BankAccount.HolderName,
+		ProductName:              loan.Product().Name(),
+		Amount:                   loan.Amount(),
+		Term:                     uint(loan.Term()),
+		FeeBasisPoints:           loan.Product().FeeBasisPoints(),
+		DailyInterestBasisPoints: loan.Product().DailyInterestBasisPoints(),
+		Fee:                      loan.Fee(),
+		Interest:                 loan.Interest(),
+		TotalPayable:             loan.TotalPayable(),
+		Required:                 agreement.Required,
+		Hash:                     agreement.Hash,
+		AcceptedFrom:             agreement.AcceptedFrom,
+		AcceptedAt:               agreement.AcceptedAt,
+	}
+	instalments := loan.Instalments()
+	dto.StartDate = loan.StartDate()
+	if agreement.IsPending() {
+		instalments = loan.ScheduleFrom(asOf)
+		dto.StartDate = asOf
+	}
+	for _, instalment := range instalments {
+		dto.Instalments = append(dto.Instalments, lms.Instalment{DueDate: instalment.DueDate, Amount: instalment.Amount})
+	}
+	return dto
+}
+
+func (cola *cola) AcceptAgreement(ktpNumber string, acceptance lms.AgreementAcceptance) error {
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return fmt.Errorf("client %s is accepting loan agreement: %v", ktpNumber, err)
+	}
+	if !found {
+		return lms.ErrClientDoesNotExist
+	}
+	if !client.HasActiveLoan() {
+		return lms.ErrNoActiveLoan
+	}
+	acceptedAt := cola.now()
+	if client.ActiveLoan().Agreement().IsPending() {
+		_, hash, err := lms.RenderAgreement(loanAgreement(client, acceptedAt))
+		if err != nil {
+			return fmt.Errorf("client %s is accepting loan agreement: %v", ktpNumber, err)
+		}
+		if hash != acceptance.Hash {
+			return lms.ErrAgreementChanged
+		}
+	}
+	challenge, err := cola.useOTP(acceptance.OTPChallengeID, client.Phone(), domain.AgreementAcceptanceOTP)
+	if err != nil {
+		return err
+	}
+	if err = client.AcceptAgreement(acceptance.Hash, acceptance.IPAddress, acceptedAt); err != nil {
+		cola.releaseOTP(challenge)
+		return err
+	}
+	if err = cola.ClientRepo.Save(client); err != nil {
+		cola.releaseOTP(challenge)
+		return fmt.Errorf("client %s is accepting loan agreement: %v", ktpNumber, err)
+	}
+	if client.ActiveLoan().DisbursementStatus() == domain.InReview {
+		return nil
+	}
+	return cola.disburse(client)
+}
+
+// disburseAccepted disburses the loan unless it waits for the client to accept its agreement
+func (cola *cola) disburseAccepted(client domain.Client) error {
+	if client.ActiveLoan().Agreement().IsPending() {
+		return nil
+	}
+	return cola.disburse(client)
+}
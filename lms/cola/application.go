@@ -0,0 +1,141 @@
+package cola
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// ApplicationRepo is used internally by lms package for loading/storing loan applications, including rejected ones
+type ApplicationRepo interface {
+	ByID(id string) (application domain.LoanApplication, found bool, err error)
+	// ByKTPNumber lists applications of the client in the order they were submitted
+	ByKTPNumber(ktpNumber string) ([]domain.LoanApplication, error)
+	// ByStatus lists applications in the status in the order they were submitted
+	ByStatus(status domain.ApplicationStatus) ([]domain.LoanApplication, error)
+	// NextApplicationSequence never returns the same sequence twice
+	NextApplicationSequence() (uint64, error)
+	Save(application domain.LoanApplication) error
+}
+
+func (cola *cola) ApplicationByID(id string) (lms.LoanApplication, bool, error) {
+	application, found, err := cola.ApplicationRepo.ByID(id)
+	if err != nil {
+		return lms.LoanApplication{}, false, fmt.Errorf("loading application %s: %v", id, err)
+	}
+	if !found {
+		return lms.LoanApplication{}, false, nil
+	}
+	return applicationDto(application), true, nil
+}
+
+func (cola *cola) ApplicationsOfClient(ktpNumber string) ([]lms.LoanApplication, error) {
+	applications, err := cola.ApplicationRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return nil, fmt.Errorf("listing applications of client %s: %v", ktpNumber, err)
+	}
+	dtos := []lms.LoanApplication{}
+	for _, application := range applications {
+		dtos = append(dtos, applicationDto(application))
+	}
+	return dtos, nil
+}
+
+func (cola *cola) CancelApplication(id string) error {
+	application, found, err := cola.ApplicationRepo.ByID(id)
+	if err != nil {
+		return fmt.Errorf("cancelling application %s: %v", id, err)
+	}
+	if !found {
+		return lms.ErrApplicationDoesNotExist
+	}
+	return cola.withdraw(application, application.Cancel, cola.now())
+}
+
+func (cola *cola) ExpireApplications(asOf time.Time) (int, error) {
+	expired := 0
+	for _, status := range []domain.ApplicationStatus{domain.ApplicationSubmitted, domain.ApplicationUnderReview} {
+		applications, err := cola.ApplicationRepo.ByStatus(status)
+		if err != nil {
+			return expired, fmt.Errorf("expiring applications as of %s: %v", asOf, err)
+		}
+		for _, application := range applications {
+			if asOf.Before(application.ExpiresAt()) {
+				continue
+			}
+			if err = cola.withdraw(application, application.Expire, asOf); err != nil {
+				return expired, fmt.Errorf("expiring application %s: %v", application.ID(), err)
+			}
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// withdraw closes the undecided application and cancels its loan in review
+func (cola *cola) withdraw(application domain.LoanApplication, close func(at time.Time) error, at time.Time) error {
+	inReview := application.Status() == domain.ApplicationUnderReview
+	if err := close(at); err != nil {
+		return err
+	}
+	if inReview {
+		client, found, err := cola.ClientRepo.ByKTPNumber(application.KTPNumber())
+		if err != nil {
+			return fmt.Errorf("withdrawing application %s: %v", application.ID(), err)
+		}
+		if !found {
+			return lms.ErrClientDoesNotExist
+		}
+		if err = client.CancelLoanInReview(at); err != nil {
+			return err
+		}
+		if err = cola.ClientRepo.Save(client); err != nil {
+			return fmt.Errorf("withdrawing application %s: %v", application.ID(), err)
+		}
+	}
+	if err := cola.ApplicationRepo.Save(application); err != nil {
+		return fmt.Errorf("withdrawing application %s: %v", application.ID(), err)
+	}
+	return nil
+}
+
+// applicationInReview returns the application of the client waiting for review, nil when there is none
+func (cola *cola) applicationInReview(ktpNumber string) (domain.LoanApplication, error) {
+	applications, err := cola.ApplicationRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return nil, err
+	}
+	for _, application := range applications {
+		if application.Status() == domain.ApplicationUnderReview {
+			return application, nil
+		}
+	}
+	return nil, nil
+}
+
+func applicationDto(application domain.LoanApplication) lms.LoanApplication {
+	affordability := application.Affordability()
+	dto := lms.LoanApplication{
+		ID:                 application.ID(),
+		KTPNumber:          application.KTPNumber(),
+		ProductCode:        application.ProductCode(),
+		Amount:             application.Amount(),
+		Term:               uint(application.Term()),
+		MonthlyIncome:      affordability.MonthlyIncome,
+		EmploymentType:     string(affordability.EmploymentType),
+		MonthlyObligations: affordability.MonthlyObligations,
+		Status:             string(application.Status()),
+		Reason:             application.Reason(),
+		LoanID:             application.LoanID(),
+		SubmittedAt:        application.SubmittedAt(),
+		DecidedAt:          application.DecidedAt(),
+		Channel:            application.Origin().Channel,
+		IPAddress:          application.Origin().IPAddress,
+	}
+	if score, found := application.Score(); found {
+		dto.Scorecard, dto.ScorePoints, dto.ScoreBand, dto.ReasonCodes = score.Scorecard, score.Points, score.Band, score.Reasons
+	}
+	return dto
+}
@@ -1,7 +1,11 @@
 package cola
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/briyanadityatama/goLoans/lms"
 	"github.com/briyanadityatama/goLoans/lms/cola/domain"
@@ -11,16 +15,72 @@ import (
 // independent of database technology.
 type ClientRepo interface {
 	ByKTPNumber(ktpNumber string) (client domain.Client, found bool, err error)
+	ByVirtualAccount(number string) (client domain.Client, found bool, err error)
+	// NextVirtualAccountSequence never returns the same sequence twice
+	NextVirtualAccountSequence() (uint64, error)
+	// Save returns ErrClientChanged when the client was saved by someone else since it was loaded, otherwise it
+	// increments the version of the client
 	Save(client domain.Client) error
+	Search(query ClientQuery) (ClientPage, error)
+}
+
+// ErrClientChanged should be returned by ClientRepo when a client is saved with a version which is not the latest.
+// Jobs and repayments load the client again and run again, other use cases fail.
+var ErrClientChanged = errors.New("client_changed")
+
+// maxAttempts is a number of times a use case runs when ClientRepo keeps returning ErrClientChanged
+const maxAttempts = 3
+
+// retryWhenClientChanged runs the use case again while it fails because the client was saved by someone else since
+// the use case loaded it
+func retryWhenClientChanged(useCase func() error) error {
+	err := useCase()
+	for attempt := 1; errors.Is(err, ErrClientChanged) && attempt < maxAttempts; attempt++ {
+		err = useCase()
+	}
+	return err
 }
 
 type cola struct {
-	ClientRepo ClientRepo
+	ClientRepo      ClientRepo
+	ApplicationRepo ApplicationRepo
+	Disburser       Disburser
+	ExchangeRates   ExchangeRates
+	now             func() time.Time
+	overpaymentMode OverpaymentMode
+	penaltyPolicy   domain.PenaltyPolicy
+	dunningSchedule domain.DunningSchedule
+	notifier        Notifier
+	scorecards      []domain.Scorecard
+	creditBureau    *cachedCreditBureau
+	maxDebtToIncome uint
+	agreements      bool
+	otp             *otpChallenges
+	notifications   *notifications
+	webhooks        *webhooks
+	officers        map[string]bool
 }
 
+// Option configures optional behaviour of Lms returned by New
+type Option func(cola *cola)
+
 // New returns a new instance of Lms
-func New(repo ClientRepo) lms.Lms {
-	return &cola{ClientRepo: repo}
+func New(repo ClientRepo, applicationRepo ApplicationRepo, disburser Disburser, options ...Option) lms.Lms {
+	cola := &cola{
+		ClientRepo:      repo,
+		ApplicationRepo: applicationRepo,
+		Disburser:       disburser,
+		ExchangeRates:   sameCurrencyRates{},
+		now:             time.Now,
+		penaltyPolicy:   domain.DefaultPenaltyPolicy,
+		dunningSchedule: domain.DefaultDunningSchedule,
+		notifier:        discardNotifier{},
+		maxDebtToIncome: domain.DefaultMaxDebtToIncomeBasisPoints,
+	}
+	for _, option := range options {
+		option(cola)
+	}
+	return cola
 }
 
 func (cola *cola) RegisterClient(clientData lms.ClientData) (lms.Client, error) {
@@ -30,34 +90,598 @@ func (cola *cola) RegisterClient(clientData lms.ClientData) (lms.Client, error)
 	ktpNumber := clientData.KTPNumber
 	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
 	if err != nil {
-		return nil, fmt.Errorf("registering client %s %s with personal number %s: %v", gender, birthDate, name, ktpNumber, err)
+		return nil, fmt.Errorf("registering client %s %s with ktp number %s: %v", birthDate, name, ktpNumber, err)
 	}
 	if found {
 		return client, lms.ErrClientAlreadyExists
 	}
+	challenge, err := cola.useOTP(clientData.OTPChallengeID, clientData.Phone, domain.RegistrationOTP)
+	if err != nil {
+		return nil, err
+	}
 	client = domain.NewClient(gender, birthDate, name, ktpNumber)
+	if clientData.Phone != "" {
+		profile := client.Profile()
+		profile.Phone = clientData.Phone
+		if err = client.UpdateProfile(profile, cola.now()); err != nil {
+			cola.releaseOTP(challenge)
+			return nil, err
+		}
+	}
+	sequence, err := cola.ClientRepo.NextVirtualAccountSequence()
+	if err != nil {
+		cola.releaseOTP(challenge)
+		return nil, fmt.Errorf("registering client %s %s with ktp number %s: %v", birthDate, name, ktpNumber, err)
+	}
+	err = client.AssignVirtualAccount(domain.NewVirtualAccount(sequence))
+	if err != nil {
+		cola.releaseOTP(challenge)
+		return nil, err
+	}
+	err = cola.ClientRepo.Save(client)
+	if err != nil {
+		cola.releaseOTP(challenge)
+		return nil, fmt.Errorf("registering client %s %s with ktp number %s: %v", birthDate, name, ktpNumber, err)
+	}
+	cola.publish(domain.ClientRegisteredWebhook, clientRegisteredData{
+		KTPNumber:      client.KTPNumber(),
+		VirtualAccount: client.VirtualAccount(),
+	})
+	return client, nil
+}
+
+func (cola *cola) UpdateClient(ktpNumber string, patch lms.ClientPatch) (lms.Client, error) {
+	if patch.KTPNumber != nil && *patch.KTPNumber != ktpNumber {
+		return nil, lms.ErrKTPNumberImmutable
+	}
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return nil, fmt.Errorf("updating client with ktp number %s: %v", ktpNumber, err)
+	}
+	if !found {
+		return nil, lms.ErrClientDoesNotExist
+	}
+	profile := client.Profile()
+	patchField(&profile.Gender, patch.Gender)
+	patchField(&profile.BirthDate, patch.BirthDate)
+	patchField(&profile.Name, patch.Name)
+	patchField(&profile.Phone, patch.Phone)
+	patchField(&profile.Email, patch.Email)
+	patchField(&profile.Address, patch.Address)
+	patchField(&profile.BankAccount.BankCode, patch.BankCode)
+	patchField(&profile.BankAccount.Number, patch.BankAccountNumber)
+	patchField(&profile.BankAccount.HolderName, patch.BankAccountHolder)
+	var challenge domain.OTPChallenge
+	if profile.Phone != "" && profile.Phone != client.Phone() {
+		challenge, err = cola.useOTP(patch.OTPChallengeID, profile.Phone, domain.PhoneChangeOTP)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = client.UpdateProfile(profile, cola.now())
+	if err != nil {
+		cola.releaseOTP(challenge)
+		return nil, err
+	}
 	err = cola.ClientRepo.Save(client)
 	if err != nil {
-		return nil, fmt.Errorf("registering client %s %s with personal number %s: %v", gender, birthDate, name, ktpNumber, err)
+		cola.releaseOTP(challenge)
+		return nil, fmt.Errorf("updating client with ktp number %s: %v", ktpNumber, err)
 	}
 	return client, nil
 }
 
+func patchField(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
+
 func (cola *cola) ClientByKTPNumber(ktpNumber string) (client lms.Client, found bool, err error) {
 	client, found, err = cola.ClientRepo.ByKTPNumber(ktpNumber)
 	if err != nil {
-		err = fmt.Errorf("loading client by personal number %s: %v", ktpNumber, err)
+		err = fmt.Errorf("loading client by ktp number %s: %v", ktpNumber, err)
+	}
+	return
+}
+
+func (cola *cola) ClientByVirtualAccount(number string) (client lms.Client, found bool, err error) {
+	client, found, err = cola.ClientRepo.ByVirtualAccount(number)
+	if err != nil {
+		err = fmt.Errorf("loading client by virtual account %s: %v", number, err)
 	}
 	return
 }
-func (cola *cola) ApplyForLoan(ktpNumber string, amount uint, term uint) error {
+
+func (cola *cola) ExportClientData(ktpNumber string) (lms.ClientDataExport, error) {
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return lms.ClientDataExport{}, fmt.Errorf("exporting data of client %s: %v", ktpNumber, err)
+	}
+	if !found {
+		return lms.ClientDataExport{}, lms.ErrClientDoesNotExist
+	}
+	profile := client.Profile()
+	export := lms.ClientDataExport{
+		KTPNumber: client.KTPNumber(),
+		Profile: lms.ProfileExport{
+			Gender:            profile.Gender,
+			BirthDate:         profile.BirthDate,
+			Name:              profile.Name,
+			Phone:             profile.Phone,
+			Email:             profile.Email,
+			Address:           profile.Address,
+			BankCode:          profile.BankAccount.BankCode,
+			BankAccountNumber: profile.BankAccount.Number,
+			BankAccountHolder: profile.BankAccount.HolderName,
+		},
+		Loans:          []lms.LoanExport{},
+		Applications:   []lms.ApplicationExport{},
+		ProfileChanges: []lms.ProfileChangeExport{},
+		CreditBalance:  client.CreditBalance(),
+		Refunds:        []lms.RefundExport{},
+		Notifications:  []lms.NotificationExport{},
+		Erased:         client.IsErased(),
+		ExportedAt:     cola.now(),
+	}
+	for _, loan := range client.Loans() {
+		loanExport := lms.LoanExport{
+			Product:          loan.Product().Code(),
+			Amount:           loan.Amount(),
+			Term:             uint(loan.Term()),
+			Fee:              loan.Fee(),
+			Interest:         loan.Interest(),
+			TotalPayable:     loan.TotalPayable(),
+			Remaining:        loan.Remaining(),
+			StartDate:        loan.StartDate(),
+			DueDate:          loan.DueDate(),
+			LateCharges:      loan.LateCharges(),
+			Repayments:       []lms.RepaymentExport{},
+			Accruals:         []lms.AccrualExport{},
+			DunningStage:     loan.DunningStage(),
+			Defaulted:        loan.IsDefaulted(),
+			WrittenOff:       loan.IsWrittenOff(),
+			WrittenOffAmount: loan.WrittenOffAmount(),
+			Recovered:        loan.Recovered(),
+			Status:           string(loan.DisbursementStatus()),
+			ReviewReason:     loan.ReviewReason(),
+			ReviewedBy:       loan.ReviewedBy(),
+		}
+		if score, found := loan.Score(); found {
+			loanExport.Score = scoreExport(score)
+		}
+		if affordability, found := loan.Affordability(); found {
+			loanExport.Affordability = &lms.AffordabilityExport{
+				MonthlyIncome:      affordability.MonthlyIncome,
+				EmploymentType:     string(affordability.EmploymentType),
+				MonthlyObligations: affordability.MonthlyObligations,
+			}
+		}
+		if agreement := loan.Agreement(); agreement.Required {
+			loanExport.Agreement = &lms.AgreementExport{Hash: agreement.Hash, AcceptedFrom: agreement.AcceptedFrom}
+			if !agreement.AcceptedAt.IsZero() {
+				loanExport.Agreement.AcceptedAt = &agreement.AcceptedAt
+			}
+		}
+		if report, found := loan.CreditBureauReport(); found {
+			loanExport.CreditBureauReport = creditBureauReportExport(report)
+		}
+		for _, repayment := range loan.Repayments() {
+			loanExport.Repayments = append(loanExport.Repayments, lms.RepaymentExport{Amount: repayment.Amount, PaidAt: repayment.PaidAt, FromCredit: repayment.FromCredit, Recovery: repayment.Recovery})
+		}
+		for _, accrual := range loan.Accruals() {
+			loanExport.Accruals = append(loanExport.Accruals, lms.AccrualExport{Date: accrual.Date, Kind: string(accrual.Kind), Base: accrual.Base, Amount: accrual.Amount})
+		}
+		export.Loans = append(export.Loans, loanExport)
+	}
+	applications, err := cola.ApplicationRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return lms.ClientDataExport{}, fmt.Errorf("exporting data of client %s: %v", ktpNumber, err)
+	}
+	for _, application := range applications {
+		affordability := application.Affordability()
+		applicationExport := lms.ApplicationExport{
+			ID:      application.ID(),
+			Product: application.ProductCode(),
+			Amount:  application.Amount(),
+			Term:    uint(application.Term()),
+			Affordability: lms.AffordabilityExport{
+				MonthlyIncome:      affordability.MonthlyIncome,
+				EmploymentType:     string(affordability.EmploymentType),
+				MonthlyObligations: affordability.MonthlyObligations,
+			},
+			Status:      string(application.Status()),
+			Reason:      application.Reason(),
+			LoanID:      application.LoanID(),
+			SubmittedAt: application.SubmittedAt(),
+			Channel:     application.Origin().Channel,
+			IPAddress:   application.Origin().IPAddress,
+		}
+		if decidedAt := application.DecidedAt(); !decidedAt.IsZero() {
+			applicationExport.DecidedAt = &decidedAt
+		}
+		if score, found := application.Score(); found {
+			applicationExport.Score = scoreExport(score)
+		}
+		if report, found := application.CreditBureauReport(); found {
+			applicationExport.CreditBureauReport = creditBureauReportExport(report)
+		}
+		export.Applications = append(export.Applications, applicationExport)
+	}
+	for _, change := range client.ProfileChanges() {
+		export.ProfileChanges = append(export.ProfileChanges, lms.ProfileChangeExport(change))
+	}
+	for _, refund := range client.Refunds() {
+		export.Refunds = append(export.Refunds, lms.RefundExport{
+			ID:          refund.ID,
+			Amount:      refund.Amount,
+			RequestedAt: refund.RequestedAt,
+			Status:      string(refund.Status),
+			Reference:   refund.Reference,
+		})
+	}
+	notifications, err := cola.NotificationsOfClient(ktpNumber)
+	if err != nil {
+		return lms.ClientDataExport{}, fmt.Errorf("exporting data of client %s: %v", ktpNumber, err)
+	}
+	for _, notification := range notifications {
+		notificationExport := lms.NotificationExport{
+			ID:        notification.ID,
+			Event:     notification.Event,
+			Reference: notification.Reference,
+			Channel:   notification.Channel,
+			Recipient: notification.Recipient,
+			Subject:   notification.Subject,
+			Body:      notification.Body,
+			CreatedAt: notification.CreatedAt,
+			Status:    notification.Status,
+		}
+		if !notification.DeliveredAt.IsZero() {
+			deliveredAt := notification.DeliveredAt
+			notificationExport.DeliveredAt = &deliveredAt
+		}
+		export.Notifications = append(export.Notifications, notificationExport)
+	}
+	return export, nil
+}
+
+// scoreExport is a part of ClientDataExport for both loans and applications
+func scoreExport(score domain.Score) *lms.ScoreExport {
+	return &lms.ScoreExport{Scorecard: score.Scorecard, Points: score.Points, Band: score.Band, Reasons: score.Reasons, ScoredAt: score.ScoredAt}
+}
+
+// creditBureauReportExport is a part of ClientDataExport for both loans and applications
+func creditBureauReportExport(report domain.CreditBureauReport) *lms.CreditBureauReportExport {
+	export := &lms.CreditBureauReportExport{Debts: []lms.BureauDebtExport{}, ReportedAt: report.ReportedAt}
+	for _, debt := range report.Debts {
+		export.Debts = append(export.Debts, lms.BureauDebtExport(debt))
+	}
+	return export
+}
+
+func (cola *cola) EraseClient(ktpNumber string) error {
 	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
 	if err != nil {
-		return fmt.Errorf("client %s is applying for %d loan with term %d: %v", ktpNumber, amount, term, err)
+		return fmt.Errorf("erasing client %s: %v", ktpNumber, err)
 	}
 	if !found {
 		return lms.ErrClientDoesNotExist
 	}
-	applicationError := client.ApplyForLoan(amount, domain.Term(term))
-	return applicationError
+	err = client.Erase(cola.now())
+	if err != nil {
+		return err
+	}
+	err = cola.ClientRepo.Save(client)
+	if err != nil {
+		return fmt.Errorf("erasing client %s: %v", ktpNumber, err)
+	}
+	err = cola.eraseNotifications(ktpNumber)
+	if err != nil {
+		return fmt.Errorf("erasing notifications of client %s: %v", ktpNumber, err)
+	}
+	return nil
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func (cola *cola) Clients(query lms.ClientQuery) (lms.ClientsPage, error) {
+	repoQuery := ClientQuery{
+		NamePrefix:    query.NamePrefix,
+		BirthDate:     query.BirthDate,
+		HasActiveLoan: query.HasActiveLoan,
+		Overdue:       query.Overdue,
+		AsOf:          cola.now(),
+		Limit:         query.Limit,
+	}
+	if repoQuery.Limit <= 0 {
+		repoQuery.Limit = defaultPageSize
+	}
+	if repoQuery.Limit > maxPageSize {
+		repoQuery.Limit = maxPageSize
+	}
+	if query.Cursor != "" {
+		direction, ktpNumber, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return lms.ClientsPage{}, err
+		}
+		if direction == "after" {
+			repoQuery.After = ktpNumber
+		} else {
+			repoQuery.Before = ktpNumber
+		}
+	}
+	page, err := cola.ClientRepo.Search(repoQuery)
+	if err != nil {
+		return lms.ClientsPage{}, fmt.Errorf("searching clients: %v", err)
+	}
+	result := lms.ClientsPage{AsOf: repoQuery.AsOf}
+	for _, client := range page.Clients {
+		result.Clients = append(result.Clients, client)
+	}
+	if len(page.Clients) > 0 {
+		if page.HasNext {
+			result.NextCursor = encodeCursor("after", page.Clients[len(page.Clients)-1].KTPNumber())
+		}
+		if page.HasPrevious {
+			result.PreviousCursor = encodeCursor("before", page.Clients[0].KTPNumber())
+		}
+	}
+	return result, nil
+}
+
+func encodeCursor(direction, ktpNumber string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(direction + ":" + ktpNumber))
+}
+
+func decodeCursor(cursor string) (direction, ktpNumber string, err error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", lms.ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || (parts[0] != "after" && parts[0] != "before") {
+		return "", "", lms.ErrInvalidCursor
+	}
+	return parts[0], parts[1], nil
+}
+
+// WithMaxDebtToIncome limits monthly repayments of the new loan together with obligations declared by the client to
+// basis points of declared income. Without it domain.DefaultMaxDebtToIncomeBasisPoints is used.
+func WithMaxDebtToIncome(basisPoints uint) Option {
+	return func(cola *cola) {
+		cola.maxDebtToIncome = basisPoints
+	}
+}
+
+func (cola *cola) ApplyForLoan(application lms.ApplicationData) error {
+	_, err := cola.SubmitApplication(application)
+	return err
+}
+
+func (cola *cola) SubmitApplication(data lms.ApplicationData) (lms.LoanApplication, error) {
+	ktpNumber, amount, term := data.KTPNumber, data.Amount, data.Term
+	product, found := domain.ProductByCode(data.ProductCode)
+	if !found {
+		return lms.LoanApplication{}, lms.ErrProductDoesNotExist
+	}
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return lms.LoanApplication{}, fmt.Errorf("client %s is applying for %s loan with term %d: %v", ktpNumber, amount, term, err)
+	}
+	if !found {
+		return lms.LoanApplication{}, lms.ErrClientDoesNotExist
+	}
+	sequence, err := cola.ApplicationRepo.NextApplicationSequence()
+	if err != nil {
+		return lms.LoanApplication{}, fmt.Errorf("client %s is applying for %s loan with term %d: %v", ktpNumber, amount, term, err)
+	}
+	affordability := domain.Affordability{
+		MonthlyIncome:      data.MonthlyIncome,
+		EmploymentType:     domain.EmploymentType(data.EmploymentType),
+		MonthlyObligations: data.MonthlyObligations,
+	}
+	origin := domain.Origin{Channel: data.Channel, IPAddress: data.IPAddress}
+	application := domain.NewLoanApplication(sequence, ktpNumber, product.Code(), amount, domain.Term(term), affordability, origin, cola.now())
+	if err = cola.ApplicationRepo.Save(application); err != nil {
+		return lms.LoanApplication{}, fmt.Errorf("client %s is applying for %s loan with term %d: %v", ktpNumber, amount, term, err)
+	}
+	// application whose assessment failed for a technical reason stays submitted until it expires
+	rejection, err := cola.assess(client, product, application)
+	if err != nil {
+		return applicationDto(application), err
+	}
+	switch {
+	case rejection != nil:
+		err = application.Reject(rejection.Error(), application.SubmittedAt())
+	case client.ActiveLoan().DisbursementStatus() == domain.InReview:
+		err = application.ReferForReview(client.ActiveLoan().ID(), client.ActiveLoan().ReviewReason())
+	default:
+		err = application.Approve(client.ActiveLoan().ID(), application.SubmittedAt())
+	}
+	if err != nil {
+		return applicationDto(application), err
+	}
+	if err = cola.ApplicationRepo.Save(application); err != nil {
+		return applicationDto(application), fmt.Errorf("client %s is applying for %s loan with term %d: %v", ktpNumber, amount, term, err)
+	}
+	switch {
+	case rejection != nil:
+		return applicationDto(application), rejection
+	case application.Status() == domain.ApplicationUnderReview:
+		return applicationDto(application), lms.ErrLoanReferredForReview
+	}
+	cola.notifyApproval(client)
+	return applicationDto(application), cola.disburseAccepted(client)
+}
+
+// assess runs all checks of the application and creates the loan, which waits for review when the credit bureau did
+// not answer in time. The error the application should be rejected with is returned as rejection, err is returned
+// when the checks could not be finished.
+func (cola *cola) assess(client domain.Client, product domain.Product, application domain.LoanApplication) (rejection error, err error) {
+	ktpNumber, amount, term := client.KTPNumber(), application.Amount(), application.Term()
+	if rejection = checkEligibility(client, product, amount, term); rejection != nil {
+		return rejection, nil
+	}
+	appliedAt := application.SubmittedAt()
+	applications, err := cola.ApplicationRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return nil, fmt.Errorf("client %s is applying for %s loan with term %d: %v", ktpNumber, amount, term, err)
+	}
+	var earlier []domain.LoanApplication
+	for _, other := range applications {
+		if other.ID() != application.ID() {
+			earlier = append(earlier, other)
+		}
+	}
+	product, score, rejection := cola.score(client, earlier, product, appliedAt)
+	if score != nil {
+		application.RecordScore(*score)
+	}
+	if rejection != nil {
+		return rejection, nil
+	}
+	if rejection = product.Validate(amount, term); rejection != nil {
+		return rejection, nil
+	}
+	affordability := application.Affordability()
+	if rejection = affordability.Check(product, amount, term, cola.maxDebtToIncome); rejection != nil {
+		return rejection, nil
+	}
+	bureauReport, reviewReason, err := cola.checkCreditBureau(ktpNumber)
+	if err != nil {
+		return nil, err
+	}
+	if bureauReport != nil {
+		application.RecordCreditBureauReport(*bureauReport)
+		if rejection = bureauReport.Check(); rejection != nil {
+			return rejection, nil
+		}
+	}
+	reportingRate, err := cola.reportingRate(product.Currency(), appliedAt)
+	if err != nil {
+		return nil, fmt.Errorf("client %s is applying for %s loan with term %d: %v", ktpNumber, amount, term, err)
+	}
+	if rejection = client.ApplyForLoan(product, amount, term, appliedAt, reportingRate); rejection != nil {
+		return rejection, nil
+	}
+	if err = client.RecordAffordability(affordability); err != nil {
+		return nil, err
+	}
+	if score != nil {
+		if err = client.RecordScore(*score); err != nil {
+			return nil, err
+		}
+	}
+	if bureauReport != nil {
+		if err = client.RecordCreditBureauReport(*bureauReport); err != nil {
+			return nil, err
+		}
+	}
+	if reviewReason != "" {
+		if err = client.ReferForReview(reviewReason); err != nil {
+			return nil, err
+		}
+	}
+	if cola.agreements {
+		if err = client.RequireAgreement(); err != nil {
+			return nil, err
+		}
+	}
+	err = cola.ClientRepo.Save(client)
+	if err != nil {
+		return nil, fmt.Errorf("client %s is applying for %s loan with term %d: %v", ktpNumber, amount, term, err)
+	}
+	return nil, nil
+}
+
+// checkEligibility adds rules of the application layer to domain.Client.CheckEligibility
+func checkEligibility(client domain.Client, product domain.Product, amount lms.Money, term domain.Term) error {
+	if err := client.CheckEligibility(product, amount, term); err != nil {
+		return err
+	}
+	if client.BankAccountNumber() == "" {
+		return lms.ErrBankAccountMissing
+	}
+	return nil
+}
+
+func (cola *cola) Products() []lms.Product {
+	var products []lms.Product
+	for _, p := range domain.Products() {
+		products = append(products, product{p})
+	}
+	return products
+}
+
+func (cola *cola) Quote(ktpNumber string, productCode string, amount lms.Money, term uint) (lms.Quote, error) {
+	product, found := domain.ProductByCode(productCode)
+	if !found {
+		return lms.Quote{}, lms.ErrProductDoesNotExist
+	}
+	now := cola.now()
+	quote, err := domain.NewQuote(product, amount, domain.Term(term), now)
+	if err != nil {
+		return lms.Quote{}, err
+	}
+	var eligibility error
+	if ktpNumber != "" {
+		client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+		if err != nil {
+			return lms.Quote{}, fmt.Errorf("client %s is asking for a quote of %s loan with term %d: %v", ktpNumber, amount, term, err)
+		}
+		if !found {
+			return lms.Quote{}, lms.ErrClientDoesNotExist
+		}
+		applications, err := cola.ApplicationRepo.ByKTPNumber(ktpNumber)
+		if err != nil {
+			return lms.Quote{}, fmt.Errorf("client %s is asking for a quote of %s loan with term %d: %v", ktpNumber, amount, term, err)
+		}
+		eligibility = checkEligibility(client, product, amount, domain.Term(term))
+		if eligibility == nil {
+			product, _, eligibility = cola.score(client, applications, product, now)
+		}
+		if eligibility == nil {
+			eligibility = product.Validate(amount, domain.Term(term))
+		}
+		// price of the score band is quoted only when the client can borrow the amount in the band
+		if eligibility == nil {
+			if quote, err = domain.NewQuote(product, amount, domain.Term(term), now); err != nil {
+				return lms.Quote{}, err
+			}
+		}
+	}
+	dto := lms.Quote{
+		ProductCode:         productCode,
+		Amount:              quote.Amount,
+		Term:                uint(quote.Term),
+		Fee:                 quote.Fee,
+		Interest:            quote.Interest,
+		TotalPayable:        quote.TotalPayable,
+		Eligible:            eligibility == nil,
+		IneligibilityReason: eligibility,
+	}
+	for _, instalment := range quote.Instalments {
+		dto.Instalments = append(dto.Instalments, lms.Instalment{DueDate: instalment.DueDate, Amount: instalment.Amount})
+	}
+	return dto, nil
+}
+
+// product adapts domain.Product to lms.Product
+type product struct {
+	domain.Product
+}
+
+func (product product) MinTerm() uint {
+	return uint(product.Product.MinTerm())
+}
+
+func (product product) MaxTerm() uint {
+	return uint(product.Product.MaxTerm())
+}
+
+func (product product) InstalmentPeriod() uint {
+	return uint(product.Product.InstalmentPeriod())
 }
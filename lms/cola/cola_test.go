@@ -1,9 +1,12 @@
 package cola
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/briyanadityatama/goLoans/lms"
 	"github.com/briyanadityatama/goLoans/lms/cola/domain"
@@ -11,24 +14,30 @@ import (
 import "github.com/stretchr/testify/assert"
 
 const (
-	ktpNumber = "3522582509010002"
-	amount    = 10000000
-	term      = 30
-	birthDate = "1 December 1994"
-	name      = "Doe"
+	ktpNumber   = "3522582509010002"
+	productCode = "payday"
+	term        = 30
+	birthDate   = "1 December 1994"
+	name        = "Doe"
 )
 
 var clientData = lms.ClientData{KTPNumber: ktpNumber, BirthDate: birthDate, Name: name}
 
 func TestLmsRegisterClient(t *testing.T) {
 	clientRepo := NewFakeClientRepo()
-	cola := New(clientRepo)
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
 	client, err := cola.RegisterClient(clientData)
 	t.Run("should create a new client", func(t *testing.T) {
 		assert.Equal(t, client.KTPNumber(), ktpNumber)
 		assert.Equal(t, client.BirthDate(), birthDate)
 		assert.Equal(t, client.Name(), name)
 	})
+	t.Run("should assign virtual account", func(t *testing.T) {
+		assert.Equal(t, domain.NewVirtualAccount(1), client.VirtualAccount())
+		clientByVirtualAccount, found, _ := cola.ClientByVirtualAccount(client.VirtualAccount())
+		assert.True(t, found)
+		assert.Equal(t, client, clientByVirtualAccount)
+	})
 	t.Run("should save a new client in repo", func(t *testing.T) {
 		clientFoundInRepo, _, _ := clientRepo.ByKTPNumber(ktpNumber)
 		assert.Equal(t, client, clientFoundInRepo)
@@ -38,9 +47,16 @@ func TestLmsRegisterClient(t *testing.T) {
 	})
 }
 
+func TestLmsRegisterClientsWithUniqueVirtualAccounts(t *testing.T) {
+	cola := New(NewFakeClientRepo(), NewFakeApplicationRepo(), NewFakeDisburser())
+	first, _ := cola.RegisterClient(lms.ClientData{KTPNumber: "1"})
+	second, _ := cola.RegisterClient(lms.ClientData{KTPNumber: "2"})
+	assert.NotEqual(t, first.VirtualAccount(), second.VirtualAccount())
+}
+
 func TestLmsRegisterClientTwice(t *testing.T) {
 	clientRepo := NewFakeClientRepo()
-	cola := New(clientRepo)
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
 	cola.RegisterClient(clientData)
 	client, err := cola.RegisterClient(clientData)
 	t.Run("should return error", func(t *testing.T) {
@@ -56,8 +72,8 @@ func TestLmsRegisterClientTwice(t *testing.T) {
 
 func TestLmsClientByPersonalNumber(t *testing.T) {
 	clientRepo := NewFakeClientRepo()
-	cola := New(clientRepo)
-	client := domain.NewClient(birthDate, name, ktpNumber)
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	client := domain.NewClient("", birthDate, name, ktpNumber)
 	clientRepo.Save(client)
 	returnedClient, found, _ := cola.ClientByKTPNumber(ktpNumber)
 	assert.True(t, found)
@@ -66,10 +82,10 @@ func TestLmsClientByPersonalNumber(t *testing.T) {
 
 func TestLmsApplyForLoan(t *testing.T) {
 	clientRepo := NewFakeClientRepo()
-	cola := New(clientRepo)
-	clientRepo.Save(domain.NewClient(birthDate, name, ktpNumber))
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
 	// when
-	err := cola.ApplyForLoan(ktpNumber, amount, term)
+	err := cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
 	t.Run("should not return error", func(t *testing.T) {
 		assert.Nil(t, err)
 	})
@@ -79,13 +95,1127 @@ func TestLmsApplyForLoan(t *testing.T) {
 	})
 }
 
+func TestLmsApplyForLoanDisbursesLoan(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	disburser := NewFakeDisburser()
+	cola := New(clientRepo, NewFakeApplicationRepo(), disburser)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	loan := client.ActiveLoan()
+	assert.Equal(t, domain.Disbursed, loan.DisbursementStatus())
+	assert.Equal(t, "disbursement-"+loan.ID(), loan.DisbursementReference())
+	assert.Equal(t, []Transfer{{
+		IdempotencyKey: "disbursement-" + loan.ID(),
+		BankCode:       "014",
+		AccountNumber:  "1234567890",
+		AccountHolder:  name,
+		Amount:         amount,
+	}}, disburser.Transfers)
+}
+
+func TestLmsApplyForLoanWhenTransferIsRejected(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	disburser := &FakeDisburser{Err: ErrTransferRejected}
+	cola := New(clientRepo, NewFakeApplicationRepo(), disburser)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	err := cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	assert.Equal(t, lms.ErrDisbursementFailed, err)
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	assert.False(t, client.HasActiveLoan())
+	assert.Equal(t, domain.DisbursementFailed, client.Loans()[0].DisbursementStatus())
+}
+
+func TestLmsApplyForLoanWhenTransferOutcomeIsUnknown(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	disburser := &FakeDisburser{Err: errors.New("timeout")}
+	cola := New(clientRepo, NewFakeApplicationRepo(), disburser)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	err := cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	t.Run("loan should stay disbursing", func(t *testing.T) {
+		assert.Equal(t, "disbursing loan "+ktpNumber+"-1: timeout", err.Error())
+		assert.Equal(t, domain.Disbursing, client.ActiveLoan().DisbursementStatus())
+	})
+	t.Run("retry should use the same idempotency key", func(t *testing.T) {
+		disburser.Err = nil
+		assert.Nil(t, cola.DisburseLoan(ktpNumber))
+		assert.Equal(t, domain.Disbursed, client.ActiveLoan().DisbursementStatus())
+		assert.Len(t, disburser.Transfers, 2)
+		assert.Equal(t, disburser.Transfers[0].IdempotencyKey, disburser.Transfers[1].IdempotencyKey)
+	})
+	t.Run("disbursed loan should not be disbursed again", func(t *testing.T) {
+		assert.Equal(t, domain.ErrLoanAlreadyDisbursed, cola.DisburseLoan(ktpNumber))
+	})
+}
+
+func TestLmsApplyForLoanWithoutBankAccount(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	cola.RegisterClient(clientData)
+	err := cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	assert.Equal(t, lms.ErrBankAccountMissing, err)
+}
+
 func TestLmsApplyForLoanWhenClientDoesNotExist(t *testing.T) {
 	clientRepo := NewFakeClientRepo()
-	cola := New(clientRepo)
-	err := cola.ApplyForLoan(ktpNumber, amount, term)
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	err := cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
 	assert.Equal(t, err, lms.ErrClientDoesNotExist)
 }
 
+func TestLmsApplyForUnknownProduct(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	err := cola.ApplyForLoan(application(ktpNumber, "mortgage", amount, term))
+	assert.Equal(t, lms.ErrProductDoesNotExist, err)
+}
+
+func TestLmsApplyForLoanWithTermOutOfRange(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	err := cola.ApplyForLoan(application(ktpNumber, productCode, amount, 0))
+	assert.Equal(t, "term_out_of_range", err.Error())
+}
+
+func TestLmsApplyForLoanInOtherCurrency(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	sgdAmount, _ := domain.ParseMoney("1000", domain.SGD)
+	t.Run("should fail without exchange rate", func(t *testing.T) {
+		err := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser()).ApplyForLoan(application(ktpNumber, "payday_sgd", sgdAmount, term))
+		assert.Contains(t, err.Error(), ErrExchangeRateNotFound.Error())
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.False(t, client.HasActiveLoan())
+	})
+	t.Run("should snapshot exchange rate on the loan", func(t *testing.T) {
+		rate, _ := domain.NewExchangeRate(domain.SGD, domain.IDR, "12150.5", today)
+		disburser := NewFakeDisburser()
+		service := New(clientRepo, NewFakeApplicationRepo(), disburser, WithExchangeRates(FakeExchangeRates{rate}))
+		assert.Nil(t, service.ApplyForLoan(application(ktpNumber, "payday_sgd", sgdAmount, term)))
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Equal(t, rate, client.ActiveLoan().ReportingRate())
+		assert.Equal(t, sgdAmount, disburser.Transfers[0].Amount)
+	})
+	t.Run("should not need exchange rate for loans in reporting currency", func(t *testing.T) {
+		clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+		service := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+		assert.Nil(t, service.ApplyForLoan(application("3522582509010001", productCode, amount, term)))
+		client, _, _ := clientRepo.ByKTPNumber("3522582509010001")
+		assert.Equal(t, "1", client.ActiveLoan().ReportingRate().Rate())
+	})
+}
+
+func TestLmsProducts(t *testing.T) {
+	products := New(NewFakeClientRepo(), NewFakeApplicationRepo(), NewFakeDisburser()).Products()
+	assert.Len(t, products, 5)
+	payday := products[0]
+	assert.Equal(t, "payday", payday.Code())
+	assert.Equal(t, domain.IDR, payday.Currency())
+	assert.Equal(t, domain.SGD, products[3].Currency())
+	assert.Equal(t, uint(7), payday.MinTerm())
+	assert.Equal(t, uint(30), payday.MaxTerm())
+	assert.Equal(t, lms.Rupiah(50000000), payday.MaxAmount())
+}
+
+// newBorrower returns a client with bank account, so loans can be disbursed
+func newBorrower(birthDate, name, ktpNumber string) domain.Client {
+	client := domain.NewClient("", birthDate, name, ktpNumber)
+	profile := client.Profile()
+	profile.BankAccount = domain.BankAccount{BankCode: "014", Number: "1234567890", HolderName: name}
+	client.UpdateProfile(profile, time.Time{})
+	return client
+}
+
+var amount = lms.Rupiah(10000000)
+
+var today = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// plus adds whole rupiah to money
+func plus(money lms.Money, rupiah int64) lms.Money {
+	sum, _ := money.Add(lms.Rupiah(rupiah))
+	return sum
+}
+
+// application declares income high enough for any loan in the currency of the amount
+func application(ktpNumber, productCode string, amount lms.Money, term uint) lms.ApplicationData {
+	return lms.ApplicationData{
+		KTPNumber:      ktpNumber,
+		ProductCode:    productCode,
+		Amount:         amount,
+		Term:           term,
+		MonthlyIncome:  domain.NewMoney(amount.MinorUnits()*10, amount.Currency()),
+		EmploymentType: string(domain.Employed),
+	}
+}
+
+func newWithFixedClock(repo ClientRepo, options ...Option) lms.Lms {
+	service := New(repo, NewFakeApplicationRepo(), NewFakeDisburser(), options...).(*cola)
+	service.now = func() time.Time { return today }
+	return service
+}
+
+func TestLmsQuote(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo)
+	t.Run("without client", func(t *testing.T) {
+		quote, err := service.Quote("", "instalment", amount, 90)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.Quote{
+			ProductCode:  "instalment",
+			Amount:       amount,
+			Term:         90,
+			Fee:          lms.Rupiah(300000),
+			Interest:     lms.Rupiah(900000),
+			TotalPayable: lms.Rupiah(11200000),
+			Instalments: []lms.Instalment{
+				{DueDate: today.AddDate(0, 0, 30), Amount: domain.NewMoney(373333333, domain.IDR)},
+				{DueDate: today.AddDate(0, 0, 60), Amount: domain.NewMoney(373333333, domain.IDR)},
+				{DueDate: today.AddDate(0, 0, 90), Amount: domain.NewMoney(373333334, domain.IDR)},
+			},
+			Eligible: true,
+		}, quote)
+	})
+	t.Run("client with active loan should not be eligible", func(t *testing.T) {
+		clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+		service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+		quote, err := service.Quote(ktpNumber, productCode, amount, term)
+		assert.Nil(t, err)
+		assert.False(t, quote.Eligible)
+		assert.Equal(t, domain.ErrClientAlreadyHasLoan, quote.IneligibilityReason)
+	})
+	t.Run("should not create a loan", func(t *testing.T) {
+		otherClient := newBorrower(birthDate, name, "3522582509010001")
+		clientRepo.Save(otherClient)
+		quote, _ := service.Quote(otherClient.KTPNumber(), productCode, amount, term)
+		assert.True(t, quote.Eligible)
+		assert.False(t, otherClient.HasActiveLoan())
+	})
+	t.Run("unknown client", func(t *testing.T) {
+		_, err := service.Quote("1", productCode, amount, term)
+		assert.Equal(t, lms.ErrClientDoesNotExist, err)
+	})
+	t.Run("term out of range", func(t *testing.T) {
+		_, err := service.Quote("", productCode, amount, 31)
+		assert.Equal(t, "term_out_of_range", err.Error())
+	})
+}
+
+func TestLmsApplyForLoanChecksAffordability(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	data := application(ktpNumber, productCode, lms.Rupiah(10000000), term)
+	data.MonthlyIncome = lms.Rupiah(40000000)
+	data.MonthlyObligations = lms.Rupiah(2000000)
+	t.Run("loan over share of income should be declined with maximum affordable amount", func(t *testing.T) {
+		err := service.ApplyForLoan(data)
+		assert.EqualError(t, err, "loan_not_affordable")
+		assert.Equal(t, domain.NewMoney(967741935, domain.IDR), err.(domain.NotAffordableStruct).MaxAffordableAmount)
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.False(t, client.HasActiveLoan())
+	})
+	t.Run("unknown employment type should be rejected", func(t *testing.T) {
+		invalid := data
+		invalid.EmploymentType = "astronaut"
+		assert.Equal(t, domain.ErrInvalidAffordability, service.ApplyForLoan(invalid))
+	})
+	t.Run("higher debt to income ratio should allow the loan", func(t *testing.T) {
+		assert.Nil(t, newWithFixedClock(clientRepo, WithMaxDebtToIncome(4000)).ApplyForLoan(data))
+	})
+	t.Run("declared income should be recorded and exported", func(t *testing.T) {
+		export, err := service.ExportClientData(ktpNumber)
+		assert.Nil(t, err)
+		assert.Equal(t, &lms.AffordabilityExport{
+			MonthlyIncome:      lms.Rupiah(40000000),
+			EmploymentType:     "employed",
+			MonthlyObligations: lms.Rupiah(2000000),
+		}, export.Loans[0].Affordability)
+	})
+}
+
+func TestLmsApplyForLoanWithCreditBureau(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	report := domain.CreditBureauReport{Debts: []domain.BureauDebt{{Lender: "BANK", Outstanding: lms.Rupiah(1000000), DaysPastDue: 5}}, ReportedAt: today}
+	bureau := &FakeCreditBureau{Reports: map[string]domain.CreditBureauReport{
+		ktpNumber:          report,
+		"3522582509010001": {Debts: []domain.BureauDebt{{Lender: "BANK", Outstanding: lms.Rupiah(1000000), DaysPastDue: 31}}},
+	}}
+	service := newWithFixedClock(clientRepo, WithCreditBureau(bureau))
+	t.Run("report should be stored on the loan", func(t *testing.T) {
+		clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+		assert.Nil(t, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		recorded, found := client.ActiveLoan().CreditBureauReport()
+		assert.True(t, found)
+		assert.Equal(t, report, recorded)
+		assert.Equal(t, domain.Disbursed, client.ActiveLoan().DisbursementStatus())
+	})
+	t.Run("client delinquent at other lender should be declined", func(t *testing.T) {
+		clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+		err := service.ApplyForLoan(application("3522582509010001", productCode, amount, term))
+		assert.Equal(t, domain.ErrDelinquentAtOtherLender, err)
+		client, _, _ := clientRepo.ByKTPNumber("3522582509010001")
+		assert.False(t, client.HasActiveLoan())
+	})
+	t.Run("report should be stored on the declined application", func(t *testing.T) {
+		export, _ := service.ExportClientData("3522582509010001")
+		assert.Equal(t, domain.ErrDelinquentAtOtherLender.Error(), export.Applications[0].Reason)
+		assert.Equal(t, &lms.CreditBureauReportExport{
+			Debts: []lms.BureauDebtExport{{Lender: "BANK", Outstanding: lms.Rupiah(1000000), DaysPastDue: 31}},
+		}, export.Applications[0].CreditBureauReport)
+	})
+	t.Run("report should be reused for 24 hours", func(t *testing.T) {
+		service.ApplyForLoan(application("3522582509010001", productCode, amount, term))
+		assert.Equal(t, 2, bureau.Requests)
+		service.(*cola).now = func() time.Time { return today.Add(24 * time.Hour) }
+		service.ApplyForLoan(application("3522582509010001", productCode, amount, term))
+		assert.Equal(t, 3, bureau.Requests)
+	})
+	t.Run("other failures of the bureau should fail the application", func(t *testing.T) {
+		bureau.Err = errors.New("connection refused")
+		clientRepo.Save(newBorrower(birthDate, name, "3522582509010003"))
+		err := service.ApplyForLoan(application("3522582509010003", productCode, amount, term))
+		assert.EqualError(t, err, "asking credit bureau about client 3522582509010003: connection refused")
+	})
+}
+
+func TestLmsApplyForLoanWhenCreditBureauTimesOut(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	disburser := NewFakeDisburser()
+	service := New(clientRepo, NewFakeApplicationRepo(), disburser, WithCreditBureau(&FakeCreditBureau{Err: ErrCreditBureauTimeout}), WithOfficers("officer")).(*cola)
+	service.now = func() time.Time { return today }
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+	t.Run("loan should be referred for review", func(t *testing.T) {
+		err := service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+		assert.Equal(t, lms.ErrLoanReferredForReview, err)
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Equal(t, domain.InReview, client.ActiveLoan().DisbursementStatus())
+		assert.Equal(t, "credit_bureau_timeout", client.ActiveLoan().ReviewReason())
+		assert.Empty(t, disburser.Transfers)
+		assert.Equal(t, domain.ErrLoanInReview, service.DisburseLoan(ktpNumber))
+	})
+	t.Run("loans in review should be listed", func(t *testing.T) {
+		service.ApplyForLoan(application("3522582509010001", productCode, amount, term))
+		loans, err := service.LoansInReview()
+		assert.Nil(t, err)
+		assert.Equal(t, []lms.LoanInReview{
+			{KTPNumber: "3522582509010001", Name: name, LoanID: "3522582509010001-1", Product: productCode, Amount: amount, Term: term, AppliedAt: today, Reason: "credit_bureau_timeout"},
+			{KTPNumber: ktpNumber, Name: name, LoanID: ktpNumber + "-1", Product: productCode, Amount: amount, Term: term, AppliedAt: today, Reason: "credit_bureau_timeout"},
+		}, loans)
+	})
+	t.Run("review should be done by an officer", func(t *testing.T) {
+		assert.Equal(t, lms.ErrOfficerRequired, service.ReviewLoan(ktpNumber, "", true))
+		assert.Equal(t, lms.ErrOfficerNotAuthorized, service.ReviewLoan(ktpNumber, "stranger", true))
+	})
+	t.Run("approved loan should be disbursed", func(t *testing.T) {
+		assert.Nil(t, service.ReviewLoan(ktpNumber, "officer", true))
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Equal(t, domain.Disbursed, client.ActiveLoan().DisbursementStatus())
+		assert.Equal(t, "officer", client.ActiveLoan().ReviewedBy())
+		assert.Len(t, disburser.Transfers, 1)
+		assert.Equal(t, domain.ErrLoanNotInReview, service.ReviewLoan(ktpNumber, "officer", true))
+	})
+	t.Run("declined loan should be cancelled", func(t *testing.T) {
+		assert.Nil(t, service.ReviewLoan("3522582509010001", "officer", false))
+		client, _, _ := clientRepo.ByKTPNumber("3522582509010001")
+		assert.False(t, client.HasActiveLoan())
+		assert.Equal(t, domain.Declined, client.Loans()[0].DisbursementStatus())
+		assert.Nil(t, client.CheckLedger())
+		assert.Len(t, disburser.Transfers, 1)
+	})
+	t.Run("applications should be decided by the review", func(t *testing.T) {
+		approved, _ := service.ApplicationsOfClient(ktpNumber)
+		assert.Equal(t, "approved", approved[0].Status)
+		declined, _ := service.ApplicationsOfClient("3522582509010001")
+		assert.Equal(t, "rejected", declined[0].Status)
+		assert.Equal(t, "declined", declined[0].Reason)
+	})
+}
+
+func TestLmsLoanAgreements(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	disburser := NewFakeDisburser()
+	service := New(clientRepo, NewFakeApplicationRepo(), disburser, WithLoanAgreements()).(*cola)
+	service.now = func() time.Time { return today }
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	t.Run("approved loan should wait for acceptance of its agreement", func(t *testing.T) {
+		assert.Nil(t, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+		assert.Empty(t, disburser.Transfers)
+		assert.Equal(t, domain.ErrAgreementNotAccepted, service.DisburseLoan(ktpNumber))
+	})
+	t.Run("agreement should list terms of the loan", func(t *testing.T) {
+		agreement, err := service.LoanAgreement(ktpNumber)
+		assert.Nil(t, err)
+		assert.Equal(t, ktpNumber+"-1", agreement.LoanID)
+		assert.Equal(t, name, agreement.Name)
+		assert.Equal(t, amount, agreement.Amount)
+		assert.Equal(t, lms.Rupiah(12400000), agreement.TotalPayable)
+		assert.Equal(t, []lms.Instalment{{DueDate: today.AddDate(0, 0, term), Amount: lms.Rupiah(12400000)}}, agreement.Instalments)
+		assert.True(t, agreement.Required)
+		assert.Empty(t, agreement.Hash)
+	})
+	t.Run("agreement should not be accepted with hash of other document", func(t *testing.T) {
+		assert.Equal(t, lms.ErrAgreementChanged, service.AcceptAgreement(ktpNumber, lms.AgreementAcceptance{Hash: "abc"}))
+		assert.Empty(t, disburser.Transfers)
+	})
+	t.Run("accepted agreement should be recorded and loan disbursed", func(t *testing.T) {
+		hash := agreementHash(service, ktpNumber)
+		assert.Nil(t, service.AcceptAgreement(ktpNumber, lms.AgreementAcceptance{Hash: hash, IPAddress: "10.0.0.1"}))
+		assert.Len(t, disburser.Transfers, 1)
+		agreement, _ := service.LoanAgreement(ktpNumber)
+		assert.Equal(t, hash, agreement.Hash)
+		assert.Equal(t, "10.0.0.1", agreement.AcceptedFrom)
+		assert.Equal(t, today, agreement.AcceptedAt)
+		assert.Equal(t, domain.ErrNoAgreementToAccept, service.AcceptAgreement(ktpNumber, lms.AgreementAcceptance{Hash: hash}))
+		export, _ := service.ExportClientData(ktpNumber)
+		assert.Equal(t, &lms.AgreementExport{Hash: hash, AcceptedFrom: "10.0.0.1", AcceptedAt: &today}, export.Loans[0].Agreement)
+	})
+	t.Run("accepted agreement should not change after disbursement", func(t *testing.T) {
+		service.now = func() time.Time { return today.AddDate(0, 0, 3) }
+		agreement, _ := service.LoanAgreement(ktpNumber)
+		_, hash, _ := lms.RenderAgreement(agreement)
+		assert.Equal(t, agreement.Hash, hash)
+		assert.Equal(t, today, agreement.StartDate)
+	})
+	t.Run("client without loan has no agreement", func(t *testing.T) {
+		clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+		_, err := service.LoanAgreement("3522582509010001")
+		assert.Equal(t, lms.ErrNoActiveLoan, err)
+		_, err = service.LoanAgreement("1")
+		assert.Equal(t, lms.ErrClientDoesNotExist, err)
+	})
+}
+
+// agreementHash is the hash of the agreement the client reads now
+func agreementHash(service lms.Lms, ktpNumber string) string {
+	agreement, _ := service.LoanAgreement(ktpNumber)
+	_, hash, _ := lms.RenderAgreement(agreement)
+	return hash
+}
+
+func TestLmsLoanAgreementAcceptedBeforeReview(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	disburser := NewFakeDisburser()
+	service := New(clientRepo, NewFakeApplicationRepo(), disburser, WithLoanAgreements(),
+		WithCreditBureau(&FakeCreditBureau{Err: ErrCreditBureauTimeout}), WithOfficers("officer")).(*cola)
+	service.now = func() time.Time { return today }
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	assert.Nil(t, service.AcceptAgreement(ktpNumber, lms.AgreementAcceptance{Hash: agreementHash(service, ktpNumber)}))
+	reviewedAt := today.AddDate(0, 0, 4)
+	service.now = func() time.Time { return reviewedAt }
+	assert.Nil(t, service.ReviewLoan(ktpNumber, "officer", true))
+	t.Run("agreement should be accepted again when the loan is approved on a later day", func(t *testing.T) {
+		assert.Empty(t, disburser.Transfers)
+		agreement, _ := service.LoanAgreement(ktpNumber)
+		assert.True(t, agreement.Required)
+		assert.Empty(t, agreement.Hash)
+		assert.Equal(t, reviewedAt, agreement.StartDate)
+		assert.Equal(t, []lms.Instalment{{DueDate: reviewedAt.AddDate(0, 0, term), Amount: lms.Rupiah(12400000)}}, agreement.Instalments)
+	})
+	t.Run("schedule should start when the agreement is accepted again", func(t *testing.T) {
+		hash := agreementHash(service, ktpNumber)
+		assert.Nil(t, service.AcceptAgreement(ktpNumber, lms.AgreementAcceptance{Hash: hash}))
+		assert.Len(t, disburser.Transfers, 1)
+		assert.Equal(t, hash, agreementHash(service, ktpNumber))
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Equal(t, reviewedAt, client.ActiveLoan().StartDate())
+	})
+}
+
+func TestLmsLoanApplications(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	bureau := &FakeCreditBureau{Err: ErrCreditBureauTimeout}
+	service := newWithFixedClock(clientRepo, WithCreditBureau(bureau)).(*cola)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+	clientRepo.Save(newBorrower(birthDate, name, "3522582509010003"))
+	t.Run("rejected application should be recorded", func(t *testing.T) {
+		data := application(ktpNumber, productCode, lms.Rupiah(1), term)
+		data.Channel, data.IPAddress = "android", "10.0.0.1"
+		application, err := service.SubmitApplication(data)
+		assert.Equal(t, "amount_too_low", err.Error())
+		assert.Equal(t, lms.LoanApplication{
+			ID:                 "APP0000000001",
+			KTPNumber:          ktpNumber,
+			ProductCode:        productCode,
+			Amount:             lms.Rupiah(1),
+			Term:               term,
+			MonthlyIncome:      lms.Rupiah(10),
+			EmploymentType:     "employed",
+			MonthlyObligations: domain.Money{},
+			Status:             "rejected",
+			Reason:             "amount_too_low",
+			SubmittedAt:        today,
+			DecidedAt:          today,
+			Channel:            "android",
+			IPAddress:          "10.0.0.1",
+		}, application)
+	})
+	t.Run("application of unknown client should not be recorded", func(t *testing.T) {
+		application, err := service.SubmitApplication(application("1", productCode, amount, term))
+		assert.Equal(t, lms.ErrClientDoesNotExist, err)
+		assert.Empty(t, application.ID)
+	})
+	t.Run("application should wait for review with its loan", func(t *testing.T) {
+		application, err := service.SubmitApplication(application(ktpNumber, productCode, amount, term))
+		assert.Equal(t, lms.ErrLoanReferredForReview, err)
+		assert.Equal(t, "under_review", application.Status)
+		assert.Equal(t, "credit_bureau_timeout", application.Reason)
+		assert.Equal(t, ktpNumber+"-1", application.LoanID)
+	})
+	t.Run("cancelled application should cancel its loan in review", func(t *testing.T) {
+		assert.Nil(t, service.CancelApplication("APP0000000002"))
+		application, found, _ := service.ApplicationByID("APP0000000002")
+		assert.True(t, found)
+		assert.Equal(t, "cancelled", application.Status)
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.False(t, client.HasActiveLoan())
+		assert.Equal(t, domain.Cancelled, client.Loans()[0].DisbursementStatus())
+		assert.Nil(t, client.CheckLedger())
+		assert.Equal(t, domain.ErrApplicationAlreadyDecided, service.CancelApplication("APP0000000002"))
+		assert.Equal(t, lms.ErrApplicationDoesNotExist, service.CancelApplication("APP0000000099"))
+	})
+	t.Run("applications should be listed in the order they were submitted", func(t *testing.T) {
+		applications, err := service.ApplicationsOfClient(ktpNumber)
+		assert.Nil(t, err)
+		assert.Len(t, applications, 2)
+		assert.Equal(t, "APP0000000001", applications[0].ID)
+		assert.Equal(t, "APP0000000002", applications[1].ID)
+	})
+	t.Run("application whose assessment failed should stay submitted", func(t *testing.T) {
+		bureau.Err = errors.New("connection refused")
+		application, err := service.SubmitApplication(application("3522582509010003", productCode, amount, term))
+		assert.NotNil(t, err)
+		assert.Equal(t, "submitted", application.Status)
+	})
+	t.Run("undecided applications should expire", func(t *testing.T) {
+		bureau.Err = ErrCreditBureauTimeout
+		service.SubmitApplication(application("3522582509010001", productCode, amount, term))
+		expired, err := service.ExpireApplications(today.Add(domain.ApplicationValidity - time.Second))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, expired)
+		expired, err = service.ExpireApplications(today.Add(domain.ApplicationValidity))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, expired)
+		client, _, _ := clientRepo.ByKTPNumber("3522582509010001")
+		assert.False(t, client.HasActiveLoan())
+		applications, _ := service.ApplicationsOfClient("3522582509010001")
+		assert.Equal(t, "expired", applications[0].Status)
+	})
+	t.Run("applications should be exported", func(t *testing.T) {
+		export, _ := service.ExportClientData(ktpNumber)
+		assert.Len(t, export.Applications, 2)
+		assert.Equal(t, "rejected", export.Applications[0].Status)
+		assert.Equal(t, &today, export.Applications[0].DecidedAt)
+		assert.Equal(t, ktpNumber+"-1", export.Applications[1].LoanID)
+	})
+}
+
+// ageScorecard puts clients from 25 years of age in band A and declines younger clients without birth date
+func ageScorecard() domain.Scorecard {
+	adult := 25
+	scorecard, _ := domain.NewScorecard(domain.Scorecard{
+		Name:      "age",
+		Products:  []string{productCode},
+		BaseScore: 500,
+		Characteristics: []domain.Characteristic{{Attribute: domain.Age, Bins: []domain.Bin{
+			{Min: &adult, Points: 100},
+			{Max: &adult, Points: 20, ReasonCode: "young_age"},
+			{Missing: true, Points: -100, ReasonCode: "birth_date_unknown"},
+		}}},
+		Bands: []domain.ScoreBand{
+			{Name: "A", MinScore: 600, MaxAmountBasisPoints: 10000, PriceBasisPoints: 5000},
+			{Name: "B", MinScore: 500, MaxAmountBasisPoints: 2000, PriceBasisPoints: 10000},
+		},
+	})
+	return scorecard
+}
+
+func TestLmsApplyForLoanWithScorecard(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo, WithScorecards(ageScorecard()))
+	t.Run("score should be stored on the loan", func(t *testing.T) {
+		clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+		err := service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+		assert.Nil(t, err)
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		score, found := client.ActiveLoan().Score()
+		assert.True(t, found)
+		assert.Equal(t, domain.Score{Scorecard: "age", Points: 600, Band: "A", ScoredAt: today}, score)
+		assert.Equal(t, lms.Rupiah(1200000), client.ActiveLoan().Interest())
+		export, _ := service.ExportClientData(ktpNumber)
+		assert.Equal(t, &lms.ScoreExport{Scorecard: "age", Points: 600, Band: "A", ScoredAt: today}, export.Loans[0].Score)
+	})
+	t.Run("band should limit amount", func(t *testing.T) {
+		clientRepo.Save(newBorrower("1 December 2006", name, "3522580112060001"))
+		err := service.ApplyForLoan(application("3522580112060001", productCode, lms.Rupiah(20000000), term))
+		assert.Equal(t, domain.ErrAmountTooHigh.Error(), err.Error())
+		assert.Equal(t, lms.Rupiah(10000000), err.(domain.AmountTooHighStruct).MaxAmount)
+		assert.Nil(t, service.ApplyForLoan(application("3522580112060001", productCode, lms.Rupiah(1000000), term)))
+	})
+	t.Run("score below all bands should decline the application", func(t *testing.T) {
+		clientRepo.Save(newBorrower("unknown", name, "3522580000000001"))
+		err := service.ApplyForLoan(application("3522580000000001", productCode, amount, term))
+		assert.Equal(t, domain.ErrScoreTooLow.Error(), err.Error())
+		assert.Equal(t, []string{"birth_date_unknown"}, err.(domain.ScoreTooLowStruct).Score.Reasons)
+		client, _, _ := clientRepo.ByKTPNumber("3522580000000001")
+		assert.False(t, client.HasActiveLoan())
+	})
+	t.Run("score should be stored on the declined application", func(t *testing.T) {
+		applications, _ := service.ApplicationsOfClient("3522580000000001")
+		assert.Equal(t, "age", applications[0].Scorecard)
+		assert.Equal(t, []string{"birth_date_unknown"}, applications[0].ReasonCodes)
+		export, _ := service.ExportClientData("3522580000000001")
+		assert.Equal(t, applications[0].ScorePoints, export.Applications[0].Score.Points)
+	})
+	t.Run("products without scorecard should not be scored", func(t *testing.T) {
+		clientRepo.Save(newBorrower("unknown", name, "3522580000000002"))
+		assert.Nil(t, service.ApplyForLoan(application("3522580000000002", "instalment", amount, 90)))
+		client, _, _ := clientRepo.ByKTPNumber("3522580000000002")
+		_, found := client.ActiveLoan().Score()
+		assert.False(t, found)
+	})
+}
+
+func TestLmsApplyForLoanAfterRejectedApplication(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	none, one := 0, 1
+	scorecard, _ := domain.NewScorecard(domain.Scorecard{
+		Name:      "recent",
+		BaseScore: 500,
+		Characteristics: []domain.Characteristic{{Attribute: domain.RecentApplications, Bins: []domain.Bin{
+			{Max: &none, Points: 100},
+			{Min: &one, Points: 0, ReasonCode: "recent_applications"},
+		}}},
+		Bands: []domain.ScoreBand{{Name: "A", MinScore: 500, MaxAmountBasisPoints: 10000, PriceBasisPoints: 10000}},
+	})
+	service := newWithFixedClock(clientRepo, WithScorecards(scorecard))
+	service.RegisterClient(clientData)
+	assert.Equal(t, lms.ErrBankAccountMissing, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+	bankCode, number, holder := "014", "1234567890", name
+	service.UpdateClient(ktpNumber, lms.ClientPatch{BankCode: &bankCode, BankAccountNumber: &number, BankAccountHolder: &holder})
+	assert.Nil(t, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	score, _ := client.ActiveLoan().Score()
+	assert.Equal(t, []string{"recent_applications"}, score.Reasons)
+}
+
+func TestLmsQuoteWithScorecard(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo, WithScorecards(ageScorecard()))
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	clientRepo.Save(newBorrower("unknown", name, "3522580000000001"))
+	t.Run("client should be quoted the price of the band", func(t *testing.T) {
+		quote, err := service.Quote(ktpNumber, productCode, amount, term)
+		assert.Nil(t, err)
+		assert.True(t, quote.Eligible)
+		assert.Equal(t, lms.Rupiah(1200000), quote.Interest)
+	})
+	t.Run("quote without client should have the price of the product", func(t *testing.T) {
+		quote, _ := service.Quote("", productCode, amount, term)
+		assert.Equal(t, lms.Rupiah(2400000), quote.Interest)
+	})
+	t.Run("client with score below all bands should not be eligible", func(t *testing.T) {
+		quote, err := service.Quote("3522580000000001", productCode, amount, term)
+		assert.Nil(t, err)
+		assert.False(t, quote.Eligible)
+		assert.Equal(t, domain.ErrScoreTooLow.Error(), quote.IneligibilityReason.Error())
+	})
+}
+
+func TestLmsUpdateClient(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	cola.RegisterClient(clientData)
+	phone, email, empty := "081234567890", "doe@example.com", ""
+	client, err := cola.UpdateClient(ktpNumber, lms.ClientPatch{Phone: &phone, Email: &email, Name: &empty})
+	t.Run("error should be nil", func(t *testing.T) {
+		assert.Nil(t, err)
+	})
+	t.Run("should change only patched fields", func(t *testing.T) {
+		assert.Equal(t, phone, client.Phone())
+		assert.Equal(t, email, client.Email())
+		assert.Equal(t, "", client.Name())
+		assert.Equal(t, birthDate, client.BirthDate())
+	})
+	t.Run("should save audit of changed fields", func(t *testing.T) {
+		clientFoundInRepo, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Len(t, clientFoundInRepo.ProfileChanges(), 3)
+	})
+}
+
+func TestLmsUpdateClientKTPNumber(t *testing.T) {
+	cola := New(NewFakeClientRepo(), NewFakeApplicationRepo(), NewFakeDisburser())
+	cola.RegisterClient(clientData)
+	sameKTPNumber, otherKTPNumber := ktpNumber, "3522582509010001"
+	_, err := cola.UpdateClient(ktpNumber, lms.ClientPatch{KTPNumber: &otherKTPNumber})
+	assert.Equal(t, lms.ErrKTPNumberImmutable, err)
+	_, err = cola.UpdateClient(ktpNumber, lms.ClientPatch{KTPNumber: &sameKTPNumber})
+	assert.Nil(t, err)
+}
+
+func TestLmsUpdateClientWithInvalidPhone(t *testing.T) {
+	cola := New(NewFakeClientRepo(), NewFakeApplicationRepo(), NewFakeDisburser())
+	cola.RegisterClient(clientData)
+	phone := "12345"
+	_, err := cola.UpdateClient(ktpNumber, lms.ClientPatch{Phone: &phone})
+	assert.Equal(t, domain.ErrInvalidPhone, err)
+}
+
+func TestLmsUpdateClientWhenClientDoesNotExist(t *testing.T) {
+	_, err := New(NewFakeClientRepo(), NewFakeApplicationRepo(), NewFakeDisburser()).UpdateClient(ktpNumber, lms.ClientPatch{})
+	assert.Equal(t, lms.ErrClientDoesNotExist, err)
+}
+
+func TestLmsClientsPagination(t *testing.T) {
+	cola := New(NewFakeClientRepo(), NewFakeApplicationRepo(), NewFakeDisburser())
+	for _, ktpNumber := range []string{"5", "3", "1", "4", "2"} {
+		cola.RegisterClient(lms.ClientData{KTPNumber: ktpNumber, Name: "Doe " + ktpNumber})
+	}
+	ktpNumbers := func(page lms.ClientsPage) (numbers []string) {
+		for _, client := range page.Clients {
+			numbers = append(numbers, client.KTPNumber())
+		}
+		return
+	}
+	first, err := cola.Clients(lms.ClientQuery{Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, ktpNumbers(first))
+	assert.Empty(t, first.PreviousCursor)
+	second, _ := cola.Clients(lms.ClientQuery{Limit: 2, Cursor: first.NextCursor})
+	assert.Equal(t, []string{"3", "4"}, ktpNumbers(second))
+	last, _ := cola.Clients(lms.ClientQuery{Limit: 2, Cursor: second.NextCursor})
+	assert.Equal(t, []string{"5"}, ktpNumbers(last))
+	assert.Empty(t, last.NextCursor)
+	previous, _ := cola.Clients(lms.ClientQuery{Limit: 2, Cursor: last.PreviousCursor})
+	assert.Equal(t, []string{"3", "4"}, ktpNumbers(previous))
+	_, err = cola.Clients(lms.ClientQuery{Cursor: "???"})
+	assert.Equal(t, lms.ErrInvalidCursor, err)
+}
+
+func TestLmsClientsFilters(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo)
+	service.RegisterClient(lms.ClientData{KTPNumber: "1", Name: "Doe", BirthDate: birthDate})
+	clientRepo.Save(newBorrower("2 December 1994", "Dolly", "2"))
+	service.RegisterClient(lms.ClientData{KTPNumber: "3", Name: "Smith", BirthDate: birthDate})
+	service.ApplyForLoan(application("2", productCode, amount, term))
+	overdueClient := domain.NewClient("", birthDate, "Smithson", "4")
+	overdueClient.ApplyForLoan(domain.PaydayLoan, amount, term, today.AddDate(0, 0, -40), domain.IdentityRate(domain.IDR, today))
+	clientRepo.Save(overdueClient)
+	yes, no := true, false
+	tests := map[string]struct {
+		query      lms.ClientQuery
+		ktpNumbers []string
+	}{
+		"name prefix":     {lms.ClientQuery{NamePrefix: "do"}, []string{"1", "2"}},
+		"birth date":      {lms.ClientQuery{BirthDate: birthDate}, []string{"1", "3", "4"}},
+		"has active loan": {lms.ClientQuery{HasActiveLoan: &yes}, []string{"2", "4"}},
+		"no active loan":  {lms.ClientQuery{HasActiveLoan: &no}, []string{"1", "3"}},
+		"overdue":         {lms.ClientQuery{Overdue: &yes}, []string{"4"}},
+		"combined":        {lms.ClientQuery{NamePrefix: "Smith", Overdue: &no}, []string{"3"}},
+		"nothing matches": {lms.ClientQuery{NamePrefix: "X"}, nil},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := service.Clients(test.query)
+			assert.Nil(t, err)
+			assert.Equal(t, today, page.AsOf)
+			var ktpNumbers []string
+			for _, client := range page.Clients {
+				ktpNumbers = append(ktpNumbers, client.KTPNumber())
+			}
+			assert.Equal(t, test.ktpNumbers, ktpNumbers)
+		})
+	}
+}
+
+func TestLmsExportClientData(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	email := "doe@example.com"
+	service.UpdateClient(ktpNumber, lms.ClientPatch{Email: &email})
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	client.Repay(lms.Rupiah(1000), today)
+	export, err := service.ExportClientData(ktpNumber)
+	assert.Nil(t, err)
+	assert.Equal(t, ktpNumber, export.KTPNumber)
+	assert.Equal(t, name, export.Profile.Name)
+	assert.Equal(t, email, export.Profile.Email)
+	assert.Len(t, export.ProfileChanges, 4)
+	assert.Equal(t, lms.ProfileChangeExport{Field: "email", NewValue: email, ChangedAt: today}, export.ProfileChanges[3])
+	assert.Len(t, export.Loans, 1)
+	assert.Equal(t, productCode, export.Loans[0].Product)
+	assert.Equal(t, []lms.RepaymentExport{{Amount: lms.Rupiah(1000), PaidAt: today}}, export.Loans[0].Repayments)
+	assert.Equal(t, today, export.ExportedAt)
+}
+
+func TestLmsEraseClient(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	cola := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	t.Run("should refuse erasure while loan is active", func(t *testing.T) {
+		cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+		assert.Equal(t, domain.ErrClientHasActiveLoan, cola.EraseClient(ktpNumber))
+	})
+	t.Run("should pseudonymize client and retain loans", func(t *testing.T) {
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		client.Repay(client.ActiveLoan().Remaining(), time.Now())
+		assert.Nil(t, cola.EraseClient(ktpNumber))
+		export, _ := cola.ExportClientData(ktpNumber)
+		assert.True(t, export.Erased)
+		assert.NotEqual(t, name, export.Profile.Name)
+		assert.Len(t, export.Loans, 1)
+	})
+	t.Run("unknown client", func(t *testing.T) {
+		assert.Equal(t, lms.ErrClientDoesNotExist, cola.EraseClient("1"))
+	})
+}
+
+func TestLmsRepay(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	err := service.Repay(ktpNumber, lms.Rupiah(1000))
+	assert.Nil(t, err)
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	assert.Equal(t, []domain.Repayment{{Amount: lms.Rupiah(1000), PaidAt: today}}, client.ActiveLoan().Repayments())
+	assert.Equal(t, domain.ErrRepaymentAmountTooHigh, service.Repay(ktpNumber, plus(client.ActiveLoan().Remaining(), 1)))
+	assert.Equal(t, lms.ErrClientDoesNotExist, service.Repay("1", lms.Rupiah(1000)))
+}
+
+func TestLmsAccrueLateCharges(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	service.ApplyForLoan(application("3522582509010001", "instalment", amount, 90))
+	asOf := today.AddDate(0, 0, term+2)
+	run, err := service.AccrueLateCharges(asOf)
+	t.Run("should charge overdue loans only", func(t *testing.T) {
+		assert.Nil(t, err)
+		assert.Equal(t, lms.AccrualRun{AsOf: asOf, Loans: 2, Accruals: 6}, run)
+	})
+	t.Run("should not charge again for the same day", func(t *testing.T) {
+		run, err := service.AccrueLateCharges(asOf)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.AccrualRun{AsOf: asOf}, run)
+	})
+	t.Run("should export accruals", func(t *testing.T) {
+		export, _ := service.ExportClientData(ktpNumber)
+		assert.Len(t, export.Loans[0].Accruals, 3)
+		assert.Equal(t, "late_fee", export.Loans[0].Accruals[0].Kind)
+		assert.Equal(t, lms.Rupiah(198400), export.Loans[0].LateCharges)
+	})
+}
+
+func TestLmsRunDunning(t *testing.T) {
+	clientRepo := &copyingClientRepo{ClientRepo: NewFakeClientRepo()}
+	notifier := &FakeNotifier{}
+	service := newWithFixedClock(clientRepo, WithDunning(domain.DefaultDunningSchedule, notifier))
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	dueDate := today.AddDate(0, 0, term)
+	t.Run("should send notice of the stage reached", func(t *testing.T) {
+		run, err := service.RunDunning(dueDate.AddDate(0, 0, 7))
+		assert.Nil(t, err)
+		assert.Equal(t, lms.DunningRun{AsOf: dueDate.AddDate(0, 0, 7), Notices: 1}, run)
+		assert.Equal(t, []Notice{{
+			Stage:       "escalation_1",
+			Action:      domain.Escalate,
+			KTPNumber:   ktpNumber,
+			LoanID:      ktpNumber + "-1",
+			DueDate:     dueDate,
+			AmountDue:   lms.Rupiah(12400000),
+			Remaining:   lms.Rupiah(12400000),
+			DaysPastDue: 7,
+		}}, notifier.Notices)
+	})
+	t.Run("should list loans by stage", func(t *testing.T) {
+		stages, err := service.LoansByDunningStage()
+		assert.Nil(t, err)
+		assert.Len(t, stages, len(domain.DefaultDunningSchedule))
+		assert.Equal(t, "escalation_1", stages[2].Stage)
+		assert.Equal(t, []lms.DunningLoan{{KTPNumber: ktpNumber, Name: name, LoanID: ktpNumber + "-1", DueDate: dueDate,
+			AmountPastDue: lms.Rupiah(0), Remaining: lms.Rupiah(12400000)}}, stages[2].Loans)
+		assert.Empty(t, stages[0].Loans)
+	})
+	t.Run("should default loan handed over to collections", func(t *testing.T) {
+		run, err := service.RunDunning(dueDate.AddDate(0, 0, 90))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, run.Defaulted)
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.True(t, client.ActiveLoan().IsDefaulted())
+	})
+	t.Run("should report failing notifier", func(t *testing.T) {
+		notifier.Err = errors.New("smtp down")
+		clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+		service.ApplyForLoan(application("3522582509010001", productCode, amount, term))
+		_, err := service.RunDunning(dueDate)
+		assert.NotNil(t, err)
+		client, _, _ := clientRepo.ByKTPNumber("3522582509010001")
+		assert.Empty(t, client.ActiveLoan().DunningStage())
+	})
+	t.Run("should send notice again after notifier failed", func(t *testing.T) {
+		notifier.Err = nil
+		notifier.Notices = nil
+		run, err := service.RunDunning(dueDate)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, run.Notices)
+		assert.Equal(t, "due_notice", notifier.Notices[0].Stage)
+	})
+}
+
+func TestLmsRunDunningNotifications(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	sms := &FakeChannel{Channel: domain.SMSChannel}
+	service := newWithFixedClock(clientRepo, WithNotifications(NewFakeNotificationRepo(), English, sms))
+	client := newBorrower(birthDate, name, ktpNumber)
+	profile := client.Profile()
+	profile.Phone = "081234567890"
+	client.UpdateProfile(profile, today)
+	client.AssignVirtualAccount(domain.NewVirtualAccount(1))
+	clientRepo.Save(client)
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	dueDate := today.AddDate(0, 0, term)
+	for _, asOf := range []time.Time{dueDate.AddDate(0, 0, 7), dueDate.AddDate(0, 0, 30), dueDate.AddDate(0, 0, 90)} {
+		service.RunDunning(asOf)
+	}
+	service.RetryNotifications(today)
+	events := []domain.NotificationEvent{}
+	for _, message := range sms.Messages {
+		events = append(events, message.Event)
+	}
+	t.Run("client should be notified of every overdue stage", func(t *testing.T) {
+		assert.Equal(t, []domain.NotificationEvent{domain.LoanApprovedEvent, domain.LoanDisbursedEvent,
+			domain.PaymentOverdueEvent, domain.PaymentOverdueEvent, domain.LoanDefaultedEvent}, events)
+		assert.Equal(t, "Hi Doe, 12400000.00 IDR of your loan 3522582509010002-1 was due on 2026-01-31 and is not paid yet. Please pay to virtual account 880800000000016.", sms.Messages[2].Body)
+	})
+}
+
+func TestLmsDisburseLoanAfterReview(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := New(clientRepo, NewFakeApplicationRepo(), NewFakeDisburser(), WithCreditBureau(&FakeCreditBureau{Err: ErrCreditBureauTimeout}), WithOfficers("officer")).(*cola)
+	service.now = func() time.Time { return today }
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	reviewedAt := today.AddDate(0, 0, 4)
+	service.now = func() time.Time { return reviewedAt }
+	assert.Nil(t, service.ReviewLoan(ktpNumber, "officer", true))
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	assert.Equal(t, reviewedAt, client.ActiveLoan().StartDate())
+	assert.Equal(t, reviewedAt.AddDate(0, 0, term), client.ActiveLoan().DueDate())
+	assert.Equal(t, uint(0), client.DaysPastDue(today.AddDate(0, 0, term+1)))
+	export, _ := service.ExportClientData(ktpNumber)
+	assert.Equal(t, reviewedAt, export.Loans[0].StartDate)
+}
+
+func TestLmsWriteOffLoan(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo, WithOfficers("officer"))
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	t.Run("should require officer", func(t *testing.T) {
+		assert.Equal(t, lms.ErrOfficerRequired, service.WriteOffLoan(ktpNumber, ""))
+		assert.Equal(t, lms.ErrOfficerNotAuthorized, service.WriteOffLoan(ktpNumber, "stranger"))
+	})
+	t.Run("should not write off loan which is not defaulted", func(t *testing.T) {
+		assert.Equal(t, domain.ErrLoanNotDefaulted, service.WriteOffLoan(ktpNumber, "officer"))
+	})
+	t.Run("should write off defaulted loan", func(t *testing.T) {
+		service.RunDunning(today.AddDate(0, 0, term+90))
+		assert.Nil(t, service.WriteOffLoan(ktpNumber, "officer"))
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.False(t, client.HasActiveLoan())
+		assert.Equal(t, "officer", client.WrittenOffLoan().WrittenOffBy())
+	})
+	t.Run("should reconcile payment as recovery", func(t *testing.T) {
+		line := lms.StatementLine{BankReference: "B1", Date: today, Amount: lms.Rupiah(5000), Reference: ktpNumber}
+		report, err := service.Reconcile([]lms.StatementLine{line})
+		assert.Nil(t, err)
+		assert.Equal(t, []lms.ReconciledLine{{StatementLine: line, KTPNumber: ktpNumber, Applied: true, Recovery: true}}, report.Matched)
+		export, _ := service.ExportClientData(ktpNumber)
+		assert.True(t, export.Loans[0].WrittenOff)
+		assert.Equal(t, lms.Rupiah(5000), export.Loans[0].Recovered)
+		assert.Equal(t, []lms.RepaymentExport{{Amount: lms.Rupiah(5000), PaidAt: today, Recovery: true}}, export.Loans[0].Repayments)
+	})
+	t.Run("should refuse new loan", func(t *testing.T) {
+		assert.Equal(t, domain.ErrClientHasWrittenOffLoan, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+	})
+}
+
+func TestLmsReconcile(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo)
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	paidAt := today.AddDate(0, 0, 3)
+	matched := lms.StatementLine{BankReference: "B1", Date: paidAt, Amount: lms.Rupiah(5000), Reference: "LOAN " + ktpNumber}
+	unmatched := lms.StatementLine{BankReference: "B2", Date: paidAt, Amount: lms.Rupiah(5000), Reference: "LOAN 1111222233334444"}
+	overpaid := lms.StatementLine{BankReference: "B3", Date: paidAt, Amount: lms.Rupiah(100000000), Reference: ktpNumber}
+	withoutLoan := lms.StatementLine{BankReference: "B4", Date: paidAt, Amount: lms.Rupiah(5000), Reference: "3522582509010001"}
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	client.AssignVirtualAccount(domain.NewVirtualAccount(7))
+	byVirtualAccount := lms.StatementLine{BankReference: "B5", Date: paidAt, Amount: lms.Rupiah(3000), Reference: "VA " + domain.NewVirtualAccount(7)}
+	report, err := service.Reconcile([]lms.StatementLine{matched, unmatched, overpaid, withoutLoan, byVirtualAccount})
+	t.Run("error should be nil", func(t *testing.T) {
+		assert.Nil(t, err)
+	})
+	t.Run("should report lines by outcome", func(t *testing.T) {
+		assert.Equal(t, []lms.ReconciledLine{
+			{StatementLine: matched, KTPNumber: ktpNumber, Applied: true},
+			{StatementLine: byVirtualAccount, KTPNumber: ktpNumber, Applied: true},
+		}, report.Matched)
+		assert.Equal(t, []lms.ReconciledLine{{StatementLine: unmatched, Reason: lms.ErrClientDoesNotExist}}, report.Unmatched)
+		assert.Equal(t, []lms.ReconciledLine{
+			{StatementLine: overpaid, KTPNumber: ktpNumber, Reason: domain.ErrRepaymentAmountTooHigh},
+			{StatementLine: withoutLoan, KTPNumber: "3522582509010001", Reason: domain.ErrRepaymentAmountTooHigh},
+		}, report.Overpaid)
+	})
+	t.Run("should apply only matched lines", func(t *testing.T) {
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Equal(t, []domain.Repayment{{Amount: lms.Rupiah(5000), PaidAt: paidAt}, {Amount: lms.Rupiah(3000), PaidAt: paidAt}}, client.ActiveLoan().Repayments())
+	})
+	t.Run("importing the same statement again should not book repayments twice", func(t *testing.T) {
+		again, err := service.Reconcile([]lms.StatementLine{matched, unmatched, byVirtualAccount})
+		assert.Nil(t, err)
+		assert.Empty(t, again.Matched)
+		assert.Equal(t, []lms.ReconciledLine{
+			{StatementLine: matched, KTPNumber: ktpNumber, Reason: lms.ErrStatementLineAlreadyProcessed},
+			{StatementLine: byVirtualAccount, KTPNumber: ktpNumber, Reason: lms.ErrStatementLineAlreadyProcessed},
+		}, again.Duplicates)
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Len(t, client.ActiveLoan().Repayments(), 2)
+	})
+	t.Run("line without bank reference should not be booked", func(t *testing.T) {
+		withoutReference := lms.StatementLine{Date: paidAt, Amount: lms.Rupiah(5000), Reference: "LOAN " + ktpNumber}
+		report, err := service.Reconcile([]lms.StatementLine{withoutReference, withoutReference})
+		assert.Nil(t, err)
+		assert.Equal(t, []lms.ReconciledLine{
+			{StatementLine: withoutReference, Reason: lms.ErrBankReferenceMissing},
+			{StatementLine: withoutReference, Reason: lms.ErrBankReferenceMissing},
+		}, report.Unmatched)
+		client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+		assert.Len(t, client.ActiveLoan().Repayments(), 2)
+	})
+}
+
+func TestLmsRepayKeepingOverpaymentAsCredit(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo, WithOverpaymentMode(KeepOverpaymentAsCredit))
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	t.Run("should not keep credit of repayment booked without statement line", func(t *testing.T) {
+		assert.Equal(t, domain.ErrRepaymentAmountTooHigh, service.Repay(ktpNumber, plus(client.ActiveLoan().Remaining(), 1000)))
+		assert.True(t, client.HasActiveLoan())
+	})
+	t.Run("should repay the loan and keep the excess as credit", func(t *testing.T) {
+		line := lms.StatementLine{BankReference: "B1", Date: today, Amount: plus(client.ActiveLoan().Remaining(), 1000), Reference: ktpNumber}
+		report, err := service.Reconcile([]lms.StatementLine{line})
+		assert.Nil(t, err)
+		assert.Equal(t, []lms.ReconciledLine{{StatementLine: line, KTPNumber: ktpNumber, Applied: true}}, report.Overpaid)
+		assert.False(t, client.HasActiveLoan())
+		assert.Equal(t, lms.Rupiah(1000), client.CreditBalance())
+	})
+	t.Run("should report overpaid statement line as applied", func(t *testing.T) {
+		line := lms.StatementLine{BankReference: "B2", Amount: lms.Rupiah(500), Reference: ktpNumber}
+		report, err := service.Reconcile([]lms.StatementLine{line})
+		assert.Nil(t, err)
+		assert.Equal(t, []lms.ReconciledLine{{StatementLine: line, KTPNumber: ktpNumber, Applied: true}}, report.Overpaid)
+		assert.Equal(t, lms.Rupiah(1500), client.CreditBalance())
+	})
+	t.Run("should apply credit to the next loan", func(t *testing.T) {
+		service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+		assert.Equal(t, lms.Rupiah(0), client.CreditBalance())
+		assert.Equal(t, []domain.Repayment{{Amount: lms.Rupiah(1500), PaidAt: today, FromCredit: true}}, client.ActiveLoan().Repayments())
+	})
+}
+
+func TestLmsRequestRefund(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	disburser := NewFakeDisburser()
+	service := New(clientRepo, NewFakeApplicationRepo(), disburser, WithOverpaymentMode(KeepOverpaymentAsCredit))
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	service.Reconcile([]lms.StatementLine{{BankReference: "B1", Date: today, Amount: lms.Rupiah(5000), Reference: ktpNumber}})
+	client, _, _ := clientRepo.ByKTPNumber(ktpNumber)
+	t.Run("should not refund more than credit balance", func(t *testing.T) {
+		assert.Equal(t, domain.ErrRefundAmountTooHigh, service.RequestRefund(ktpNumber, lms.Rupiah(5001)))
+	})
+	t.Run("should transfer refund to client's bank account", func(t *testing.T) {
+		assert.Nil(t, service.RequestRefund(ktpNumber, lms.Rupiah(2000)))
+		assert.Equal(t, []Transfer{{IdempotencyKey: ktpNumber + "-refund-1", BankCode: "014", AccountNumber: "1234567890", AccountHolder: name, Amount: lms.Rupiah(2000)}}, disburser.Transfers)
+		assert.Equal(t, lms.Rupiah(3000), client.CreditBalance())
+		assert.Equal(t, domain.RefundCompleted, client.Refunds()[0].Status)
+	})
+	t.Run("should return amount to credit balance when transfer is rejected", func(t *testing.T) {
+		disburser.Err = ErrTransferRejected
+		assert.Equal(t, lms.ErrRefundFailed, service.RequestRefund(ktpNumber, lms.Rupiah(3000)))
+		assert.Equal(t, lms.Rupiah(3000), client.CreditBalance())
+		assert.Equal(t, domain.RefundFailed, client.Refunds()[1].Status)
+	})
+	t.Run("should keep refund pending when outcome of transfer is unknown", func(t *testing.T) {
+		disburser.Err = errors.New("bank timed out")
+		assert.Equal(t, lms.ErrRefundPending, service.RequestRefund(ktpNumber, lms.Rupiah(1000)))
+		assert.Equal(t, lms.Rupiah(2000), client.CreditBalance())
+		assert.Equal(t, domain.RefundPending, client.Refunds()[2].Status)
+		run, err := service.RetryRefunds(today)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.RefundRun{AsOf: today, Pending: 1}, run)
+	})
+	t.Run("should complete pending refund with the same idempotency key", func(t *testing.T) {
+		disburser.Err = nil
+		run, err := service.RetryRefunds(today)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.RefundRun{AsOf: today, Completed: 1}, run)
+		assert.Equal(t, domain.RefundCompleted, client.Refunds()[2].Status)
+		assert.Equal(t, ktpNumber+"-refund-3", disburser.Transfers[len(disburser.Transfers)-1].IdempotencyKey)
+		assert.Equal(t, lms.Rupiah(2000), client.CreditBalance())
+	})
+	t.Run("should not erase client with credit balance", func(t *testing.T) {
+		assert.Equal(t, domain.ErrClientHasCreditBalance, service.EraseClient(ktpNumber))
+	})
+	t.Run("should return error when client does not exist", func(t *testing.T) {
+		assert.Equal(t, lms.ErrClientDoesNotExist, service.RequestRefund("1", lms.Rupiah(1000)))
+	})
+}
+
+// copyingClientRepo hands out and keeps copies of clients, so changes made to a client which was not saved are lost
+// as they would be with a database
+type copyingClientRepo struct {
+	ClientRepo
+}
+
+func (repo *copyingClientRepo) ByKTPNumber(ktpNumber string) (domain.Client, bool, error) {
+	client, found, err := repo.ClientRepo.ByKTPNumber(ktpNumber)
+	if !found || err != nil {
+		return client, found, err
+	}
+	return client.Clone(), found, nil
+}
+
+func (repo *copyingClientRepo) Save(client domain.Client) error {
+	return repo.ClientRepo.Save(client.Clone())
+}
+
+func (repo *copyingClientRepo) Search(query ClientQuery) (ClientPage, error) {
+	page, err := repo.ClientRepo.Search(query)
+	for i, client := range page.Clients {
+		page.Clients[i] = client.Clone()
+	}
+	return page, err
+}
+
 type SaveFailingClientRepo struct {
 	ClientRepo
 }
@@ -96,7 +1226,7 @@ func (repo *SaveFailingClientRepo) Save(client domain.Client) error {
 
 func TestLmsWhenRepoSavingIsFailing(t *testing.T) {
 	failingClientRepo := &SaveFailingClientRepo{ClientRepo: NewFakeClientRepo()}
-	cola := New(failingClientRepo)
+	cola := New(failingClientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
 	t.Run("RegisterClient", func(t *testing.T) {
 		_, err := cola.RegisterClient(clientData)
 		expectedErr := fmt.Sprintf("registering client %s %s with ktp number %s: database is down", birthDate, name, ktpNumber)
@@ -114,7 +1244,7 @@ func (repo *byKTPFailingClientRepo) ByKTPNumber(ktpNumber string) (client domain
 
 func TestLmsWhenRepoByPersonalNumberIsFailing(t *testing.T) {
 	failingClientRepo := &byKTPFailingClientRepo{ClientRepo: NewFakeClientRepo()}
-	cola := New(failingClientRepo)
+	cola := New(failingClientRepo, NewFakeApplicationRepo(), NewFakeDisburser())
 	t.Run("RegisterClient", func(t *testing.T) {
 		_, err := cola.RegisterClient(clientData)
 		expectedErr := fmt.Sprintf("registering client %s %s with ktp number %s: database is down again", birthDate, name, ktpNumber)
@@ -125,8 +1255,422 @@ func TestLmsWhenRepoByPersonalNumberIsFailing(t *testing.T) {
 		assert.Equal(t, "loading client by ktp number "+ktpNumber+": database is down again", err.Error())
 	})
 	t.Run("ApplyForLoan", func(t *testing.T) {
-		err := cola.ApplyForLoan(ktpNumber, amount, term)
-		expectedErr := fmt.Sprintf("client %s is applying for %d loan with term %d: database is down again", ktpNumber, amount, term)
+		err := cola.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+		expectedErr := fmt.Sprintf("client %s is applying for %s loan with term %d: database is down again", ktpNumber, amount, term)
 		assert.Equal(t, expectedErr, err.Error())
 	})
 }
+
+func TestLmsTrialBalance(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	service := newWithFixedClock(clientRepo, WithOverpaymentMode(KeepOverpaymentAsCredit))
+	clientRepo.Save(newBorrower(birthDate, name, ktpNumber))
+	clientRepo.Save(newBorrower(birthDate, name, "3522582509010001"))
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	service.Repay(ktpNumber, lms.Rupiah(5000))
+	service.Reconcile([]lms.StatementLine{{BankReference: "B1", Date: today, Amount: lms.Rupiah(100), Reference: "3522582509010001"}})
+	trialBalance, err := service.TrialBalance()
+	assert.Nil(t, err)
+	t.Run("should net balances of all clients", func(t *testing.T) {
+		lines := make(map[string]lms.TrialBalanceLine)
+		for _, line := range trialBalance.Lines {
+			lines[line.Account] = line
+		}
+		assert.Equal(t, lms.TrialBalanceLine{Account: "cash", Currency: "IDR", Debit: lms.Rupiah(0), Credit: lms.Rupiah(9994900)}, lines["cash"])
+		assert.Equal(t, lms.TrialBalanceLine{Account: "loans_receivable", Currency: "IDR", Debit: lms.Rupiah(12395000), Credit: lms.Rupiah(0)}, lines["loans_receivable"])
+		assert.Equal(t, lms.TrialBalanceLine{Account: "client_credit", Currency: "IDR", Debit: lms.Rupiah(0), Credit: lms.Rupiah(100)}, lines["client_credit"])
+	})
+	t.Run("total debits should equal total credits", func(t *testing.T) {
+		debits, credits := lms.Rupiah(0), lms.Rupiah(0)
+		for _, line := range trialBalance.Lines {
+			debits, _ = debits.Add(line.Debit)
+			credits, _ = credits.Add(line.Credit)
+		}
+		assert.Equal(t, debits, credits)
+	})
+	t.Run("ledger should match loans", func(t *testing.T) {
+		assert.Empty(t, trialBalance.Mismatches)
+	})
+}
+
+func TestLmsOTPVerification(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	sender := &FakeSMSSender{}
+	service := newWithFixedClock(clientRepo, WithOTP(sender), WithLoanAgreements()).(*cola)
+	phone := "081234567890"
+	sentCode := func() string {
+		return regexp.MustCompile(`[0-9]{6}`).FindString(sender.Messages[len(sender.Messages)-1].Message)
+	}
+	t.Run("client should not register without verified phone number", func(t *testing.T) {
+		_, err := service.RegisterClient(lms.ClientData{KTPNumber: ktpNumber, BirthDate: birthDate, Name: name, Phone: phone})
+		assert.Equal(t, lms.ErrOTPVerificationRequired, err)
+	})
+	t.Run("code should be sent to the phone number", func(t *testing.T) {
+		challenge, err := service.ChallengeOTP(phone, "registration")
+		assert.Nil(t, err)
+		assert.Equal(t, "+6281234567890", challenge.Phone)
+		assert.Equal(t, today.Add(domain.OTPValidity), challenge.ExpiresAt)
+		assert.Len(t, sender.Messages, 1)
+		assert.Equal(t, "+6281234567890", sender.Messages[0].Phone)
+		_, err = service.ChallengeOTP(phone, "registration")
+		assert.Equal(t, lms.ErrOTPResendTooSoon, err)
+		_, err = service.ChallengeOTP(phone, "payment")
+		assert.Equal(t, domain.ErrInvalidOTPPurpose, err)
+	})
+	t.Run("client should register with verified phone number once", func(t *testing.T) {
+		service.now = func() time.Time { return today.Add(domain.OTPResendInterval) }
+		challenge, _ := service.ChallengeOTP(phone, "registration")
+		assert.Equal(t, lms.ErrOTPChallengeDoesNotExist, service.VerifyOTP("unknown", sentCode()))
+		assert.Equal(t, "otp_invalid", service.VerifyOTP(challenge.ID, "abc").Error())
+		assert.Nil(t, service.VerifyOTP(challenge.ID, sentCode()))
+		clientData := lms.ClientData{KTPNumber: ktpNumber, BirthDate: birthDate, Name: name, Phone: phone, OTPChallengeID: challenge.ID}
+		client, err := service.RegisterClient(clientData)
+		assert.Nil(t, err)
+		assert.Equal(t, phone, client.Phone())
+		clientData.KTPNumber = "3522582509010001"
+		_, err = service.RegisterClient(clientData)
+		assert.Equal(t, lms.ErrOTPVerificationRequired, err)
+	})
+	t.Run("client should accept agreement with code verified for acceptance", func(t *testing.T) {
+		bankCode, number, holder := "014", "1234567890", name
+		service.UpdateClient(ktpNumber, lms.ClientPatch{BankCode: &bankCode, BankAccountNumber: &number, BankAccountHolder: &holder})
+		assert.Nil(t, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+		registration, _ := service.ChallengeOTP("+6281234567890", "registration")
+		service.VerifyOTP(registration.ID, sentCode())
+		acceptance := lms.AgreementAcceptance{Hash: agreementHash(service, ktpNumber), IPAddress: "10.0.0.1", OTPChallengeID: registration.ID}
+		assert.Equal(t, lms.ErrOTPVerificationRequired, service.AcceptAgreement(ktpNumber, acceptance))
+		challenge, _ := service.ChallengeOTP(phone, "agreement_acceptance")
+		service.VerifyOTP(challenge.ID, sentCode())
+		acceptance.OTPChallengeID = challenge.ID
+		assert.Nil(t, service.AcceptAgreement(ktpNumber, acceptance))
+	})
+	t.Run("client should change phone number verified for phone change", func(t *testing.T) {
+		newPhone := "081234567899"
+		_, err := service.UpdateClient(ktpNumber, lms.ClientPatch{Phone: &newPhone})
+		assert.Equal(t, lms.ErrOTPVerificationRequired, err)
+		challenge, _ := service.ChallengeOTP(phone, "phone_change")
+		service.VerifyOTP(challenge.ID, sentCode())
+		_, err = service.UpdateClient(ktpNumber, lms.ClientPatch{Phone: &newPhone, OTPChallengeID: challenge.ID})
+		assert.Equal(t, lms.ErrOTPVerificationRequired, err)
+		challenge, _ = service.ChallengeOTP(newPhone, "phone_change")
+		service.VerifyOTP(challenge.ID, sentCode())
+		client, err := service.UpdateClient(ktpNumber, lms.ClientPatch{Phone: &newPhone, OTPChallengeID: challenge.ID})
+		assert.Nil(t, err)
+		assert.Equal(t, newPhone, client.Phone())
+		email := "doe@example.com"
+		_, err = service.UpdateClient(ktpNumber, lms.ClientPatch{Phone: &newPhone, Email: &email})
+		assert.Nil(t, err)
+	})
+	t.Run("code should not be sent when SMS fails", func(t *testing.T) {
+		sender.Err = errors.New("gateway down")
+		_, err := service.ChallengeOTP("081234567891", "registration")
+		assert.EqualError(t, err, "sending otp to 081234567891: gateway down")
+		sender.Err = nil
+		_, err = service.ChallengeOTP("081234567891", "registration")
+		assert.Nil(t, err)
+	})
+	t.Run("OTP should not be required without sender", func(t *testing.T) {
+		_, err := newWithFixedClock(NewFakeClientRepo()).ChallengeOTP(phone, "registration")
+		assert.Equal(t, lms.ErrOTPDisabled, err)
+	})
+}
+
+// reenteringSMSSender requests another code for the same phone while the first one is being sent
+type reenteringSMSSender struct {
+	service lms.Lms
+	err     error
+}
+
+func (sender *reenteringSMSSender) Send(phone string, message string) error {
+	_, sender.err = sender.service.ChallengeOTP(phone, "registration")
+	return nil
+}
+
+func TestLmsOTPWhileSending(t *testing.T) {
+	sender := &reenteringSMSSender{}
+	service := newWithFixedClock(NewFakeClientRepo(), WithOTP(sender))
+	sender.service = service
+	_, err := service.ChallengeOTP("081234567890", "registration")
+	assert.Nil(t, err)
+	assert.Equal(t, lms.ErrOTPResendTooSoon, sender.err)
+}
+
+func TestLmsOTPWhenRegistrationFails(t *testing.T) {
+	sender := &FakeSMSSender{}
+	service := newWithFixedClock(&SaveFailingClientRepo{ClientRepo: NewFakeClientRepo()}, WithOTP(sender)).(*cola)
+	phone := "081234567890"
+	challenge, _ := service.ChallengeOTP(phone, "registration")
+	service.VerifyOTP(challenge.ID, regexp.MustCompile(`[0-9]{6}`).FindString(sender.Messages[0].Message))
+	_, err := service.RegisterClient(lms.ClientData{KTPNumber: ktpNumber, BirthDate: birthDate, Name: name, Phone: phone, OTPChallengeID: challenge.ID})
+	assert.NotNil(t, err)
+	t.Run("challenge should allow another attempt", func(t *testing.T) {
+		used, err := service.useOTP(challenge.ID, phone, domain.RegistrationOTP)
+		assert.Nil(t, err)
+		assert.NotNil(t, used)
+	})
+	t.Run("challenge should not be used twice", func(t *testing.T) {
+		_, err := service.useOTP(challenge.ID, phone, domain.RegistrationOTP)
+		assert.Equal(t, lms.ErrOTPVerificationRequired, err)
+	})
+}
+
+func TestLmsNotifications(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	sms := &FakeChannel{Channel: domain.SMSChannel}
+	email := &FakeChannel{Channel: domain.EmailChannel}
+	webhook := &FakeChannel{Channel: domain.WebhookChannel, Err: errors.New("webhook down")}
+	service := newWithFixedClock(clientRepo, WithNotifications(NewFakeNotificationRepo(), English, sms, email, webhook)).(*cola)
+	client := newBorrower(birthDate, name, ktpNumber)
+	profile := client.Profile()
+	profile.Phone = "081234567890"
+	client.UpdateProfile(profile, today)
+	client.AssignVirtualAccount(domain.NewVirtualAccount(1))
+	clientRepo.Save(client)
+	t.Run("notifications should be queued", func(t *testing.T) {
+		assert.Nil(t, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+		assert.Empty(t, sms.Messages)
+		notifications, _ := service.NotificationsOfClient(ktpNumber)
+		assert.Len(t, notifications, 4)
+		assert.Equal(t, "pending", notifications[0].Status)
+	})
+	t.Run("client should be notified of approval and disbursement through channels of their contacts", func(t *testing.T) {
+		run, err := service.RetryNotifications(today)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.NotificationRun{AsOf: today, Delivered: 2, Pending: 2}, run)
+		assert.Empty(t, email.Messages)
+		assert.Equal(t, []Message{
+			{
+				ID:        "NTF0000000001",
+				KTPNumber: ktpNumber,
+				Event:     domain.LoanApprovedEvent,
+				Recipient: "081234567890",
+				Subject:   "Your loan 3522582509010002-1 is approved",
+				Body:      "Hi Doe, your loan 3522582509010002-1 of 10000000.00 IDR is approved. The total payable is 12400000.00 IDR.",
+			},
+			{
+				ID:        "NTF0000000003",
+				KTPNumber: ktpNumber,
+				Event:     domain.LoanDisbursedEvent,
+				Recipient: "081234567890",
+				Subject:   "Your loan 3522582509010002-1 is disbursed",
+				Body:      "Hi Doe, 10000000.00 IDR of your loan 3522582509010002-1 was transferred to your bank account.",
+			},
+		}, sms.Messages)
+	})
+	t.Run("failed delivery should be tracked", func(t *testing.T) {
+		notifications, err := service.NotificationsOfClient(ktpNumber)
+		assert.Nil(t, err)
+		assert.Len(t, notifications, 4)
+		assert.Equal(t, "delivered", notifications[0].Status)
+		assert.Equal(t, today, notifications[0].DeliveredAt)
+		assert.Equal(t, "webhook", notifications[1].Channel)
+		assert.Equal(t, "pending", notifications[1].Status)
+		assert.Equal(t, "webhook down", notifications[1].LastError)
+		assert.Equal(t, today.Add(domain.NotificationRetryDelays[0]), notifications[1].NextAttemptAt)
+	})
+	t.Run("client should get receipt of repayment", func(t *testing.T) {
+		assert.Nil(t, service.Repay(ktpNumber, lms.Rupiah(1000000)))
+		service.RetryNotifications(today)
+		receipt := sms.Messages[len(sms.Messages)-1]
+		assert.Equal(t, domain.RepaymentReceivedEvent, receipt.Event)
+		assert.Equal(t, "Hi Doe, we received your repayment of 1000000.00 IDR. The remaining amount of your loan 3522582509010002-1 is 11400000.00 IDR.", receipt.Body)
+	})
+	t.Run("client should be reminded of instalment due once", func(t *testing.T) {
+		notifications, _ := service.NotificationsOfClient(ktpNumber)
+		service.RunDunning(today.AddDate(0, 0, term-4))
+		reminders, _ := service.NotificationsOfClient(ktpNumber)
+		assert.Len(t, reminders, len(notifications))
+		service.RunDunning(today.AddDate(0, 0, term-3))
+		reminders, _ = service.NotificationsOfClient(ktpNumber)
+		assert.Len(t, reminders, len(notifications)+2)
+		service.RetryNotifications(today)
+		reminder := sms.Messages[len(sms.Messages)-1]
+		assert.Equal(t, domain.InstalmentDueEvent, reminder.Event)
+		assert.Equal(t, "Hi Doe, 11400000.00 IDR of your loan 3522582509010002-1 is due on 2026-01-31. Please pay to virtual account 880800000000016.", reminder.Body)
+		service.RunDunning(today.AddDate(0, 0, term-1))
+		reminders, _ = service.NotificationsOfClient(ktpNumber)
+		assert.Len(t, reminders, len(notifications)+2)
+	})
+	t.Run("failed notifications should be retried when due", func(t *testing.T) {
+		run, err := service.RetryNotifications(today)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.NotificationRun{AsOf: today}, run)
+		webhook.Err = nil
+		run, err = service.RetryNotifications(today.Add(domain.NotificationRetryDelays[0]))
+		assert.Nil(t, err)
+		assert.Equal(t, 4, run.Delivered)
+		assert.Len(t, webhook.Messages, 4)
+		assert.Equal(t, "NTF0000000002", webhook.Messages[0].ID)
+		assert.Empty(t, webhook.Messages[0].Recipient)
+	})
+	t.Run("notifications should be sent in Indonesian", func(t *testing.T) {
+		clientRepo := NewFakeClientRepo()
+		sms := &FakeChannel{Channel: domain.SMSChannel}
+		service := newWithFixedClock(clientRepo, WithNotifications(NewFakeNotificationRepo(), Indonesian, sms))
+		clientRepo.Save(client)
+		assert.Nil(t, service.Repay(ktpNumber, lms.Rupiah(1000000)))
+		service.RetryNotifications(today)
+		assert.Equal(t, "Halo Doe, pembayaran Anda sebesar 1000000.00 IDR telah kami terima. Sisa pinjaman 3522582509010002-1 Anda 10400000.00 IDR.", sms.Messages[0].Body)
+	})
+	t.Run("client should not be notified without notifications", func(t *testing.T) {
+		notifications, err := newWithFixedClock(NewFakeClientRepo()).NotificationsOfClient(ktpNumber)
+		assert.Nil(t, err)
+		assert.Empty(t, notifications)
+	})
+}
+
+func TestLmsNotificationsOfErasedClient(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	sms := &FakeChannel{Channel: domain.SMSChannel}
+	service := newWithFixedClock(clientRepo, WithNotifications(NewFakeNotificationRepo(), English, sms))
+	client := newBorrower(birthDate, name, ktpNumber)
+	profile := client.Profile()
+	profile.Phone = "081234567890"
+	client.UpdateProfile(profile, today)
+	clientRepo.Save(client)
+	service.ApplyForLoan(application(ktpNumber, productCode, amount, term))
+	service.Repay(ktpNumber, lms.Rupiah(12400000))
+	t.Run("notifications should be exported", func(t *testing.T) {
+		export, err := service.ExportClientData(ktpNumber)
+		assert.Nil(t, err)
+		assert.Len(t, export.Notifications, 3)
+		assert.Equal(t, lms.NotificationExport{
+			ID:        "NTF0000000001",
+			Event:     "loan_approved",
+			Reference: ktpNumber + "-1",
+			Channel:   "sms",
+			Recipient: "081234567890",
+			Subject:   "Your loan 3522582509010002-1 is approved",
+			Body:      "Hi Doe, your loan 3522582509010002-1 of 10000000.00 IDR is approved. The total payable is 12400000.00 IDR.",
+			CreatedAt: today,
+			Status:    "pending",
+		}, export.Notifications[0])
+	})
+	t.Run("erasure should remove personal data from notifications and cancel their delivery", func(t *testing.T) {
+		assert.Nil(t, service.EraseClient(ktpNumber))
+		notifications, _ := service.NotificationsOfClient(ktpNumber)
+		assert.Len(t, notifications, 3)
+		for _, notification := range notifications {
+			assert.Empty(t, notification.Recipient)
+			assert.Empty(t, notification.Subject)
+			assert.Empty(t, notification.Body)
+			assert.Equal(t, "failed", notification.Status)
+		}
+		run, err := service.RetryNotifications(today)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.NotificationRun{AsOf: today}, run)
+		assert.Empty(t, sms.Messages)
+	})
+}
+
+func TestLmsWebhooks(t *testing.T) {
+	clientRepo := NewFakeClientRepo()
+	poster := &FakeWebhookPoster{}
+	service := newWithFixedClock(clientRepo, WithWebhooks(NewFakeWebhookRepo(), poster))
+	subscription, err := service.SubscribeWebhook(lms.WebhookSubscriptionData{
+		URL:        "https://partner.example/hooks",
+		EventTypes: []string{"client.registered", "loan.state_changed"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "WHS0000000001", subscription.ID)
+	assert.Len(t, subscription.Secret, 2*domain.WebhookMinSecretLength)
+	events := func() []webhookEvent {
+		var events []webhookEvent
+		for _, request := range poster.Requests {
+			var event webhookEvent
+			json.Unmarshal(request.Payload, &event)
+			events = append(events, event)
+		}
+		return events
+	}
+	t.Run("registration should be posted signed", func(t *testing.T) {
+		_, err := service.RegisterClient(lms.ClientData{BirthDate: birthDate, Name: name, KTPNumber: ktpNumber})
+		assert.Nil(t, err)
+		assert.Empty(t, poster.Requests)
+		run, err := service.RetryWebhookDeliveries(today)
+		assert.Nil(t, err)
+		assert.Equal(t, lms.WebhookRun{AsOf: today, Delivered: 1}, run)
+		assert.Len(t, poster.Requests, 1)
+		request := poster.Requests[0]
+		assert.Equal(t, "https://partner.example/hooks", request.URL)
+		assert.Equal(t, "WHD0000000001", request.DeliveryID)
+		assert.Equal(t, domain.ClientRegisteredWebhook, request.EventType)
+		assert.Equal(t, "1767225600", request.Timestamp)
+		assert.Equal(t, domain.SignWebhook(subscription.Secret, request.Timestamp, request.Payload), request.Signature)
+		assert.Equal(t, map[string]interface{}{"ktpNumber": ktpNumber, "virtualAccount": "880800000000016"}, events()[0].Data)
+	})
+	t.Run("every loan state change should be posted", func(t *testing.T) {
+		bankCode, number, holder := "014", "1234567890", name
+		_, err := service.UpdateClient(ktpNumber, lms.ClientPatch{BankCode: &bankCode, BankAccountNumber: &number, BankAccountHolder: &holder})
+		assert.Nil(t, err)
+		assert.Nil(t, service.ApplyForLoan(application(ktpNumber, productCode, amount, term)))
+		service.RetryWebhookDeliveries(today)
+		var states []interface{}
+		for _, event := range events()[1:] {
+			assert.Equal(t, domain.LoanStateChangedWebhook, event.Type)
+			data := event.Data.(map[string]interface{})
+			assert.Equal(t, "3522582509010002-1", data["loanId"])
+			states = append(states, []interface{}{data["previousState"], data["state"]})
+		}
+		assert.Equal(t, []interface{}{[]interface{}{nil, "approved"}, []interface{}{"approved", "disbursing"}, []interface{}{"disbursing", "disbursed"}}, states)
+	})
+	t.Run("failed delivery should be retried with back off", func(t *testing.T) {
+		poster.StatusCode = 503
+		assert.Nil(t, service.Repay(ktpNumber, lms.Rupiah(12400000)))
+		service.RetryWebhookDeliveries(today)
+		deliveries, err := service.WebhookDeliveries(subscription.ID)
+		assert.Nil(t, err)
+		failed := deliveries[len(deliveries)-1]
+		assert.Equal(t, "pending", failed.Status)
+		assert.Equal(t, []lms.WebhookAttempt{{At: today, StatusCode: 503}}, failed.Attempts)
+		assert.Equal(t, today.Add(domain.WebhookRetryDelay), failed.NextAttemptAt)
+		assert.Contains(t, failed.Payload, `"state":"repaid"`)
+		run, err := service.RetryWebhookDeliveries(today.Add(domain.WebhookRetryDelay))
+		assert.Nil(t, err)
+		assert.Equal(t, lms.WebhookRun{AsOf: today.Add(domain.WebhookRetryDelay), Pending: 1}, run)
+		poster.StatusCode = 0
+		run, err = service.RetryWebhookDeliveries(today.Add(3 * domain.WebhookRetryDelay))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, run.Delivered)
+		deliveries, _ = service.WebhookDeliveries(subscription.ID)
+		assert.Equal(t, "delivered", deliveries[len(deliveries)-1].Status)
+		assert.Len(t, deliveries[len(deliveries)-1].Attempts, 3)
+	})
+	t.Run("delivery should be replayed with the same payload", func(t *testing.T) {
+		replay, err := service.ReplayWebhookDelivery("WHD0000000001")
+		assert.Nil(t, err)
+		assert.Equal(t, "WHD0000000001", replay.ReplayOf)
+		assert.Equal(t, "delivered", replay.Status)
+		original := poster.Requests[0]
+		replayed := poster.Requests[len(poster.Requests)-1]
+		assert.Equal(t, original.Payload, replayed.Payload)
+		assert.Equal(t, replay.ID, replayed.DeliveryID)
+		_, err = service.ReplayWebhookDelivery("WHD9999999999")
+		assert.Equal(t, lms.ErrWebhookDeliveryDoesNotExist, err)
+	})
+	t.Run("delivery log should hold no name of the erased client", func(t *testing.T) {
+		assert.Nil(t, service.EraseClient(ktpNumber))
+		deliveries, _ := service.WebhookDeliveries(subscription.ID)
+		for _, delivery := range deliveries {
+			assert.NotContains(t, delivery.Payload, name)
+		}
+	})
+	t.Run("unsubscribed partner should not get events", func(t *testing.T) {
+		assert.Nil(t, service.UnsubscribeWebhook(subscription.ID))
+		posted := len(poster.Requests)
+		service.RegisterClient(lms.ClientData{KTPNumber: "3522582509010003"})
+		service.RetryWebhookDeliveries(today)
+		assert.Len(t, poster.Requests, posted)
+		_, err := service.WebhookDeliveries(subscription.ID)
+		assert.Equal(t, lms.ErrWebhookSubscriptionDoesNotExist, err)
+		assert.Equal(t, lms.ErrWebhookSubscriptionDoesNotExist, service.UnsubscribeWebhook(subscription.ID))
+	})
+	t.Run("invalid subscription should be rejected", func(t *testing.T) {
+		_, err := service.SubscribeWebhook(lms.WebhookSubscriptionData{URL: "https://partner.example/hooks", EventTypes: []string{"loan.approved"}})
+		assert.Equal(t, domain.ErrInvalidWebhookEventType, err)
+	})
+	t.Run("webhooks should be disabled without option", func(t *testing.T) {
+		_, err := newWithFixedClock(NewFakeClientRepo()).SubscribeWebhook(lms.WebhookSubscriptionData{})
+		assert.Equal(t, lms.ErrWebhooksDisabled, err)
+	})
+}
@@ -0,0 +1,121 @@
+package cola
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// OverpaymentMode decides what happens with money received from a client which exceeds what the client owes
+type OverpaymentMode int
+
+const (
+	// RejectOverpayment refuses the whole repayment with domain.ErrRepaymentAmountTooHigh. This is the default.
+	RejectOverpayment OverpaymentMode = iota
+	// KeepOverpaymentAsCredit keeps the excess of a reconciled statement line as client's credit balance. The balance
+	// is applied to the next loan or refunded on request. Repayments booked without statement line are still rejected.
+	KeepOverpaymentAsCredit
+)
+
+// WithOverpaymentMode configures how repayments exceeding the remaining amount of a loan are handled
+func WithOverpaymentMode(mode OverpaymentMode) Option {
+	return func(cola *cola) {
+		cola.overpaymentMode = mode
+	}
+}
+
+// RequestRefund saves the refund as pending before calling Disburser, in the same way as disburse does for loans.
+// When the outcome of the transfer is unknown the refund stays pending until RetryRefunds transfers it.
+func (cola *cola) RequestRefund(ktpNumber string, amount lms.Money) error {
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return fmt.Errorf("refunding %s to client %s: %v", amount, ktpNumber, err)
+	}
+	if !found {
+		return lms.ErrClientDoesNotExist
+	}
+	if client.BankAccountNumber() == "" {
+		return lms.ErrBankAccountMissing
+	}
+	refund, err := client.RequestRefund(amount, cola.now())
+	if err != nil {
+		return err
+	}
+	if err = cola.ClientRepo.Save(client); err != nil {
+		return fmt.Errorf("refunding %s: %v", refund.ID, err)
+	}
+	err = cola.transferRefund(client, refund, cola.now())
+	if err != nil && err != lms.ErrRefundFailed && isRefundPending(client, refund.ID) {
+		return lms.ErrRefundPending
+	}
+	return err
+}
+
+// transferRefund calls Disburser with the ID of the pending refund as IdempotencyKey, so a retried refund is never
+// transferred twice
+func (cola *cola) transferRefund(client domain.Client, refund domain.Refund, at time.Time) error {
+	reference, err := cola.Disburser.Disburse(Transfer{
+		IdempotencyKey: refund.ID,
+		BankCode:       client.BankCode(),
+		AccountNumber:  client.BankAccountNumber(),
+		AccountHolder:  client.BankAccountHolder(),
+		Amount:         refund.Amount,
+	})
+	if err == ErrTransferRejected {
+		if err = client.FailRefund(refund.ID, at); err != nil {
+			return err
+		}
+		if err = cola.ClientRepo.Save(client); err != nil {
+			return fmt.Errorf("cancelling %s: %w", refund.ID, err)
+		}
+		return lms.ErrRefundFailed
+	}
+	if err != nil {
+		return fmt.Errorf("refunding %s: %v", refund.ID, err)
+	}
+	if err = client.CompleteRefund(refund.ID, reference); err != nil {
+		return err
+	}
+	if err = cola.ClientRepo.Save(client); err != nil {
+		return fmt.Errorf("refunding %s: %w", refund.ID, err)
+	}
+	return nil
+}
+
+func (cola *cola) RetryRefunds(asOf time.Time) (lms.RefundRun, error) {
+	run := lms.RefundRun{AsOf: asOf}
+	err := cola.forEachClient(ClientQuery{}, func(client domain.Client) error {
+		for _, refund := range client.Refunds() {
+			if refund.Status != domain.RefundPending {
+				continue
+			}
+			err := cola.transferRefund(client, refund, asOf)
+			switch {
+			case err == nil:
+				run.Completed++
+			case err == lms.ErrRefundFailed:
+				run.Failed++
+			case isRefundPending(client, refund.ID):
+				run.Pending++
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return run, fmt.Errorf("retrying refunds as of %s: %v", asOf.Format("2006-01-02"), err)
+	}
+	return run, nil
+}
+
+func isRefundPending(client domain.Client, id string) bool {
+	for _, refund := range client.Refunds() {
+		if refund.ID == id {
+			return refund.Status == domain.RefundPending
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package cola
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// CreditBureau reports debts of clients at other lenders by KTP number. Client unknown to the bureau has a report
+// without debts.
+type CreditBureau interface {
+	Report(ktpNumber string) (domain.CreditBureauReport, error)
+}
+
+// ErrCreditBureauTimeout should be returned by CreditBureau when the bureau did not answer in time. Loans applied for
+// meanwhile are referred for manual review, any other error fails the application.
+var ErrCreditBureauTimeout = errors.New("credit_bureau_timeout")
+
+// creditBureauCacheTTL is how long a report of the bureau is reused for the same client
+const creditBureauCacheTTL = 24 * time.Hour
+
+// WithCreditBureau consults the bureau before a loan is approved. Clients delinquent at other lenders are declined.
+// Without it loans are approved without the bureau.
+func WithCreditBureau(bureau CreditBureau) Option {
+	return func(cola *cola) {
+		cola.creditBureau = &cachedCreditBureau{bureau: bureau, reports: make(map[string]cachedReport)}
+	}
+}
+
+// cachedCreditBureau keeps reports of the bureau by KTP number, failed requests are not cached
+type cachedCreditBureau struct {
+	bureau  CreditBureau
+	mutex   sync.Mutex
+	reports map[string]cachedReport
+}
+
+type cachedReport struct {
+	report    domain.CreditBureauReport
+	fetchedAt time.Time
+}
+
+func (cache *cachedCreditBureau) report(ktpNumber string, now time.Time) (domain.CreditBureauReport, error) {
+	cache.mutex.Lock()
+	cached, found := cache.reports[ktpNumber]
+	cache.mutex.Unlock()
+	if found && now.Sub(cached.fetchedAt) < creditBureauCacheTTL {
+		return cached.report, nil
+	}
+	report, err := cache.bureau.Report(ktpNumber)
+	if err != nil {
+		return domain.CreditBureauReport{}, err
+	}
+	cache.mutex.Lock()
+	cache.reports[ktpNumber] = cachedReport{report: report, fetchedAt: now}
+	cache.mutex.Unlock()
+	return report, nil
+}
+
+// checkCreditBureau returns the report of the client when the bureau answered or reason for review when it timed out.
+// Without configured bureau neither is returned. The report is not checked.
+func (cola *cola) checkCreditBureau(ktpNumber string) (report *domain.CreditBureauReport, reviewReason string, err error) {
+	if cola.creditBureau == nil {
+		return nil, "", nil
+	}
+	bureauReport, err := cola.creditBureau.report(ktpNumber, cola.now())
+	if err == ErrCreditBureauTimeout {
+		return nil, err.Error(), nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("asking credit bureau about client %s: %v", ktpNumber, err)
+	}
+	return &bureauReport, "", nil
+}
+
+func (cola *cola) ReviewLoan(ktpNumber string, officer string, approve bool) error {
+	if err := cola.AuthorizeOfficer(officer); err != nil {
+		return err
+	}
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return fmt.Errorf("officer %s is reviewing loan of client %s: %v", officer, ktpNumber, err)
+	}
+	if !found {
+		return lms.ErrClientDoesNotExist
+	}
+	application, err := cola.applicationInReview(ktpNumber)
+	if err != nil {
+		return fmt.Errorf("officer %s is reviewing loan of client %s: %v", officer, ktpNumber, err)
+	}
+	reviewedAt := cola.now()
+	if err = client.ReviewLoan(officer, approve, reviewedAt); err != nil {
+		return err
+	}
+	if err = cola.ClientRepo.Save(client); err != nil {
+		return fmt.Errorf("officer %s is reviewing loan of client %s: %v", officer, ktpNumber, err)
+	}
+	if application != nil {
+		if approve {
+			err = application.Approve(application.LoanID(), reviewedAt)
+		} else {
+			err = application.Reject(string(domain.Declined), reviewedAt)
+		}
+		if err == nil {
+			err = cola.ApplicationRepo.Save(application)
+		}
+		if err != nil {
+			return fmt.Errorf("officer %s is reviewing loan of client %s: %v", officer, ktpNumber, err)
+		}
+	}
+	if !approve {
+		return nil
+	}
+	cola.notifyApproval(client)
+	return cola.disburseAccepted(client)
+}
+
+func (cola *cola) LoansInReview() ([]lms.LoanInReview, error) {
+	loans := []lms.LoanInReview{}
+	err := cola.forEachClientWithActiveLoan(func(client domain.Client) error {
+		loan := client.ActiveLoan()
+		if loan.DisbursementStatus() != domain.InReview {
+			return nil
+		}
+		loans = append(loans, lms.LoanInReview{
+			KTPNumber: client.KTPNumber(),
+			Name:      client.Name(),
+			LoanID:    loan.ID(),
+			Product:   loan.Product().Code(),
+			Amount:    loan.Amount(),
+			Term:      uint(loan.Term()),
+			AppliedAt: loan.StartDate(),
+			Reason:    loan.ReviewReason(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing loans in review: %v", err)
+	}
+	return loans, nil
+}
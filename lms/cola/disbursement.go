@@ -0,0 +1,79 @@
+package cola
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// Disburser transfers money of approved loans to clients' bank accounts. Implementations must not transfer money
+// twice for the same IdempotencyKey, but return the reference of the original transfer instead.
+type Disburser interface {
+	Disburse(transfer Transfer) (reference string, err error)
+}
+
+// Transfer is a request for bank transfer sent to Disburser
+type Transfer struct {
+	IdempotencyKey string
+	BankCode       string
+	AccountNumber  string
+	AccountHolder  string
+	Amount         domain.Money
+}
+
+// ErrTransferRejected should be returned by Disburser when the bank refused the transfer for good. Any other error
+// means the outcome is unknown and disbursement can be retried with the same IdempotencyKey.
+var ErrTransferRejected = errors.New("transfer_rejected")
+
+func (cola *cola) DisburseLoan(ktpNumber string) error {
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return fmt.Errorf("disbursing loan of client %s: %v", ktpNumber, err)
+	}
+	if !found {
+		return lms.ErrClientDoesNotExist
+	}
+	return cola.disburse(client)
+}
+
+// disburse saves the loan as disbursing before calling Disburser, so a crash in between leaves the loan in a state
+// which can be retried
+func (cola *cola) disburse(client domain.Client) error {
+	ktpNumber := client.KTPNumber()
+	loan, err := client.StartDisbursement()
+	if err != nil {
+		return err
+	}
+	if err = cola.ClientRepo.Save(client); err != nil {
+		return fmt.Errorf("disbursing loan %s: %v", loan.ID(), err)
+	}
+	reference, err := cola.Disburser.Disburse(Transfer{
+		IdempotencyKey: "disbursement-" + loan.ID(),
+		BankCode:       client.BankCode(),
+		AccountNumber:  client.BankAccountNumber(),
+		AccountHolder:  client.BankAccountHolder(),
+		Amount:         loan.Amount(),
+	})
+	if err == ErrTransferRejected {
+		if err = client.FailDisbursement(cola.now()); err != nil {
+			return err
+		}
+		if err = cola.ClientRepo.Save(client); err != nil {
+			return fmt.Errorf("cancelling loan %s of client %s: %v", loan.ID(), ktpNumber, err)
+		}
+		return lms.ErrDisbursementFailed
+	}
+	if err != nil {
+		return fmt.Errorf("disbursing loan %s: %v", loan.ID(), err)
+	}
+	if err = client.CompleteDisbursement(reference, cola.now()); err != nil {
+		return err
+	}
+	if err = cola.ClientRepo.Save(client); err != nil {
+		return fmt.Errorf("disbursing loan %s: %v", loan.ID(), err)
+	}
+	cola.notify(client, domain.LoanDisbursedEvent, loan.ID(), notificationData{LoanID: loan.ID(), Amount: loan.Amount()})
+	return nil
+}
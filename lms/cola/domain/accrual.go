@@ -0,0 +1,145 @@
+package domain
+
+import "time"
+
+// PenaltyPolicy defines late charges of overdue loans. Charges are calculated from the instalments past due, so they
+// are never charged on other late charges.
+type PenaltyPolicy struct {
+	// LateFeeBasisPoints is a one-off fee charged on the unpaid part of an instalment the day after its due date
+	LateFeeBasisPoints uint
+	// DailyPenaltyBasisPoints is a penalty interest charged on the amount past due for every day the loan is overdue
+	DailyPenaltyBasisPoints uint
+	// MaxTotalCostBasisPoints caps fee, interest and all late charges of a loan together relative to the loan amount
+	MaxTotalCostBasisPoints uint
+}
+
+// DefaultPenaltyPolicy follows OJK rules for online lending, which cap late charges at 0.3% a day and total cost of
+// a loan at 100% of its amount
+var DefaultPenaltyPolicy = PenaltyPolicy{
+	LateFeeBasisPoints:      100,
+	DailyPenaltyBasisPoints: 30,
+	MaxTotalCostBasisPoints: 10000,
+}
+
+// Accrual is a late charge added to the remaining amount of a loan. Together with the repayments accruals explain
+// how the remaining amount was reached.
+type Accrual struct {
+	// Date is the day of the loan for which the charge was accrued
+	Date time.Time
+	Kind AccrualKind
+	// Base is the amount past due the charge was calculated from
+	Base   Money
+	Amount Money
+}
+
+// AccrualKind tells which late charge was accrued
+type AccrualKind string
+
+const (
+	// LateFee is charged once for every overdue instalment
+	LateFee AccrualKind = "late_fee"
+	// PenaltyInterest is charged for every day the loan is overdue
+	PenaltyInterest AccrualKind = "penalty_interest"
+)
+
+func (client *paydayLoanClient) AccrueLateCharges(policy PenaltyPolicy, asOf time.Time) []Accrual {
+	if client.loan == nil || client.loan.DisbursementStatus() != Disbursed {
+		return nil
+	}
+	accruals := client.loan.accrue(policy, asOf)
+	for _, accrual := range accruals {
+		income := InterestIncome
+		if accrual.Kind == LateFee {
+			income = FeeIncome
+		}
+		client.post(ChargeAccrued, client.loan.id, accrual.Date, debit(LoansReceivable, accrual.Amount), credit(income, accrual.Amount))
+	}
+	return accruals
+}
+
+func (loan *paydayLoan) Accruals() []Accrual {
+	return loan.accruals
+}
+
+func (loan *paydayLoan) LateCharges() Money {
+	charges := NewMoney(0, loan.remaining.Currency())
+	for _, accrual := range loan.accruals {
+		charges = mustAdd(charges, accrual.Amount)
+	}
+	return charges
+}
+
+// accrue charges every day of the loan since the last accrual until asOf. Days are counted from the start date of the
+// loan, the same as due dates of instalments.
+func (loan *paydayLoan) accrue(policy PenaltyPolicy, asOf time.Time) []Accrual {
+	if loan.accruedUntil.IsZero() {
+		loan.accruedUntil = loan.startDate
+	}
+	var accruals []Accrual
+	for day := loan.accruedUntil.AddDate(0, 0, 1); !day.After(asOf); day = day.AddDate(0, 0, 1) {
+		paid := loan.paidUntil(day)
+		pastDue := NewMoney(0, loan.remaining.Currency())
+		for _, instalment := range loan.Quote.Instalments {
+			if !day.After(instalment.DueDate) {
+				break
+			}
+			unpaid := instalment.Amount
+			if paid.Cmp(unpaid) >= 0 {
+				paid = mustSub(paid, unpaid)
+				continue
+			}
+			unpaid, paid = mustSub(unpaid, paid), NewMoney(0, paid.Currency())
+			pastDue = mustAdd(pastDue, unpaid)
+			if day.AddDate(0, 0, -1).Equal(instalment.DueDate) {
+				accruals = loan.charge(accruals, policy, Accrual{Date: day, Kind: LateFee, Base: unpaid}, policy.LateFeeBasisPoints)
+			}
+		}
+		if pastDue.IsPositive() {
+			accruals = loan.charge(accruals, policy, Accrual{Date: day, Kind: PenaltyInterest, Base: pastDue}, policy.DailyPenaltyBasisPoints)
+		}
+		loan.accruedUntil = day
+	}
+	return accruals
+}
+
+// charge calculates amount of the accrual capped by the policy and adds it to the remaining amount of the loan
+func (loan *paydayLoan) charge(accruals []Accrual, policy PenaltyPolicy, accrual Accrual, basisPoints uint) []Accrual {
+	amount, err := accrual.Base.MulDiv(uint64(basisPoints), basisPointsPerUnit)
+	if err != nil {
+		panic(err)
+	}
+	if headroom := loan.costHeadroom(policy); amount.Cmp(headroom) > 0 {
+		amount = headroom
+	}
+	if !amount.IsPositive() {
+		return accruals
+	}
+	accrual.Amount = amount
+	loan.remaining = mustAdd(loan.remaining, amount)
+	loan.accruals = append(loan.accruals, accrual)
+	return append(accruals, accrual)
+}
+
+// costHeadroom is the amount which can still be charged without exceeding the maximum total cost of the loan
+func (loan *paydayLoan) costHeadroom(policy PenaltyPolicy) Money {
+	maxCost, err := loan.Quote.Amount.MulDiv(uint64(policy.MaxTotalCostBasisPoints), basisPointsPerUnit)
+	if err != nil {
+		panic(err)
+	}
+	cost := mustAdd(mustAdd(loan.Quote.Fee, loan.Quote.Interest), loan.LateCharges())
+	if cost.Cmp(maxCost) >= 0 {
+		return NewMoney(0, cost.Currency())
+	}
+	return mustSub(maxCost, cost)
+}
+
+// paidUntil sums repayments received until the day, so accruals do not depend on when they are run
+func (loan *paydayLoan) paidUntil(day time.Time) Money {
+	paid := NewMoney(0, loan.remaining.Currency())
+	for _, repayment := range loan.repayments {
+		if !repayment.PaidAt.After(day) {
+			paid = mustAdd(paid, repayment.Amount)
+		}
+	}
+	return paid
+}
@@ -0,0 +1,176 @@
+package domain
+
+import "errors"
+
+// EmploymentType is how the client earns the declared income
+type EmploymentType string
+
+const (
+	// Employed client receives salary
+	Employed EmploymentType = "employed"
+	// SelfEmployed client runs own business
+	SelfEmployed EmploymentType = "self_employed"
+	// Unemployed client has no regular income from work
+	Unemployed EmploymentType = "unemployed"
+	// Retired client lives on pension
+	Retired EmploymentType = "retired"
+)
+
+var employmentTypes = []EmploymentType{Employed, SelfEmployed, Unemployed, Retired}
+
+// DefaultMaxDebtToIncomeBasisPoints allows monthly repayments of all debts up to 35% of monthly income
+const DefaultMaxDebtToIncomeBasisPoints = 3500
+
+// daysInMonth is used to convert repayments of loans to monthly repayments
+const daysInMonth = 30
+
+// Affordability is income and obligations declared by the client when applying for a loan
+type Affordability struct {
+	MonthlyIncome  Money
+	EmploymentType EmploymentType
+	// MonthlyObligations are repayments of existing debts every month
+	MonthlyObligations Money
+}
+
+// Validate returns ErrInvalidAffordability when employment type is unknown or amounts are negative or in different
+// currencies
+func (affordability Affordability) Validate() error {
+	known := false
+	for _, employmentType := range employmentTypes {
+		if employmentType == affordability.EmploymentType {
+			known = true
+		}
+	}
+	obligations := affordability.MonthlyObligations
+	if !known || affordability.MonthlyIncome.IsNegative() || obligations.IsNegative() ||
+		(!obligations.IsZero() && !affordability.MonthlyIncome.SameCurrency(obligations)) {
+		return ErrInvalidAffordability
+	}
+	return nil
+}
+
+// Check returns NotAffordableStruct when monthly repayment of the loan together with obligations exceeds
+// maxDebtToIncomeBasisPoints of income. Income has to be in the currency of the product.
+func (affordability Affordability) Check(product Product, amount Money, term Term, maxDebtToIncomeBasisPoints uint) error {
+	if err := affordability.Validate(); err != nil {
+		return err
+	}
+	if !affordability.MonthlyIncome.SameCurrency(amount) {
+		return ErrCurrencyMismatch
+	}
+	capacity, err := affordability.repaymentCapacity(maxDebtToIncomeBasisPoints)
+	if err != nil {
+		return err
+	}
+	repayment, err := monthlyRepayment(product, amount, term)
+	if err != nil {
+		return err
+	}
+	if repayment.Cmp(capacity) <= 0 {
+		return nil
+	}
+	maxAmount, err := maxAffordableAmount(product, term, capacity)
+	if err != nil {
+		return err
+	}
+	return NotAffordableStruct{ErrLoanNotAffordable, maxAmount}
+}
+
+// repaymentCapacity is the part of monthly income left for repaying a new loan, it is never negative
+func (affordability Affordability) repaymentCapacity(maxDebtToIncomeBasisPoints uint) (Money, error) {
+	limit, err := affordability.MonthlyIncome.MulDiv(uint64(maxDebtToIncomeBasisPoints), basisPointsPerUnit)
+	if err != nil {
+		return Money{}, err
+	}
+	if affordability.MonthlyObligations.IsZero() {
+		return limit, nil
+	}
+	capacity, err := limit.Sub(affordability.MonthlyObligations)
+	if err != nil {
+		return Money{}, err
+	}
+	if capacity.IsNegative() {
+		return NewMoney(0, capacity.Currency()), nil
+	}
+	return capacity, nil
+}
+
+// monthlyRepayment spreads total payable over the months of the term, loans shorter than a month are repaid in one month
+func monthlyRepayment(product Product, amount Money, term Term) (Money, error) {
+	fee, err := product.Fee(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	interest, err := product.Interest(amount, term)
+	if err != nil {
+		return Money{}, err
+	}
+	total, err := amount.Add(fee)
+	if err == nil {
+		total, err = total.Add(interest)
+	}
+	if err != nil {
+		return Money{}, err
+	}
+	return total.MulDiv(daysInMonth, uint64(monthsOfTerm(term)))
+}
+
+func monthsOfTerm(term Term) Term {
+	if term < daysInMonth {
+		return daysInMonth
+	}
+	return term
+}
+
+// maxAffordableAmount inverts pricing of the product, the result is lowered by rounding differences until it fits
+// the capacity. It never exceeds the maximum amount of the product.
+func maxAffordableAmount(product Product, term Term, capacity Money) (Money, error) {
+	price := uint64(basisPointsPerUnit) + uint64(product.FeeBasisPoints()) + uint64(product.DailyInterestBasisPoints())*uint64(term)
+	amount, err := capacity.MulDiv(uint64(monthsOfTerm(term))*basisPointsPerUnit, daysInMonth*price)
+	if err != nil {
+		return Money{}, err
+	}
+	if max := product.MaxAmount(); max.SameCurrency(amount) && amount.Cmp(max) > 0 {
+		amount = max
+	}
+	for amount.IsPositive() {
+		repayment, err := monthlyRepayment(product, amount, term)
+		if err != nil {
+			return Money{}, err
+		}
+		if repayment.Cmp(capacity) <= 0 {
+			break
+		}
+		amount = NewMoney(amount.MinorUnits()-1, amount.Currency())
+	}
+	return amount, nil
+}
+
+func (client *paydayLoanClient) RecordAffordability(affordability Affordability) error {
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	client.loan.affordability = &affordability
+	return nil
+}
+
+func (loan *paydayLoan) Affordability() (Affordability, bool) {
+	if loan.affordability == nil {
+		return Affordability{}, false
+	}
+	return *loan.affordability, true
+}
+
+// ErrInvalidAffordability is returned when employment type is unknown, income or obligations are negative or in
+// different currencies. Zero obligations can be in any currency.
+var ErrInvalidAffordability = errors.New("invalid_affordability")
+
+// ErrLoanNotAffordable is returned when repayments of the loan would exceed the share of income client can afford
+var ErrLoanNotAffordable = errors.New("loan_not_affordable")
+
+// NotAffordableStruct is an error struct wrapping ErrLoanNotAffordable with the highest amount the client can afford
+// for the same product and term
+type NotAffordableStruct struct {
+	error
+	MaxAffordableAmount Money
+}
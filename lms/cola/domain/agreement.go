@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Agreement is the loan agreement which the client accepts electronically before the loan is disbursed
+type Agreement struct {
+	// Required is false for loans disbursed without an agreement
+	Required bool
+	// Hash identifies the document the client accepted
+	Hash string
+	// AcceptedFrom is the IP address the client accepted the agreement from
+	AcceptedFrom string
+	AcceptedAt   time.Time
+}
+
+// IsPending agreement must be accepted before the loan is disbursed
+func (agreement Agreement) IsPending() bool {
+	return agreement.Required && agreement.AcceptedAt.IsZero()
+}
+
+func (client *paydayLoanClient) RequireAgreement() error {
+	defer client.trackLoanStates()()
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	if client.loan.disbursement.status != Approved && client.loan.disbursement.status != InReview {
+		return ErrLoanAlreadyDisbursed
+	}
+	client.loan.agreement.Required = true
+	return nil
+}
+
+func (client *paydayLoanClient) AcceptAgreement(hash string, ipAddress string, acceptedAt time.Time) error {
+	defer client.trackLoanStates()()
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	if !client.loan.agreement.IsPending() {
+		return ErrNoAgreementToAccept
+	}
+	client.loan.agreement.Hash = hash
+	client.loan.agreement.AcceptedFrom = ipAddress
+	client.loan.agreement.AcceptedAt = acceptedAt
+	client.loan.startAt(acceptedAt)
+	return nil
+}
+
+// sameDay tells whether both times fall on the same calendar day
+func sameDay(at, other time.Time) bool {
+	year, month, day := at.Date()
+	otherYear, otherMonth, otherDay := other.Date()
+	return year == otherYear && month == otherMonth && day == otherDay
+}
+
+func (loan *paydayLoan) Agreement() Agreement {
+	return loan.agreement
+}
+
+// ErrAgreementNotAccepted is returned when disbursement is started before the client accepted the loan agreement
+var ErrAgreementNotAccepted = errors.New("agreement_not_accepted")
+
+// ErrNoAgreementToAccept is returned when the client accepts agreement of a loan which does not require it or which was
+// already accepted
+var ErrNoAgreementToAccept = errors.New("no_agreement_to_accept")
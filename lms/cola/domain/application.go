@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ApplicationStatus tells how far a loan application got
+type ApplicationStatus string
+
+const (
+	// ApplicationSubmitted is being assessed, it stays submitted when the assessment failed for a technical reason
+	ApplicationSubmitted ApplicationStatus = "submitted"
+	// ApplicationUnderReview is waiting for an officer, its loan was created but not disbursed
+	ApplicationUnderReview ApplicationStatus = "under_review"
+	// ApplicationApproved got a loan
+	ApplicationApproved ApplicationStatus = "approved"
+	// ApplicationRejected did not pass the checks or was declined by an officer
+	ApplicationRejected ApplicationStatus = "rejected"
+	// ApplicationCancelled was withdrawn by the client before it was decided
+	ApplicationCancelled ApplicationStatus = "cancelled"
+	// ApplicationExpired was not decided in ApplicationValidity
+	ApplicationExpired ApplicationStatus = "expired"
+)
+
+// ApplicationValidity is how long an application can wait for a decision
+const ApplicationValidity = 7 * 24 * time.Hour
+
+// Origin tells where an application came from, the per-IP limit and fraud checks rely on it
+type Origin struct {
+	// Channel is the application the client applied through, e.g. web or android
+	Channel   string
+	IPAddress string
+}
+
+// LoanApplication is a request of a client for a loan. It is kept whatever the outcome was.
+type LoanApplication interface {
+	// ID is unique across all applications, it is made of application sequence number
+	ID() string
+	KTPNumber() string
+	ProductCode() string
+	Amount() Money
+	Term() Term
+	Affordability() Affordability
+	Origin() Origin
+	Status() ApplicationStatus
+	SubmittedAt() time.Time
+	// ExpiresAt is the time after which undecided application expires
+	ExpiresAt() time.Time
+	// DecidedAt is zero while the application is submitted or under review
+	DecidedAt() time.Time
+	// Reason is the error the application was rejected with or why it was referred for review
+	Reason() string
+	// LoanID is the loan created for the application, empty when no loan was created
+	LoanID() string
+	// CreditBureauReport is the report the application was assessed with, found is false when the bureau was not asked
+	CreditBureauReport() (report CreditBureauReport, found bool)
+	RecordCreditBureauReport(report CreditBureauReport)
+	// Score is the score the application was assessed with including its reason codes, found is false when no scorecard
+	// applied to the product
+	Score() (score Score, found bool)
+	RecordScore(score Score)
+	// ReferForReview records the loan which waits for an officer
+	ReferForReview(loanID string, reason string) error
+	Approve(loanID string, approvedAt time.Time) error
+	Reject(reason string, rejectedAt time.Time) error
+	Cancel(cancelledAt time.Time) error
+	Expire(expiredAt time.Time) error
+	// Clone returns a copy which shares no mutable state with the application, so repositories can hand out and keep
+	// applications which are changed by use cases running at the same time
+	Clone() LoanApplication
+}
+
+type loanApplication struct {
+	id            string
+	ktpNumber     string
+	productCode   string
+	amount        Money
+	term          Term
+	affordability Affordability
+	origin        Origin
+	status        ApplicationStatus
+	submittedAt   time.Time
+	decidedAt     time.Time
+	reason        string
+	loanID        string
+	bureauReport  *CreditBureauReport
+	score         Score
+}
+
+// NewLoanApplication submits an application. Every application must get a different sequence.
+func NewLoanApplication(sequence uint64, ktpNumber string, productCode string, amount Money, term Term, affordability Affordability, origin Origin, submittedAt time.Time) LoanApplication {
+	return &loanApplication{
+		id:            fmt.Sprintf("APP%010d", sequence),
+		ktpNumber:     ktpNumber,
+		productCode:   productCode,
+		amount:        amount,
+		term:          term,
+		affordability: affordability,
+		origin:        origin,
+		status:        ApplicationSubmitted,
+		submittedAt:   submittedAt,
+	}
+}
+
+func (application *loanApplication) ID() string {
+	return application.id
+}
+
+func (application *loanApplication) KTPNumber() string {
+	return application.ktpNumber
+}
+
+func (application *loanApplication) ProductCode() string {
+	return application.productCode
+}
+
+func (application *loanApplication) Amount() Money {
+	return application.amount
+}
+
+func (application *loanApplication) Term() Term {
+	return application.term
+}
+
+func (application *loanApplication) Affordability() Affordability {
+	return application.affordability
+}
+
+func (application *loanApplication) Origin() Origin {
+	return application.origin
+}
+
+func (application *loanApplication) Status() ApplicationStatus {
+	return application.status
+}
+
+func (application *loanApplication) SubmittedAt() time.Time {
+	return application.submittedAt
+}
+
+func (application *loanApplication) ExpiresAt() time.Time {
+	return application.submittedAt.Add(ApplicationValidity)
+}
+
+func (application *loanApplication) DecidedAt() time.Time {
+	return application.decidedAt
+}
+
+func (application *loanApplication) Reason() string {
+	return application.reason
+}
+
+func (application *loanApplication) LoanID() string {
+	return application.loanID
+}
+
+func (application *loanApplication) CreditBureauReport() (CreditBureauReport, bool) {
+	if application.bureauReport == nil {
+		return CreditBureauReport{}, false
+	}
+	return *application.bureauReport, true
+}
+
+func (application *loanApplication) RecordCreditBureauReport(report CreditBureauReport) {
+	application.bureauReport = &report
+}
+
+func (application *loanApplication) Score() (Score, bool) {
+	return application.score, application.score.Scorecard != ""
+}
+
+func (application *loanApplication) RecordScore(score Score) {
+	application.score = score
+}
+
+func (application *loanApplication) ReferForReview(loanID string, reason string) error {
+	if application.status != ApplicationSubmitted {
+		return ErrApplicationAlreadyDecided
+	}
+	application.status = ApplicationUnderReview
+	application.loanID = loanID
+	application.reason = reason
+	return nil
+}
+
+func (application *loanApplication) Approve(loanID string, approvedAt time.Time) error {
+	if err := application.decide(ApplicationApproved, approvedAt); err != nil {
+		return err
+	}
+	application.loanID = loanID
+	return nil
+}
+
+func (application *loanApplication) Reject(reason string, rejectedAt time.Time) error {
+	if err := application.decide(ApplicationRejected, rejectedAt); err != nil {
+		return err
+	}
+	application.reason = reason
+	return nil
+}
+
+func (application *loanApplication) Cancel(cancelledAt time.Time) error {
+	return application.decide(ApplicationCancelled, cancelledAt)
+}
+
+func (application *loanApplication) Expire(expiredAt time.Time) error {
+	if expiredAt.Before(application.ExpiresAt()) {
+		return ErrApplicationNotExpired
+	}
+	return application.decide(ApplicationExpired, expiredAt)
+}
+
+func (application *loanApplication) Clone() LoanApplication {
+	clone := *application
+	if application.bureauReport != nil {
+		report := *application.bureauReport
+		report.Debts = append([]BureauDebt(nil), report.Debts...)
+		clone.bureauReport = &report
+	}
+	clone.score.Reasons = append([]string(nil), application.score.Reasons...)
+	return &clone
+}
+
+// decide closes the application which is submitted or under review
+func (application *loanApplication) decide(status ApplicationStatus, decidedAt time.Time) error {
+	if application.status != ApplicationSubmitted && application.status != ApplicationUnderReview {
+		return ErrApplicationAlreadyDecided
+	}
+	application.status = status
+	application.decidedAt = decidedAt
+	return nil
+}
+
+// ErrApplicationAlreadyDecided is returned when an application which was already approved, rejected, cancelled or
+// expired is changed
+var ErrApplicationAlreadyDecided = errors.New("application_already_decided")
+
+// ErrApplicationNotExpired is returned when an application is expired before ApplicationValidity elapsed
+var ErrApplicationNotExpired = errors.New("application_not_expired")
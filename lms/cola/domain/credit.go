@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Refund returns credit balance of a Client to client's bank account
+type Refund struct {
+	ID          string
+	Amount      Money
+	RequestedAt time.Time
+	Status      RefundStatus
+	// Reference is assigned by the bank when the money is transferred
+	Reference string
+}
+
+// RefundStatus tells whether the money of a refund was already transferred to the client
+type RefundStatus string
+
+const (
+	// RefundPending has a bank transfer in progress
+	RefundPending RefundStatus = "pending"
+	// RefundCompleted was transferred to client's bank account
+	RefundCompleted RefundStatus = "completed"
+	// RefundFailed was rejected by the bank and its amount was returned to credit balance
+	RefundFailed RefundStatus = "failed"
+)
+
+func (client *paydayLoanClient) CreditBalance() Money {
+	return client.creditBalance
+}
+
+func (client *paydayLoanClient) RepayWithCredit(amount Money, paidAt time.Time) error {
+	defer client.trackLoanStates()()
+	if amount.IsNegative() {
+		return ErrNegativeAmount
+	}
+	loan := client.loanToRepay()
+	if loan == nil {
+		if err := client.addCredit(amount); err != nil {
+			return err
+		}
+		client.post(OverpaymentReceived, "", paidAt, debit(Cash, amount), credit(ClientCredit, amount))
+		return nil
+	}
+	if !amount.SameCurrency(loan.remaining) {
+		return ErrCurrencyMismatch
+	}
+	toRepay := amount
+	if toRepay.Cmp(loan.remaining) > 0 {
+		toRepay = loan.remaining
+	}
+	excess := mustSub(amount, toRepay)
+	balance, err := client.creditBalanceWith(excess)
+	if err != nil {
+		return err
+	}
+	if err = client.repay(toRepay, paidAt); err != nil {
+		return err
+	}
+	client.creditBalance = balance
+	client.post(OverpaymentReceived, loan.id, paidAt, debit(Cash, excess), credit(ClientCredit, excess))
+	return nil
+}
+
+// addCredit keeps credit balance in one currency, it takes the currency of the amount when the balance is zero
+func (client *paydayLoanClient) addCredit(amount Money) error {
+	balance, err := client.creditBalanceWith(amount)
+	if err != nil {
+		return err
+	}
+	client.creditBalance = balance
+	return nil
+}
+
+// creditBalanceWith returns what the credit balance would be with the amount added without changing it, so the
+// repayment can be validated before anything is booked
+func (client *paydayLoanClient) creditBalanceWith(amount Money) (Money, error) {
+	balance := client.creditBalance
+	if balance.IsZero() {
+		balance = NewMoney(0, amount.Currency())
+	}
+	return balance.Add(amount)
+}
+
+// applyCredit uses credit balance to repay the active loan
+func (client *paydayLoanClient) applyCredit(appliedAt time.Time) {
+	if client.creditBalance.IsZero() || client.loan == nil || !client.creditBalance.SameCurrency(client.loan.remaining) {
+		return
+	}
+	amount := client.creditBalance
+	if amount.Cmp(client.loan.remaining) > 0 {
+		amount = client.loan.remaining
+	}
+	client.creditBalance = mustSub(client.creditBalance, amount)
+	client.loan.remaining = mustSub(client.loan.remaining, amount)
+	client.loan.repayments = append(client.loan.repayments, Repayment{Amount: amount, PaidAt: appliedAt, FromCredit: true})
+	client.post(CreditApplied, client.loan.id, appliedAt, debit(ClientCredit, amount), credit(LoansReceivable, amount))
+}
+
+func (client *paydayLoanClient) RequestRefund(amount Money, requestedAt time.Time) (Refund, error) {
+	if !amount.SameCurrency(client.creditBalance) {
+		return Refund{}, ErrCurrencyMismatch
+	}
+	if !amount.IsPositive() {
+		return Refund{}, ErrNegativeAmount
+	}
+	if amount.Cmp(client.creditBalance) > 0 {
+		return Refund{}, ErrRefundAmountTooHigh
+	}
+	refund := Refund{
+		ID:          fmt.Sprintf("%s-refund-%d", client.ktpNumber, len(client.refunds)+1),
+		Amount:      amount,
+		RequestedAt: requestedAt,
+		Status:      RefundPending,
+	}
+	client.creditBalance = mustSub(client.creditBalance, amount)
+	client.refunds = append(client.refunds, refund)
+	client.post(RefundRequested, "", requestedAt, debit(ClientCredit, amount), credit(Cash, amount))
+	return refund, nil
+}
+
+func (client *paydayLoanClient) CompleteRefund(id string, reference string) error {
+	refund, err := client.pendingRefund(id)
+	if err != nil {
+		return err
+	}
+	refund.Status = RefundCompleted
+	refund.Reference = reference
+	return nil
+}
+
+func (client *paydayLoanClient) FailRefund(id string, failedAt time.Time) error {
+	refund, err := client.pendingRefund(id)
+	if err != nil {
+		return err
+	}
+	refund.Status = RefundFailed
+	client.creditBalance = mustAdd(client.creditBalance, refund.Amount)
+	client.post(RefundReturned, "", failedAt, debit(Cash, refund.Amount), credit(ClientCredit, refund.Amount))
+	return nil
+}
+
+func (client *paydayLoanClient) pendingRefund(id string) (*Refund, error) {
+	for i := range client.refunds {
+		if client.refunds[i].ID == id && client.refunds[i].Status == RefundPending {
+			return &client.refunds[i], nil
+		}
+	}
+	return nil, ErrRefundNotPending
+}
+
+func (client *paydayLoanClient) Refunds() []Refund {
+	return client.refunds
+}
+
+// ErrRefundAmountTooHigh is returned when Client requested refund of more than credit balance
+var ErrRefundAmountTooHigh = errors.New("refund_amount_too_high")
+
+// ErrRefundNotPending is returned when refund is completed or failed twice
+var ErrRefundNotPending = errors.New("refund_not_pending")
+
+// ErrClientHasCreditBalance is returned when Client's data can't be erased, because the client still has money with us
+var ErrClientHasCreditBalance = errors.New("client_has_credit_balance")
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// CreditBureauReport lists debts of a client at other lenders as known to the credit bureau
+type CreditBureauReport struct {
+	Debts      []BureauDebt
+	ReportedAt time.Time
+}
+
+// BureauDebt is a loan of the client at another lender
+type BureauDebt struct {
+	Lender      string
+	Outstanding Money
+	DaysPastDue uint
+}
+
+// maxBureauDaysPastDue is the most days a debt at another lender can be past due for the client to get a loan
+const maxBureauDaysPastDue = 30
+
+// Check returns ErrDelinquentAtOtherLender when any debt is more than 30 days past due
+func (report CreditBureauReport) Check() error {
+	for _, debt := range report.Debts {
+		if debt.DaysPastDue > maxBureauDaysPastDue {
+			return ErrDelinquentAtOtherLender
+		}
+	}
+	return nil
+}
+
+// review is a decision of an officer about a loan which could not be approved automatically
+type review struct {
+	reason string
+	by     string
+	at     time.Time
+}
+
+func (client *paydayLoanClient) RecordCreditBureauReport(report CreditBureauReport) error {
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	client.loan.bureauReport = &report
+	return nil
+}
+
+func (client *paydayLoanClient) ReferForReview(reason string) error {
+	defer client.trackLoanStates()()
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	if client.loan.disbursement.status != Approved {
+		return ErrLoanAlreadyDisbursed
+	}
+	client.loan.disbursement.status = InReview
+	client.loan.review = review{reason: reason}
+	return nil
+}
+
+func (client *paydayLoanClient) ReviewLoan(officer string, approved bool, reviewedAt time.Time) error {
+	defer client.trackLoanStates()()
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	if client.loan.disbursement.status != InReview {
+		return ErrLoanNotInReview
+	}
+	client.loan.review.by = officer
+	client.loan.review.at = reviewedAt
+	if approved {
+		client.loan.disbursement.status = Approved
+		agreement := client.loan.agreement
+		if agreement.Required && !agreement.IsPending() && !sameDay(agreement.AcceptedAt, reviewedAt) {
+			// the accepted schedule started before the loan was approved, the client accepts it again
+			client.loan.agreement = Agreement{Required: true}
+		}
+		return nil
+	}
+	return client.cancel(Declined, reviewedAt)
+}
+
+func (client *paydayLoanClient) CancelLoanInReview(cancelledAt time.Time) error {
+	defer client.trackLoanStates()()
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	if client.loan.disbursement.status != InReview {
+		return ErrLoanNotInReview
+	}
+	return client.cancel(Cancelled, cancelledAt)
+}
+
+func (loan *paydayLoan) CreditBureauReport() (CreditBureauReport, bool) {
+	if loan.bureauReport == nil {
+		return CreditBureauReport{}, false
+	}
+	return *loan.bureauReport, true
+}
+
+func (loan *paydayLoan) ReviewReason() string {
+	return loan.review.reason
+}
+
+func (loan *paydayLoan) ReviewedBy() string {
+	return loan.review.by
+}
+
+// ErrDelinquentAtOtherLender is returned when Client applies for a loan while being late with a debt at another lender
+var ErrDelinquentAtOtherLender = errors.New("delinquent_at_other_lender")
+
+// ErrLoanNotInReview is returned when officer reviews a loan which was not referred for review
+var ErrLoanNotInReview = errors.New("loan_not_in_review")
+
+// ErrLoanInReview is returned when disbursement is started for a loan waiting for review
+var ErrLoanInReview = errors.New("loan_in_review")
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// DisbursementStatus tells whether the money of a loan was already transferred to the client
+type DisbursementStatus string
+
+const (
+	// Approved loan is waiting for disbursement
+	Approved DisbursementStatus = "approved"
+	// Disbursing loan has a bank transfer in progress
+	Disbursing DisbursementStatus = "disbursing"
+	// Disbursed loan was transferred to client's bank account
+	Disbursed DisbursementStatus = "disbursed"
+	// DisbursementFailed loan was rejected by the bank and is cancelled
+	DisbursementFailed DisbursementStatus = "failed"
+	// InReview loan is waiting for an officer to approve or decline it before disbursement
+	InReview DisbursementStatus = "in_review"
+	// Declined loan was declined by an officer during review and is cancelled
+	Declined DisbursementStatus = "declined"
+	// Cancelled loan was in review when its application was cancelled or expired
+	Cancelled DisbursementStatus = "cancelled"
+)
+
+type disbursement struct {
+	status    DisbursementStatus
+	reference string
+	at        time.Time
+}
+
+func (loan *paydayLoan) DisbursementStatus() DisbursementStatus {
+	return loan.disbursement.status
+}
+
+func (loan *paydayLoan) DisbursementReference() string {
+	return loan.disbursement.reference
+}
+
+func (client *paydayLoanClient) StartDisbursement() (Loan, error) {
+	defer client.trackLoanStates()()
+	if client.loan == nil {
+		return nil, ErrClientHasNoActiveLoan
+	}
+	status := client.loan.disbursement.status
+	if status == InReview {
+		return nil, ErrLoanInReview
+	}
+	if client.loan.agreement.IsPending() {
+		return nil, ErrAgreementNotAccepted
+	}
+	if status != Approved && status != Disbursing {
+		return nil, ErrLoanAlreadyDisbursed
+	}
+	client.loan.disbursement.status = Disbursing
+	return client.loan, nil
+}
+
+func (client *paydayLoanClient) CompleteDisbursement(reference string, disbursedAt time.Time) error {
+	defer client.trackLoanStates()()
+	if client.loan == nil || client.loan.disbursement.status != Disbursing {
+		return ErrLoanNotDisbursing
+	}
+	client.loan.disbursement = disbursement{status: Disbursed, reference: reference, at: disbursedAt}
+	if !client.loan.agreement.Required {
+		// the schedule of a loan with agreement was fixed when the client accepted it
+		client.loan.startAt(disbursedAt)
+	}
+	client.post(Disbursement, client.loan.id, disbursedAt, debit(DisbursementsPayable, client.loan.Amount()), credit(Cash, client.loan.Amount()))
+	return nil
+}
+
+func (client *paydayLoanClient) FailDisbursement(failedAt time.Time) error {
+	defer client.trackLoanStates()()
+	if client.loan == nil || client.loan.disbursement.status != Disbursing {
+		return ErrLoanNotDisbursing
+	}
+	return client.cancel(DisbursementFailed, failedAt)
+}
+
+// cancel closes the active loan which was never disbursed, amounts already paid are kept as credit balance
+func (client *paydayLoanClient) cancel(status DisbursementStatus, cancelledAt time.Time) error {
+	loan := client.loan
+	paid := loan.paid()
+	if err := client.addCredit(paid); err != nil {
+		return err
+	}
+	client.post(Cancellation, loan.id, cancelledAt,
+		debit(DisbursementsPayable, loan.Amount()),
+		debit(FeeIncome, loan.Fee()),
+		debit(InterestIncome, loan.Interest()),
+		credit(LoansReceivable, loan.remaining),
+		credit(ClientCredit, paid))
+	loan.remaining = NewMoney(0, loan.remaining.Currency())
+	loan.disbursement = disbursement{status: status, at: cancelledAt}
+	client.closedLoans = append(client.closedLoans, client.loan)
+	client.loan = nil
+	return nil
+}
+
+// ErrLoanAlreadyDisbursed is returned when disbursement is started for a loan which was already disbursed
+var ErrLoanAlreadyDisbursed = errors.New("loan_already_disbursed")
+
+// ErrLoanNotDisbursing is returned when disbursement is completed or failed without being started
+var ErrLoanNotDisbursing = errors.New("loan_not_disbursing")
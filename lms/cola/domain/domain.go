@@ -1,53 +1,249 @@
 // Package domain provides core business logic which is independent of any other systems and repositories
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
-const maximumAmountForFirstLoan = 50000000
+// maximumAmountForFirstLoan limits loans of clients without credit history in every currency we lend in
+var maximumAmountForFirstLoan = map[Currency]Money{
+	IDR: Rupiah(50000000),
+	SGD: mustParseMoney("4000", SGD),
+	MYR: mustParseMoney("12000", MYR),
+}
 
 // Client can only have one active loan
 type Client interface {
 	KTPNumber() string
+	// VirtualAccount is a bank account number used by the client for repayments, so payments can be matched
+	VirtualAccount() string
+	AssignVirtualAccount(number string) (err error)
 	BirthDate() string
 	Name() string
 	Gender() string
-	ApplyForLoan(amount uint, term Term) (err error)
+	Phone() string
+	Email() string
+	Address() string
+	BankCode() string
+	BankAccountNumber() string
+	BankAccountHolder() string
+	Profile() Profile
+	// UpdateProfile validates and replaces the profile recording every changed field in ProfileChanges
+	UpdateProfile(profile Profile, changedAt time.Time) (err error)
+	ProfileChanges() []ProfileChange
+	// CheckEligibility returns the reason why client can't apply for a loan or nil when client is eligible
+	CheckEligibility(product Product, amount Money, term Term) (err error)
+	// ApplyForLoan stores reportingRate on the loan, so reports in other currency than the loan's one do not change
+	// with exchange rates
+	ApplyForLoan(product Product, amount Money, term Term, appliedAt time.Time, reportingRate ExchangeRate) (err error)
 	HasActiveLoan() bool
 	ActiveLoan() Loan
-	Repay(amount uint) (err error)
+	// DaysPastDue of the active loan, zero when client has no overdue loan
+	DaysPastDue(asOf time.Time) uint
+	// AccrueLateCharges charges late fees and penalty interest of the active loan for every day since the last accrual
+	// until asOf and returns the new accruals. Days which were already accrued are never charged again.
+	AccrueLateCharges(policy PenaltyPolicy, asOf time.Time) []Accrual
+	// Dun moves the active loan to the latest dunning stage reached as of asOf and returns the stage. Stages passed
+	// over between two runs are skipped. Reaching HandOverToCollections defaults the loan, which ends its dunning.
+	Dun(schedule DunningSchedule, asOf time.Time) (stage DunningStage, reached bool)
+	// Repay returns ErrRepaymentAmountTooHigh when amount exceeds the remaining amount of the active loan. Without
+	// active loan the amount is a recovery of the written-off loan.
+	Repay(amount Money, paidAt time.Time) (err error)
+	// RepayWithCredit keeps the amount exceeding the remaining amount of the active or written-off loan as credit
+	// balance, which is applied to the next loan or refunded. Without any loan the whole amount becomes credit balance.
+	RepayWithCredit(amount Money, paidAt time.Time) (err error)
+	CreditBalance() Money
+	// RequestRefund deducts amount from credit balance until the refund is completed or failed
+	RequestRefund(amount Money, requestedAt time.Time) (refund Refund, err error)
+	CompleteRefund(id string, reference string) (err error)
+	// FailRefund returns the amount of the refund to credit balance
+	FailRefund(id string, failedAt time.Time) (err error)
+	Refunds() []Refund
+	// WriteOff takes the defaulted active loan off the books. The client can't take new loans, but repayments of the
+	// loan are still accepted as recoveries.
+	WriteOff(officer string, writtenOffAt time.Time) (err error)
+	// WrittenOffLoan returns the written-off loan which was not fully recovered yet, nil when there is none
+	WrittenOffLoan() Loan
+	// Loans returns all loans of the client in order of application including the active one
+	Loans() []Loan
+	// RecordBankReference remembers the bank statement line a repayment of the client was booked from
+	RecordBankReference(reference string)
+	// HasBankReference tells whether a repayment was already booked from the bank statement line
+	HasBankReference(reference string) bool
+	// StartDisbursement moves the active loan to Disbursing status. Loan which is already disbursing can be started
+	// again when the outcome of previous attempt is unknown.
+	StartDisbursement() (loan Loan, err error)
+	// CompleteDisbursement starts the loan at disbursedAt, instalments are due counting from that day even when the
+	// loan waited for review or agreement acceptance
+	CompleteDisbursement(reference string, disbursedAt time.Time) (err error)
+	// FailDisbursement cancels the active loan, because money never reached the client
+	FailDisbursement(failedAt time.Time) (err error)
+	// Journal lists ledger entries of all money movements of the client in order they happened
+	Journal() []JournalEntry
+	// CheckLedger returns LedgerMismatchStruct when ledger balances do not match remaining amounts of loans or credit
+	// balance
+	CheckLedger() (err error)
+	// ScoringAttributes are the attributes scorecards score the client on as of the date. RecentApplications are
+	// counted from applications, which should not include the application being scored.
+	ScoringAttributes(asOf time.Time, applications []LoanApplication) Attributes
+	// RecordScore stores the score the active loan was approved with
+	RecordScore(score Score) (err error)
+	// RecordAffordability stores income and obligations declared by the client when applying for the active loan
+	RecordAffordability(affordability Affordability) (err error)
+	// RecordCreditBureauReport stores the report the active loan was approved with
+	RecordCreditBureauReport(report CreditBureauReport) (err error)
+	// ReferForReview holds the approved active loan until an officer reviews it, it can't be disbursed before
+	ReferForReview(reason string) (err error)
+	// ReviewLoan approves the loan in review for disbursement or declines and cancels it
+	ReviewLoan(officer string, approved bool, reviewedAt time.Time) (err error)
+	// CancelLoanInReview cancels the loan in review when its application is cancelled or expires
+	CancelLoanInReview(cancelledAt time.Time) (err error)
+	// RequireAgreement holds the approved active loan until the client accepts its agreement
+	RequireAgreement() (err error)
+	// AcceptAgreement records the electronic acceptance of the agreement of the active loan
+	AcceptAgreement(hash string, ipAddress string, acceptedAt time.Time) (err error)
+	// Erase pseudonymizes personal data of the client. Loans are retained for legal reasons.
+	Erase(erasedAt time.Time) (err error)
+	IsErased() bool
+	// LoanStateChanges lists every change of a loan state made since the client was loaded or the changes were
+	// cleared. Changes are not kept by repositories and not copied by Clone.
+	LoanStateChanges() []LoanStateChange
+	// ClearLoanStateChanges forgets the changes once they were published
+	ClearLoanStateChanges()
+	// Clone returns a copy which shares no mutable state with the client, so repositories can hand out and keep
+	// clients which are changed by use cases running at the same time
+	Clone() Client
+	// Version is set by repositories on every save, it tells whether the client was saved by someone else since it
+	// was loaded
+	Version() uint64
+	SetVersion(version uint64)
 }
 
 // NewClient returns Client instance
 func NewClient(gender, birthDate, name, ktpNumber string) Client {
-	return &paydayLoanClient{gender: gender, birthDate: birthDate, name: name, ktpNumber: ktpNumber}
+	return &paydayLoanClient{
+		ktpNumber:     ktpNumber,
+		profile:       Profile{Gender: gender, BirthDate: birthDate, Name: name},
+		creditBalance: NewMoney(0, DefaultCurrency),
+	}
 }
 
 // Loan should be repaid in a given term or something bad will happen
 type Loan interface {
-	Amount() uint
+	// ID is unique across all loans, it is made of client's KTP number and loan sequence number
+	ID() string
+	Product() Product
+	Amount() Money
 	Term() Term
-	Remaining() uint
+	Fee() Money
+	Interest() Money
+	TotalPayable() Money
+	// Remaining is the amount still to be repaid including fee, interest and late charges
+	Remaining() Money
+	// LateCharges is the sum of all accruals
+	LateCharges() Money
+	Accruals() []Accrual
+	StartDate() time.Time
+	// DueDate is the due date of the last instalment
+	DueDate() time.Time
+	Instalments() []Instalment
+	// ScheduleFrom is the repayment schedule of the loan if it started at start
+	ScheduleFrom(start time.Time) []Instalment
+	// AmountPastDue is the sum of instalments due before asOf which were not repaid yet
+	AmountPastDue(asOf time.Time) Money
+	// DaysPastDue counts days since the oldest unpaid instalment became due, zero when the loan is not overdue
+	DaysPastDue(asOf time.Time) uint
+	// OldestUnpaidInstalment allocates repayments to instalments in order of their due dates
+	OldestUnpaidInstalment() (instalment Instalment, unpaid bool)
+	Repayments() []Repayment
+	DisbursementStatus() DisbursementStatus
+	// DisbursementReference is assigned by the bank when the money is transferred
+	DisbursementReference() string
+	// DunningStage is the name of the latest stage reached by the oldest unpaid instalment, empty before dunning
+	DunningStage() string
+	// IsDefaulted loan was handed over to collections, it can still be repaid
+	IsDefaulted() bool
+	DefaultedAt() time.Time
+	IsWrittenOff() bool
+	WrittenOffAt() time.Time
+	// WrittenOffBy is the officer who wrote the loan off
+	WrittenOffBy() string
+	// WrittenOffAmount is the remaining amount at the time of write-off
+	WrittenOffAmount() Money
+	// Recovered is the sum of repayments received after write-off
+	Recovered() Money
+	// ReportingRate is a snapshot of exchange rate from currency of the loan to reporting currency at origination
+	ReportingRate() ExchangeRate
+	// Score the loan was approved with, not found for loans approved without scorecard
+	Score() (score Score, found bool)
+	// Affordability declared by the client in the application, not found for loans applied for without it
+	Affordability() (affordability Affordability, found bool)
+	// CreditBureauReport the loan was approved with, not found when the bureau was not consulted or did not answer
+	CreditBureauReport() (report CreditBureauReport, found bool)
+	// ReviewReason is why the loan was referred for review, empty when it was approved automatically
+	ReviewReason() string
+	// ReviewedBy is the officer who approved or declined the loan in review
+	ReviewedBy() string
+	Agreement() Agreement
+}
+
+// Repayment is money received from the client to repay a loan
+type Repayment struct {
+	Amount Money
+	PaidAt time.Time
+	// FromCredit repayment was paid from client's credit balance when the loan was taken
+	FromCredit bool
+	// Recovery was received after the loan was written off
+	Recovery bool
 }
 
 type paydayLoan struct {
-	amount    uint
-	term      Term
-	remaining uint
+	Quote
+	id            string
+	startDate     time.Time
+	remaining     Money
+	repayments    []Repayment
+	disbursement  disbursement
+	reportingRate ExchangeRate
+	accruals      []Accrual
+	accruedUntil  time.Time
+	dunning       dunning
+	defaultedAt   time.Time
+	writeOff      writeOff
+	score         Score
+	bureauReport  *CreditBureauReport
+	review        review
+	affordability *Affordability
+	agreement     Agreement
 }
 
-func (loan *paydayLoan) Remaining() uint {
+func (loan *paydayLoan) Remaining() Money {
 	return loan.remaining
 }
 
 type paydayLoanClient struct {
-	ktpNumber string
-	birthDate string
-	name      string
-	gender    string
-	loan      *paydayLoan
+	ktpNumber      string
+	virtualAccount string
+	profile        Profile
+	profileChanges []ProfileChange
+	loan           *paydayLoan
+	closedLoans    []*paydayLoan
+	erasedAt       time.Time
+	creditBalance  Money
+	refunds        []Refund
+	journal        []JournalEntry
+	bankReferences []string
+	version        uint64
+	// loanStateChanges are not part of the state of the client
+	loanStateChanges []LoanStateChange
 }
 
 func (client *paydayLoanClient) ActiveLoan() Loan {
+	if client.loan == nil {
+		return nil
+	}
 	return client.loan
 }
 
@@ -56,56 +252,347 @@ func (client *paydayLoanClient) KTPNumber() string {
 }
 
 func (client *paydayLoanClient) BirthDate() string {
-	return client.birthDate
+	return client.profile.BirthDate
 }
 
 func (client *paydayLoanClient) Name() string {
-	return client.name
+	return client.profile.Name
 }
 
 func (client *paydayLoanClient) Gender() string {
-	return client.gender
+	return client.profile.Gender
+}
+
+func (client *paydayLoanClient) Phone() string {
+	return client.profile.Phone
+}
+
+func (client *paydayLoanClient) Email() string {
+	return client.profile.Email
+}
+
+func (client *paydayLoanClient) Address() string {
+	return client.profile.Address
+}
+
+func (client *paydayLoanClient) BankCode() string {
+	return client.profile.BankAccount.BankCode
+}
+
+func (client *paydayLoanClient) BankAccountNumber() string {
+	return client.profile.BankAccount.Number
+}
+
+func (client *paydayLoanClient) BankAccountHolder() string {
+	return client.profile.BankAccount.HolderName
+}
+
+func (client *paydayLoanClient) Profile() Profile {
+	return client.profile
+}
+
+func (client *paydayLoanClient) UpdateProfile(profile Profile, changedAt time.Time) error {
+	if client.IsErased() {
+		return ErrClientErased
+	}
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	client.profileChanges = append(client.profileChanges, client.profile.changes(profile, changedAt)...)
+	client.profile = profile
+	return nil
+}
+
+func (client *paydayLoanClient) ProfileChanges() []ProfileChange {
+	return client.profileChanges
+}
+
+func (client *paydayLoanClient) DaysPastDue(asOf time.Time) uint {
+	if client.loan == nil {
+		return 0
+	}
+	return client.loan.DaysPastDue(asOf)
 }
 
 func (client *paydayLoanClient) HasActiveLoan() bool {
 	return client.loan != nil
 }
 
-func (client *paydayLoanClient) ApplyForLoan(amount uint, term Term) error {
+func (client *paydayLoanClient) CheckEligibility(product Product, amount Money, term Term) error {
+	if client.IsErased() {
+		return ErrClientErased
+	}
 	if client.HasActiveLoan() {
 		return ErrClientAlreadyHasLoan
 	}
-	if amount > maximumAmountForFirstLoan {
-		return ErrAmountTooHigh
+	if client.hasWrittenOffLoan() {
+		return ErrClientHasWrittenOffLoan
+	}
+	return product.Validate(amount, term)
+}
+
+func (client *paydayLoanClient) ApplyForLoan(product Product, amount Money, term Term, appliedAt time.Time, reportingRate ExchangeRate) error {
+	defer client.trackLoanStates()()
+	if err := client.CheckEligibility(product, amount, term); err != nil {
+		return err
+	}
+	if reportingRate.From != product.Currency() {
+		return ErrCurrencyMismatch
+	}
+	quote, err := NewQuote(product, amount, term, appliedAt)
+	if err != nil {
+		return err
 	}
-	loan := &paydayLoan{amount: amount, term: term, remaining: amount}
-	client.loan = loan
+	client.loan = &paydayLoan{
+		Quote:         quote,
+		id:            fmt.Sprintf("%s-%d", client.ktpNumber, len(client.closedLoans)+1),
+		startDate:     appliedAt,
+		remaining:     quote.TotalPayable,
+		disbursement:  disbursement{status: Approved},
+		reportingRate: reportingRate,
+	}
+	client.post(Origination, client.loan.id, appliedAt,
+		debit(LoansReceivable, quote.TotalPayable),
+		credit(DisbursementsPayable, quote.Amount),
+		credit(FeeIncome, quote.Fee),
+		credit(InterestIncome, quote.Interest))
+	client.applyCredit(appliedAt)
 	return nil
 }
 
-func (client *paydayLoanClient) Repay(amount uint) (err error) {
-	repaymentError := client.loan.repay(amount)
+func (client *paydayLoanClient) Repay(amount Money, paidAt time.Time) (err error) {
+	defer client.trackLoanStates()()
+	return client.repay(amount, paidAt)
+}
+
+func (client *paydayLoanClient) repay(amount Money, paidAt time.Time) error {
+	loan := client.loanToRepay()
+	if loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	repaymentError := loan.repay(amount, paidAt)
 	if repaymentError != nil {
 		return repaymentError
 	}
-	if client.loan.Remaining() == 0 {
+	if loan.IsWrittenOff() {
+		client.post(RecoveryReceived, loan.id, paidAt, debit(Cash, amount), credit(WriteOffs, amount))
+	} else {
+		client.post(RepaymentReceived, loan.id, paidAt, debit(Cash, amount), credit(LoansReceivable, amount))
+	}
+	if client.loan != nil && client.loan.Remaining().IsZero() {
+		client.closedLoans = append(client.closedLoans, client.loan)
 		client.loan = nil
 	}
 	return nil
 }
 
-func (loan *paydayLoan) Amount() uint {
-	return loan.amount
+func (client *paydayLoanClient) Loans() []Loan {
+	var loans []Loan
+	for _, loan := range client.closedLoans {
+		loans = append(loans, loan)
+	}
+	if client.loan != nil {
+		loans = append(loans, client.loan)
+	}
+	return loans
+}
+
+func (client *paydayLoanClient) RecordBankReference(reference string) {
+	if !client.HasBankReference(reference) {
+		client.bankReferences = append(client.bankReferences, reference)
+	}
+}
+
+func (client *paydayLoanClient) HasBankReference(reference string) bool {
+	for _, recorded := range client.bankReferences {
+		if recorded == reference {
+			return true
+		}
+	}
+	return false
+}
+
+func (client *paydayLoanClient) Erase(erasedAt time.Time) error {
+	if client.HasActiveLoan() {
+		return ErrClientHasActiveLoan
+	}
+	if !client.creditBalance.IsZero() {
+		return ErrClientHasCreditBalance
+	}
+	if client.writtenOffLoan() != nil {
+		return ErrClientHasWrittenOffLoan
+	}
+	if client.IsErased() {
+		return nil
+	}
+	salt, err := newErasureSalt()
+	if err != nil {
+		return err
+	}
+	client.profile = client.profile.pseudonymized(salt)
+	for i, change := range client.profileChanges {
+		client.profileChanges[i].OldValue = pseudonym(salt, change.Field, change.OldValue)
+		client.profileChanges[i].NewValue = pseudonym(salt, change.Field, change.NewValue)
+	}
+	client.erasedAt = erasedAt
+	return nil
+}
+
+func (client *paydayLoanClient) Version() uint64 {
+	return client.version
+}
+
+func (client *paydayLoanClient) SetVersion(version uint64) {
+	client.version = version
+}
+
+func (client *paydayLoanClient) Clone() Client {
+	clone := *client
+	clone.profileChanges = append([]ProfileChange(nil), client.profileChanges...)
+	clone.loan = client.loan.clone()
+	clone.closedLoans = nil
+	for _, loan := range client.closedLoans {
+		clone.closedLoans = append(clone.closedLoans, loan.clone())
+	}
+	clone.refunds = append([]Refund(nil), client.refunds...)
+	clone.journal = append([]JournalEntry(nil), client.journal...)
+	clone.bankReferences = append([]string(nil), client.bankReferences...)
+	clone.loanStateChanges = nil
+	return &clone
+}
+
+// clone copies slices and pointers the loan changes, values they hold are never changed in place
+func (loan *paydayLoan) clone() *paydayLoan {
+	if loan == nil {
+		return nil
+	}
+	clone := *loan
+	clone.Quote.Instalments = append([]Instalment(nil), loan.Quote.Instalments...)
+	clone.repayments = append([]Repayment(nil), loan.repayments...)
+	clone.accruals = append([]Accrual(nil), loan.accruals...)
+	if loan.bureauReport != nil {
+		report := *loan.bureauReport
+		clone.bureauReport = &report
+	}
+	if loan.affordability != nil {
+		affordability := *loan.affordability
+		clone.affordability = &affordability
+	}
+	return &clone
+}
+
+func (client *paydayLoanClient) IsErased() bool {
+	return !client.erasedAt.IsZero()
+}
+
+func (loan *paydayLoan) ID() string {
+	return loan.id
+}
+
+func (loan *paydayLoan) Product() Product {
+	return loan.Quote.Product
+}
+
+func (loan *paydayLoan) Amount() Money {
+	return loan.Quote.Amount
 }
 
 func (loan *paydayLoan) Term() Term {
-	return loan.term
+	return loan.Quote.Term
+}
+
+func (loan *paydayLoan) Fee() Money {
+	return loan.Quote.Fee
+}
+
+func (loan *paydayLoan) Interest() Money {
+	return loan.Quote.Interest
+}
+
+func (loan *paydayLoan) TotalPayable() Money {
+	return loan.Quote.TotalPayable
+}
+
+func (loan *paydayLoan) StartDate() time.Time {
+	return loan.startDate
+}
+
+func (loan *paydayLoan) DueDate() time.Time {
+	return loan.Quote.Instalments[len(loan.Quote.Instalments)-1].DueDate
+}
+
+func (loan *paydayLoan) Instalments() []Instalment {
+	return loan.Quote.Instalments
+}
+
+func (loan *paydayLoan) ScheduleFrom(start time.Time) []Instalment {
+	return schedule(loan.Quote.TotalPayable, loan.Quote.Term, loan.Quote.Product.InstalmentPeriod(), start)
+}
+
+// startAt moves the repayment schedule to start at start
+func (loan *paydayLoan) startAt(start time.Time) {
+	loan.Quote.Instalments = loan.ScheduleFrom(start)
+	loan.startDate = start
 }
-func (loan *paydayLoan) repay(amount uint) (err error) {
-	if amount > loan.remaining {
+
+func (loan *paydayLoan) AmountPastDue(asOf time.Time) Money {
+	due := NewMoney(0, loan.remaining.Currency())
+	for _, instalment := range loan.Quote.Instalments {
+		if instalment.DueDate.Before(asOf) {
+			due = mustAdd(due, instalment.Amount)
+		}
+	}
+	paid := loan.paid()
+	if due.Cmp(paid) < 0 {
+		return NewMoney(0, due.Currency())
+	}
+	return mustSub(due, paid)
+}
+
+func (loan *paydayLoan) DaysPastDue(asOf time.Time) uint {
+	instalment, unpaid := loan.OldestUnpaidInstalment()
+	if !unpaid || !asOf.After(instalment.DueDate) {
+		return 0
+	}
+	return uint(asOf.Sub(instalment.DueDate) / (24 * time.Hour))
+}
+
+func (loan *paydayLoan) OldestUnpaidInstalment() (Instalment, bool) {
+	paid := loan.paid()
+	for _, instalment := range loan.Quote.Instalments {
+		if paid.Cmp(instalment.Amount) < 0 {
+			return instalment, true
+		}
+		paid = mustSub(paid, instalment.Amount)
+	}
+	return Instalment{}, false
+}
+
+func (loan *paydayLoan) ReportingRate() ExchangeRate {
+	return loan.reportingRate
+}
+
+func (loan *paydayLoan) Repayments() []Repayment {
+	return loan.repayments
+}
+
+// paid is the part of total payable amount and late charges which was already repaid
+func (loan *paydayLoan) paid() Money {
+	return mustSub(mustAdd(loan.Quote.TotalPayable, loan.LateCharges()), loan.remaining)
+}
+
+func (loan *paydayLoan) repay(amount Money, paidAt time.Time) (err error) {
+	if !amount.SameCurrency(loan.remaining) {
+		return ErrCurrencyMismatch
+	}
+	if amount.IsNegative() {
+		return ErrNegativeAmount
+	}
+	if amount.Cmp(loan.remaining) > 0 {
 		return ErrRepaymentAmountTooHigh
 	}
-	loan.remaining -= amount
+	loan.remaining = mustSub(loan.remaining, amount)
+	loan.repayments = append(loan.repayments, Repayment{Amount: amount, PaidAt: paidAt, Recovery: loan.IsWrittenOff()})
 	return nil
 }
 
@@ -116,13 +603,22 @@ type Term uint
 var ErrClientAlreadyHasLoan = errors.New("client_already_has_loan")
 
 // ErrAmountTooHigh is returned when Client applied for a loan with excessive amount
-var ErrAmountTooHigh = AmountTooHighStruct{errors.New("amount_too_high"), maximumAmountForFirstLoan}
+var ErrAmountTooHigh = errors.New("amount_too_high")
 
-// AmountTooHighStruct is an error struct used by ErrAmountTooHigh with additional MaxAmount field indicating the maximum amount of a loan
+// AmountTooHighStruct is an error struct wrapping ErrAmountTooHigh with additional MaxAmount field indicating the maximum amount of a loan
 type AmountTooHighStruct struct {
 	error
-	MaxAmount int
+	MaxAmount Money
 }
 
 // ErrRepaymentAmountTooHigh is returned when Client tried to repay more than remaining amount of a loan
 var ErrRepaymentAmountTooHigh = errors.New("repayment_amount_too_high")
+
+// ErrClientHasNoActiveLoan is returned when Client without active loan tries to repay
+var ErrClientHasNoActiveLoan = errors.New("client_has_no_active_loan")
+
+// ErrClientHasActiveLoan is returned when Client's data can't be erased because of an active loan
+var ErrClientHasActiveLoan = errors.New("client_has_active_loan")
+
+// ErrClientErased is returned when erased Client tries to change profile or apply for a loan
+var ErrClientErased = errors.New("client_erased")
@@ -1,20 +1,32 @@
 package domain
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 const (
-	amount    uint = 10000000
-	term           = Term(30)
-	ktpNumber      = "3522582509010002"
+	term      = Term(30)
+	ktpNumber = "3522582509010002"
 )
 
+var (
+	amount    = Rupiah(10000000)
+	appliedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// testProduct has no fee and interest so remaining amount of a loan is equal to its amount
+var testProduct = &product{code: "test", currency: IDR, minAmount: Rupiah(100), maxAmount: maximumAmountForFirstLoan[IDR], minTerm: 1, maxTerm: 30}
+
+// rupiahRate is a reporting rate of loans in IDR
+var rupiahRate = IdentityRate(IDR, appliedAt)
+
 func TestClientApplyForLoan(t *testing.T) {
-	client := NewClient("", "", ktpNumber)
-	err := client.ApplyForLoan(amount, term)
+	client := NewClient("", "", "", ktpNumber)
+	err := client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate)
 	t.Run("active loan should be assigned to client", func(t *testing.T) {
 		assert.True(t, client.HasActiveLoan())
 		loan := client.ActiveLoan()
@@ -28,9 +40,9 @@ func TestClientApplyForLoan(t *testing.T) {
 }
 
 func TestClientApplyForLoanTwice(t *testing.T) {
-	client := NewClient("", "", ktpNumber)
-	client.ApplyForLoan(amount, term)
-	err := client.ApplyForLoan(amount, term)
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate)
+	err := client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate)
 	t.Run("should return error", func(t *testing.T) {
 		assert.Equal(t, "client_already_has_loan", err.Error())
 		assert.Equal(t, err, ErrClientAlreadyHasLoan)
@@ -38,18 +50,96 @@ func TestClientApplyForLoanTwice(t *testing.T) {
 }
 
 func TestClientApplyForMoreThanMaxAmount(t *testing.T) {
-	client := NewClient("", "", ktpNumber)
-	err := client.ApplyForLoan(10000001, term)
-	assert.Equal(t, err, ErrAmountTooHigh)
+	client := NewClient("", "", "", ktpNumber)
+	err := client.ApplyForLoan(testProduct, NewMoney(maximumAmountForFirstLoan[IDR].MinorUnits()+1, IDR), term, appliedAt, rupiahRate)
 	assert.Equal(t, "amount_too_high", err.Error())
-	assert.Equal(t, 1600, err.(AmountTooHighStruct).MaxAmount)
+	assert.Equal(t, maximumAmountForFirstLoan[IDR], err.(AmountTooHighStruct).MaxAmount)
+	assert.False(t, client.HasActiveLoan())
+}
+
+func TestClientApplyForLessThanMinAmount(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	err := client.ApplyForLoan(testProduct, Rupiah(99), term, appliedAt, rupiahRate)
+	assert.Equal(t, "amount_too_low", err.Error())
+	assert.Equal(t, Rupiah(100), err.(AmountTooLowStruct).MinAmount)
+}
+
+func TestClientApplyForTermOutOfRange(t *testing.T) {
+	for _, term := range []Term{0, 31} {
+		client := NewClient("", "", "", ktpNumber)
+		err := client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate)
+		assert.Equal(t, "term_out_of_range", err.Error())
+		assert.Equal(t, Term(1), err.(TermOutOfRangeStruct).MinTerm)
+		assert.Equal(t, Term(30), err.(TermOutOfRangeStruct).MaxTerm)
+	}
+}
+
+func TestClientApplyForInstalmentLoanWithTermNotMultipleOfPeriod(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	err := client.ApplyForLoan(InstalmentLoan, amount, 100, appliedAt, rupiahRate)
+	assert.Equal(t, "term_not_allowed", err.Error())
+	assert.Equal(t, Term(30), err.(TermNotAllowedStruct).InstalmentPeriod)
+}
+
+func TestClientApplyForPricedLoan(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(InstalmentLoan, amount, 90, appliedAt, rupiahRate)
+	loan := client.ActiveLoan()
+	assert.Equal(t, InstalmentLoan, loan.Product())
+	assert.Equal(t, Rupiah(300000), loan.Fee())
+	assert.Equal(t, Rupiah(900000), loan.Interest())
+	assert.Equal(t, Rupiah(11200000), loan.Remaining())
+}
+
+func TestClientApplyForLoanSchedulesInstalments(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(InstalmentLoan, Rupiah(1000001), 90, appliedAt, rupiahRate)
+	loan := client.ActiveLoan()
+	assert.Equal(t, appliedAt, loan.StartDate())
+	assert.Equal(t, []Instalment{
+		{DueDate: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), Amount: NewMoney(37333370, IDR)},
+		{DueDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Amount: NewMoney(37333370, IDR)},
+		{DueDate: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), Amount: NewMoney(37333372, IDR)},
+	}, loan.Instalments())
+	assert.Equal(t, NewMoney(37333370+37333370+37333372, IDR), loan.TotalPayable())
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), loan.DueDate())
+}
+
+func TestNewQuote(t *testing.T) {
+	quote, err := NewQuote(PaydayLoan, amount, term, appliedAt)
+	assert.Nil(t, err)
+	assert.Equal(t, Rupiah(0), quote.Fee)
+	assert.Equal(t, Rupiah(2400000), quote.Interest)
+	assert.Equal(t, Rupiah(12400000), quote.TotalPayable)
+	assert.Equal(t, []Instalment{{DueDate: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), Amount: Rupiah(12400000)}}, quote.Instalments)
+}
+
+func TestNewQuoteWithInvalidTerm(t *testing.T) {
+	_, err := NewQuote(PaydayLoan, amount, 31, appliedAt)
+	assert.Equal(t, "term_out_of_range", err.Error())
+}
+
+func TestClientCheckEligibility(t *testing.T) {
+	client, _ := clientWithLoan(Rupiah(100))
+	assert.Equal(t, ErrClientAlreadyHasLoan, client.CheckEligibility(PaydayLoan, amount, term))
+	assert.Nil(t, NewClient("", "", "", ktpNumber).CheckEligibility(PaydayLoan, amount, term))
+}
+
+func TestProductByCode(t *testing.T) {
+	for _, code := range []string{"payday", "instalment", "micro_business", "payday_sgd", "payday_myr"} {
+		product, found := ProductByCode(code)
+		assert.True(t, found)
+		assert.Equal(t, code, product.Code())
+	}
+	_, found := ProductByCode("mortgage")
+	assert.False(t, found)
 }
 
 func TestClientRepaysLoanPart(t *testing.T) {
-	client, loan := clientWithLoan(10000000)
-	err := client.Repay(50)
+	client, loan := clientWithLoan(Rupiah(100))
+	err := client.Repay(Rupiah(50), appliedAt)
 	t.Run("Remaining amount should be 50", func(t *testing.T) {
-		assert.Equal(t, uint(50), loan.Remaining())
+		assert.Equal(t, Rupiah(50), loan.Remaining())
 	})
 	t.Run("Should have active loan", func(t *testing.T) {
 		assert.True(t, client.HasActiveLoan())
@@ -61,14 +151,14 @@ func TestClientRepaysLoanPart(t *testing.T) {
 }
 
 func TestClientRepaysWholeLoan(t *testing.T) {
-	client, loan := clientWithLoan(10000000)
-	err := client.Repay(10000000)
+	client, loan := clientWithLoan(Rupiah(100))
+	err := client.Repay(Rupiah(100), appliedAt)
 	t.Run("Should not have active loan", func(t *testing.T) {
 		assert.False(t, client.HasActiveLoan())
 		assert.Nil(t, client.ActiveLoan())
 	})
 	t.Run("Remaning amount of repaid loan should 0", func(t *testing.T) {
-		assert.Equal(t, loan.Remaining(), uint(0))
+		assert.Equal(t, Rupiah(0), loan.Remaining())
 	})
 	t.Run("error should be nil", func(t *testing.T) {
 		assert.Nil(t, err)
@@ -76,14 +166,1020 @@ func TestClientRepaysWholeLoan(t *testing.T) {
 }
 
 func TestClientRepaysTooMuch(t *testing.T) {
-	client, _ := clientWithLoan(10000000)
-	err := client.Repay(110)
+	client, _ := clientWithLoan(Rupiah(100))
+	err := client.Repay(Rupiah(110), appliedAt)
 	assert.Equal(t, ErrRepaymentAmountTooHigh, err)
 	assert.Equal(t, "repayment_amount_too_high", err.Error())
 }
 
-func clientWithLoan(amount uint) (Client, Loan) {
-	var client = NewClient("", "", ktpNumber)
-	client.ApplyForLoan(amount, term)
+func clientWithLoan(amount Money) (Client, Loan) {
+	var client = NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate)
 	return client, client.ActiveLoan()
 }
+
+func TestClientUpdateProfile(t *testing.T) {
+	client := NewClient("F", "1 December 1994", "Doe", ktpNumber)
+	profile := client.Profile()
+	profile.Name = "Jane Doe"
+	profile.Phone = "081234567890"
+	profile.BankAccount = BankAccount{BankCode: "014", Number: "1234567890", HolderName: "Jane Doe"}
+	err := client.UpdateProfile(profile, appliedAt)
+	t.Run("error should be nil", func(t *testing.T) {
+		assert.Nil(t, err)
+	})
+	t.Run("should replace profile", func(t *testing.T) {
+		assert.Equal(t, "Jane Doe", client.Name())
+		assert.Equal(t, "081234567890", client.Phone())
+		assert.Equal(t, "1234567890", client.BankAccountNumber())
+		assert.Equal(t, ktpNumber, client.KTPNumber())
+	})
+	t.Run("should record changed fields", func(t *testing.T) {
+		assert.Equal(t, []ProfileChange{
+			{Field: "name", OldValue: "Doe", NewValue: "Jane Doe", ChangedAt: appliedAt},
+			{Field: "phone", OldValue: "", NewValue: "081234567890", ChangedAt: appliedAt},
+			{Field: "bankAccount.bankCode", OldValue: "", NewValue: "014", ChangedAt: appliedAt},
+			{Field: "bankAccount.number", OldValue: "", NewValue: "1234567890", ChangedAt: appliedAt},
+			{Field: "bankAccount.holderName", OldValue: "", NewValue: "Jane Doe", ChangedAt: appliedAt},
+		}, client.ProfileChanges())
+	})
+}
+
+func TestClientUpdateProfileWithInvalidFields(t *testing.T) {
+	tests := map[string]struct {
+		profile Profile
+		err     error
+	}{
+		"phone":        {Profile{Phone: "12345"}, ErrInvalidPhone},
+		"email":        {Profile{Email: "doe@"}, ErrInvalidEmail},
+		"email name":   {Profile{Email: "Doe <doe@example.com>"}, ErrInvalidEmail},
+		"address":      {Profile{Address: strings.Repeat("a", 201)}, ErrInvalidAddress},
+		"bank code":    {Profile{BankAccount: BankAccount{BankCode: "14", Number: "1234567890", HolderName: "Doe"}}, ErrInvalidBankAccount},
+		"no holder":    {Profile{BankAccount: BankAccount{BankCode: "014", Number: "1234567890"}}, ErrInvalidBankAccount},
+		"short number": {Profile{BankAccount: BankAccount{BankCode: "014", Number: "123", HolderName: "Doe"}}, ErrInvalidBankAccount},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := NewClient("", "", "Doe", ktpNumber)
+			err := client.UpdateProfile(test.profile, appliedAt)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, "Doe", client.Name())
+			assert.Empty(t, client.ProfileChanges())
+		})
+	}
+}
+
+func TestLoanDaysPastDue(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(InstalmentLoan, Rupiah(1000000), 90, appliedAt, rupiahRate)
+	loan := client.ActiveLoan()
+	firstDueDate := loan.Instalments()[0].DueDate
+	instalmentAmount := loan.Instalments()[0].Amount
+	t.Run("should not be overdue on due date", func(t *testing.T) {
+		assert.Equal(t, uint(0), loan.DaysPastDue(firstDueDate))
+		assert.Equal(t, Rupiah(0), loan.AmountPastDue(firstDueDate))
+	})
+	t.Run("should be overdue after due date", func(t *testing.T) {
+		asOf := firstDueDate.AddDate(0, 0, 40)
+		assert.Equal(t, uint(40), loan.DaysPastDue(asOf))
+		assert.Equal(t, uint(40), client.DaysPastDue(asOf))
+		assert.Equal(t, mustAdd(instalmentAmount, instalmentAmount), loan.AmountPastDue(asOf))
+	})
+	t.Run("should count from the oldest unpaid instalment", func(t *testing.T) {
+		client.Repay(mustAdd(instalmentAmount, NewMoney(1, IDR)), appliedAt)
+		asOf := firstDueDate.AddDate(0, 0, 40)
+		assert.Equal(t, uint(10), loan.DaysPastDue(asOf))
+		assert.Equal(t, mustSub(instalmentAmount, NewMoney(1, IDR)), loan.AmountPastDue(asOf))
+	})
+}
+
+func TestClientRepayWithoutActiveLoan(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	assert.Equal(t, ErrClientHasNoActiveLoan, client.Repay(Rupiah(100), appliedAt))
+}
+
+func TestClientKeepsRepaidLoans(t *testing.T) {
+	client, loan := clientWithLoan(Rupiah(100))
+	client.Repay(Rupiah(40), appliedAt)
+	client.Repay(Rupiah(60), appliedAt.AddDate(0, 0, 1))
+	client.ApplyForLoan(testProduct, Rupiah(200), term, appliedAt.AddDate(0, 0, 2), rupiahRate)
+	assert.Equal(t, []Loan{loan, client.ActiveLoan()}, client.Loans())
+	assert.Equal(t, []Repayment{{Amount: Rupiah(40), PaidAt: appliedAt}, {Amount: Rupiah(60), PaidAt: appliedAt.AddDate(0, 0, 1)}}, loan.Repayments())
+}
+
+func TestClientErase(t *testing.T) {
+	client := NewClient("F", "1 December 1994", "Doe", ktpNumber)
+	profile := client.Profile()
+	profile.Email = "doe@example.com"
+	client.UpdateProfile(profile, appliedAt)
+	err := client.Erase(appliedAt)
+	t.Run("error should be nil", func(t *testing.T) {
+		assert.Nil(t, err)
+		assert.True(t, client.IsErased())
+	})
+	t.Run("should pseudonymize personal data", func(t *testing.T) {
+		assert.Equal(t, ktpNumber, client.KTPNumber())
+		assert.NotContains(t, client.Name(), "Doe")
+		assert.Equal(t, "erased:", client.Email()[:7])
+		assert.Equal(t, "", client.Phone())
+		assert.Equal(t, "", client.ProfileChanges()[0].OldValue)
+		assert.Equal(t, client.Email(), client.ProfileChanges()[0].NewValue)
+	})
+	t.Run("pseudonyms should not be reproducible from the same data", func(t *testing.T) {
+		twin := NewClient("F", "1 December 1994", "Doe", ktpNumber)
+		assert.Nil(t, twin.Erase(appliedAt))
+		assert.NotEqual(t, client.Name(), twin.Name())
+		assert.NotEqual(t, client.BirthDate(), twin.BirthDate())
+	})
+	t.Run("should refuse new loans and profile changes", func(t *testing.T) {
+		assert.Equal(t, ErrClientErased, client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate))
+		assert.Equal(t, ErrClientErased, client.UpdateProfile(Profile{}, appliedAt))
+	})
+}
+
+func TestClientEraseWithActiveLoan(t *testing.T) {
+	client, _ := clientWithLoan(Rupiah(100))
+	assert.Equal(t, ErrClientHasActiveLoan, client.Erase(appliedAt))
+	assert.False(t, client.IsErased())
+}
+
+func TestLoanDisbursement(t *testing.T) {
+	client, loan := clientWithLoan(Rupiah(100))
+	t.Run("new loan should be approved", func(t *testing.T) {
+		assert.Equal(t, ktpNumber+"-1", loan.ID())
+		assert.Equal(t, Approved, loan.DisbursementStatus())
+	})
+	t.Run("should not complete disbursement which was not started", func(t *testing.T) {
+		assert.Equal(t, ErrLoanNotDisbursing, client.CompleteDisbursement("ref", appliedAt))
+	})
+	t.Run("should start disbursement twice", func(t *testing.T) {
+		client.StartDisbursement()
+		started, err := client.StartDisbursement()
+		assert.Nil(t, err)
+		assert.Equal(t, loan, started)
+		assert.Equal(t, Disbursing, loan.DisbursementStatus())
+	})
+	t.Run("should complete disbursement", func(t *testing.T) {
+		assert.Nil(t, client.CompleteDisbursement("ref", appliedAt))
+		assert.Equal(t, Disbursed, loan.DisbursementStatus())
+		assert.Equal(t, "ref", loan.DisbursementReference())
+	})
+	t.Run("should not disburse twice", func(t *testing.T) {
+		_, err := client.StartDisbursement()
+		assert.Equal(t, ErrLoanAlreadyDisbursed, err)
+	})
+}
+
+func TestLoanDisbursementAfterDelay(t *testing.T) {
+	client, loan := clientWithLoan(Rupiah(100))
+	disbursedAt := appliedAt.AddDate(0, 0, 5)
+	client.StartDisbursement()
+	assert.Nil(t, client.CompleteDisbursement("ref", disbursedAt))
+	assert.Equal(t, disbursedAt, loan.StartDate())
+	assert.Equal(t, disbursedAt.AddDate(0, 0, int(loan.Term())), loan.DueDate())
+	assert.Equal(t, uint(0), loan.DaysPastDue(appliedAt.AddDate(0, 0, int(loan.Term())+1)))
+}
+
+func TestLoanDisbursementFailure(t *testing.T) {
+	client, loan := clientWithLoan(Rupiah(100))
+	client.StartDisbursement()
+	err := client.FailDisbursement(appliedAt)
+	assert.Nil(t, err)
+	assert.Equal(t, DisbursementFailed, loan.DisbursementStatus())
+	assert.False(t, client.HasActiveLoan())
+	assert.Equal(t, []Loan{loan}, client.Loans())
+	client.ApplyForLoan(testProduct, Rupiah(100), term, appliedAt, rupiahRate)
+	assert.Equal(t, ktpNumber+"-2", client.ActiveLoan().ID())
+}
+
+func TestNewVirtualAccount(t *testing.T) {
+	assert.Equal(t, "880800000000016", NewVirtualAccount(1))
+	assert.Equal(t, "880800000012342", NewVirtualAccount(1234))
+	assert.True(t, IsValidVirtualAccount(NewVirtualAccount(9876543210)))
+	for _, number := range []string{"880800000000017", "880800000000", "990800000000016", "88080000000001X"} {
+		assert.False(t, IsValidVirtualAccount(number), number)
+	}
+}
+
+func TestClientAssignVirtualAccount(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	assert.Equal(t, ErrInvalidVirtualAccount, client.AssignVirtualAccount("880800000000017"))
+	assert.Nil(t, client.AssignVirtualAccount("880800000000016"))
+	assert.Equal(t, "880800000000016", client.VirtualAccount())
+	assert.Equal(t, ErrVirtualAccountAlreadyAssigned, client.AssignVirtualAccount(NewVirtualAccount(2)))
+}
+
+func TestClientRepayWithCredit(t *testing.T) {
+	client, loan := clientWithLoan(Rupiah(100))
+	err := client.RepayWithCredit(Rupiah(130), appliedAt)
+	t.Run("should repay the loan and keep the excess", func(t *testing.T) {
+		assert.Nil(t, err)
+		assert.False(t, client.HasActiveLoan())
+		assert.Equal(t, []Repayment{{Amount: Rupiah(100), PaidAt: appliedAt}}, loan.Repayments())
+		assert.Equal(t, Rupiah(30), client.CreditBalance())
+	})
+	t.Run("without active loan the whole amount should become credit", func(t *testing.T) {
+		client.RepayWithCredit(Rupiah(20), appliedAt)
+		assert.Equal(t, Rupiah(50), client.CreditBalance())
+	})
+	t.Run("credit should be applied to the next loan", func(t *testing.T) {
+		client.ApplyForLoan(testProduct, Rupiah(200), term, appliedAt, rupiahRate)
+		assert.Equal(t, Rupiah(150), client.ActiveLoan().Remaining())
+		assert.Equal(t, []Repayment{{Amount: Rupiah(50), PaidAt: appliedAt, FromCredit: true}}, client.ActiveLoan().Repayments())
+		assert.Equal(t, Rupiah(0), client.CreditBalance())
+	})
+}
+
+func TestClientRepayWithCreditFailure(t *testing.T) {
+	client, loan := clientWithLoan(Rupiah(100))
+	client.(*paydayLoanClient).creditBalance = mustParseMoney("5", SGD)
+	journal := len(client.Journal())
+	err := client.RepayWithCredit(Rupiah(130), appliedAt)
+	t.Run("excess in another currency than credit balance should be an error", func(t *testing.T) {
+		assert.Equal(t, ErrCurrencyMismatch, err)
+	})
+	t.Run("neither the loan nor the credit balance should change", func(t *testing.T) {
+		assert.Empty(t, loan.Repayments())
+		assert.Equal(t, mustParseMoney("5", SGD), client.CreditBalance())
+		assert.Len(t, client.Journal(), journal)
+	})
+}
+
+func TestClientRefund(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.RepayWithCredit(Rupiah(100), appliedAt)
+	t.Run("should not refund more than credit balance", func(t *testing.T) {
+		_, err := client.RequestRefund(Rupiah(101), appliedAt)
+		assert.Equal(t, ErrRefundAmountTooHigh, err)
+	})
+	t.Run("should not erase client with credit balance", func(t *testing.T) {
+		assert.Equal(t, ErrClientHasCreditBalance, client.Erase(appliedAt))
+	})
+	first, _ := client.RequestRefund(Rupiah(60), appliedAt)
+	t.Run("should deduct pending refund from credit balance", func(t *testing.T) {
+		assert.Equal(t, Refund{ID: ktpNumber + "-refund-1", Amount: Rupiah(60), RequestedAt: appliedAt, Status: RefundPending}, first)
+		assert.Equal(t, Rupiah(40), client.CreditBalance())
+	})
+	t.Run("failed refund should return amount to credit balance", func(t *testing.T) {
+		assert.Nil(t, client.FailRefund(first.ID, appliedAt))
+		assert.Equal(t, Rupiah(100), client.CreditBalance())
+		assert.Equal(t, ErrRefundNotPending, client.FailRefund(first.ID, appliedAt))
+	})
+	t.Run("should complete refund", func(t *testing.T) {
+		second, _ := client.RequestRefund(Rupiah(100), appliedAt)
+		assert.Nil(t, client.CompleteRefund(second.ID, "ref"))
+		assert.Equal(t, Rupiah(0), client.CreditBalance())
+		assert.Equal(t, RefundCompleted, client.Refunds()[1].Status)
+		assert.Equal(t, "ref", client.Refunds()[1].Reference)
+	})
+}
+
+func TestClientApplyForLoanInOtherCurrency(t *testing.T) {
+	sgdRate, _ := NewExchangeRate(SGD, IDR, "12150.5", appliedAt)
+	t.Run("amount should be in currency of the product", func(t *testing.T) {
+		client := NewClient("", "", "", ktpNumber)
+		assert.Equal(t, ErrCurrencyMismatch, client.ApplyForLoan(PaydayLoanSGD, amount, term, appliedAt, sgdRate))
+	})
+	t.Run("limits should be in currency of the product", func(t *testing.T) {
+		client := NewClient("", "", "", ktpNumber)
+		err := client.ApplyForLoan(PaydayLoanSGD, mustParseMoney("4000.01", SGD), term, appliedAt, sgdRate)
+		assert.Equal(t, mustParseMoney("4000", SGD), err.(AmountTooHighStruct).MaxAmount)
+	})
+	t.Run("reporting rate should convert from currency of the product", func(t *testing.T) {
+		client := NewClient("", "", "", ktpNumber)
+		assert.Equal(t, ErrCurrencyMismatch, client.ApplyForLoan(PaydayLoanSGD, mustParseMoney("1000", SGD), term, appliedAt, rupiahRate))
+	})
+	t.Run("should store reporting rate on the loan", func(t *testing.T) {
+		client := NewClient("", "", "", ktpNumber)
+		assert.Nil(t, client.ApplyForLoan(PaydayLoanSGD, mustParseMoney("1000", SGD), term, appliedAt, sgdRate))
+		assert.Equal(t, mustParseMoney("1039", SGD), client.ActiveLoan().TotalPayable())
+		assert.Equal(t, sgdRate, client.ActiveLoan().ReportingRate())
+	})
+	t.Run("credit balance should take currency of the first overpayment", func(t *testing.T) {
+		client := NewClient("", "", "", ktpNumber)
+		assert.Nil(t, client.RepayWithCredit(mustParseMoney("10", SGD), appliedAt))
+		assert.Equal(t, mustParseMoney("10", SGD), client.CreditBalance())
+		assert.Equal(t, ErrCurrencyMismatch, client.RepayWithCredit(Rupiah(10), appliedAt))
+	})
+}
+
+func TestExchangeRate(t *testing.T) {
+	rate, err := NewExchangeRate(SGD, IDR, "12150.12345678", appliedAt)
+	assert.Nil(t, err)
+	assert.Equal(t, "12150.12345678", rate.Rate())
+	t.Run("should convert and round half to even", func(t *testing.T) {
+		converted, err := rate.Convert(mustParseMoney("100.01", SGD))
+		assert.Nil(t, err)
+		assert.Equal(t, mustParseMoney("1215133.85", IDR), converted)
+		half, _ := NewExchangeRate(SGD, MYR, "0.5", appliedAt)
+		converted, _ = half.Convert(mustParseMoney("0.01", SGD))
+		assert.Equal(t, mustParseMoney("0", MYR), converted)
+		converted, _ = half.Convert(mustParseMoney("0.03", SGD))
+		assert.Equal(t, mustParseMoney("0.02", MYR), converted)
+	})
+	t.Run("should not convert other currency", func(t *testing.T) {
+		_, err := rate.Convert(Rupiah(1))
+		assert.Equal(t, ErrCurrencyMismatch, err)
+	})
+	t.Run("identity should keep the amount", func(t *testing.T) {
+		converted, _ := rupiahRate.Convert(amount)
+		assert.Equal(t, amount, converted)
+		assert.Equal(t, "1", rupiahRate.Rate())
+	})
+	t.Run("should refuse invalid rates", func(t *testing.T) {
+		for _, invalid := range []string{"0", "-1", "1.123456789", "abc", "100000000"} {
+			_, err := NewExchangeRate(SGD, IDR, invalid, appliedAt)
+			assert.Equal(t, ErrInvalidExchangeRate, err, invalid)
+		}
+		_, err := NewExchangeRate("XXX", IDR, "1", appliedAt)
+		assert.Equal(t, ErrUnknownCurrency, err)
+	})
+}
+
+func TestLoanAccruesLateCharges(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	loan := client.ActiveLoan()
+	dueDate := loan.DueDate()
+	t.Run("should not accrue before disbursement", func(t *testing.T) {
+		assert.Empty(t, client.AccrueLateCharges(DefaultPenaltyPolicy, dueDate.AddDate(0, 0, 1)))
+	})
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", appliedAt)
+	t.Run("should not accrue until due date", func(t *testing.T) {
+		assert.Empty(t, client.AccrueLateCharges(DefaultPenaltyPolicy, dueDate))
+		assert.Equal(t, Rupiah(12400000), loan.Remaining())
+	})
+	t.Run("should charge late fee once and penalty interest every day", func(t *testing.T) {
+		accruals := client.AccrueLateCharges(DefaultPenaltyPolicy, dueDate.AddDate(0, 0, 2))
+		assert.Equal(t, []Accrual{
+			{Date: dueDate.AddDate(0, 0, 1), Kind: LateFee, Base: Rupiah(12400000), Amount: Rupiah(124000)},
+			{Date: dueDate.AddDate(0, 0, 1), Kind: PenaltyInterest, Base: Rupiah(12400000), Amount: Rupiah(37200)},
+			{Date: dueDate.AddDate(0, 0, 2), Kind: PenaltyInterest, Base: Rupiah(12400000), Amount: Rupiah(37200)},
+		}, accruals)
+		assert.Equal(t, accruals, loan.Accruals())
+		assert.Equal(t, Rupiah(198400), loan.LateCharges())
+		assert.Equal(t, Rupiah(12598400), loan.Remaining())
+	})
+	t.Run("should not charge the same day twice", func(t *testing.T) {
+		assert.Empty(t, client.AccrueLateCharges(DefaultPenaltyPolicy, dueDate.AddDate(0, 0, 2)))
+		assert.Equal(t, Rupiah(12598400), loan.Remaining())
+	})
+	t.Run("should charge penalty interest on the amount past due", func(t *testing.T) {
+		client.Repay(Rupiah(12000000), dueDate.AddDate(0, 0, 3))
+		accruals := client.AccrueLateCharges(DefaultPenaltyPolicy, dueDate.AddDate(0, 0, 3))
+		assert.Equal(t, []Accrual{{Date: dueDate.AddDate(0, 0, 3), Kind: PenaltyInterest, Base: Rupiah(400000), Amount: Rupiah(1200)}}, accruals)
+		assert.Equal(t, uint(3), loan.DaysPastDue(dueDate.AddDate(0, 0, 3)))
+	})
+}
+
+func TestLoanLateChargesAreCapped(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", appliedAt)
+	policy := DefaultPenaltyPolicy
+	policy.MaxTotalCostBasisPoints = 2500
+	accruals := client.AccrueLateCharges(policy, client.ActiveLoan().DueDate().AddDate(0, 0, 10))
+	assert.Equal(t, []Accrual{{Date: client.ActiveLoan().DueDate().AddDate(0, 0, 1), Kind: LateFee, Base: Rupiah(12400000), Amount: Rupiah(100000)}}, accruals)
+	assert.Equal(t, Rupiah(12500000), client.ActiveLoan().Remaining())
+}
+
+func TestLoanDunning(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(InstalmentLoan, Rupiah(1000000), 90, appliedAt, rupiahRate)
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", appliedAt)
+	loan := client.ActiveLoan()
+	firstDueDate := loan.Instalments()[0].DueDate
+	t.Run("should not dun before the first stage", func(t *testing.T) {
+		_, reached := client.Dun(DefaultDunningSchedule, firstDueDate.AddDate(0, 0, -4))
+		assert.False(t, reached)
+		assert.Equal(t, "", loan.DunningStage())
+	})
+	t.Run("should reach every stage once", func(t *testing.T) {
+		stage, reached := client.Dun(DefaultDunningSchedule, firstDueDate.AddDate(0, 0, -3))
+		assert.True(t, reached)
+		assert.Equal(t, DefaultDunningSchedule[0], stage)
+		_, reached = client.Dun(DefaultDunningSchedule, firstDueDate.AddDate(0, 0, -2))
+		assert.False(t, reached)
+		assert.Equal(t, "reminder", loan.DunningStage())
+	})
+	t.Run("should skip stages passed over between runs", func(t *testing.T) {
+		stage, reached := client.Dun(DefaultDunningSchedule, firstDueDate.AddDate(0, 0, 8))
+		assert.True(t, reached)
+		assert.Equal(t, "escalation_1", stage.Name)
+	})
+	t.Run("should start again with the next instalment", func(t *testing.T) {
+		client.Repay(loan.Instalments()[0].Amount, firstDueDate.AddDate(0, 0, 9))
+		_, reached := client.Dun(DefaultDunningSchedule, firstDueDate.AddDate(0, 0, 9))
+		assert.False(t, reached)
+		stage, reached := client.Dun(DefaultDunningSchedule, loan.Instalments()[1].DueDate)
+		assert.True(t, reached)
+		assert.Equal(t, "due_notice", stage.Name)
+	})
+	t.Run("should default the loan handed over to collections", func(t *testing.T) {
+		asOf := loan.Instalments()[1].DueDate.AddDate(0, 0, 90)
+		stage, reached := client.Dun(DefaultDunningSchedule, asOf)
+		assert.True(t, reached)
+		assert.Equal(t, HandOverToCollections, stage.Action)
+		assert.True(t, loan.IsDefaulted())
+		assert.Equal(t, asOf, loan.DefaultedAt())
+		_, reached = client.Dun(DefaultDunningSchedule, asOf.AddDate(0, 0, 100))
+		assert.False(t, reached)
+	})
+}
+
+func TestNewDunningSchedule(t *testing.T) {
+	schedule, err := NewDunningSchedule(
+		DunningStage{Name: "late", DaysFromDueDate: 5, Action: Escalate},
+		DunningStage{Name: "due", DaysFromDueDate: 0, Action: SendNotice},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "due", schedule[0].Name)
+	_, err = NewDunningSchedule(DunningStage{Name: "due", Action: SendNotice}, DunningStage{Name: "due", DaysFromDueDate: 1, Action: Escalate})
+	assert.Equal(t, ErrInvalidDunningSchedule, err)
+	_, err = NewDunningSchedule(DunningStage{Name: "due", Action: "call"})
+	assert.Equal(t, ErrInvalidDunningSchedule, err)
+}
+
+func TestClientWriteOff(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", appliedAt)
+	loan := client.ActiveLoan()
+	t.Run("should not write off loan which is not defaulted", func(t *testing.T) {
+		assert.Equal(t, ErrLoanNotDefaulted, client.WriteOff("officer", appliedAt))
+	})
+	writtenOffAt := loan.DueDate().AddDate(0, 0, 100)
+	client.Dun(DefaultDunningSchedule, loan.DueDate().AddDate(0, 0, 90))
+	client.Repay(Rupiah(400000), loan.DueDate().AddDate(0, 0, 95))
+	t.Run("should take the loan off the books", func(t *testing.T) {
+		assert.Nil(t, client.WriteOff("officer", writtenOffAt))
+		assert.False(t, client.HasActiveLoan())
+		assert.True(t, loan.IsWrittenOff())
+		assert.Equal(t, writtenOffAt, loan.WrittenOffAt())
+		assert.Equal(t, "officer", loan.WrittenOffBy())
+		assert.Equal(t, Rupiah(12000000), loan.WrittenOffAmount())
+		assert.Equal(t, loan, client.WrittenOffLoan())
+	})
+	t.Run("should block the client", func(t *testing.T) {
+		assert.Equal(t, ErrClientHasWrittenOffLoan, client.CheckEligibility(PaydayLoan, amount, term))
+		assert.Equal(t, ErrClientHasWrittenOffLoan, client.Erase(writtenOffAt))
+	})
+	t.Run("should record repayments as recoveries", func(t *testing.T) {
+		assert.Nil(t, client.Repay(Rupiah(2000000), writtenOffAt))
+		assert.Equal(t, Repayment{Amount: Rupiah(2000000), PaidAt: writtenOffAt, Recovery: true}, loan.Repayments()[1])
+		assert.Equal(t, Rupiah(2000000), loan.Recovered())
+		assert.Equal(t, Rupiah(10000000), loan.Remaining())
+		assert.Equal(t, ErrRepaymentAmountTooHigh, client.Repay(Rupiah(10000001), writtenOffAt))
+	})
+	t.Run("should keep overpaid recovery as credit", func(t *testing.T) {
+		assert.Nil(t, client.RepayWithCredit(Rupiah(10000100), writtenOffAt))
+		assert.Equal(t, Rupiah(12000000), loan.Recovered())
+		assert.Equal(t, Rupiah(100), client.CreditBalance())
+		assert.Nil(t, client.WrittenOffLoan())
+		assert.Equal(t, ErrClientHasWrittenOffLoan, client.CheckEligibility(PaydayLoan, amount, term))
+	})
+}
+
+func TestClientLedger(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.RepayWithCredit(Rupiah(100), appliedAt)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", appliedAt)
+	loan := client.ActiveLoan()
+	client.AccrueLateCharges(DefaultPenaltyPolicy, loan.DueDate().AddDate(0, 0, 5))
+	client.Repay(Rupiah(400000), loan.DueDate().AddDate(0, 0, 6))
+	client.Dun(DefaultDunningSchedule, loan.DueDate().AddDate(0, 0, 90))
+	writtenOffAt := loan.DueDate().AddDate(0, 0, 100)
+	client.WriteOff("officer", writtenOffAt)
+	client.RepayWithCredit(mustAdd(loan.Remaining(), Rupiah(50)), writtenOffAt)
+	client.RequestRefund(Rupiah(50), writtenOffAt)
+	journal := client.Journal()
+	t.Run("every entry should balance", func(t *testing.T) {
+		for _, entry := range journal {
+			debits, credits := Rupiah(0), Rupiah(0)
+			for _, posting := range entry.Postings {
+				debits = mustAdd(debits, posting.Debit)
+				credits = mustAdd(credits, posting.Credit)
+			}
+			assert.Equal(t, debits, credits, entry.ID)
+		}
+	})
+	t.Run("should post every money movement", func(t *testing.T) {
+		var events []JournalEvent
+		for _, entry := range journal {
+			events = append(events, entry.Event)
+		}
+		assert.Equal(t, []JournalEvent{OverpaymentReceived, Origination, CreditApplied, Disbursement, ChargeAccrued}, events[:5])
+		assert.Equal(t, []JournalEvent{RepaymentReceived, WrittenOff, RecoveryReceived, OverpaymentReceived, RefundRequested}, events[len(events)-5:])
+	})
+	t.Run("balances should match the loan and credit balance", func(t *testing.T) {
+		assert.Nil(t, client.CheckLedger())
+		assert.Equal(t, Rupiah(0), Balance(journal, LoansReceivable, loan.ID(), IDR))
+		assert.Equal(t, Rupiah(0), Balance(journal, WriteOffs, loan.ID(), IDR))
+		assert.Equal(t, Rupiah(0), Balance(journal, ClientCredit, "", IDR))
+		income := mustAdd(Balance(journal, FeeIncome, "", IDR), Balance(journal, InterestIncome, "", IDR))
+		assert.Equal(t, NewMoney(-income.MinorUnits(), IDR), Balance(journal, Cash, "", IDR))
+	})
+	t.Run("should report mismatch", func(t *testing.T) {
+		loan.(*paydayLoan).remaining = Rupiah(1)
+		assert.Equal(t, LedgerMismatchStruct{ErrLedgerMismatch, loan.ID(), Rupiah(0), Rupiah(1)}, client.CheckLedger())
+	})
+}
+
+func TestClientLedgerOfFailedDisbursement(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.RepayWithCredit(Rupiah(50), appliedAt)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	client.StartDisbursement()
+	client.FailDisbursement(appliedAt)
+	t.Run("should return credit applied to the loan", func(t *testing.T) {
+		assert.Equal(t, Rupiah(50), client.CreditBalance())
+		assert.Equal(t, Rupiah(0), client.Loans()[0].Remaining())
+	})
+	t.Run("should reverse origination", func(t *testing.T) {
+		assert.Nil(t, client.CheckLedger())
+		for _, account := range []Account{LoansReceivable, DisbursementsPayable, FeeIncome, InterestIncome} {
+			assert.Equal(t, Rupiah(0), Balance(client.Journal(), account, "", IDR), account)
+		}
+	})
+}
+
+func TestClientScoringAttributes(t *testing.T) {
+	client := NewClient("m", "25 September 1990", "", ktpNumber)
+	client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate)
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", appliedAt)
+	client.Repay(amount, appliedAt.AddDate(0, 0, 35))
+	applications := []LoanApplication{
+		NewLoanApplication(1, ktpNumber, "test", amount, term, Affordability{}, Origin{}, appliedAt),
+		NewLoanApplication(2, ktpNumber, "test", amount, term, Affordability{}, Origin{}, appliedAt.AddDate(0, 0, 5)),
+	}
+	applications[1].Reject("affordability_check_failed", appliedAt.AddDate(0, 0, 5))
+	t.Run("repaid loan should be counted with its delay", func(t *testing.T) {
+		attributes := client.ScoringAttributes(appliedAt.AddDate(0, 0, 40), applications)
+		assert.Equal(t, Attributes{Age: 35, KTPRegion: 35, RepaidLoans: 1, WorstDaysPastDue: 5, RecentApplications: 0}, attributes)
+	})
+	t.Run("applications submitted in the last 30 days should be recent also when rejected", func(t *testing.T) {
+		attributes := client.ScoringAttributes(appliedAt.AddDate(0, 0, 10), applications)
+		assert.Equal(t, 2, attributes[RecentApplications])
+	})
+	t.Run("age should be missing when birth date is not a date", func(t *testing.T) {
+		_, found := NewClient("", "unknown", "", ktpNumber).ScoringAttributes(appliedAt, nil)[Age]
+		assert.False(t, found)
+	})
+}
+
+func TestParseBirthDate(t *testing.T) {
+	for _, birthDate := range []string{"25 September 1990", "1990-09-25", "25-09-1990", " 1990-09-25 "} {
+		date, err := ParseBirthDate(birthDate)
+		assert.Nil(t, err, birthDate)
+		assert.Equal(t, time.Date(1990, 9, 25, 0, 0, 0, 0, time.UTC), date, birthDate)
+	}
+	_, err := ParseBirthDate("25/09/1990")
+	assert.NotNil(t, err)
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+// testScorecard scores young clients and clients with late repayments low
+var testScorecard, _ = NewScorecard(Scorecard{
+	Name:      "test",
+	BaseScore: 500,
+	Characteristics: []Characteristic{
+		{Attribute: Age, Bins: []Bin{
+			{Max: intPtr(24), Points: 0, ReasonCode: "young_age"},
+			{Min: intPtr(25), Points: 50},
+			{Missing: true, Points: -20, ReasonCode: "birth_date_unknown"},
+		}},
+		{Attribute: KTPRegion, Bins: []Bin{
+			{Values: []int{31, 35}, Points: 10},
+		}},
+		{Attribute: WorstDaysPastDue, Bins: []Bin{
+			{Max: intPtr(0), Points: 100},
+			{Min: intPtr(1), Points: 0, ReasonCode: "late_repayment"},
+		}},
+	},
+	Bands: []ScoreBand{
+		{Name: "C", MinScore: 500, MaxAmountBasisPoints: 5000, PriceBasisPoints: 15000},
+		{Name: "A", MinScore: 650, MaxAmountBasisPoints: 10000, PriceBasisPoints: 10000},
+	},
+})
+
+func TestScorecardScore(t *testing.T) {
+	t.Run("best bins should reach the highest band", func(t *testing.T) {
+		score := testScorecard.Score(Attributes{Age: 30, KTPRegion: 35, WorstDaysPastDue: 0}, appliedAt)
+		assert.Equal(t, Score{Scorecard: "test", Points: 660, Band: "A", ScoredAt: appliedAt}, score)
+	})
+	t.Run("reasons should start with the biggest loss", func(t *testing.T) {
+		score := testScorecard.Score(Attributes{KTPRegion: 12, WorstDaysPastDue: 3}, appliedAt)
+		assert.Equal(t, 480, score.Points)
+		assert.Equal(t, "", score.Band)
+		assert.Equal(t, []string{"late_repayment", "birth_date_unknown"}, score.Reasons)
+	})
+}
+
+func TestNewScorecard(t *testing.T) {
+	assert.Equal(t, "A", testScorecard.Bands[0].Name)
+	band := ScoreBand{Name: "A", MinScore: 1, MaxAmountBasisPoints: 10000, PriceBasisPoints: 10000}
+	_, err := NewScorecard(Scorecard{Name: "test", Bands: []ScoreBand{band, band}})
+	assert.Equal(t, ErrInvalidScorecard, err)
+	_, err = NewScorecard(Scorecard{Name: "test", Bands: []ScoreBand{band}, Characteristics: []Characteristic{{Attribute: "income", Bins: []Bin{{Missing: true}}}}})
+	assert.Equal(t, ErrInvalidScorecard, err)
+	_, err = NewScorecard(Scorecard{Name: "test", Bands: []ScoreBand{band}, Characteristics: []Characteristic{{Attribute: Age, Bins: []Bin{{Min: intPtr(1), Missing: true}}}}})
+	assert.Equal(t, ErrInvalidScorecard, err)
+}
+
+func TestScorecardApply(t *testing.T) {
+	product, err := testScorecard.Apply(PaydayLoan, Score{Points: 500, Band: "C"})
+	assert.Nil(t, err)
+	t.Run("band should limit maximum amount", func(t *testing.T) {
+		assert.Equal(t, Rupiah(25000000), product.MaxAmount())
+		err := product.Validate(Rupiah(25000001), term)
+		assert.Equal(t, ErrAmountTooHigh, err.(AmountTooHighStruct).error)
+		assert.Equal(t, Rupiah(25000000), err.(AmountTooHighStruct).MaxAmount)
+	})
+	t.Run("band should change price", func(t *testing.T) {
+		assert.Equal(t, uint(120), product.DailyInterestBasisPoints())
+		interest, _ := product.Interest(amount, term)
+		assert.Equal(t, Rupiah(3600000), interest)
+	})
+	t.Run("score below all bands should be too low", func(t *testing.T) {
+		score := Score{Scorecard: "test", Points: 499}
+		_, err := testScorecard.Apply(PaydayLoan, score)
+		assert.Equal(t, ScoreTooLowStruct{ErrScoreTooLow, score}, err)
+	})
+}
+
+func TestClientRecordScore(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	score := Score{Scorecard: "test", Points: 660, Band: "A", ScoredAt: appliedAt}
+	assert.Equal(t, ErrClientHasNoActiveLoan, client.RecordScore(score))
+	client.ApplyForLoan(testProduct, amount, term, appliedAt, rupiahRate)
+	assert.Nil(t, client.RecordScore(score))
+	recorded, found := client.ActiveLoan().Score()
+	assert.True(t, found)
+	assert.Equal(t, score, recorded)
+}
+
+func TestCreditBureauReportCheck(t *testing.T) {
+	debt := BureauDebt{Lender: "BANK", Outstanding: amount, DaysPastDue: 30}
+	assert.Nil(t, CreditBureauReport{Debts: []BureauDebt{debt}}.Check())
+	debt.DaysPastDue = 31
+	assert.Equal(t, ErrDelinquentAtOtherLender, CreditBureauReport{Debts: []BureauDebt{debt}}.Check())
+}
+
+func TestClientReviewLoan(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	assert.Equal(t, ErrClientHasNoActiveLoan, client.ReferForReview("credit_bureau_timeout"))
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	assert.Nil(t, client.ReferForReview("credit_bureau_timeout"))
+	_, err := client.StartDisbursement()
+	assert.Equal(t, ErrLoanInReview, err)
+	t.Run("approved loan can be disbursed", func(t *testing.T) {
+		assert.Nil(t, client.ReviewLoan("officer", true, appliedAt))
+		assert.Equal(t, Approved, client.ActiveLoan().DisbursementStatus())
+		assert.Equal(t, "officer", client.ActiveLoan().ReviewedBy())
+		assert.Equal(t, ErrLoanNotInReview, client.ReviewLoan("officer", false, appliedAt))
+	})
+	t.Run("declined loan is cancelled", func(t *testing.T) {
+		other := NewClient("", "", "", "3522582509010001")
+		other.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+		other.ReferForReview("credit_bureau_timeout")
+		assert.Nil(t, other.ReviewLoan("officer", false, appliedAt))
+		assert.False(t, other.HasActiveLoan())
+		assert.Equal(t, Declined, other.Loans()[0].DisbursementStatus())
+		assert.Nil(t, other.CheckLedger())
+	})
+}
+
+func TestAffordabilityCheck(t *testing.T) {
+	affordability := Affordability{MonthlyIncome: Rupiah(40000000), EmploymentType: Employed, MonthlyObligations: Rupiah(2000000)}
+	t.Run("repayment within share of income should be affordable", func(t *testing.T) {
+		assert.Nil(t, affordability.Check(PaydayLoan, Rupiah(9000000), term, DefaultMaxDebtToIncomeBasisPoints))
+	})
+	t.Run("repayment over share of income should return maximum affordable amount", func(t *testing.T) {
+		err := affordability.Check(PaydayLoan, amount, term, DefaultMaxDebtToIncomeBasisPoints)
+		assert.Equal(t, NotAffordableStruct{ErrLoanNotAffordable, NewMoney(967741935, IDR)}, err)
+		assert.Nil(t, affordability.Check(PaydayLoan, NewMoney(967741935, IDR), term, DefaultMaxDebtToIncomeBasisPoints))
+	})
+	t.Run("instalment loan should be spread over months of the term", func(t *testing.T) {
+		assert.Nil(t, affordability.Check(InstalmentLoan, Rupiah(30000000), 90, DefaultMaxDebtToIncomeBasisPoints))
+	})
+	t.Run("obligations over share of income should leave nothing to borrow", func(t *testing.T) {
+		indebted := Affordability{MonthlyIncome: Rupiah(4000000), EmploymentType: SelfEmployed, MonthlyObligations: Rupiah(2000000)}
+		err := indebted.Check(PaydayLoan, amount, term, DefaultMaxDebtToIncomeBasisPoints)
+		assert.Equal(t, NewMoney(0, IDR), err.(NotAffordableStruct).MaxAffordableAmount)
+	})
+	t.Run("invalid declaration", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidAffordability, Affordability{MonthlyIncome: Rupiah(1), EmploymentType: "astronaut"}.Check(PaydayLoan, amount, term, 3500))
+		assert.Equal(t, ErrInvalidAffordability, Affordability{MonthlyIncome: Rupiah(1), EmploymentType: Retired, MonthlyObligations: Rupiah(-1)}.Check(PaydayLoan, amount, term, 3500))
+		assert.Equal(t, ErrCurrencyMismatch, Affordability{MonthlyIncome: mustParseMoney("1000", SGD), EmploymentType: Employed}.Check(PaydayLoan, amount, term, 3500))
+	})
+}
+
+func TestLoanApplication(t *testing.T) {
+	newApplication := func() LoanApplication {
+		return NewLoanApplication(1, ktpNumber, PaydayLoan.Code(), amount, term, Affordability{}, Origin{Channel: "web", IPAddress: "10.0.0.1"}, appliedAt)
+	}
+	t.Run("new application should be submitted", func(t *testing.T) {
+		application := newApplication()
+		assert.Equal(t, "APP0000000001", application.ID())
+		assert.Equal(t, ApplicationSubmitted, application.Status())
+		assert.Equal(t, Origin{Channel: "web", IPAddress: "10.0.0.1"}, application.Origin())
+		assert.True(t, application.DecidedAt().IsZero())
+		_, found := application.Score()
+		assert.False(t, found)
+	})
+	t.Run("application under review should be approved", func(t *testing.T) {
+		application := newApplication()
+		assert.Nil(t, application.ReferForReview("loan-1", "credit_bureau_timeout"))
+		assert.Equal(t, ApplicationUnderReview, application.Status())
+		assert.Equal(t, ErrApplicationAlreadyDecided, application.ReferForReview("loan-1", "credit_bureau_timeout"))
+		assert.Nil(t, application.Approve("loan-1", appliedAt.Add(time.Hour)))
+		assert.Equal(t, ApplicationApproved, application.Status())
+		assert.Equal(t, appliedAt.Add(time.Hour), application.DecidedAt())
+	})
+	t.Run("decided application can't be changed", func(t *testing.T) {
+		application := newApplication()
+		assert.Nil(t, application.Reject("amount_too_low", appliedAt))
+		assert.Equal(t, "amount_too_low", application.Reason())
+		assert.Equal(t, ErrApplicationAlreadyDecided, application.Approve("loan-1", appliedAt))
+		assert.Equal(t, ErrApplicationAlreadyDecided, application.Cancel(appliedAt))
+		assert.Equal(t, ApplicationRejected, application.Status())
+	})
+	t.Run("application should expire after validity", func(t *testing.T) {
+		application := newApplication()
+		assert.Equal(t, ErrApplicationNotExpired, application.Expire(appliedAt.Add(ApplicationValidity-time.Second)))
+		assert.Nil(t, application.Expire(application.ExpiresAt()))
+		assert.Equal(t, ApplicationExpired, application.Status())
+	})
+}
+
+func TestClientAcceptAgreement(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	assert.Equal(t, ErrClientHasNoActiveLoan, client.RequireAgreement())
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	assert.Equal(t, ErrNoAgreementToAccept, client.AcceptAgreement("abc", "10.0.0.1", appliedAt))
+	assert.Nil(t, client.RequireAgreement())
+	_, err := client.StartDisbursement()
+	assert.Equal(t, ErrAgreementNotAccepted, err)
+	assert.Nil(t, client.AcceptAgreement("abc", "10.0.0.1", appliedAt))
+	assert.Equal(t, Agreement{Required: true, Hash: "abc", AcceptedFrom: "10.0.0.1", AcceptedAt: appliedAt}, client.ActiveLoan().Agreement())
+	assert.Equal(t, ErrNoAgreementToAccept, client.AcceptAgreement("abc", "10.0.0.1", appliedAt))
+	_, err = client.StartDisbursement()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrLoanAlreadyDisbursed, client.RequireAgreement())
+}
+
+func TestClientAcceptAgreementBeforeDisbursement(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	client.RequireAgreement()
+	acceptedAt := appliedAt.AddDate(0, 0, 1)
+	client.AcceptAgreement("abc", "10.0.0.1", acceptedAt)
+	accepted := client.ActiveLoan().Instalments()
+	client.StartDisbursement()
+	assert.Nil(t, client.CompleteDisbursement("ref", acceptedAt.AddDate(0, 0, 2)))
+	assert.Equal(t, acceptedAt, client.ActiveLoan().StartDate())
+	assert.Equal(t, accepted, client.ActiveLoan().Instalments())
+	assert.Equal(t, acceptedAt.AddDate(0, 0, int(term)), client.ActiveLoan().DueDate())
+}
+
+func TestClientReviewLoanAfterAcceptance(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	client.ReferForReview("credit_bureau_unavailable")
+	client.RequireAgreement()
+	client.AcceptAgreement("abc", "10.0.0.1", appliedAt)
+	t.Run("should keep agreement accepted on the day of approval", func(t *testing.T) {
+		reviewed := client.Clone()
+		assert.Nil(t, reviewed.ReviewLoan("officer", true, appliedAt.Add(time.Hour)))
+		assert.False(t, reviewed.ActiveLoan().Agreement().IsPending())
+	})
+	t.Run("should require agreement accepted before the day of approval to be accepted again", func(t *testing.T) {
+		assert.Nil(t, client.ReviewLoan("officer", true, appliedAt.AddDate(0, 0, 1)))
+		assert.Equal(t, Agreement{Required: true}, client.ActiveLoan().Agreement())
+	})
+}
+
+func TestClientLoanStateChanges(t *testing.T) {
+	client := NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(PaydayLoan, amount, term, appliedAt, rupiahRate)
+	client.StartDisbursement()
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", appliedAt)
+	client.Repay(client.ActiveLoan().Remaining(), appliedAt)
+	t.Run("should record every change of loan state", func(t *testing.T) {
+		var states [][]string
+		for _, change := range client.LoanStateChanges() {
+			assert.Equal(t, ktpNumber+"-1", change.LoanID)
+			states = append(states, []string{change.PreviousState, change.State})
+		}
+		assert.Equal(t, [][]string{{"", "approved"}, {"approved", "disbursing"}, {"disbursing", "disbursed"}, {"disbursed", "repaid"}}, states)
+	})
+	t.Run("should not copy changes to clone", func(t *testing.T) {
+		assert.Empty(t, client.Clone().LoanStateChanges())
+	})
+	t.Run("should forget cleared changes", func(t *testing.T) {
+		client.ClearLoanStateChanges()
+		assert.Empty(t, client.LoanStateChanges())
+	})
+}
+
+func TestOTPChallenge(t *testing.T) {
+	sentAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t.Run("should validate phone number and purpose", func(t *testing.T) {
+		_, err := NewOTPChallenge("1", "12345", RegistrationOTP, "123456", sentAt)
+		assert.Equal(t, ErrInvalidPhone, err)
+		_, err = NewOTPChallenge("1", "081234567890", OTPPurpose("payment"), "123456", sentAt)
+		assert.Equal(t, ErrInvalidOTPPurpose, err)
+		challenge, _ := NewOTPChallenge("1", "6281234567890", RegistrationOTP, "123456", sentAt)
+		assert.Equal(t, "+6281234567890", challenge.Phone())
+	})
+	t.Run("verified challenge should be used once for its phone and purpose", func(t *testing.T) {
+		challenge, _ := NewOTPChallenge("1", "081234567890", RegistrationOTP, "123456", sentAt)
+		assert.False(t, challenge.IsVerifiedFor("081234567890", RegistrationOTP, sentAt))
+		assert.Equal(t, OTPInvalidStruct{ErrOTPInvalid, OTPMaxAttempts - 1}, challenge.Verify("654321", sentAt))
+		assert.Nil(t, challenge.Verify("123456", sentAt.Add(time.Minute)))
+		assert.True(t, challenge.IsVerifiedFor("+6281234567890", RegistrationOTP, sentAt.Add(time.Minute)))
+		assert.False(t, challenge.IsVerifiedFor("081234567891", RegistrationOTP, sentAt.Add(time.Minute)))
+		assert.False(t, challenge.IsVerifiedFor("081234567890", AgreementAcceptanceOTP, sentAt.Add(time.Minute)))
+		assert.False(t, challenge.IsVerifiedFor("081234567890", RegistrationOTP, sentAt.Add(16*time.Minute)))
+		challenge.Use()
+		assert.False(t, challenge.IsVerifiedFor("081234567890", RegistrationOTP, sentAt.Add(time.Minute)))
+		assert.Equal(t, ErrOTPExpired, challenge.Verify("123456", sentAt.Add(time.Minute)))
+		assert.True(t, challenge.IsObsolete(sentAt.Add(time.Minute)))
+		challenge.Release()
+		assert.True(t, challenge.IsVerifiedFor("081234567890", RegistrationOTP, sentAt.Add(time.Minute)))
+	})
+	t.Run("code should not be verified after expiry", func(t *testing.T) {
+		challenge, _ := NewOTPChallenge("1", "081234567890", RegistrationOTP, "123456", sentAt)
+		assert.Equal(t, ErrOTPExpired, challenge.Verify("123456", sentAt.Add(OTPValidity)))
+		assert.True(t, challenge.IsObsolete(sentAt.Add(OTPValidity)))
+	})
+	t.Run("code should not be verified after too many attempts", func(t *testing.T) {
+		challenge, _ := NewOTPChallenge("1", "081234567890", RegistrationOTP, "123456", sentAt)
+		for attempt := 0; attempt < OTPMaxAttempts; attempt++ {
+			challenge.Verify("000000", sentAt)
+		}
+		assert.Equal(t, ErrOTPAttemptsExceeded, challenge.Verify("123456", sentAt))
+	})
+}
+
+func TestNotificationDelivery(t *testing.T) {
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newNotification := func() Notification {
+		return NewNotification(1, "3522582509010002", LoanApprovedEvent, "3522582509010002-1", SMSChannel, "081234567890", "Approved", "Hi Doe", createdAt)
+	}
+	t.Run("new notification should be due right away", func(t *testing.T) {
+		notification := newNotification()
+		assert.Equal(t, "NTF0000000001", notification.ID())
+		assert.Equal(t, DeliveryPending, notification.Status())
+		assert.True(t, notification.IsDue(createdAt))
+	})
+	t.Run("delivered notification should not be due", func(t *testing.T) {
+		notification := newNotification()
+		assert.Nil(t, notification.RecordDelivery(createdAt))
+		assert.Equal(t, DeliveryDelivered, notification.Status())
+		assert.Equal(t, uint(1), notification.Attempts())
+		assert.Equal(t, createdAt, notification.DeliveredAt())
+		assert.False(t, notification.IsDue(createdAt.Add(time.Hour)))
+		assert.Equal(t, ErrNotificationNotPending, notification.RecordDelivery(createdAt))
+	})
+	t.Run("failed delivery should be retried with growing delays until attempts run out", func(t *testing.T) {
+		notification := newNotification()
+		failedAt := createdAt
+		for _, delay := range NotificationRetryDelays {
+			assert.Nil(t, notification.RecordFailure("gateway down", failedAt))
+			assert.Equal(t, failedAt.Add(delay), notification.NextAttemptAt())
+			assert.False(t, notification.IsDue(failedAt.Add(delay-time.Second)))
+			failedAt = failedAt.Add(delay)
+			assert.True(t, notification.IsDue(failedAt))
+		}
+		assert.Nil(t, notification.RecordFailure("gateway still down", failedAt))
+		assert.Equal(t, DeliveryFailed, notification.Status())
+		assert.Equal(t, uint(NotificationMaxAttempts), notification.Attempts())
+		assert.Equal(t, "gateway still down", notification.LastError())
+		assert.False(t, notification.IsDue(failedAt.Add(24*time.Hour)))
+		assert.Equal(t, ErrNotificationNotPending, notification.RecordFailure("gateway down", failedAt))
+	})
+	t.Run("erased notification should hold no personal data and should not be delivered", func(t *testing.T) {
+		notification := newNotification()
+		notification.Erase()
+		assert.Equal(t, "", notification.Recipient())
+		assert.Equal(t, "", notification.Subject())
+		assert.Equal(t, "", notification.Body())
+		assert.Equal(t, DeliveryFailed, notification.Status())
+		assert.Equal(t, "client_erased", notification.LastError())
+		assert.False(t, notification.IsDue(createdAt))
+		delivered := newNotification()
+		delivered.RecordDelivery(createdAt)
+		delivered.Erase()
+		assert.Equal(t, DeliveryDelivered, delivered.Status())
+		assert.Equal(t, "", delivered.Body())
+	})
+}
+
+func TestWebhookSubscription(t *testing.T) {
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	secret := "0123456789abcdef"
+	t.Run("should subscribe URL to event types", func(t *testing.T) {
+		subscription, err := NewWebhookSubscription(1, "https://partner.example/hooks", []WebhookEventType{LoanStateChangedWebhook}, secret, createdAt)
+		assert.Nil(t, err)
+		assert.Equal(t, "WHS0000000001", subscription.ID())
+		assert.True(t, subscription.Subscribes(LoanStateChangedWebhook))
+		assert.False(t, subscription.Subscribes(ClientRegisteredWebhook))
+	})
+	t.Run("should reject invalid subscription", func(t *testing.T) {
+		_, err := NewWebhookSubscription(1, "partner.example/hooks", WebhookEventTypes, secret, createdAt)
+		assert.Equal(t, ErrInvalidWebhookURL, err)
+		_, err = NewWebhookSubscription(1, "ftp://partner.example/hooks", WebhookEventTypes, secret, createdAt)
+		assert.Equal(t, ErrInvalidWebhookURL, err)
+		for _, private := range []string{"http://localhost:8080/hooks", "http://api.localhost/hooks", "http://127.0.0.1/hooks",
+			"http://10.0.0.5/hooks", "https://192.168.1.1/hooks", "http://169.254.169.254/latest", "http://[::1]/hooks",
+			"http://[::ffff:127.0.0.1]/hooks", "http://0.0.0.0/hooks"} {
+			_, err = NewWebhookSubscription(1, private, WebhookEventTypes, secret, createdAt)
+			assert.Equal(t, ErrWebhookHostNotAllowed, err, private)
+		}
+		_, err = NewWebhookSubscription(1, "https://partner.example/hooks", nil, secret, createdAt)
+		assert.Equal(t, ErrInvalidWebhookEventType, err)
+		_, err = NewWebhookSubscription(1, "https://partner.example/hooks", []WebhookEventType{"loan.approved"}, secret, createdAt)
+		assert.Equal(t, ErrInvalidWebhookEventType, err)
+		_, err = NewWebhookSubscription(1, "https://partner.example/hooks", WebhookEventTypes, "short", createdAt)
+		assert.Equal(t, ErrWebhookSecretTooShort, err)
+	})
+	t.Run("should sign timestamp and payload", func(t *testing.T) {
+		signature := SignWebhook(secret, "1767225600", []byte(`{"id":"1"}`))
+		assert.Equal(t, "54ef2ce91e2e4fd53b0b40b072dae96bc3111edeac3c4a3fd5735b97a94353c0", signature)
+		assert.NotEqual(t, signature, SignWebhook(secret, "1767225601", []byte(`{"id":"1"}`)))
+	})
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newDelivery := func() WebhookDelivery {
+		return NewWebhookDelivery(1, "WHS0000000001", "event", ClientRegisteredWebhook, []byte(`{}`), createdAt, "")
+	}
+	t.Run("new delivery should be due right away", func(t *testing.T) {
+		delivery := newDelivery()
+		assert.Equal(t, "WHD0000000001", delivery.ID())
+		assert.Equal(t, DeliveryPending, delivery.Status())
+		assert.True(t, delivery.IsDue(createdAt))
+	})
+	t.Run("2xx response should deliver the payload", func(t *testing.T) {
+		delivery := newDelivery()
+		assert.Nil(t, delivery.RecordAttempt(WebhookAttempt{At: createdAt, StatusCode: 204}))
+		assert.Equal(t, DeliveryDelivered, delivery.Status())
+		assert.False(t, delivery.IsDue(createdAt.Add(time.Hour)))
+		assert.Equal(t, ErrWebhookDeliveryNotPending, delivery.RecordAttempt(WebhookAttempt{At: createdAt, StatusCode: 200}))
+	})
+	t.Run("failed attempts should back off exponentially until attempts run out", func(t *testing.T) {
+		delivery := newDelivery()
+		attemptedAt := createdAt
+		delay := WebhookRetryDelay
+		for attempt := 1; attempt < WebhookMaxAttempts; attempt++ {
+			assert.Nil(t, delivery.RecordAttempt(WebhookAttempt{At: attemptedAt, StatusCode: 500}))
+			assert.Equal(t, attemptedAt.Add(delay), delivery.NextAttemptAt())
+			assert.False(t, delivery.IsDue(attemptedAt.Add(delay-time.Second)))
+			attemptedAt = attemptedAt.Add(delay)
+			delay *= 2
+		}
+		assert.Nil(t, delivery.RecordAttempt(WebhookAttempt{At: attemptedAt, Error: "connection refused"}))
+		assert.Equal(t, DeliveryFailed, delivery.Status())
+		assert.Len(t, delivery.Attempts(), WebhookMaxAttempts)
+		assert.True(t, delivery.NextAttemptAt().IsZero())
+	})
+	t.Run("abandoned delivery should fail", func(t *testing.T) {
+		delivery := newDelivery()
+		assert.Nil(t, delivery.Abandon("unsubscribed", createdAt))
+		assert.Equal(t, DeliveryFailed, delivery.Status())
+		assert.Equal(t, ErrWebhookDeliveryNotPending, delivery.Abandon("unsubscribed", createdAt))
+	})
+}
+
+func TestClientClone(t *testing.T) {
+	client, loan := clientWithLoan(Rupiah(100))
+	clone := client.Clone()
+	assert.Nil(t, clone.Repay(Rupiah(40), appliedAt))
+	profile := clone.Profile()
+	profile.Email = "doe@example.com"
+	clone.UpdateProfile(profile, appliedAt)
+	t.Run("clone should be changed", func(t *testing.T) {
+		assert.Len(t, clone.ActiveLoan().Repayments(), 1)
+		assert.Equal(t, "doe@example.com", clone.Email())
+	})
+	t.Run("original should not be changed", func(t *testing.T) {
+		assert.Empty(t, loan.Repayments())
+		assert.Equal(t, Rupiah(100), loan.Remaining())
+		assert.Empty(t, client.Email())
+		assert.Empty(t, client.ProfileChanges())
+		assert.Len(t, client.Journal(), len(clone.Journal())-1)
+	})
+}
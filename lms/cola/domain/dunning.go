@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// DunningAction is what happens when a loan reaches a dunning stage
+type DunningAction string
+
+const (
+	// SendReminder reminds the client of an upcoming instalment
+	SendReminder DunningAction = "reminder"
+	// SendNotice tells the client the instalment is due
+	SendNotice DunningAction = "notice"
+	// Escalate sends a stronger notice to the client of an overdue loan
+	Escalate DunningAction = "escalation"
+	// HandOverToCollections marks the loan defaulted and passes it to the collections agency
+	HandOverToCollections DunningAction = "collections"
+)
+
+// DunningStage is reached when the oldest unpaid instalment of a loan is DaysFromDueDate days after its due date.
+// Negative days are before the due date.
+type DunningStage struct {
+	Name            string
+	DaysFromDueDate int
+	Action          DunningAction
+}
+
+// DunningSchedule lists dunning stages in the order they are reached
+type DunningSchedule []DunningStage
+
+// DefaultDunningSchedule reminds the client 3 days before the due date and hands the loan over to collections after
+// 90 days
+var DefaultDunningSchedule = DunningSchedule{
+	{Name: "reminder", DaysFromDueDate: -3, Action: SendReminder},
+	{Name: "due_notice", DaysFromDueDate: 0, Action: SendNotice},
+	{Name: "escalation_1", DaysFromDueDate: 7, Action: Escalate},
+	{Name: "escalation_2", DaysFromDueDate: 30, Action: Escalate},
+	{Name: "collections", DaysFromDueDate: 90, Action: HandOverToCollections},
+}
+
+// NewDunningSchedule orders stages by days from due date. Names of stages must be unique, so a loan can be found
+// by its stage.
+func NewDunningSchedule(stages ...DunningStage) (DunningSchedule, error) {
+	schedule := make(DunningSchedule, len(stages))
+	copy(schedule, stages)
+	sort.SliceStable(schedule, func(i, j int) bool {
+		return schedule[i].DaysFromDueDate < schedule[j].DaysFromDueDate
+	})
+	names := make(map[string]bool)
+	for i, stage := range schedule {
+		if stage.Name == "" || names[stage.Name] {
+			return nil, ErrInvalidDunningSchedule
+		}
+		if i > 0 && schedule[i-1].DaysFromDueDate == stage.DaysFromDueDate {
+			return nil, ErrInvalidDunningSchedule
+		}
+		switch stage.Action {
+		case SendReminder, SendNotice, Escalate, HandOverToCollections:
+		default:
+			return nil, ErrInvalidDunningSchedule
+		}
+		names[stage.Name] = true
+	}
+	return schedule, nil
+}
+
+// index returns position of the stage in the schedule or -1 when the schedule has no such stage
+func (schedule DunningSchedule) index(name string) int {
+	for i, stage := range schedule {
+		if stage.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// dunning is the progress of the oldest unpaid instalment of a loan through the schedule
+type dunning struct {
+	dueDate time.Time
+	stage   string
+}
+
+func (client *paydayLoanClient) Dun(schedule DunningSchedule, asOf time.Time) (DunningStage, bool) {
+	defer client.trackLoanStates()()
+	if client.loan == nil || client.loan.DisbursementStatus() != Disbursed || client.loan.IsDefaulted() {
+		return DunningStage{}, false
+	}
+	return client.loan.dun(schedule, asOf)
+}
+
+func (loan *paydayLoan) DunningStage() string {
+	return loan.dunning.stage
+}
+
+func (loan *paydayLoan) IsDefaulted() bool {
+	return !loan.defaultedAt.IsZero()
+}
+
+func (loan *paydayLoan) DefaultedAt() time.Time {
+	return loan.defaultedAt
+}
+
+// dun starts the schedule again for every instalment, so repaying an overdue instalment of an instalment loan stops
+// escalations until the next one is due
+func (loan *paydayLoan) dun(schedule DunningSchedule, asOf time.Time) (DunningStage, bool) {
+	instalment, unpaid := loan.OldestUnpaidInstalment()
+	if !unpaid {
+		loan.dunning = dunning{}
+		return DunningStage{}, false
+	}
+	if !loan.dunning.dueDate.Equal(instalment.DueDate) {
+		loan.dunning = dunning{dueDate: instalment.DueDate}
+	}
+	days := int(math.Floor(asOf.Sub(instalment.DueDate).Hours() / 24))
+	reached := -1
+	for i, stage := range schedule {
+		if stage.DaysFromDueDate <= days {
+			reached = i
+		}
+	}
+	if reached < 0 || reached <= schedule.index(loan.dunning.stage) {
+		return DunningStage{}, false
+	}
+	stage := schedule[reached]
+	loan.dunning.stage = stage.Name
+	if stage.Action == HandOverToCollections {
+		loan.defaultedAt = asOf
+	}
+	return stage, true
+}
+
+// ErrInvalidDunningSchedule is returned when stages of dunning schedule have no name, duplicate names or days or
+// unknown action
+var ErrInvalidDunningSchedule = errors.New("invalid_dunning_schedule")
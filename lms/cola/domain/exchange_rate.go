@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exchangeRateDigits is the precision of exchange rates, rates of all currency pairs we lend in fit into it
+const exchangeRateDigits = 8
+
+// maxScaledRate keeps conversions of scaled rate to minor units within uint64
+const maxScaledRate = 1e15
+
+// ExchangeRate is a price of one major unit of From currency in major units of To currency valid as of a date
+type ExchangeRate struct {
+	From Currency
+	To   Currency
+	AsOf time.Time
+	// scaled is the rate multiplied by 10^exchangeRateDigits
+	scaled int64
+}
+
+var ratePattern = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?$`)
+
+// NewExchangeRate parses decimal rate, e.g. "11850.25" for SGD to IDR
+func NewExchangeRate(from, to Currency, rate string, asOf time.Time) (ExchangeRate, error) {
+	if !from.IsSupported() || !to.IsSupported() {
+		return ExchangeRate{}, ErrUnknownCurrency
+	}
+	parts := ratePattern.FindStringSubmatch(rate)
+	if parts == nil || len(parts[2]) > exchangeRateDigits {
+		return ExchangeRate{}, ErrInvalidExchangeRate
+	}
+	scaled, err := strconv.ParseInt(parts[1]+parts[2]+strings.Repeat("0", exchangeRateDigits-len(parts[2])), 10, 64)
+	if err != nil || scaled == 0 || scaled > maxScaledRate {
+		return ExchangeRate{}, ErrInvalidExchangeRate
+	}
+	return ExchangeRate{From: from, To: to, AsOf: asOf, scaled: scaled}, nil
+}
+
+// IdentityRate converts currency to itself
+func IdentityRate(currency Currency, asOf time.Time) ExchangeRate {
+	return ExchangeRate{From: currency, To: currency, AsOf: asOf, scaled: pow10(exchangeRateDigits)}
+}
+
+// Rate formats the rate as decimal number
+func (rate ExchangeRate) Rate() string {
+	digits := strconv.FormatInt(rate.scaled, 10)
+	if len(digits) <= exchangeRateDigits {
+		digits = strings.Repeat("0", exchangeRateDigits-len(digits)+1) + digits
+	}
+	point := len(digits) - exchangeRateDigits
+	fraction := strings.TrimRight(digits[point:], "0")
+	if fraction == "" {
+		return digits[:point]
+	}
+	return digits[:point] + "." + fraction
+}
+
+// Convert returns money in To currency rounded half to even
+func (rate ExchangeRate) Convert(money Money) (Money, error) {
+	if money.Currency() != rate.From {
+		return Money{}, ErrCurrencyMismatch
+	}
+	numerator := uint64(rate.scaled) * uint64(pow10(rate.To.MinorUnitDigits()))
+	denominator := uint64(pow10(exchangeRateDigits + rate.From.MinorUnitDigits()))
+	converted, err := money.MulDiv(numerator, denominator)
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(converted.MinorUnits(), rate.To), nil
+}
+
+func pow10(exponent int) int64 {
+	result := int64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// ErrInvalidExchangeRate is returned when exchange rate is not a positive decimal number
+var ErrInvalidExchangeRate = errors.New("invalid_exchange_rate")
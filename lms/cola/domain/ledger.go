@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Account of the general ledger
+type Account string
+
+const (
+	// LoansReceivable is money owed by clients including fee, interest and late charges
+	LoansReceivable Account = "loans_receivable"
+	// Cash is money in our bank account
+	Cash Account = "cash"
+	// InterestIncome includes interest of loans and penalty interest
+	InterestIncome Account = "interest_income"
+	// FeeIncome includes fees of loans and late fees
+	FeeIncome Account = "fee_income"
+	// WriteOffs is the expense of loans which were written off, recoveries reduce it
+	WriteOffs Account = "write_offs"
+	// DisbursementsPayable is money of approved loans which was not transferred to clients yet
+	DisbursementsPayable Account = "disbursements_payable"
+	// ClientCredit is money received from clients which was not needed for repaying a loan
+	ClientCredit Account = "client_credit"
+)
+
+// Accounts lists all accounts in the order of trial balance
+var Accounts = []Account{Cash, LoansReceivable, DisbursementsPayable, ClientCredit, FeeIncome, InterestIncome, WriteOffs}
+
+// JournalEvent tells which money movement was posted
+type JournalEvent string
+
+const (
+	// Origination of an approved loan recognizes the receivable and income
+	Origination JournalEvent = "origination"
+	// Disbursement transfers the loan amount to the client
+	Disbursement JournalEvent = "disbursement"
+	// Cancellation reverses origination of a loan which could not be disbursed
+	Cancellation JournalEvent = "cancellation"
+	// RepaymentReceived is money received from the client for a loan
+	RepaymentReceived JournalEvent = "repayment"
+	// CreditApplied repays a new loan from credit balance
+	CreditApplied JournalEvent = "credit_applied"
+	// OverpaymentReceived is kept as credit balance
+	OverpaymentReceived JournalEvent = "overpayment"
+	// RefundRequested transfers credit balance to the client
+	RefundRequested JournalEvent = "refund"
+	// RefundReturned reverses refund which was rejected by the bank
+	RefundReturned JournalEvent = "refund_failed"
+	// ChargeAccrued is a late fee or penalty interest
+	ChargeAccrued JournalEvent = "accrual"
+	// WrittenOff takes the receivable off the books
+	WrittenOff JournalEvent = "write_off"
+	// RecoveryReceived is money received for a written-off loan
+	RecoveryReceived JournalEvent = "recovery"
+)
+
+// JournalEntry records one money movement. Debits and credits of every entry are equal.
+type JournalEntry struct {
+	ID    string
+	Date  time.Time
+	Event JournalEvent
+	// LoanID is empty for movements of credit balance only
+	LoanID   string
+	Postings []Posting
+}
+
+// Posting debits or credits an account
+type Posting struct {
+	Account Account
+	Debit   Money
+	Credit  Money
+}
+
+func debit(account Account, amount Money) Posting {
+	return Posting{Account: account, Debit: amount, Credit: NewMoney(0, amount.Currency())}
+}
+
+func credit(account Account, amount Money) Posting {
+	return Posting{Account: account, Debit: NewMoney(0, amount.Currency()), Credit: amount}
+}
+
+func (client *paydayLoanClient) Journal() []JournalEntry {
+	return client.journal
+}
+
+// post leaves out zero postings and does not record entries without any posting. Unbalanced entry means broken
+// invariant, so it panics.
+func (client *paydayLoanClient) post(event JournalEvent, loanID string, date time.Time, postings ...Posting) {
+	entry := JournalEntry{
+		ID:     fmt.Sprintf("%s-journal-%d", client.ktpNumber, len(client.journal)+1),
+		Date:   date,
+		Event:  event,
+		LoanID: loanID,
+	}
+	balance := make(map[Currency]Money)
+	for _, posting := range postings {
+		if posting.Debit.IsZero() && posting.Credit.IsZero() {
+			continue
+		}
+		currency := posting.Debit.Currency()
+		if _, found := balance[currency]; !found {
+			balance[currency] = NewMoney(0, currency)
+		}
+		balance[currency] = mustSub(mustAdd(balance[currency], posting.Debit), posting.Credit)
+		entry.Postings = append(entry.Postings, posting)
+	}
+	for _, difference := range balance {
+		if !difference.IsZero() {
+			panic(fmt.Sprintf("unbalanced journal entry %s: %v", event, postings))
+		}
+	}
+	if len(entry.Postings) > 0 {
+		client.journal = append(client.journal, entry)
+	}
+}
+
+// Balance sums debits minus credits of the account in entries of the loan. Empty loanID sums entries of the whole
+// client.
+func Balance(journal []JournalEntry, account Account, loanID string, currency Currency) Money {
+	balance := NewMoney(0, currency)
+	for _, entry := range journal {
+		if loanID != "" && entry.LoanID != loanID {
+			continue
+		}
+		for _, posting := range entry.Postings {
+			if posting.Account == account && posting.Debit.Currency() == currency {
+				balance = mustSub(mustAdd(balance, posting.Debit), posting.Credit)
+			}
+		}
+	}
+	return balance
+}
+
+// CheckLedger compares ledger balances with the loans and credit balance of the client. Remaining amount of
+// a written-off loan is kept in WriteOffs until it is recovered.
+func (client *paydayLoanClient) CheckLedger() error {
+	for _, loan := range client.Loans() {
+		currency := loan.Remaining().Currency()
+		ledger := mustAdd(Balance(client.journal, LoansReceivable, loan.ID(), currency), Balance(client.journal, WriteOffs, loan.ID(), currency))
+		if ledger.Cmp(loan.Remaining()) != 0 {
+			return LedgerMismatchStruct{ErrLedgerMismatch, loan.ID(), ledger, loan.Remaining()}
+		}
+	}
+	// ClientCredit is a liability, so its balance is negative
+	ledger := Balance(client.journal, ClientCredit, "", client.creditBalance.Currency())
+	if ledger = NewMoney(-ledger.MinorUnits(), ledger.Currency()); ledger.Cmp(client.creditBalance) != 0 {
+		return LedgerMismatchStruct{ErrLedgerMismatch, "", ledger, client.creditBalance}
+	}
+	return nil
+}
+
+// ErrLedgerMismatch is returned when ledger balances do not match amounts held by Client
+var ErrLedgerMismatch = errors.New("ledger_mismatch")
+
+// LedgerMismatchStruct is an error struct wrapping ErrLedgerMismatch with the balances which do not match. LoanID is
+// empty when credit balance does not match.
+type LedgerMismatchStruct struct {
+	error
+	LoanID   string
+	Ledger   Money
+	Expected Money
+}
@@ -0,0 +1,62 @@
+package domain
+
+// LoanStateChange is recorded by the client whenever one of its loans moves to another state
+type LoanStateChange struct {
+	LoanID string
+	// PreviousState is empty for a new loan
+	PreviousState string
+	State         string
+	Amount        Money
+	Remaining     Money
+}
+
+// LoanState follows the disbursement status until the loan is disbursed, then it is repaid, defaulted or written off
+func LoanState(loan Loan) string {
+	status := loan.DisbursementStatus()
+	switch {
+	case loan.IsWrittenOff():
+		return "written_off"
+	case status == Approved && loan.Agreement().IsPending():
+		return "awaiting_agreement"
+	case status != Disbursed:
+		return string(status)
+	case loan.Remaining().IsZero():
+		return "repaid"
+	case loan.IsDefaulted():
+		return "defaulted"
+	}
+	return string(status)
+}
+
+func (client *paydayLoanClient) LoanStateChanges() []LoanStateChange {
+	return client.loanStateChanges
+}
+
+func (client *paydayLoanClient) ClearLoanStateChanges() {
+	client.loanStateChanges = nil
+}
+
+// trackLoanStates remembers the states of loans and returns a function recording the loans which changed state since,
+// every method which can change a loan state defers it
+func (client *paydayLoanClient) trackLoanStates() func() {
+	states := make(map[string]string)
+	for _, loan := range client.Loans() {
+		states[loan.ID()] = LoanState(loan)
+	}
+	return func() {
+		for _, loan := range client.Loans() {
+			previousState := states[loan.ID()]
+			state := LoanState(loan)
+			if state == previousState {
+				continue
+			}
+			client.loanStateChanges = append(client.loanStateChanges, LoanStateChange{
+				LoanID:        loan.ID(),
+				PreviousState: previousState,
+				State:         state,
+				Amount:        loan.Amount(),
+				Remaining:     loan.Remaining(),
+			})
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/briyanadityatama/goLoans/money"
+)
+
+// Money is an amount in integer minor units of a currency, it is defined by package money, so the API shares it
+// without depending on the domain
+type Money = money.Money
+
+// Currency is an ISO 4217 currency code
+type Currency = money.Currency
+
+// Supported currencies
+const (
+	IDR = money.IDR
+	SGD = money.SGD
+	MYR = money.MYR
+)
+
+// DefaultCurrency is used when amount is given without currency
+const DefaultCurrency = money.DefaultCurrency
+
+// NewMoney returns amount given in minor units, e.g. sen for IDR
+func NewMoney(minorUnits int64, currency Currency) Money {
+	return money.New(minorUnits, currency)
+}
+
+// Rupiah returns amount given in whole rupiah, it should be used for constants only
+func Rupiah(rupiah int64) Money {
+	return money.Rupiah(rupiah)
+}
+
+// ParseMoney parses decimal amount in major units, e.g. "1500.50"
+func ParseMoney(amount string, currency Currency) (Money, error) {
+	return money.Parse(amount, currency)
+}
+
+// mustParseMoney is used for constants of products
+func mustParseMoney(amount string, currency Currency) Money {
+	parsed, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// mustAdd and mustSub are used for amounts of the same loan which share currency and are limited by its total payable
+// amount, so an error means broken invariant
+func mustAdd(amount, other Money) Money {
+	sum, err := amount.Add(other)
+	if err != nil {
+		panic(err)
+	}
+	return sum
+}
+
+func mustSub(amount, other Money) Money {
+	difference, err := amount.Sub(other)
+	if err != nil {
+		panic(err)
+	}
+	return difference
+}
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are added, subtracted or compared
+var ErrCurrencyMismatch = money.ErrCurrencyMismatch
+
+// ErrMoneyOverflow is returned when result of arithmetic does not fit into minor units
+var ErrMoneyOverflow = money.ErrMoneyOverflow
+
+// ErrInvalidMoney is returned when amount is not a decimal number or has more decimal places than the currency
+var ErrInvalidMoney = money.ErrInvalidMoney
+
+// ErrUnknownCurrency is returned for currency which is not supported
+var ErrUnknownCurrency = money.ErrUnknownCurrency
+
+// ErrNegativeAmount is returned when negative amount is repaid or refunded
+var ErrNegativeAmount = errors.New("negative_amount")
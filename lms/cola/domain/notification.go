@@ -0,0 +1,237 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NotificationEvent is what the client is notified about
+type NotificationEvent string
+
+const (
+	// LoanApprovedEvent is sent when the loan is approved, either right after the application or by an officer
+	LoanApprovedEvent NotificationEvent = "loan_approved"
+	// LoanDisbursedEvent is sent when the amount of the loan was transferred to the client
+	LoanDisbursedEvent NotificationEvent = "loan_disbursed"
+	// InstalmentDueEvent reminds the client of an instalment which is due soon, it is sent at dunning stages with
+	// SendReminder action
+	InstalmentDueEvent NotificationEvent = "instalment_due"
+	// PaymentOverdueEvent tells the client that an instalment was not repaid by its due date, it is sent at dunning
+	// stages with SendNotice and Escalate actions
+	PaymentOverdueEvent NotificationEvent = "payment_overdue"
+	// LoanDefaultedEvent tells the client that the loan was handed over to collections
+	LoanDefaultedEvent NotificationEvent = "loan_defaulted"
+	// RepaymentReceivedEvent is the receipt of a repayment
+	RepaymentReceivedEvent NotificationEvent = "repayment_received"
+)
+
+// NotificationChannel is how the notification is delivered
+type NotificationChannel string
+
+const (
+	// EmailChannel delivers to the email of the client
+	EmailChannel NotificationChannel = "email"
+	// SMSChannel delivers to the phone of the client
+	SMSChannel NotificationChannel = "sms"
+	// WebhookChannel delivers to a configured URL, the notification has no recipient
+	WebhookChannel NotificationChannel = "webhook"
+)
+
+// DeliveryStatus tells whether the notification reached its channel
+type DeliveryStatus string
+
+const (
+	// DeliveryPending was not delivered yet and will be retried
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered was accepted by its channel
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed failed NotificationMaxAttempts times and is not retried any more
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// NotificationRetryDelays are waits after the failed attempts, the last one repeats until NotificationMaxAttempts
+var NotificationRetryDelays = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+
+// NotificationMaxAttempts is how many times delivery is attempted before the notification fails
+const NotificationMaxAttempts = 5
+
+// Notification is a message rendered for one channel of a client
+type Notification interface {
+	// ID is unique across all notifications, it is made of notification sequence number
+	ID() string
+	KTPNumber() string
+	Event() NotificationEvent
+	// Reference is what the notification is about, e.g. a loan or its instalment, the same event is not sent twice
+	// for the same reference and channel
+	Reference() string
+	Channel() NotificationChannel
+	// Recipient is the email or phone of the client, empty for WebhookChannel
+	Recipient() string
+	Subject() string
+	Body() string
+	CreatedAt() time.Time
+	Status() DeliveryStatus
+	Attempts() uint
+	// LastError is the reason of the last failed attempt
+	LastError() string
+	// DeliveredAt is zero until the notification is delivered
+	DeliveredAt() time.Time
+	// NextAttemptAt is zero when the notification is not pending
+	NextAttemptAt() time.Time
+	// IsDue tells whether the pending notification should be delivered at a given time
+	IsDue(at time.Time) bool
+	RecordDelivery(deliveredAt time.Time) error
+	// RecordFailure schedules the next attempt after NotificationRetryDelays or fails the notification
+	RecordFailure(reason string, failedAt time.Time) error
+	// Erase removes the recipient, subject and body which hold personal data of the erased client, pending
+	// notification fails
+	Erase()
+	// Clone returns a copy which shares no mutable state with the notification, so repositories can hand out and keep
+	// notifications which are changed by use cases running at the same time
+	Clone() Notification
+}
+
+type notification struct {
+	id            string
+	ktpNumber     string
+	event         NotificationEvent
+	reference     string
+	channel       NotificationChannel
+	recipient     string
+	subject       string
+	body          string
+	createdAt     time.Time
+	status        DeliveryStatus
+	attempts      uint
+	lastError     string
+	deliveredAt   time.Time
+	nextAttemptAt time.Time
+}
+
+// NewNotification creates a pending notification which is due right away. Every notification must get a different
+// sequence.
+func NewNotification(sequence uint64, ktpNumber string, event NotificationEvent, reference string, channel NotificationChannel, recipient string, subject string, body string, createdAt time.Time) Notification {
+	return &notification{
+		id:            fmt.Sprintf("NTF%010d", sequence),
+		ktpNumber:     ktpNumber,
+		event:         event,
+		reference:     reference,
+		channel:       channel,
+		recipient:     recipient,
+		subject:       subject,
+		body:          body,
+		createdAt:     createdAt,
+		status:        DeliveryPending,
+		nextAttemptAt: createdAt,
+	}
+}
+
+func (notification *notification) ID() string {
+	return notification.id
+}
+
+func (notification *notification) KTPNumber() string {
+	return notification.ktpNumber
+}
+
+func (notification *notification) Event() NotificationEvent {
+	return notification.event
+}
+
+func (notification *notification) Reference() string {
+	return notification.reference
+}
+
+func (notification *notification) Channel() NotificationChannel {
+	return notification.channel
+}
+
+func (notification *notification) Recipient() string {
+	return notification.recipient
+}
+
+func (notification *notification) Subject() string {
+	return notification.subject
+}
+
+func (notification *notification) Body() string {
+	return notification.body
+}
+
+func (notification *notification) CreatedAt() time.Time {
+	return notification.createdAt
+}
+
+func (notification *notification) Status() DeliveryStatus {
+	return notification.status
+}
+
+func (notification *notification) Attempts() uint {
+	return notification.attempts
+}
+
+func (notification *notification) LastError() string {
+	return notification.lastError
+}
+
+func (notification *notification) DeliveredAt() time.Time {
+	return notification.deliveredAt
+}
+
+func (notification *notification) NextAttemptAt() time.Time {
+	return notification.nextAttemptAt
+}
+
+func (notification *notification) IsDue(at time.Time) bool {
+	return notification.status == DeliveryPending && !at.Before(notification.nextAttemptAt)
+}
+
+func (notification *notification) RecordDelivery(deliveredAt time.Time) error {
+	if notification.status != DeliveryPending {
+		return ErrNotificationNotPending
+	}
+	notification.attempts++
+	notification.status = DeliveryDelivered
+	notification.deliveredAt = deliveredAt
+	notification.nextAttemptAt = time.Time{}
+	return nil
+}
+
+func (notification *notification) RecordFailure(reason string, failedAt time.Time) error {
+	if notification.status != DeliveryPending {
+		return ErrNotificationNotPending
+	}
+	notification.attempts++
+	notification.lastError = reason
+	if notification.attempts >= NotificationMaxAttempts {
+		notification.status = DeliveryFailed
+		notification.nextAttemptAt = time.Time{}
+		return nil
+	}
+	delay := NotificationRetryDelays[len(NotificationRetryDelays)-1]
+	if int(notification.attempts) <= len(NotificationRetryDelays) {
+		delay = NotificationRetryDelays[notification.attempts-1]
+	}
+	notification.nextAttemptAt = failedAt.Add(delay)
+	return nil
+}
+
+func (notification *notification) Erase() {
+	notification.recipient = ""
+	notification.subject = ""
+	notification.body = ""
+	if notification.status == DeliveryPending {
+		notification.status = DeliveryFailed
+		notification.lastError = ErrClientErased.Error()
+		notification.nextAttemptAt = time.Time{}
+	}
+}
+
+func (notification *notification) Clone() Notification {
+	clone := *notification
+	return &clone
+}
+
+// ErrNotificationNotPending is returned when delivery of a notification which was delivered or failed is recorded
+var ErrNotificationNotPending = errors.New("notification_not_pending")
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+)
+
+// OTPPurpose is the action a one-time password is sent for, code verified for one action can't be used for another
+type OTPPurpose string
+
+const (
+	// RegistrationOTP verifies the phone number of a new client
+	RegistrationOTP OTPPurpose = "registration"
+	// AgreementAcceptanceOTP verifies the client accepting a loan agreement
+	AgreementAcceptanceOTP OTPPurpose = "agreement_acceptance"
+	// PhoneChangeOTP verifies the new phone number of a client
+	PhoneChangeOTP OTPPurpose = "phone_change"
+)
+
+const (
+	// OTPValidity is how long the code can be verified after it was sent
+	OTPValidity = 5 * time.Minute
+	// OTPMaxAttempts is how many codes can be tried for one challenge
+	OTPMaxAttempts = 5
+	// OTPResendInterval is how long the client waits before another code is sent to the same phone for the same purpose
+	OTPResendInterval = time.Minute
+	// verifiedOTPValidity is how long the verified challenge can be used by the action
+	verifiedOTPValidity = 15 * time.Minute
+)
+
+// OTPChallenge is a one-time password sent to a phone number. Only a hash of the code is kept.
+type OTPChallenge interface {
+	ID() string
+	// Phone is normalized to +62 prefix
+	Phone() string
+	Purpose() OTPPurpose
+	SentAt() time.Time
+	ExpiresAt() time.Time
+	// Verify checks the code, verifying the challenge again is not an error
+	Verify(code string, verifiedAt time.Time) error
+	// IsVerifiedFor tells whether the challenge can be used by the action for the phone number
+	IsVerifiedFor(phone string, purpose OTPPurpose, at time.Time) bool
+	// Use ensures the challenge is used by one action only
+	Use()
+	// Release lets the challenge be used again when the action it was used by failed
+	Release()
+	// IsObsolete challenge can be neither verified nor used
+	IsObsolete(at time.Time) bool
+}
+
+type otpChallenge struct {
+	id         string
+	phone      string
+	purpose    OTPPurpose
+	codeHash   [sha256.Size]byte
+	sentAt     time.Time
+	attempts   uint
+	verifiedAt time.Time
+	used       bool
+}
+
+// NewOTPChallenge validates the phone number and the purpose of a code which is being sent
+func NewOTPChallenge(id string, phone string, purpose OTPPurpose, code string, sentAt time.Time) (OTPChallenge, error) {
+	if !phonePattern.MatchString(phone) {
+		return nil, ErrInvalidPhone
+	}
+	if purpose != RegistrationOTP && purpose != AgreementAcceptanceOTP && purpose != PhoneChangeOTP {
+		return nil, ErrInvalidOTPPurpose
+	}
+	return &otpChallenge{
+		id:       id,
+		phone:    normalizePhone(phone),
+		purpose:  purpose,
+		codeHash: sha256.Sum256([]byte(code)),
+		sentAt:   sentAt,
+	}, nil
+}
+
+// normalizePhone replaces 0 or 62 prefix of a valid phone number with +62
+func normalizePhone(phone string) string {
+	switch {
+	case strings.HasPrefix(phone, "+62"):
+		return phone
+	case strings.HasPrefix(phone, "62"):
+		return "+" + phone
+	case strings.HasPrefix(phone, "0"):
+		return "+62" + phone[1:]
+	}
+	return phone
+}
+
+func (challenge *otpChallenge) ID() string {
+	return challenge.id
+}
+
+func (challenge *otpChallenge) Phone() string {
+	return challenge.phone
+}
+
+func (challenge *otpChallenge) Purpose() OTPPurpose {
+	return challenge.purpose
+}
+
+func (challenge *otpChallenge) SentAt() time.Time {
+	return challenge.sentAt
+}
+
+func (challenge *otpChallenge) ExpiresAt() time.Time {
+	return challenge.sentAt.Add(OTPValidity)
+}
+
+func (challenge *otpChallenge) Verify(code string, verifiedAt time.Time) error {
+	if !challenge.verifiedAt.IsZero() && !challenge.used {
+		return nil
+	}
+	if challenge.used || !verifiedAt.Before(challenge.ExpiresAt()) {
+		return ErrOTPExpired
+	}
+	if challenge.attempts >= OTPMaxAttempts {
+		return ErrOTPAttemptsExceeded
+	}
+	challenge.attempts++
+	codeHash := sha256.Sum256([]byte(code))
+	if subtle.ConstantTimeCompare(codeHash[:], challenge.codeHash[:]) != 1 {
+		return OTPInvalidStruct{ErrOTPInvalid, OTPMaxAttempts - challenge.attempts}
+	}
+	challenge.verifiedAt = verifiedAt
+	return nil
+}
+
+func (challenge *otpChallenge) IsVerifiedFor(phone string, purpose OTPPurpose, at time.Time) bool {
+	return !challenge.verifiedAt.IsZero() && !challenge.used && challenge.phone == normalizePhone(phone) &&
+		challenge.purpose == purpose && at.Before(challenge.verifiedAt.Add(verifiedOTPValidity))
+}
+
+func (challenge *otpChallenge) Use() {
+	challenge.used = true
+}
+
+func (challenge *otpChallenge) Release() {
+	challenge.used = false
+}
+
+func (challenge *otpChallenge) IsObsolete(at time.Time) bool {
+	if challenge.used {
+		return true
+	}
+	if challenge.verifiedAt.IsZero() {
+		return !at.Before(challenge.ExpiresAt())
+	}
+	return !at.Before(challenge.verifiedAt.Add(verifiedOTPValidity))
+}
+
+// ErrInvalidOTPPurpose is returned when a code is requested for an action which does not need it
+var ErrInvalidOTPPurpose = errors.New("invalid_otp_purpose")
+
+// ErrOTPExpired is returned when the code is verified too late or after the challenge was used
+var ErrOTPExpired = errors.New("otp_expired")
+
+// ErrOTPAttemptsExceeded is returned when all OTPMaxAttempts were used, the client has to request a new code
+var ErrOTPAttemptsExceeded = errors.New("otp_attempts_exceeded")
+
+// ErrOTPInvalid is returned when the code does not match the code which was sent
+var ErrOTPInvalid = errors.New("otp_invalid")
+
+// OTPInvalidStruct is an error struct wrapping ErrOTPInvalid with the number of codes which can still be tried
+type OTPInvalidStruct struct {
+	error
+	AttemptsLeft uint
+}
@@ -0,0 +1,215 @@
+package domain
+
+import "errors"
+
+// Product defines the limits and pricing of a loan which can be offered to a Client
+type Product interface {
+	Code() string
+	Name() string
+	// Currency of the loan, amount limits and pricing
+	Currency() Currency
+	MinAmount() Money
+	MaxAmount() Money
+	MinTerm() Term
+	MaxTerm() Term
+	// InstalmentPeriod is a number of days between two instalments. Zero means the loan is repaid at once at the end of term.
+	InstalmentPeriod() Term
+	// FeeBasisPoints is a one-off fee charged on the loan amount (100 basis points = 1%)
+	FeeBasisPoints() uint
+	// DailyInterestBasisPoints is an interest charged on the loan amount for every day of the term (100 basis points = 1%)
+	DailyInterestBasisPoints() uint
+	// Validate returns ErrCurrencyMismatch when amount is not in the currency of the product
+	Validate(amount Money, term Term) error
+	// Fee and Interest are rounded half to even
+	Fee(amount Money) (Money, error)
+	Interest(amount Money, term Term) (Money, error)
+}
+
+const basisPointsPerUnit = 10000
+
+type product struct {
+	code                     string
+	name                     string
+	currency                 Currency
+	minAmount                Money
+	maxAmount                Money
+	minTerm                  Term
+	maxTerm                  Term
+	instalmentPeriod         Term
+	feeBasisPoints           uint
+	dailyInterestBasisPoints uint
+}
+
+// PaydayLoan is a short-term loan repaid at once at the end of term
+var PaydayLoan Product = &product{
+	code:                     "payday",
+	name:                     "Payday loan",
+	currency:                 IDR,
+	minAmount:                Rupiah(500000),
+	maxAmount:                maximumAmountForFirstLoan[IDR],
+	minTerm:                  7,
+	maxTerm:                  30,
+	dailyInterestBasisPoints: 80,
+}
+
+// PaydayLoanSGD is PaydayLoan for Singapore priced within the moneylenders interest cap
+var PaydayLoanSGD Product = &product{
+	code:                     "payday_sgd",
+	name:                     "Payday loan (SGD)",
+	currency:                 SGD,
+	minAmount:                mustParseMoney("50", SGD),
+	maxAmount:                maximumAmountForFirstLoan[SGD],
+	minTerm:                  7,
+	maxTerm:                  30,
+	dailyInterestBasisPoints: 13,
+}
+
+// PaydayLoanMYR is PaydayLoan for Malaysia priced within the moneylenders interest cap
+var PaydayLoanMYR Product = &product{
+	code:                     "payday_myr",
+	name:                     "Payday loan (MYR)",
+	currency:                 MYR,
+	minAmount:                mustParseMoney("150", MYR),
+	maxAmount:                maximumAmountForFirstLoan[MYR],
+	minTerm:                  7,
+	maxTerm:                  30,
+	dailyInterestBasisPoints: 5,
+}
+
+// InstalmentLoan is a consumer loan repaid in monthly instalments
+var InstalmentLoan Product = &product{
+	code:                     "instalment",
+	name:                     "Instalment loan",
+	currency:                 IDR,
+	minAmount:                Rupiah(1000000),
+	maxAmount:                Rupiah(100000000),
+	minTerm:                  90,
+	maxTerm:                  360,
+	instalmentPeriod:         30,
+	feeBasisPoints:           300,
+	dailyInterestBasisPoints: 10,
+}
+
+// MicroBusinessLoan is a working capital loan for small merchants repaid in weekly instalments
+var MicroBusinessLoan Product = &product{
+	code:                     "micro_business",
+	name:                     "Micro-business loan",
+	currency:                 IDR,
+	minAmount:                Rupiah(5000000),
+	maxAmount:                Rupiah(250000000),
+	minTerm:                  28,
+	maxTerm:                  364,
+	instalmentPeriod:         7,
+	feeBasisPoints:           200,
+	dailyInterestBasisPoints: 5,
+}
+
+// Products returns the catalog of all products offered to clients
+func Products() []Product {
+	return []Product{PaydayLoan, InstalmentLoan, MicroBusinessLoan, PaydayLoanSGD, PaydayLoanMYR}
+}
+
+// ProductByCode finds a product in the catalog
+func ProductByCode(code string) (product Product, found bool) {
+	for _, product := range Products() {
+		if product.Code() == code {
+			return product, true
+		}
+	}
+	return nil, false
+}
+
+func (product *product) Code() string {
+	return product.code
+}
+
+func (product *product) Name() string {
+	return product.name
+}
+
+func (product *product) Currency() Currency {
+	return product.currency
+}
+
+func (product *product) MinAmount() Money {
+	return product.minAmount
+}
+
+func (product *product) MaxAmount() Money {
+	return product.maxAmount
+}
+
+func (product *product) MinTerm() Term {
+	return product.minTerm
+}
+
+func (product *product) MaxTerm() Term {
+	return product.maxTerm
+}
+
+func (product *product) InstalmentPeriod() Term {
+	return product.instalmentPeriod
+}
+
+func (product *product) FeeBasisPoints() uint {
+	return product.feeBasisPoints
+}
+
+func (product *product) DailyInterestBasisPoints() uint {
+	return product.dailyInterestBasisPoints
+}
+
+func (product *product) Validate(amount Money, term Term) error {
+	if amount.Currency() != product.currency {
+		return ErrCurrencyMismatch
+	}
+	if amount.Cmp(product.maxAmount) > 0 {
+		return AmountTooHighStruct{ErrAmountTooHigh, product.maxAmount}
+	}
+	if amount.Cmp(product.minAmount) < 0 {
+		return AmountTooLowStruct{ErrAmountTooLow, product.minAmount}
+	}
+	if term < product.minTerm || term > product.maxTerm {
+		return TermOutOfRangeStruct{ErrTermOutOfRange, product.minTerm, product.maxTerm}
+	}
+	if product.instalmentPeriod != 0 && term%product.instalmentPeriod != 0 {
+		return TermNotAllowedStruct{ErrTermNotAllowed, product.instalmentPeriod}
+	}
+	return nil
+}
+
+func (product *product) Fee(amount Money) (Money, error) {
+	return amount.MulDiv(uint64(product.feeBasisPoints), basisPointsPerUnit)
+}
+
+func (product *product) Interest(amount Money, term Term) (Money, error) {
+	return amount.MulDiv(uint64(product.dailyInterestBasisPoints)*uint64(term), basisPointsPerUnit)
+}
+
+// ErrAmountTooLow is returned when Client applied for a loan with amount lower than product minimum
+var ErrAmountTooLow = errors.New("amount_too_low")
+
+// AmountTooLowStruct is an error struct wrapping ErrAmountTooLow with additional MinAmount field indicating the minimum amount of a loan
+type AmountTooLowStruct struct {
+	error
+	MinAmount Money
+}
+
+// ErrTermOutOfRange is returned when Client applied for a loan with term outside of product limits
+var ErrTermOutOfRange = errors.New("term_out_of_range")
+
+// TermOutOfRangeStruct is an error struct wrapping ErrTermOutOfRange with the allowed range of terms
+type TermOutOfRangeStruct struct {
+	error
+	MinTerm Term
+	MaxTerm Term
+}
+
+// ErrTermNotAllowed is returned when term of instalment loan is not a multiple of instalment period
+var ErrTermNotAllowed = errors.New("term_not_allowed")
+
+// TermNotAllowedStruct is an error struct wrapping ErrTermNotAllowed with instalment period the term should be a multiple of
+type TermNotAllowedStruct struct {
+	error
+	InstalmentPeriod Term
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"regexp"
+	"time"
+)
+
+// Profile is personal and contact information of a Client which can be changed after registration. KTP number
+// is not part of the profile, because it identifies the client and must never change.
+type Profile struct {
+	Gender      string
+	BirthDate   string
+	Name        string
+	Phone       string
+	Email       string
+	Address     string
+	BankAccount BankAccount
+}
+
+// BankAccount is where the loan is disbursed to. Zero value means the client has not provided any account yet.
+type BankAccount struct {
+	BankCode   string
+	Number     string
+	HolderName string
+}
+
+// ProfileChange is an audit entry recorded for every changed field of a Profile
+type ProfileChange struct {
+	Field     string
+	OldValue  string
+	NewValue  string
+	ChangedAt time.Time
+}
+
+const maxAddressLength = 200
+
+var (
+	phonePattern             = regexp.MustCompile(`^(\+62|62|0)8[0-9]{7,11}$`)
+	bankCodePattern          = regexp.MustCompile(`^[0-9]{3}$`)
+	bankAccountNumberPattern = regexp.MustCompile(`^[0-9]{6,20}$`)
+)
+
+// Validate returns an error for the first invalid field. Empty contact fields are valid.
+func (profile Profile) Validate() error {
+	if profile.Phone != "" && !phonePattern.MatchString(profile.Phone) {
+		return ErrInvalidPhone
+	}
+	if profile.Email != "" {
+		address, err := mail.ParseAddress(profile.Email)
+		if err != nil || address.Address != profile.Email {
+			return ErrInvalidEmail
+		}
+	}
+	if len(profile.Address) > maxAddressLength {
+		return ErrInvalidAddress
+	}
+	account := profile.BankAccount
+	if account == (BankAccount{}) {
+		return nil
+	}
+	if !bankCodePattern.MatchString(account.BankCode) || !bankAccountNumberPattern.MatchString(account.Number) || account.HolderName == "" {
+		return ErrInvalidBankAccount
+	}
+	return nil
+}
+
+// changes lists the fields which differ between two profiles
+func (profile Profile) changes(updated Profile, changedAt time.Time) []ProfileChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"gender", profile.Gender, updated.Gender},
+		{"birthDate", profile.BirthDate, updated.BirthDate},
+		{"name", profile.Name, updated.Name},
+		{"phone", profile.Phone, updated.Phone},
+		{"email", profile.Email, updated.Email},
+		{"address", profile.Address, updated.Address},
+		{"bankAccount.bankCode", profile.BankAccount.BankCode, updated.BankAccount.BankCode},
+		{"bankAccount.number", profile.BankAccount.Number, updated.BankAccount.Number},
+		{"bankAccount.holderName", profile.BankAccount.HolderName, updated.BankAccount.HolderName},
+	}
+	var changes []ProfileChange
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, ProfileChange{Field: field.name, OldValue: field.old, NewValue: field.new, ChangedAt: changedAt})
+		}
+	}
+	return changes
+}
+
+// pseudonymized replaces every non-empty field with a pseudonym keyed by the salt
+func (profile Profile) pseudonymized(salt []byte) Profile {
+	return Profile{
+		Gender:    pseudonym(salt, "gender", profile.Gender),
+		BirthDate: pseudonym(salt, "birthDate", profile.BirthDate),
+		Name:      pseudonym(salt, "name", profile.Name),
+		Phone:     pseudonym(salt, "phone", profile.Phone),
+		Email:     pseudonym(salt, "email", profile.Email),
+		Address:   pseudonym(salt, "address", profile.Address),
+		BankAccount: BankAccount{
+			BankCode:   pseudonym(salt, "bankAccount.bankCode", profile.BankAccount.BankCode),
+			Number:     pseudonym(salt, "bankAccount.number", profile.BankAccount.Number),
+			HolderName: pseudonym(salt, "bankAccount.holderName", profile.BankAccount.HolderName),
+		},
+	}
+}
+
+// newErasureSalt returns random salt of one erasure. The salt is never stored, so pseudonyms can't be recomputed from
+// guessed values, e.g. by trying every birth date.
+func newErasureSalt() ([]byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// pseudonym is not reversible, but the same value gets the same pseudonym within one erasure, so erased profile
+// changes still show which values were equal
+func pseudonym(salt []byte, field, value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(field + "/" + value))
+	return "erased:" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// ErrInvalidPhone is returned when phone is not an Indonesian mobile number
+var ErrInvalidPhone = errors.New("invalid_phone")
+
+// ErrInvalidEmail is returned when email is not a valid address
+var ErrInvalidEmail = errors.New("invalid_email")
+
+// ErrInvalidAddress is returned when address is too long
+var ErrInvalidAddress = errors.New("invalid_address")
+
+// ErrInvalidBankAccount is returned when bank account is incomplete or bank code or account number is malformed
+var ErrInvalidBankAccount = errors.New("invalid_bank_account")
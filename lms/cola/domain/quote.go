@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// Quote is a price of a loan calculated using product pricing before the loan is taken
+type Quote struct {
+	Product      Product
+	Amount       Money
+	Term         Term
+	Fee          Money
+	Interest     Money
+	TotalPayable Money
+	Instalments  []Instalment
+}
+
+// Instalment is a part of a loan which should be repaid until due date
+type Instalment struct {
+	DueDate time.Time
+	Amount  Money
+}
+
+// NewQuote validates amount and term against product limits and calculates the price of a loan starting at a given date
+func NewQuote(product Product, amount Money, term Term, start time.Time) (Quote, error) {
+	if err := product.Validate(amount, term); err != nil {
+		return Quote{}, err
+	}
+	fee, err := product.Fee(amount)
+	if err != nil {
+		return Quote{}, err
+	}
+	interest, err := product.Interest(amount, term)
+	if err != nil {
+		return Quote{}, err
+	}
+	total, err := amount.Add(fee)
+	if err == nil {
+		total, err = total.Add(interest)
+	}
+	if err != nil {
+		return Quote{}, err
+	}
+	return Quote{
+		Product:      product,
+		Amount:       amount,
+		Term:         term,
+		Fee:          fee,
+		Interest:     interest,
+		TotalPayable: total,
+		Instalments:  schedule(total, term, product.InstalmentPeriod(), start),
+	}, nil
+}
+
+// schedule splits total into equal instalments, the last one takes the rounding remainder
+func schedule(total Money, term Term, period Term, start time.Time) []Instalment {
+	if period == 0 {
+		period = term
+	}
+	amounts := total.Split(int(term / period))
+	instalments := make([]Instalment, len(amounts))
+	for i := range instalments {
+		instalments[i] = Instalment{
+			DueDate: start.AddDate(0, 0, int(period)*(i+1)),
+			Amount:  amounts[i],
+		}
+	}
+	return instalments
+}
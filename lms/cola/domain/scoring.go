@@ -0,0 +1,341 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attribute is a characteristic of a Client used by scorecards
+type Attribute string
+
+const (
+	// Age in whole years computed from birth date
+	Age Attribute = "age"
+	// KTPRegion is the province code made of the first two digits of KTP number
+	KTPRegion Attribute = "ktp_region"
+	// RepaidLoans is a number of loans the client repaid in full
+	RepaidLoans Attribute = "repaid_loans"
+	// WorstDaysPastDue is the most days any repaid loan was repaid after its due date
+	WorstDaysPastDue Attribute = "worst_days_past_due"
+	// RecentApplications is a number of applications submitted in the last 30 days, whether they were approved or not
+	RecentApplications Attribute = "recent_applications"
+)
+
+var attributes = []Attribute{Age, KTPRegion, RepaidLoans, WorstDaysPastDue, RecentApplications}
+
+// Attributes are values of attributes of a Client. Attribute which can't be determined, such as age of a client whose
+// birth date is not a date, is missing.
+type Attributes map[Attribute]int
+
+// recentApplicationsDays is the window of RecentApplications
+const recentApplicationsDays = 30
+
+func (client *paydayLoanClient) ScoringAttributes(asOf time.Time, applications []LoanApplication) Attributes {
+	result := Attributes{RepaidLoans: 0, WorstDaysPastDue: 0, RecentApplications: 0}
+	if birthDate, err := ParseBirthDate(client.profile.BirthDate); err == nil {
+		age := asOf.Year() - birthDate.Year()
+		if asOf.Month() < birthDate.Month() || (asOf.Month() == birthDate.Month() && asOf.Day() < birthDate.Day()) {
+			age--
+		}
+		result[Age] = age
+	}
+	if len(client.ktpNumber) >= 2 {
+		if region, err := strconv.Atoi(client.ktpNumber[:2]); err == nil {
+			result[KTPRegion] = region
+		}
+	}
+	for _, application := range applications {
+		if asOf.Sub(application.SubmittedAt()) < recentApplicationsDays*24*time.Hour {
+			result[RecentApplications]++
+		}
+	}
+	for _, loan := range client.Loans() {
+		if loan.DisbursementStatus() != Disbursed || loan.IsWrittenOff() || !loan.Remaining().IsZero() {
+			continue
+		}
+		result[RepaidLoans]++
+		repayments := loan.Repayments()
+		if len(repayments) == 0 {
+			continue
+		}
+		late := int(repayments[len(repayments)-1].PaidAt.Sub(loan.DueDate()) / (24 * time.Hour))
+		if late > result[WorstDaysPastDue] {
+			result[WorstDaysPastDue] = late
+		}
+	}
+	return result
+}
+
+// birthDateLayouts are formats of birth date accepted by ParseBirthDate
+var birthDateLayouts = []string{"2 January 2006", "2006-01-02", "02-01-2006"}
+
+// ParseBirthDate reads birth date of a profile, which is free text entered during registration
+func ParseBirthDate(birthDate string) (time.Time, error) {
+	for _, layout := range birthDateLayouts {
+		if date, err := time.Parse(layout, strings.TrimSpace(birthDate)); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("birth date %q is not a date", birthDate)
+}
+
+// Bin awards points to values of an attribute. It matches either values from Min to Max inclusive (nil bound is open),
+// one of Values or missing attribute.
+type Bin struct {
+	Min     *int
+	Max     *int
+	Values  []int
+	Missing bool
+	Points  int
+	// ReasonCode explains to the client why points were lost in this bin
+	ReasonCode string
+}
+
+func (bin Bin) matches(value int, found bool) bool {
+	if bin.Missing || !found {
+		return bin.Missing && !found
+	}
+	if len(bin.Values) > 0 {
+		for _, v := range bin.Values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+	return (bin.Min == nil || value >= *bin.Min) && (bin.Max == nil || value <= *bin.Max)
+}
+
+// Characteristic is an attribute with its bins, the first matching bin awards points
+type Characteristic struct {
+	Attribute Attribute
+	Bins      []Bin
+}
+
+// ScoreBand is reached by a score of at least MinScore. It sets maximum amount and price of a loan relative to the
+// product.
+type ScoreBand struct {
+	Name     string
+	MinScore int
+	// MaxAmountBasisPoints is the share of product maximum amount the client can borrow
+	MaxAmountBasisPoints uint
+	// PriceBasisPoints multiplies fee and interest of the product, 10000 keeps product price
+	PriceBasisPoints uint
+}
+
+// Scorecard adds points of all characteristics to BaseScore. Score below the lowest band is declined.
+type Scorecard struct {
+	Name string
+	// Products the scorecard is used for, empty means all products
+	Products        []string
+	BaseScore       int
+	Characteristics []Characteristic
+	Bands           []ScoreBand
+}
+
+// NewScorecard orders bands from the highest score and validates that every bin matches exactly one way and every
+// characteristic is a known attribute
+func NewScorecard(scorecard Scorecard) (Scorecard, error) {
+	if scorecard.Name == "" || len(scorecard.Bands) == 0 {
+		return Scorecard{}, ErrInvalidScorecard
+	}
+	for _, characteristic := range scorecard.Characteristics {
+		if !knownAttribute(characteristic.Attribute) || len(characteristic.Bins) == 0 {
+			return Scorecard{}, ErrInvalidScorecard
+		}
+		for _, bin := range characteristic.Bins {
+			ways := 0
+			if bin.Min != nil || bin.Max != nil {
+				ways++
+			}
+			if len(bin.Values) > 0 {
+				ways++
+			}
+			if bin.Missing {
+				ways++
+			}
+			if ways != 1 {
+				return Scorecard{}, ErrInvalidScorecard
+			}
+		}
+	}
+	bands := make([]ScoreBand, len(scorecard.Bands))
+	copy(bands, scorecard.Bands)
+	sort.SliceStable(bands, func(i, j int) bool {
+		return bands[i].MinScore > bands[j].MinScore
+	})
+	names := make(map[string]bool)
+	for _, band := range bands {
+		if band.Name == "" || names[band.Name] || band.MaxAmountBasisPoints == 0 || band.MaxAmountBasisPoints > basisPointsPerUnit || band.PriceBasisPoints == 0 {
+			return Scorecard{}, ErrInvalidScorecard
+		}
+		names[band.Name] = true
+	}
+	scorecard.Bands = bands
+	return scorecard, nil
+}
+
+func knownAttribute(attribute Attribute) bool {
+	for _, known := range attributes {
+		if known == attribute {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesTo tells whether the scorecard is used for the product
+func (scorecard Scorecard) AppliesTo(product Product) bool {
+	if len(scorecard.Products) == 0 {
+		return true
+	}
+	for _, code := range scorecard.Products {
+		if code == product.Code() {
+			return true
+		}
+	}
+	return false
+}
+
+// Score is the outcome of a Scorecard
+type Score struct {
+	Scorecard string
+	Points    int
+	// Band is empty when the score is below all bands
+	Band string
+	// Reasons are reason codes of characteristics which lost the most points, starting with the biggest loss
+	Reasons  []string
+	ScoredAt time.Time
+}
+
+// maxReasons is a number of reason codes kept in Score
+const maxReasons = 4
+
+// Score awards points of the first matching bin of every characteristic, characteristic without matching bin awards
+// no points
+func (scorecard Scorecard) Score(attributes Attributes, scoredAt time.Time) Score {
+	score := Score{Scorecard: scorecard.Name, Points: scorecard.BaseScore, ScoredAt: scoredAt}
+	type loss struct {
+		points     int
+		reasonCode string
+	}
+	var losses []loss
+	for _, characteristic := range scorecard.Characteristics {
+		value, found := attributes[characteristic.Attribute]
+		best := characteristic.Bins[0].Points
+		for _, bin := range characteristic.Bins {
+			if bin.Points > best {
+				best = bin.Points
+			}
+		}
+		for _, bin := range characteristic.Bins {
+			if !bin.matches(value, found) {
+				continue
+			}
+			score.Points += bin.Points
+			if lost := best - bin.Points; lost > 0 {
+				reasonCode := bin.ReasonCode
+				if reasonCode == "" {
+					reasonCode = string(characteristic.Attribute)
+				}
+				losses = append(losses, loss{lost, reasonCode})
+			}
+			break
+		}
+	}
+	sort.SliceStable(losses, func(i, j int) bool {
+		return losses[i].points > losses[j].points
+	})
+	for i := 0; i < len(losses) && i < maxReasons; i++ {
+		score.Reasons = append(score.Reasons, losses[i].reasonCode)
+	}
+	if band, found := scorecard.Band(score.Points); found {
+		score.Band = band.Name
+	}
+	return score
+}
+
+// Band returns the highest band the score reached
+func (scorecard Scorecard) Band(points int) (ScoreBand, bool) {
+	for _, band := range scorecard.Bands {
+		if points >= band.MinScore {
+			return band, true
+		}
+	}
+	return ScoreBand{}, false
+}
+
+// Apply limits maximum amount and adjusts price of the product by the band of the score. Score below all bands is
+// returned as ScoreTooLowStruct.
+func (scorecard Scorecard) Apply(product Product, score Score) (Product, error) {
+	band, found := scorecard.Band(score.Points)
+	if !found {
+		return nil, ScoreTooLowStruct{ErrScoreTooLow, score}
+	}
+	return bandedProduct{product, band}, nil
+}
+
+// bandedProduct is a Product whose maximum amount and price depend on the score band of the client
+type bandedProduct struct {
+	Product
+	band ScoreBand
+}
+
+func (product bandedProduct) MaxAmount() Money {
+	max, err := product.Product.MaxAmount().MulDiv(uint64(product.band.MaxAmountBasisPoints), basisPointsPerUnit)
+	if err != nil || max.Cmp(product.MinAmount()) < 0 {
+		return product.MinAmount()
+	}
+	return max
+}
+
+func (product bandedProduct) FeeBasisPoints() uint {
+	return product.Product.FeeBasisPoints() * product.band.PriceBasisPoints / basisPointsPerUnit
+}
+
+func (product bandedProduct) DailyInterestBasisPoints() uint {
+	return product.Product.DailyInterestBasisPoints() * product.band.PriceBasisPoints / basisPointsPerUnit
+}
+
+func (product bandedProduct) Validate(amount Money, term Term) error {
+	if amount.Currency() == product.Currency() && amount.Cmp(product.MaxAmount()) > 0 {
+		return AmountTooHighStruct{ErrAmountTooHigh, product.MaxAmount()}
+	}
+	return product.Product.Validate(amount, term)
+}
+
+func (product bandedProduct) Fee(amount Money) (Money, error) {
+	return amount.MulDiv(uint64(product.FeeBasisPoints()), basisPointsPerUnit)
+}
+
+func (product bandedProduct) Interest(amount Money, term Term) (Money, error) {
+	return amount.MulDiv(uint64(product.DailyInterestBasisPoints())*uint64(term), basisPointsPerUnit)
+}
+
+func (client *paydayLoanClient) RecordScore(score Score) error {
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	client.loan.score = score
+	return nil
+}
+
+func (loan *paydayLoan) Score() (Score, bool) {
+	return loan.score, loan.score.Scorecard != ""
+}
+
+// ErrInvalidScorecard is returned when scorecard has no name or bands, unknown attribute, ambiguous bin or invalid band
+var ErrInvalidScorecard = errors.New("invalid_scorecard")
+
+// ErrScoreTooLow is returned when score of the client is below all bands of the scorecard
+var ErrScoreTooLow = errors.New("score_too_low")
+
+// ScoreTooLowStruct is an error struct wrapping ErrScoreTooLow with the score, so the client can be told the reasons
+type ScoreTooLowStruct struct {
+	error
+	Score Score
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// virtualAccountPrefix is the company code assigned to the lender by the bank issuing virtual accounts
+const virtualAccountPrefix = "8808"
+
+// NewVirtualAccount builds a virtual account number from the company prefix, 10 digit sequence and Luhn check digit.
+// Every client must get a different sequence.
+func NewVirtualAccount(sequence uint64) string {
+	number := fmt.Sprintf("%s%010d", virtualAccountPrefix, sequence)
+	return number + string('0'+luhnCheckDigit(number))
+}
+
+// IsValidVirtualAccount checks the prefix, length and check digit of a virtual account number
+func IsValidVirtualAccount(number string) bool {
+	if len(number) != len(virtualAccountPrefix)+11 || number[:len(virtualAccountPrefix)] != virtualAccountPrefix {
+		return false
+	}
+	for _, digit := range number {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+	}
+	last := len(number) - 1
+	return luhnCheckDigit(number[:last]) == number[last]-'0'
+}
+
+func luhnCheckDigit(number string) byte {
+	sum := 0
+	double := true
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return byte((10 - sum%10) % 10)
+}
+
+func (client *paydayLoanClient) VirtualAccount() string {
+	return client.virtualAccount
+}
+
+func (client *paydayLoanClient) AssignVirtualAccount(number string) error {
+	if client.virtualAccount != "" {
+		return ErrVirtualAccountAlreadyAssigned
+	}
+	if !IsValidVirtualAccount(number) {
+		return ErrInvalidVirtualAccount
+	}
+	client.virtualAccount = number
+	return nil
+}
+
+// ErrVirtualAccountAlreadyAssigned is returned when virtual account of a Client would be changed
+var ErrVirtualAccountAlreadyAssigned = errors.New("virtual_account_already_assigned")
+
+// ErrInvalidVirtualAccount is returned when virtual account number has wrong format or check digit
+var ErrInvalidVirtualAccount = errors.New("invalid_virtual_account")
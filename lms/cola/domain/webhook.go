@@ -0,0 +1,311 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebhookEventType is what partners subscribe to
+type WebhookEventType string
+
+const (
+	// ClientRegisteredWebhook is published when a new client is registered
+	ClientRegisteredWebhook WebhookEventType = "client.registered"
+	// LoanStateChangedWebhook is published whenever a loan moves to another state, e.g. from approved to disbursed
+	LoanStateChangedWebhook WebhookEventType = "loan.state_changed"
+)
+
+// WebhookEventTypes are all events which can be subscribed to
+var WebhookEventTypes = []WebhookEventType{ClientRegisteredWebhook, LoanStateChangedWebhook}
+
+const (
+	// WebhookMaxAttempts is how many times a payload is posted before the delivery fails
+	WebhookMaxAttempts = 8
+	// WebhookRetryDelay is the wait after the first failed attempt, it doubles after every other failed attempt
+	WebhookRetryDelay = 30 * time.Second
+	// WebhookMinSecretLength is the shortest secret payloads can be signed with
+	WebhookMinSecretLength = 16
+)
+
+// WebhookSubscription is a URL of a partner which payloads of the subscribed events are posted to
+type WebhookSubscription interface {
+	// ID is unique across all subscriptions, it is made of subscription sequence number
+	ID() string
+	URL() string
+	EventTypes() []WebhookEventType
+	// Secret is shared with the partner, who verifies signatures of payloads with it
+	Secret() string
+	CreatedAt() time.Time
+	Subscribes(eventType WebhookEventType) bool
+}
+
+type webhookSubscription struct {
+	id         string
+	url        string
+	eventTypes []WebhookEventType
+	secret     string
+	createdAt  time.Time
+}
+
+// NewWebhookSubscription validates the URL, event types and secret of a subscription. URL of this machine or of
+// a private network is refused. Every subscription must get a different sequence.
+func NewWebhookSubscription(sequence uint64, rawURL string, eventTypes []WebhookEventType, secret string, createdAt time.Time) (WebhookSubscription, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if isPrivateHost(parsed.Hostname()) {
+		return nil, ErrWebhookHostNotAllowed
+	}
+	if len(eventTypes) == 0 {
+		return nil, ErrInvalidWebhookEventType
+	}
+	for _, eventType := range eventTypes {
+		if !isWebhookEventType(eventType) {
+			return nil, ErrInvalidWebhookEventType
+		}
+	}
+	if len(secret) < WebhookMinSecretLength {
+		return nil, ErrWebhookSecretTooShort
+	}
+	return &webhookSubscription{
+		id:         fmt.Sprintf("WHS%010d", sequence),
+		url:        rawURL,
+		eventTypes: append([]WebhookEventType(nil), eventTypes...),
+		secret:     secret,
+		createdAt:  createdAt,
+	}, nil
+}
+
+// isPrivateHost tells whether the host name or address belongs to this machine or to a private network
+func isPrivateHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && !IsPublicIP(ip)
+}
+
+// IsPublicIP tells whether webhooks can be posted to the address. Loopback, private, link-local, multicast and
+// unspecified addresses are not public.
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+func isWebhookEventType(eventType WebhookEventType) bool {
+	for _, known := range WebhookEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (subscription *webhookSubscription) ID() string {
+	return subscription.id
+}
+
+func (subscription *webhookSubscription) URL() string {
+	return subscription.url
+}
+
+func (subscription *webhookSubscription) EventTypes() []WebhookEventType {
+	return subscription.eventTypes
+}
+
+func (subscription *webhookSubscription) Secret() string {
+	return subscription.secret
+}
+
+func (subscription *webhookSubscription) CreatedAt() time.Time {
+	return subscription.createdAt
+}
+
+func (subscription *webhookSubscription) Subscribes(eventType WebhookEventType) bool {
+	for _, subscribed := range subscription.eventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// SignWebhook returns hex encoded HMAC-SHA256 of the timestamp and the payload joined by a dot. The timestamp is
+// signed, so a captured payload can't be posted again later.
+func SignWebhook(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookAttempt is one post of the payload, it succeeded when the subscriber responded with 2xx status
+type WebhookAttempt struct {
+	At time.Time
+	// StatusCode is zero when the subscriber did not respond
+	StatusCode int
+	Error      string
+}
+
+// Succeeded tells whether the subscriber accepted the payload
+func (attempt WebhookAttempt) Succeeded() bool {
+	return attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode <= 299
+}
+
+// WebhookDelivery is the payload of an event posted to one subscription with the log of its attempts
+type WebhookDelivery interface {
+	// ID is unique across all deliveries, it is made of delivery sequence number
+	ID() string
+	SubscriptionID() string
+	// EventID is the same for deliveries of the event to all subscriptions and for its replays
+	EventID() string
+	EventType() WebhookEventType
+	Payload() []byte
+	CreatedAt() time.Time
+	// ReplayOf is the delivery which was replayed, empty for the original delivery
+	ReplayOf() string
+	Status() DeliveryStatus
+	Attempts() []WebhookAttempt
+	// NextAttemptAt is zero when the delivery is not pending
+	NextAttemptAt() time.Time
+	// IsDue tells whether the pending delivery should be attempted at a given time
+	IsDue(at time.Time) bool
+	// RecordAttempt delivers the payload when the attempt succeeded, otherwise the next attempt is scheduled after
+	// a delay growing exponentially from WebhookRetryDelay or the delivery fails after WebhookMaxAttempts
+	RecordAttempt(attempt WebhookAttempt) error
+	// Abandon fails the pending delivery, e.g. when its subscription was deleted
+	Abandon(reason string, at time.Time) error
+	// Clone returns a copy which shares no mutable state with the delivery, so repositories can hand out and keep
+	// deliveries which are changed by use cases running at the same time
+	Clone() WebhookDelivery
+}
+
+type webhookDelivery struct {
+	id             string
+	subscriptionID string
+	eventID        string
+	eventType      WebhookEventType
+	payload        []byte
+	createdAt      time.Time
+	replayOf       string
+	status         DeliveryStatus
+	attempts       []WebhookAttempt
+	nextAttemptAt  time.Time
+}
+
+// NewWebhookDelivery creates a pending delivery which is due right away. Every delivery must get a different sequence.
+func NewWebhookDelivery(sequence uint64, subscriptionID string, eventID string, eventType WebhookEventType, payload []byte, createdAt time.Time, replayOf string) WebhookDelivery {
+	return &webhookDelivery{
+		id:             fmt.Sprintf("WHD%010d", sequence),
+		subscriptionID: subscriptionID,
+		eventID:        eventID,
+		eventType:      eventType,
+		payload:        payload,
+		createdAt:      createdAt,
+		replayOf:       replayOf,
+		status:         DeliveryPending,
+		nextAttemptAt:  createdAt,
+	}
+}
+
+func (delivery *webhookDelivery) ID() string {
+	return delivery.id
+}
+
+func (delivery *webhookDelivery) SubscriptionID() string {
+	return delivery.subscriptionID
+}
+
+func (delivery *webhookDelivery) EventID() string {
+	return delivery.eventID
+}
+
+func (delivery *webhookDelivery) EventType() WebhookEventType {
+	return delivery.eventType
+}
+
+func (delivery *webhookDelivery) Payload() []byte {
+	return delivery.payload
+}
+
+func (delivery *webhookDelivery) CreatedAt() time.Time {
+	return delivery.createdAt
+}
+
+func (delivery *webhookDelivery) ReplayOf() string {
+	return delivery.replayOf
+}
+
+func (delivery *webhookDelivery) Status() DeliveryStatus {
+	return delivery.status
+}
+
+func (delivery *webhookDelivery) Attempts() []WebhookAttempt {
+	return delivery.attempts
+}
+
+func (delivery *webhookDelivery) NextAttemptAt() time.Time {
+	return delivery.nextAttemptAt
+}
+
+func (delivery *webhookDelivery) IsDue(at time.Time) bool {
+	return delivery.status == DeliveryPending && !at.Before(delivery.nextAttemptAt)
+}
+
+func (delivery *webhookDelivery) RecordAttempt(attempt WebhookAttempt) error {
+	if delivery.status != DeliveryPending {
+		return ErrWebhookDeliveryNotPending
+	}
+	delivery.attempts = append(delivery.attempts, attempt)
+	switch {
+	case attempt.Succeeded():
+		delivery.status = DeliveryDelivered
+		delivery.nextAttemptAt = time.Time{}
+	case len(delivery.attempts) >= WebhookMaxAttempts:
+		delivery.status = DeliveryFailed
+		delivery.nextAttemptAt = time.Time{}
+	default:
+		delivery.nextAttemptAt = attempt.At.Add(WebhookRetryDelay << (len(delivery.attempts) - 1))
+	}
+	return nil
+}
+
+func (delivery *webhookDelivery) Abandon(reason string, at time.Time) error {
+	if delivery.status != DeliveryPending {
+		return ErrWebhookDeliveryNotPending
+	}
+	delivery.attempts = append(delivery.attempts, WebhookAttempt{At: at, Error: reason})
+	delivery.status = DeliveryFailed
+	delivery.nextAttemptAt = time.Time{}
+	return nil
+}
+
+func (delivery *webhookDelivery) Clone() WebhookDelivery {
+	clone := *delivery
+	clone.attempts = append([]WebhookAttempt(nil), delivery.attempts...)
+	return &clone
+}
+
+// ErrInvalidWebhookURL is returned when the subscription URL is not an absolute http or https URL
+var ErrInvalidWebhookURL = errors.New("invalid_webhook_url")
+
+// ErrWebhookHostNotAllowed is returned when the subscription URL points to this machine or to a private network
+var ErrWebhookHostNotAllowed = errors.New("webhook_host_not_allowed")
+
+// ErrInvalidWebhookEventType is returned when the subscription has no event types or an unknown one
+var ErrInvalidWebhookEventType = errors.New("invalid_webhook_event_type")
+
+// ErrWebhookSecretTooShort is returned when the secret is shorter than WebhookMinSecretLength
+var ErrWebhookSecretTooShort = errors.New("webhook_secret_too_short")
+
+// ErrWebhookDeliveryNotPending is returned when an attempt of a delivery which was delivered or failed is recorded
+var ErrWebhookDeliveryNotPending = errors.New("webhook_delivery_not_pending")
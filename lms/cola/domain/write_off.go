@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// writeOff takes a defaulted loan off the active books, the client still owes the remaining amount
+type writeOff struct {
+	at     time.Time
+	by     string
+	amount Money
+}
+
+// WriteOff can be done only by an officer, who is recorded on the loan
+func (client *paydayLoanClient) WriteOff(officer string, writtenOffAt time.Time) error {
+	defer client.trackLoanStates()()
+	if client.loan == nil {
+		return ErrClientHasNoActiveLoan
+	}
+	if !client.loan.IsDefaulted() {
+		return ErrLoanNotDefaulted
+	}
+	client.loan.writeOff = writeOff{at: writtenOffAt, by: officer, amount: client.loan.remaining}
+	client.post(WrittenOff, client.loan.id, writtenOffAt, debit(WriteOffs, client.loan.remaining), credit(LoansReceivable, client.loan.remaining))
+	client.closedLoans = append(client.closedLoans, client.loan)
+	client.loan = nil
+	return nil
+}
+
+func (client *paydayLoanClient) WrittenOffLoan() Loan {
+	if loan := client.writtenOffLoan(); loan != nil {
+		return loan
+	}
+	return nil
+}
+
+// writtenOffLoan returns the written-off loan which was not fully recovered yet or nil
+func (client *paydayLoanClient) writtenOffLoan() *paydayLoan {
+	for _, loan := range client.closedLoans {
+		if loan.IsWrittenOff() && loan.remaining.IsPositive() {
+			return loan
+		}
+	}
+	return nil
+}
+
+func (client *paydayLoanClient) hasWrittenOffLoan() bool {
+	for _, loan := range client.closedLoans {
+		if loan.IsWrittenOff() {
+			return true
+		}
+	}
+	return false
+}
+
+// loanToRepay is the loan money received from the client is applied to, repayments of a written-off loan are
+// recoveries
+func (client *paydayLoanClient) loanToRepay() *paydayLoan {
+	if client.loan != nil {
+		return client.loan
+	}
+	return client.writtenOffLoan()
+}
+
+func (loan *paydayLoan) IsWrittenOff() bool {
+	return !loan.writeOff.at.IsZero()
+}
+
+func (loan *paydayLoan) WrittenOffAt() time.Time {
+	return loan.writeOff.at
+}
+
+func (loan *paydayLoan) WrittenOffBy() string {
+	return loan.writeOff.by
+}
+
+func (loan *paydayLoan) WrittenOffAmount() Money {
+	if !loan.IsWrittenOff() {
+		return NewMoney(0, loan.remaining.Currency())
+	}
+	return loan.writeOff.amount
+}
+
+func (loan *paydayLoan) Recovered() Money {
+	recovered := NewMoney(0, loan.remaining.Currency())
+	for _, repayment := range loan.repayments {
+		if repayment.Recovery {
+			recovered = mustAdd(recovered, repayment.Amount)
+		}
+	}
+	return recovered
+}
+
+// ErrLoanNotDefaulted is returned when loan which was not handed over to collections is written off
+var ErrLoanNotDefaulted = errors.New("loan_not_defaulted")
+
+// ErrClientHasWrittenOffLoan is returned when Client whose loan was written off applies for a new loan or is erased
+// before the loan is recovered
+var ErrClientHasWrittenOffLoan = errors.New("client_has_written_off_loan")
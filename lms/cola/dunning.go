@@ -0,0 +1,124 @@
+package cola
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// Notifier delivers dunning notices to clients and hands defaulted loans over to collections
+type Notifier interface {
+	Notify(notice Notice) error
+}
+
+// Notice is sent to Notifier when a loan reaches a dunning stage. It carries no personal data, which would be left
+// behind by the Notifier when the client is erased; contact details are looked up by KTPNumber.
+type Notice struct {
+	Stage     string
+	Action    domain.DunningAction
+	KTPNumber string
+	LoanID    string
+	// DueDate of the oldest unpaid instalment
+	DueDate time.Time
+	// AmountDue is the amount of instalments due until DueDate or until the notice was sent which were not repaid yet
+	AmountDue   domain.Money
+	Remaining   domain.Money
+	DaysPastDue uint
+}
+
+// WithDunning configures dunning stages and where the notices are sent. Without it domain.DefaultDunningSchedule is
+// used and notices are discarded.
+func WithDunning(schedule domain.DunningSchedule, notifier Notifier) Option {
+	return func(cola *cola) {
+		cola.dunningSchedule = schedule
+		cola.notifier = notifier
+	}
+}
+
+// discardNotifier is used when no Notifier is configured
+type discardNotifier struct{}
+
+func (discardNotifier) Notify(notice Notice) error {
+	return nil
+}
+
+// RunDunning sends the notice before the stage of a loan is saved, so a notice is never lost when Notifier fails,
+// the stage is reached again by the next run. A notice can be sent twice when saving the client fails.
+func (cola *cola) RunDunning(asOf time.Time) (lms.DunningRun, error) {
+	run := lms.DunningRun{AsOf: asOf}
+	err := cola.forEachClientWithActiveLoan(func(client domain.Client) error {
+		stage, reached := client.Dun(cola.dunningSchedule, asOf)
+		if !reached {
+			return nil
+		}
+		notice := newNotice(client, stage, asOf)
+		if err := cola.notifier.Notify(notice); err != nil {
+			return fmt.Errorf("notifying client %s of %s: %v", client.KTPNumber(), stage.Name, err)
+		}
+		cola.notifyDunning(client, stage, notice)
+		if err := cola.ClientRepo.Save(client); err != nil {
+			return fmt.Errorf("client %s: %w", client.KTPNumber(), err)
+		}
+		if stage.Action == domain.HandOverToCollections {
+			run.Defaulted++
+		}
+		run.Notices++
+		return nil
+	})
+	if err != nil {
+		return run, fmt.Errorf("dunning as of %s: %v", asOf.Format("2006-01-02"), err)
+	}
+	return run, nil
+}
+
+func newNotice(client domain.Client, stage domain.DunningStage, asOf time.Time) Notice {
+	loan := client.ActiveLoan()
+	instalment, _ := loan.OldestUnpaidInstalment()
+	dueUntil := instalment.DueDate.AddDate(0, 0, 1)
+	if asOf.After(dueUntil) {
+		dueUntil = asOf
+	}
+	return Notice{
+		Stage:       stage.Name,
+		Action:      stage.Action,
+		KTPNumber:   client.KTPNumber(),
+		LoanID:      loan.ID(),
+		DueDate:     instalment.DueDate,
+		AmountDue:   loan.AmountPastDue(dueUntil),
+		Remaining:   loan.Remaining(),
+		DaysPastDue: loan.DaysPastDue(asOf),
+	}
+}
+
+func (cola *cola) LoansByDunningStage() ([]lms.DunningStageLoans, error) {
+	stages := make([]lms.DunningStageLoans, len(cola.dunningSchedule))
+	for i, stage := range cola.dunningSchedule {
+		stages[i] = lms.DunningStageLoans{Stage: stage.Name, Action: string(stage.Action), Loans: []lms.DunningLoan{}}
+	}
+	asOf := cola.now()
+	err := cola.forEachClientWithActiveLoan(func(client domain.Client) error {
+		loan := client.ActiveLoan()
+		instalment, _ := loan.OldestUnpaidInstalment()
+		for i := range stages {
+			if stages[i].Stage == loan.DunningStage() {
+				stages[i].Loans = append(stages[i].Loans, lms.DunningLoan{
+					KTPNumber:     client.KTPNumber(),
+					Name:          client.Name(),
+					LoanID:        loan.ID(),
+					DueDate:       instalment.DueDate,
+					DaysPastDue:   loan.DaysPastDue(asOf),
+					AmountPastDue: loan.AmountPastDue(asOf),
+					Remaining:     loan.Remaining(),
+					Defaulted:     loan.IsDefaulted(),
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing loans by dunning stage: %v", err)
+	}
+	return stages, nil
+}
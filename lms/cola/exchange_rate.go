@@ -0,0 +1,46 @@
+package cola
+
+import (
+	"errors"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// ReportingCurrency is the currency in which loans in all currencies are reported
+const ReportingCurrency = domain.IDR
+
+// ExchangeRates provides exchange rates for reporting loans in ReportingCurrency. Rates are snapshotted on a loan at
+// origination, so the provider is not needed for reading existing loans.
+type ExchangeRates interface {
+	// Rate returns the latest rate valid as of the date or ErrExchangeRateNotFound
+	Rate(from, to domain.Currency, asOf time.Time) (domain.ExchangeRate, error)
+}
+
+// ErrExchangeRateNotFound should be returned by ExchangeRates when there is no rate for the currency pair
+var ErrExchangeRateNotFound = errors.New("exchange_rate_not_found")
+
+// WithExchangeRates configures provider of exchange rates. Without it only loans in ReportingCurrency can be taken.
+func WithExchangeRates(rates ExchangeRates) Option {
+	return func(cola *cola) {
+		cola.ExchangeRates = rates
+	}
+}
+
+// sameCurrencyRates is used when no ExchangeRates are configured
+type sameCurrencyRates struct{}
+
+func (sameCurrencyRates) Rate(from, to domain.Currency, asOf time.Time) (domain.ExchangeRate, error) {
+	if from != to {
+		return domain.ExchangeRate{}, ErrExchangeRateNotFound
+	}
+	return domain.IdentityRate(from, asOf), nil
+}
+
+// reportingRate returns identity rate for loans in ReportingCurrency without asking the provider
+func (cola *cola) reportingRate(currency domain.Currency, asOf time.Time) (domain.ExchangeRate, error) {
+	if currency == ReportingCurrency {
+		return domain.IdentityRate(currency, asOf), nil
+	}
+	return cola.ExchangeRates.Rate(currency, ReportingCurrency, asOf)
+}
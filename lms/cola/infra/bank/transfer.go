@@ -0,0 +1,42 @@
+// Package bank provides cola.Disburser implementation which pretends to transfer money to clients' bank accounts
+package bank
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+)
+
+// closedAccountSuffix marks accounts which are rejected by the fake bank, so failed disbursements can be tried out locally
+const closedAccountSuffix = "0000"
+
+type fakeBankTransfer struct {
+	mutex           sync.Mutex
+	referencesByKey map[string]string
+}
+
+// NewFakeBankTransfer returns Disburser which only logs the transfers. Transfers to account numbers ending with 0000
+// are rejected.
+func NewFakeBankTransfer() cola.Disburser {
+	return &fakeBankTransfer{referencesByKey: make(map[string]string)}
+}
+
+func (bank *fakeBankTransfer) Disburse(transfer cola.Transfer) (string, error) {
+	bank.mutex.Lock()
+	defer bank.mutex.Unlock()
+	if reference, found := bank.referencesByKey[transfer.IdempotencyKey]; found {
+		return reference, nil
+	}
+	if strings.HasSuffix(transfer.AccountNumber, closedAccountSuffix) {
+		log.Printf("[INFO] Bank transfer %s rejected, account %s %s is closed", transfer.IdempotencyKey, transfer.BankCode, transfer.AccountNumber)
+		return "", cola.ErrTransferRejected
+	}
+	reference := fmt.Sprintf("TRF%08d", len(bank.referencesByKey)+1)
+	bank.referencesByKey[transfer.IdempotencyKey] = reference
+	log.Printf("[INFO] Bank transfer %s of %s to %s %s (%s) done with reference %s", transfer.IdempotencyKey, transfer.Amount,
+		transfer.BankCode, transfer.AccountNumber, transfer.AccountHolder, reference)
+	return reference, nil
+}
@@ -0,0 +1,45 @@
+package bureau
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPCreditBureau(t *testing.T) {
+	stub := NewStub(300 * time.Millisecond)
+	reportedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	stub.Reports["3522582509010002"] = domain.CreditBureauReport{
+		Debts:      []domain.BureauDebt{{Lender: "BANK", Outstanding: domain.Rupiah(2500000), DaysPastDue: 12}},
+		ReportedAt: reportedAt,
+	}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	bureau := NewHTTPCreditBureau(server.URL, 100*time.Millisecond)
+	t.Run("should read debts of the client", func(t *testing.T) {
+		report, err := bureau.Report("3522582509010002")
+		assert.Nil(t, err)
+		assert.Equal(t, stub.Reports["3522582509010002"], report)
+	})
+	t.Run("client unknown to the bureau should have no debts", func(t *testing.T) {
+		report, err := bureau.Report("3522582509010001")
+		assert.Nil(t, err)
+		assert.Empty(t, report.Debts)
+	})
+	t.Run("slow answer should time out", func(t *testing.T) {
+		_, err := bureau.Report("3522582509019999")
+		assert.Equal(t, cola.ErrCreditBureauTimeout, err)
+	})
+	t.Run("unexpected status should be an error", func(t *testing.T) {
+		failing := httptest.NewServer(http.NotFoundHandler())
+		defer failing.Close()
+		_, err := NewHTTPCreditBureau(failing.URL, time.Second).Report("3522582509010002")
+		assert.EqualError(t, err, "credit bureau responded with status 404")
+	})
+	assert.Equal(t, 3, stub.Requests())
+}
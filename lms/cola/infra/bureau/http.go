@@ -0,0 +1,69 @@
+// Package bureau provides cola.CreditBureau implementation which asks the credit bureau over HTTP and a stub of the
+// bureau API for local runs and tests
+package bureau
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+type httpCreditBureau struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPCreditBureau returns CreditBureau calling GET {baseURL}/reports/{ktpNumber}. Request not answered within the
+// timeout returns cola.ErrCreditBureauTimeout.
+func NewHTTPCreditBureau(baseURL string, timeout time.Duration) cola.CreditBureau {
+	return &httpCreditBureau{baseURL: strings.TrimSuffix(baseURL, "/"), client: &http.Client{Timeout: timeout}}
+}
+
+func (bureau *httpCreditBureau) Report(ktpNumber string) (domain.CreditBureauReport, error) {
+	response, err := bureau.client.Get(bureau.baseURL + "/reports/" + url.PathEscape(ktpNumber))
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return domain.CreditBureauReport{}, cola.ErrCreditBureauTimeout
+	}
+	if err != nil {
+		return domain.CreditBureauReport{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return domain.CreditBureauReport{}, fmt.Errorf("credit bureau responded with status %d", response.StatusCode)
+	}
+	var dto reportDto
+	err = json.NewDecoder(response.Body).Decode(&dto)
+	if errors.As(err, &netError) && netError.Timeout() {
+		return domain.CreditBureauReport{}, cola.ErrCreditBureauTimeout
+	}
+	if err != nil {
+		return domain.CreditBureauReport{}, fmt.Errorf("reading credit bureau report: %v", err)
+	}
+	report := domain.CreditBureauReport{ReportedAt: dto.ReportedAt}
+	for _, debt := range dto.Debts {
+		report.Debts = append(report.Debts, domain.BureauDebt(debt))
+	}
+	return report, nil
+}
+
+// reportDto DTO for JSON marshaling
+type reportDto struct {
+	Debts      []debtDto `json:"debts"`
+	ReportedAt time.Time `json:"reportedAt"`
+}
+
+// debtDto DTO for JSON marshaling
+type debtDto struct {
+	Lender      string       `json:"lender"`
+	Outstanding domain.Money `json:"outstanding"`
+	DaysPastDue uint         `json:"daysPastDue"`
+}
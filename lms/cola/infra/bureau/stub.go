@@ -0,0 +1,62 @@
+package bureau
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// slowKTPSuffix marks clients answered by Stub only after its Delay, so timeouts can be tried out locally
+const slowKTPSuffix = "9999"
+
+// Stub serves the credit bureau API from memory. Clients which are not in Reports have no debts.
+type Stub struct {
+	Reports map[string]domain.CreditBureauReport
+	// Delay of answers about clients whose KTP number ends with 9999
+	Delay    time.Duration
+	mutex    sync.Mutex
+	requests int
+}
+
+// NewStub returns Stub without any debts
+func NewStub(delay time.Duration) *Stub {
+	return &Stub{Reports: make(map[string]domain.CreditBureauReport), Delay: delay}
+}
+
+// Requests is the number of reports requested so far
+func (stub *Stub) Requests() int {
+	stub.mutex.Lock()
+	defer stub.mutex.Unlock()
+	return stub.requests
+}
+
+func (stub *Stub) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	ktpNumber := strings.TrimPrefix(request.URL.Path, "/reports/")
+	if request.Method != "GET" || ktpNumber == request.URL.Path {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	stub.mutex.Lock()
+	stub.requests++
+	report, found := stub.Reports[ktpNumber]
+	stub.mutex.Unlock()
+	if !found {
+		report = domain.CreditBureauReport{ReportedAt: time.Now().UTC()}
+	}
+	if strings.HasSuffix(ktpNumber, slowKTPSuffix) {
+		time.Sleep(stub.Delay)
+	}
+	dto := reportDto{Debts: []debtDto{}, ReportedAt: report.ReportedAt}
+	for _, debt := range report.Debts {
+		dto.Debts = append(dto.Debts, debtDto(debt))
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(dto); err != nil {
+		log.Printf("[WARN] credit bureau stub failed to write report of %s: %s", ktpNumber, err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+package email
+
+import (
+	"io"
+	"log"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPChannel(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	stub := NewStub(log.New(io.Discard, "", 0))
+	go stub.Serve(listener)
+	channel := NewSMTPChannel(listener.Addr().String(), "noreply@goloans.test")
+	t.Run("should send email to the recipient", func(t *testing.T) {
+		err := channel.Deliver(cola.Message{
+			ID:        "NTF0000000001",
+			KTPNumber: "3522582509010002",
+			Event:     domain.LoanDisbursedEvent,
+			Recipient: "doe@example.com",
+			Subject:   "Pinjaman 3522582509010002-1 Anda telah dicairkan",
+			Body:      "Halo Doe, dana pinjaman Anda telah ditransfer.",
+		})
+		assert.Nil(t, err)
+		emails := stub.Emails()
+		assert.Len(t, emails, 1)
+		assert.Equal(t, "noreply@goloans.test", emails[0].From)
+		assert.Equal(t, []string{"doe@example.com"}, emails[0].To)
+		assert.Contains(t, emails[0].Data, "Subject: Pinjaman 3522582509010002-1 Anda telah dicairkan\n")
+		assert.Contains(t, emails[0].Data, "Message-ID: <NTF0000000001@goloans>\n")
+		assert.True(t, strings.HasSuffix(emails[0].Data, "\n\nHalo Doe, dana pinjaman Anda telah ditransfer.\n"))
+	})
+	t.Run("unreachable server should be an error", func(t *testing.T) {
+		closed, _ := net.Listen("tcp", "127.0.0.1:0")
+		closed.Close()
+		err := NewSMTPChannel(closed.Addr().String(), "noreply@goloans.test").Deliver(cola.Message{ID: "NTF0000000002", Recipient: "doe@example.com"})
+		assert.NotNil(t, err)
+	})
+}
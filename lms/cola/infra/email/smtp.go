@@ -0,0 +1,47 @@
+// Package email provides cola.Channel implementation which sends notifications over SMTP and a stub of the mail server
+// for local runs and tests
+package email
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+type smtpChannel struct {
+	addr string
+	from string
+}
+
+// NewSMTPChannel returns Channel sending plain text emails from the address through the SMTP server at addr, e.g.
+// localhost:2525. The server must accept mail without authentication.
+func NewSMTPChannel(addr string, from string) cola.Channel {
+	return &smtpChannel{addr: addr, from: from}
+}
+
+func (channel *smtpChannel) Kind() domain.NotificationChannel {
+	return domain.EmailChannel
+}
+
+func (channel *smtpChannel) Deliver(message cola.Message) error {
+	headers := []string{
+		"From: " + channel.from,
+		"To: " + message.Recipient,
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%s@goloans>", message.ID),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(message.Body, "\n", "\r\n") + "\r\n"
+	if err := smtp.SendMail(channel.addr, nil, channel.from, []string{message.Recipient}, []byte(body)); err != nil {
+		return fmt.Errorf("sending email %s to %s: %v", message.ID, message.Recipient, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package email
+
+import (
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Stub is an SMTP server which accepts every email and keeps it in memory, it stands in for the mail server in local
+// runs and tests. Only commands sent by net/smtp without TLS and authentication are implemented.
+type Stub struct {
+	logger *log.Logger
+	mutex  sync.Mutex
+	emails []StubEmail
+}
+
+// StubEmail is an email received by Stub
+type StubEmail struct {
+	From string
+	To   []string
+	// Data are headers and body of the email with lines ended by LF
+	Data string
+}
+
+// NewStub returns Stub writing one line per received email to the logger
+func NewStub(logger *log.Logger) *Stub {
+	return &Stub{logger: logger}
+}
+
+// Emails received so far
+func (stub *Stub) Emails() []StubEmail {
+	stub.mutex.Lock()
+	defer stub.mutex.Unlock()
+	return append([]StubEmail(nil), stub.emails...)
+}
+
+// Serve accepts connections until the listener is closed
+func (stub *Stub) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go stub.handle(conn)
+	}
+}
+
+func (stub *Stub) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 goLoans SMTP stub") {
+		return
+	}
+	var email StubEmail
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		var replied bool
+		switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+		case "EHLO", "HELO":
+			replied = reply("250 localhost")
+		case "MAIL":
+			email = StubEmail{From: address(line)}
+			replied = reply("250 OK")
+		case "RCPT":
+			email.To = append(email.To, address(line))
+			replied = reply("250 OK")
+		case "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			email.Data = string(data)
+			stub.mutex.Lock()
+			stub.emails = append(stub.emails, email)
+			stub.mutex.Unlock()
+			stub.logger.Printf("[EMAIL] from %s to %s:\n%s", email.From, strings.Join(email.To, ", "), email.Data)
+			replied = reply("250 OK")
+		case "RSET", "NOOP":
+			replied = reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			replied = reply("502 Command %s not implemented", command)
+		}
+		if !replied {
+			return
+		}
+	}
+}
+
+// address returns the address between angle brackets of MAIL or RCPT command
+func address(line string) string {
+	start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
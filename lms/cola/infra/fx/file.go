@@ -0,0 +1,80 @@
+// Package fx provides cola.ExchangeRates implementation which reads exchange rates from a local CSV file
+package fx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+const dateLayout = "2006-01-02"
+
+type currencyPair struct {
+	from, to domain.Currency
+}
+
+type fileExchangeRates struct {
+	// ratesByPair are sorted by date, the oldest first
+	ratesByPair map[currencyPair][]domain.ExchangeRate
+}
+
+// NewFileExchangeRates loads exchange rates from CSV file with header date,from,to,rate, e.g.
+// 2026-01-01,SGD,IDR,12150.25. The rate of a pair is valid from its date until the next rate of the same pair.
+func NewFileExchangeRates(path string) (cola.ExchangeRates, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 4
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("reading header of %s: %v", path, err)
+	}
+	rates := &fileExchangeRates{ratesByPair: make(map[currencyPair][]domain.ExchangeRate)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", path, err)
+		}
+		asOf, err := time.Parse(dateLayout, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %s in %s: %v", record[0], path, err)
+		}
+		rate, err := domain.NewExchangeRate(domain.Currency(record[1]), domain.Currency(record[2]), record[3], asOf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %v in %s: %v", record, path, err)
+		}
+		pair := currencyPair{rate.From, rate.To}
+		rates.ratesByPair[pair] = append(rates.ratesByPair[pair], rate)
+	}
+	for _, pairRates := range rates.ratesByPair {
+		sort.SliceStable(pairRates, func(i, j int) bool {
+			return pairRates[i].AsOf.Before(pairRates[j].AsOf)
+		})
+	}
+	return rates, nil
+}
+
+func (rates *fileExchangeRates) Rate(from, to domain.Currency, asOf time.Time) (domain.ExchangeRate, error) {
+	if from == to {
+		return domain.IdentityRate(from, asOf), nil
+	}
+	pairRates := rates.ratesByPair[currencyPair{from, to}]
+	valid := sort.Search(len(pairRates), func(i int) bool {
+		return pairRates[i].AsOf.After(asOf)
+	})
+	if valid == 0 {
+		return domain.ExchangeRate{}, cola.ErrExchangeRateNotFound
+	}
+	return pairRates[valid-1], nil
+}
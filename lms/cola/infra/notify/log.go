@@ -0,0 +1,24 @@
+// Package notify provides cola.Notifier implementation which writes notices to a log instead of sending them
+package notify
+
+import (
+	"log"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+)
+
+type logNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier returns Notifier which writes one line per notice to the logger, e.g. to a file read by the
+// collections team
+func NewLogNotifier(logger *log.Logger) cola.Notifier {
+	return &logNotifier{logger: logger}
+}
+
+func (notifier *logNotifier) Notify(notice cola.Notice) error {
+	notifier.logger.Printf("[NOTICE] %s (%s) of loan %s to %s due=%s amountDue=%s remaining=%s daysPastDue=%d",
+		notice.Stage, notice.Action, notice.LoanID, notice.KTPNumber, notice.DueDate.Format("2006-01-02"), notice.AmountDue, notice.Remaining, notice.DaysPastDue)
+	return nil
+}
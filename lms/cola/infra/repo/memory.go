@@ -1,13 +1,21 @@
-// Package repo provides lms.ClientRepo implementation which stores everything in memory
+// Package repo provides cola.ClientRepo, cola.ApplicationRepo, cola.NotificationRepo and cola.WebhookRepo
+// implementations which store everything in memory
 package repo
 
 import (
+	"sync"
+
 	"github.com/briyanadityatama/goLoans/lms/cola"
 	"github.com/briyanadityatama/goLoans/lms/cola/domain"
 )
 
+// memoryClientRepo keeps clones of saved clients and hands out clones, so the HTTP server and the end of day job never
+// change the same client at the same time. The mutex guards the map only. When two use cases change the same client
+// at the same time, the first save wins and the other gets cola.ErrClientChanged.
 type memoryClientRepo struct {
-	clientsByKTPNumber map[string]domain.Client
+	mutex                  sync.RWMutex
+	clientsByKTPNumber     map[string]domain.Client
+	virtualAccountSequence uint64
 }
 
 // NewMemoryClientRepo returns a new instance of repository holding everything in memory
@@ -17,12 +25,297 @@ func NewMemoryClientRepo() cola.ClientRepo {
 }
 
 func (repo *memoryClientRepo) ByKTPNumber(ktplNumber string) (domain.Client, bool, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
 	client, ok := repo.clientsByKTPNumber[ktplNumber]
-	return client, ok, nil
+	if !ok {
+		return nil, false, nil
+	}
+	return client.Clone(), true, nil
 }
 
 func (repo *memoryClientRepo) Save(client domain.Client) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
 	ktplNumber := client.KTPNumber()
-	repo.clientsByKTPNumber[ktplNumber] = client
+	if saved, ok := repo.clientsByKTPNumber[ktplNumber]; ok && saved.Version() != client.Version() {
+		return cola.ErrClientChanged
+	}
+	client.SetVersion(client.Version() + 1)
+	repo.clientsByKTPNumber[ktplNumber] = client.Clone()
+	return nil
+}
+
+func (repo *memoryClientRepo) Search(query cola.ClientQuery) (cola.ClientPage, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	var clients []domain.Client
+	for _, client := range repo.clientsByKTPNumber {
+		clients = append(clients, client)
+	}
+	page := cola.SearchClients(clients, query)
+	for i, client := range page.Clients {
+		page.Clients[i] = client.Clone()
+	}
+	return page, nil
+}
+
+func (repo *memoryClientRepo) ByVirtualAccount(number string) (domain.Client, bool, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	for _, client := range repo.clientsByKTPNumber {
+		if client.VirtualAccount() == number {
+			return client.Clone(), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (repo *memoryClientRepo) NextVirtualAccountSequence() (uint64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.virtualAccountSequence++
+	return repo.virtualAccountSequence, nil
+}
+
+// memoryApplicationRepo keeps clones of applications in the order they were submitted and hands out clones, like
+// memoryClientRepo
+type memoryApplicationRepo struct {
+	mutex               sync.RWMutex
+	applications        []domain.LoanApplication
+	indexByID           map[string]int
+	applicationSequence uint64
+}
+
+// NewMemoryApplicationRepo returns a new instance of repository holding loan applications in memory
+func NewMemoryApplicationRepo() cola.ApplicationRepo {
+	return &memoryApplicationRepo{indexByID: make(map[string]int)}
+}
+
+func (repo *memoryApplicationRepo) ByID(id string) (domain.LoanApplication, bool, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	index, ok := repo.indexByID[id]
+	if !ok {
+		return nil, false, nil
+	}
+	return repo.applications[index].Clone(), true, nil
+}
+
+func (repo *memoryApplicationRepo) ByKTPNumber(ktpNumber string) ([]domain.LoanApplication, error) {
+	return repo.filter(func(application domain.LoanApplication) bool {
+		return application.KTPNumber() == ktpNumber
+	}), nil
+}
+
+func (repo *memoryApplicationRepo) ByStatus(status domain.ApplicationStatus) ([]domain.LoanApplication, error) {
+	return repo.filter(func(application domain.LoanApplication) bool {
+		return application.Status() == status
+	}), nil
+}
+
+func (repo *memoryApplicationRepo) filter(matches func(application domain.LoanApplication) bool) []domain.LoanApplication {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	var applications []domain.LoanApplication
+	for _, application := range repo.applications {
+		if matches(application) {
+			applications = append(applications, application.Clone())
+		}
+	}
+	return applications
+}
+
+func (repo *memoryApplicationRepo) NextApplicationSequence() (uint64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.applicationSequence++
+	return repo.applicationSequence, nil
+}
+
+func (repo *memoryApplicationRepo) Save(application domain.LoanApplication) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if index, ok := repo.indexByID[application.ID()]; ok {
+		repo.applications[index] = application.Clone()
+		return nil
+	}
+	repo.indexByID[application.ID()] = len(repo.applications)
+	repo.applications = append(repo.applications, application.Clone())
+	return nil
+}
+
+// memoryNotificationRepo keeps clones of notifications in the order they were created and hands out clones, like
+// memoryClientRepo
+type memoryNotificationRepo struct {
+	mutex                sync.RWMutex
+	notifications        []domain.Notification
+	indexByID            map[string]int
+	notificationSequence uint64
+}
+
+// NewMemoryNotificationRepo returns a new instance of repository holding notifications in memory
+func NewMemoryNotificationRepo() cola.NotificationRepo {
+	return &memoryNotificationRepo{indexByID: make(map[string]int)}
+}
+
+func (repo *memoryNotificationRepo) ByKTPNumber(ktpNumber string) ([]domain.Notification, error) {
+	return repo.filter(func(notification domain.Notification) bool {
+		return notification.KTPNumber() == ktpNumber
+	}), nil
+}
+
+func (repo *memoryNotificationRepo) ByStatus(status domain.DeliveryStatus) ([]domain.Notification, error) {
+	return repo.filter(func(notification domain.Notification) bool {
+		return notification.Status() == status
+	}), nil
+}
+
+func (repo *memoryNotificationRepo) filter(matches func(notification domain.Notification) bool) []domain.Notification {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	var notifications []domain.Notification
+	for _, notification := range repo.notifications {
+		if matches(notification) {
+			notifications = append(notifications, notification.Clone())
+		}
+	}
+	return notifications
+}
+
+func (repo *memoryNotificationRepo) NextNotificationSequence() (uint64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.notificationSequence++
+	return repo.notificationSequence, nil
+}
+
+func (repo *memoryNotificationRepo) Save(notification domain.Notification) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if index, ok := repo.indexByID[notification.ID()]; ok {
+		repo.notifications[index] = notification.Clone()
+		return nil
+	}
+	repo.indexByID[notification.ID()] = len(repo.notifications)
+	repo.notifications = append(repo.notifications, notification.Clone())
+	return nil
+}
+
+// memoryWebhookRepo keeps subscriptions and deliveries in the order they were created. Subscriptions never change,
+// deliveries are kept and handed out as clones like in memoryClientRepo.
+type memoryWebhookRepo struct {
+	mutex                sync.RWMutex
+	subscriptions        []domain.WebhookSubscription
+	subscriptionSequence uint64
+	deliveries           []domain.WebhookDelivery
+	deliveryIndexByID    map[string]int
+	deliverySequence     uint64
+}
+
+// NewMemoryWebhookRepo returns a new instance of repository holding webhook subscriptions and deliveries in memory
+func NewMemoryWebhookRepo() cola.WebhookRepo {
+	return &memoryWebhookRepo{deliveryIndexByID: make(map[string]int)}
+}
+
+func (repo *memoryWebhookRepo) Subscriptions() ([]domain.WebhookSubscription, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	return append([]domain.WebhookSubscription(nil), repo.subscriptions...), nil
+}
+
+func (repo *memoryWebhookRepo) SubscriptionByID(id string) (domain.WebhookSubscription, bool, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	for _, subscription := range repo.subscriptions {
+		if subscription.ID() == id {
+			return subscription, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (repo *memoryWebhookRepo) NextSubscriptionSequence() (uint64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.subscriptionSequence++
+	return repo.subscriptionSequence, nil
+}
+
+func (repo *memoryWebhookRepo) SaveSubscription(subscription domain.WebhookSubscription) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for i, saved := range repo.subscriptions {
+		if saved.ID() == subscription.ID() {
+			repo.subscriptions[i] = subscription
+			return nil
+		}
+	}
+	repo.subscriptions = append(repo.subscriptions, subscription)
+	return nil
+}
+
+func (repo *memoryWebhookRepo) DeleteSubscription(id string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for i, saved := range repo.subscriptions {
+		if saved.ID() == id {
+			repo.subscriptions = append(repo.subscriptions[:i:i], repo.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (repo *memoryWebhookRepo) DeliveryByID(id string) (domain.WebhookDelivery, bool, error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	index, ok := repo.deliveryIndexByID[id]
+	if !ok {
+		return nil, false, nil
+	}
+	return repo.deliveries[index].Clone(), true, nil
+}
+
+func (repo *memoryWebhookRepo) DeliveriesOfSubscription(subscriptionID string) ([]domain.WebhookDelivery, error) {
+	return repo.filterDeliveries(func(delivery domain.WebhookDelivery) bool {
+		return delivery.SubscriptionID() == subscriptionID
+	}), nil
+}
+
+func (repo *memoryWebhookRepo) DeliveriesByStatus(status domain.DeliveryStatus) ([]domain.WebhookDelivery, error) {
+	return repo.filterDeliveries(func(delivery domain.WebhookDelivery) bool {
+		return delivery.Status() == status
+	}), nil
+}
+
+func (repo *memoryWebhookRepo) filterDeliveries(matches func(delivery domain.WebhookDelivery) bool) []domain.WebhookDelivery {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range repo.deliveries {
+		if matches(delivery) {
+			deliveries = append(deliveries, delivery.Clone())
+		}
+	}
+	return deliveries
+}
+
+func (repo *memoryWebhookRepo) NextDeliverySequence() (uint64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.deliverySequence++
+	return repo.deliverySequence, nil
+}
+
+func (repo *memoryWebhookRepo) SaveDelivery(delivery domain.WebhookDelivery) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if index, ok := repo.deliveryIndexByID[delivery.ID()]; ok {
+		repo.deliveries[index] = delivery.Clone()
+		return nil
+	}
+	repo.deliveryIndexByID[delivery.ID()] = len(repo.deliveries)
+	repo.deliveries = append(repo.deliveries, delivery.Clone())
 	return nil
 }
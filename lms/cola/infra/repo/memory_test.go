@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+const ktpNumber = "3522582509010002"
+
+func newBorrower() domain.Client {
+	client := domain.NewClient("", "1 December 1994", "Doe", ktpNumber)
+	profile := client.Profile()
+	profile.BankAccount = domain.BankAccount{BankCode: "014", Number: "1234567890", HolderName: "Doe"}
+	client.UpdateProfile(profile, time.Time{})
+	return client
+}
+
+func TestMemoryClientRepoSave(t *testing.T) {
+	repo := NewMemoryClientRepo()
+	repo.Save(newBorrower())
+	first, _, _ := repo.ByKTPNumber(ktpNumber)
+	second, _, _ := repo.ByKTPNumber(ktpNumber)
+	t.Run("should save the latest version", func(t *testing.T) {
+		assert.Nil(t, repo.Save(first))
+		assert.Nil(t, repo.Save(first))
+	})
+	t.Run("should not save client which was saved by someone else since it was loaded", func(t *testing.T) {
+		assert.Equal(t, cola.ErrClientChanged, repo.Save(second))
+	})
+}
+
+func TestMemoryApplicationRepoHandsOutCopies(t *testing.T) {
+	repo := NewMemoryApplicationRepo()
+	application := domain.NewLoanApplication(1, ktpNumber, "payday", domain.Rupiah(100), 30, domain.Affordability{}, domain.Origin{}, time.Now())
+	repo.Save(application)
+	application.Cancel(time.Now())
+	loaded, _, _ := repo.ByID(application.ID())
+	assert.Equal(t, domain.ApplicationSubmitted, loaded.Status())
+	loaded.Cancel(time.Now())
+	submitted, _ := repo.ByStatus(domain.ApplicationSubmitted)
+	assert.Len(t, submitted, 1)
+}
+
+func TestMemoryNotificationRepoHandsOutCopies(t *testing.T) {
+	repo := NewMemoryNotificationRepo()
+	notification := domain.NewNotification(1, ktpNumber, domain.LoanApprovedEvent, "loan-1", domain.SMSChannel, "+6281234567890", "", "Approved", time.Now())
+	repo.Save(notification)
+	notification.RecordDelivery(time.Now())
+	pending, _ := repo.ByStatus(domain.DeliveryPending)
+	assert.Len(t, pending, 1)
+	pending[0].Erase()
+	notifications, _ := repo.ByKTPNumber(ktpNumber)
+	assert.Equal(t, "Approved", notifications[0].Body())
+}
+
+func TestMemoryWebhookRepoHandsOutCopies(t *testing.T) {
+	repo := NewMemoryWebhookRepo()
+	delivery := domain.NewWebhookDelivery(1, "WHS0000000001", "event-1", domain.LoanStateChangedWebhook, []byte("{}"), time.Now(), "")
+	repo.SaveDelivery(delivery)
+	delivery.RecordAttempt(domain.WebhookAttempt{At: time.Now(), StatusCode: 200})
+	loaded, _, _ := repo.DeliveryByID(delivery.ID())
+	assert.Equal(t, domain.DeliveryPending, loaded.Status())
+	loaded.RecordAttempt(domain.WebhookAttempt{At: time.Now(), StatusCode: 500})
+	pending, _ := repo.DeliveriesByStatus(domain.DeliveryPending)
+	assert.Empty(t, pending[0].Attempts())
+}
+
+// pausingClientRepo lets a repayment run after the end of day job loaded its page of clients and before it saves them
+type pausingClientRepo struct {
+	cola.ClientRepo
+	loaded chan struct{}
+	repaid chan struct{}
+}
+
+func (repo *pausingClientRepo) Search(query cola.ClientQuery) (cola.ClientPage, error) {
+	page, err := repo.ClientRepo.Search(query)
+	repo.loaded <- struct{}{}
+	<-repo.repaid
+	return page, err
+}
+
+func TestMemoryClientRepoWhenRepayingDuringEndOfDay(t *testing.T) {
+	repo := &pausingClientRepo{ClientRepo: NewMemoryClientRepo(), loaded: make(chan struct{}), repaid: make(chan struct{})}
+	service := cola.New(repo, NewMemoryApplicationRepo(), cola.NewFakeDisburser())
+	repo.Save(newBorrower())
+	service.ApplyForLoan(lms.ApplicationData{
+		KTPNumber:      ktpNumber,
+		ProductCode:    "payday",
+		Amount:         lms.Rupiah(10000000),
+		Term:           30,
+		MonthlyIncome:  lms.Rupiah(100000000),
+		EmploymentType: string(domain.Employed),
+	})
+	asOf := time.Now().AddDate(0, 0, 40)
+	accrued := make(chan lms.AccrualRun)
+	go func() {
+		run, err := service.AccrueLateCharges(asOf)
+		assert.Nil(t, err)
+		accrued <- run
+	}()
+	<-repo.loaded
+	err := service.Repay(ktpNumber, lms.Rupiah(100))
+	repo.repaid <- struct{}{}
+	run := <-accrued
+	client, _, _ := repo.ByKTPNumber(ktpNumber)
+	t.Run("should keep the repayment", func(t *testing.T) {
+		assert.Nil(t, err)
+		assert.Len(t, client.ActiveLoan().Repayments(), 1)
+	})
+	t.Run("should charge the client loaded again", func(t *testing.T) {
+		assert.Equal(t, 1, run.Loans)
+		assert.Len(t, client.ActiveLoan().Accruals(), run.Accruals)
+	})
+}
@@ -0,0 +1,23 @@
+package sms
+
+import (
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+type smsChannel struct {
+	sender cola.SMSSender
+}
+
+// NewChannel returns Channel sending the body of notifications as text messages through the sender
+func NewChannel(sender cola.SMSSender) cola.Channel {
+	return &smsChannel{sender: sender}
+}
+
+func (channel *smsChannel) Kind() domain.NotificationChannel {
+	return domain.SMSChannel
+}
+
+func (channel *smsChannel) Deliver(message cola.Message) error {
+	return channel.sender.Send(message.Recipient, message.Body)
+}
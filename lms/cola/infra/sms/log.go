@@ -0,0 +1,24 @@
+// Package sms provides cola.SMSSender implementation which writes text messages to a log instead of sending them,
+// it stands in for the SMS gateway, and cola.Channel sending notifications through any SMSSender
+package sms
+
+import (
+	"log"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+)
+
+type logSMSSender struct {
+	logger *log.Logger
+}
+
+// NewLogSMSSender returns SMSSender which writes one line per message to the logger, it stands in for an SMS gateway
+// during development
+func NewLogSMSSender(logger *log.Logger) cola.SMSSender {
+	return &logSMSSender{logger: logger}
+}
+
+func (sender *logSMSSender) Send(phone string, message string) error {
+	sender.logger.Printf("[SMS] to %s: %s", phone, message)
+	return nil
+}
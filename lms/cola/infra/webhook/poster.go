@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+type httpPoster struct {
+	client *http.Client
+}
+
+// NewHTTPPoster returns WebhookPoster posting signed payloads to subscribers. The event type, delivery ID, timestamp
+// and signature are sent in X-GoLoans-* headers, so partners can verify the payload before parsing it. Host names
+// resolving to addresses which are not public are refused.
+func NewHTTPPoster(timeout time.Duration) cola.WebhookPoster {
+	dialer := &net.Dialer{Timeout: timeout, Control: refusePrivateAddress}
+	transport := &http.Transport{DialContext: dialer.DialContext}
+	return &httpPoster{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+// refusePrivateAddress is checked after the host name was resolved, so a public host name can't lead to this machine
+// or a private network
+func refusePrivateAddress(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !domain.IsPublicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public", address)
+	}
+	return nil
+}
+
+func (poster *httpPoster) Post(webhookRequest cola.WebhookRequest) (int, error) {
+	request, err := http.NewRequest("POST", webhookRequest.URL, bytes.NewReader(webhookRequest.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "goLoans-Webhooks")
+	request.Header.Set("X-GoLoans-Event", string(webhookRequest.EventType))
+	request.Header.Set("X-GoLoans-Delivery", webhookRequest.DeliveryID)
+	request.Header.Set("X-GoLoans-Timestamp", webhookRequest.Timestamp)
+	request.Header.Set("X-GoLoans-Signature", "sha256="+webhookRequest.Signature)
+	response, err := poster.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	return response.StatusCode, nil
+}
@@ -0,0 +1,64 @@
+// Package webhook provides cola.Channel implementation which posts notifications as JSON to a URL and
+// cola.WebhookPoster implementation which posts signed payloads to partners
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+type webhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+// NewChannel returns Channel posting every notification to the URL. Any other status than 2xx is a failed delivery.
+func NewChannel(url string, timeout time.Duration) cola.Channel {
+	return &webhookChannel{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (channel *webhookChannel) Kind() domain.NotificationChannel {
+	return domain.WebhookChannel
+}
+
+func (channel *webhookChannel) Deliver(message cola.Message) error {
+	body, err := json.Marshal(notificationDto{
+		ID:        message.ID,
+		KTPNumber: message.KTPNumber,
+		Event:     string(message.Event),
+		Subject:   message.Subject,
+		Body:      message.Body,
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", channel.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := channel.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// notificationDto DTO for JSON marshaling
+type notificationDto struct {
+	ID        string `json:"id"`
+	KTPNumber string `json:"ktpNumber"`
+	Event     string `json:"event"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookChannel(t *testing.T) {
+	var received []notificationDto
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var notification notificationDto
+		json.NewDecoder(request.Body).Decode(&notification)
+		received = append(received, notification)
+		if notification.ID == "NTF0000000002" {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	channel := NewChannel(server.URL, time.Second)
+	t.Run("should post notification", func(t *testing.T) {
+		err := channel.Deliver(cola.Message{ID: "NTF0000000001", KTPNumber: "3522582509010002", Event: domain.LoanApprovedEvent, Subject: "Approved", Body: "Hi Doe"})
+		assert.Nil(t, err)
+		assert.Equal(t, []notificationDto{{ID: "NTF0000000001", KTPNumber: "3522582509010002", Event: "loan_approved", Subject: "Approved", Body: "Hi Doe"}}, received)
+	})
+	t.Run("unexpected status should be an error", func(t *testing.T) {
+		err := channel.Deliver(cola.Message{ID: "NTF0000000002"})
+		assert.EqualError(t, err, "webhook responded with status 503")
+	})
+}
+
+func TestHTTPPoster(t *testing.T) {
+	var headers http.Header
+	var payload []byte
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		headers = request.Header
+		payload, _ = ioutil.ReadAll(request.Body)
+		if request.URL.Path == "/down" {
+			writer.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+	// the test server listens on loopback which NewHTTPPoster refuses
+	poster := &httpPoster{client: &http.Client{Timeout: time.Second}}
+	t.Run("should post signed payload", func(t *testing.T) {
+		statusCode, err := poster.Post(cola.WebhookRequest{
+			URL:        server.URL + "/hooks",
+			DeliveryID: "WHD0000000001",
+			EventType:  domain.ClientRegisteredWebhook,
+			Timestamp:  "1767225600",
+			Signature:  "abc123",
+			Payload:    []byte(`{"id":"1"}`),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 200, statusCode)
+		assert.Equal(t, `{"id":"1"}`, string(payload))
+		assert.Equal(t, "application/json", headers.Get("Content-Type"))
+		assert.Equal(t, "client.registered", headers.Get("X-GoLoans-Event"))
+		assert.Equal(t, "WHD0000000001", headers.Get("X-GoLoans-Delivery"))
+		assert.Equal(t, "1767225600", headers.Get("X-GoLoans-Timestamp"))
+		assert.Equal(t, "sha256=abc123", headers.Get("X-GoLoans-Signature"))
+	})
+	t.Run("should return status of subscriber", func(t *testing.T) {
+		statusCode, err := poster.Post(cola.WebhookRequest{URL: server.URL + "/down"})
+		assert.Nil(t, err)
+		assert.Equal(t, 502, statusCode)
+	})
+	t.Run("subscriber on private address should be refused", func(t *testing.T) {
+		payload = nil
+		statusCode, err := NewHTTPPoster(time.Second).Post(cola.WebhookRequest{URL: server.URL + "/hooks"})
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, statusCode)
+		assert.Nil(t, payload)
+	})
+	t.Run("unreachable subscriber should be an error", func(t *testing.T) {
+		statusCode, err := poster.Post(cola.WebhookRequest{URL: "http://127.0.0.1:1/hooks"})
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, statusCode)
+	})
+}
@@ -0,0 +1,83 @@
+package cola
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+type accountCurrency struct {
+	account  domain.Account
+	currency domain.Currency
+}
+
+// TrialBalance nets debits and credits of every account, so each line has balance on one side only
+func (cola *cola) TrialBalance() (lms.TrialBalance, error) {
+	balances := make(map[accountCurrency]domain.Money)
+	var currencies []domain.Currency
+	trialBalance := lms.TrialBalance{Lines: []lms.TrialBalanceLine{}, Mismatches: []lms.LedgerMismatch{}}
+	err := cola.forEachClient(ClientQuery{}, func(client domain.Client) error {
+		journal := client.Journal()
+		for _, entry := range journal {
+			for _, posting := range entry.Postings {
+				key := accountCurrency{posting.Account, posting.Debit.Currency()}
+				balance, found := balances[key]
+				if !found {
+					balance = domain.NewMoney(0, key.currency)
+					if !containsCurrency(currencies, key.currency) {
+						currencies = append(currencies, key.currency)
+					}
+				}
+				balance, err := balance.Add(posting.Debit)
+				if err == nil {
+					balance, err = balance.Sub(posting.Credit)
+				}
+				if err != nil {
+					return fmt.Errorf("client %s entry %s: %v", client.KTPNumber(), entry.ID, err)
+				}
+				balances[key] = balance
+			}
+		}
+		var mismatch domain.LedgerMismatchStruct
+		if err := client.CheckLedger(); errors.As(err, &mismatch) {
+			trialBalance.Mismatches = append(trialBalance.Mismatches, lms.LedgerMismatch{
+				KTPNumber: client.KTPNumber(),
+				LoanID:    mismatch.LoanID,
+				Ledger:    mismatch.Ledger,
+				Expected:  mismatch.Expected,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return lms.TrialBalance{}, fmt.Errorf("building trial balance: %v", err)
+	}
+	for _, currency := range currencies {
+		for _, account := range domain.Accounts {
+			balance, found := balances[accountCurrency{account, currency}]
+			if !found {
+				continue
+			}
+			zero := domain.NewMoney(0, currency)
+			line := lms.TrialBalanceLine{Account: string(account), Currency: currency, Debit: zero, Credit: zero}
+			if balance.IsNegative() {
+				line.Credit = domain.NewMoney(-balance.MinorUnits(), currency)
+			} else {
+				line.Debit = balance
+			}
+			trialBalance.Lines = append(trialBalance.Lines, line)
+		}
+	}
+	return trialBalance, nil
+}
+
+func containsCurrency(currencies []domain.Currency, currency domain.Currency) bool {
+	for _, c := range currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
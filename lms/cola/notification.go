@@ -0,0 +1,346 @@
+package cola
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// NotificationRepo is used internally by lms package for loading/storing notifications with their delivery status
+type NotificationRepo interface {
+	// ByKTPNumber lists notifications of the client in the order they were created
+	ByKTPNumber(ktpNumber string) ([]domain.Notification, error)
+	// ByStatus lists notifications in the status in the order they were created
+	ByStatus(status domain.DeliveryStatus) ([]domain.Notification, error)
+	// NextNotificationSequence never returns the same sequence twice
+	NextNotificationSequence() (uint64, error)
+	Save(notification domain.Notification) error
+}
+
+// Channel delivers notifications to clients, e.g. by email or SMS. Any error means the delivery is retried later.
+type Channel interface {
+	Kind() domain.NotificationChannel
+	Deliver(message Message) error
+}
+
+// Message is a rendered notification sent to Channel
+type Message struct {
+	// ID is the same for every attempt to deliver the notification
+	ID        string
+	KTPNumber string
+	Event     domain.NotificationEvent
+	// Recipient is the email or phone of the client, empty for domain.WebhookChannel
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+// Language of notification templates
+type Language string
+
+const (
+	// English templates
+	English Language = "en"
+	// Indonesian templates in Bahasa Indonesia
+	Indonesian Language = "id"
+)
+
+// WithNotifications notifies clients of approval, disbursement, dunning stages and repayments through every channel in
+// the language. Without it clients are not notified.
+func WithNotifications(repo NotificationRepo, language Language, channels ...Channel) Option {
+	return func(cola *cola) {
+		cola.notifications = &notifications{repo: repo, language: language, channels: channels}
+	}
+}
+
+type notifications struct {
+	repo     NotificationRepo
+	language Language
+	channels []Channel
+}
+
+// notificationData is what the templates are rendered from
+type notificationData struct {
+	Name           string
+	LoanID         string
+	VirtualAccount string
+	Amount         domain.Money
+	TotalPayable   domain.Money
+	Remaining      domain.Money
+	DueDate        time.Time
+}
+
+// notificationTemplate renders subject and body of a notification, the body is short enough for SMS
+type notificationTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newNotificationTemplate(subject string, body string) notificationTemplate {
+	funcs := template.FuncMap{"date": func(date time.Time) string { return date.Format("2006-01-02") }}
+	return notificationTemplate{
+		subject: template.Must(template.New("subject").Funcs(funcs).Parse(subject)),
+		body:    template.Must(template.New("body").Funcs(funcs).Parse(body)),
+	}
+}
+
+var notificationTemplates = map[Language]map[domain.NotificationEvent]notificationTemplate{
+	English: {
+		domain.LoanApprovedEvent: newNotificationTemplate(
+			"Your loan {{.LoanID}} is approved",
+			"Hi {{.Name}}, your loan {{.LoanID}} of {{.Amount}} is approved. The total payable is {{.TotalPayable}}."),
+		domain.LoanDisbursedEvent: newNotificationTemplate(
+			"Your loan {{.LoanID}} is disbursed",
+			"Hi {{.Name}}, {{.Amount}} of your loan {{.LoanID}} was transferred to your bank account."),
+		domain.InstalmentDueEvent: newNotificationTemplate(
+			"Instalment of your loan {{.LoanID}} is due on {{date .DueDate}}",
+			"Hi {{.Name}}, {{.Amount}} of your loan {{.LoanID}} is due on {{date .DueDate}}. Please pay to virtual account {{.VirtualAccount}}."),
+		domain.PaymentOverdueEvent: newNotificationTemplate(
+			"Instalment of your loan {{.LoanID}} is overdue",
+			"Hi {{.Name}}, {{.Amount}} of your loan {{.LoanID}} was due on {{date .DueDate}} and is not paid yet. Please pay to virtual account {{.VirtualAccount}}."),
+		domain.LoanDefaultedEvent: newNotificationTemplate(
+			"Your loan {{.LoanID}} is handed over to collections",
+			"Hi {{.Name}}, your loan {{.LoanID}} was handed over to collections. The remaining amount is {{.Remaining}}, please pay to virtual account {{.VirtualAccount}}."),
+		domain.RepaymentReceivedEvent: newNotificationTemplate(
+			"Repayment of your loan {{.LoanID}} received",
+			"Hi {{.Name}}, we received your repayment of {{.Amount}}. The remaining amount of your loan {{.LoanID}} is {{.Remaining}}."),
+	},
+	Indonesian: {
+		domain.LoanApprovedEvent: newNotificationTemplate(
+			"Pinjaman {{.LoanID}} Anda disetujui",
+			"Halo {{.Name}}, pinjaman {{.LoanID}} Anda sebesar {{.Amount}} disetujui. Total yang harus dibayar {{.TotalPayable}}."),
+		domain.LoanDisbursedEvent: newNotificationTemplate(
+			"Pinjaman {{.LoanID}} Anda telah dicairkan",
+			"Halo {{.Name}}, dana pinjaman {{.LoanID}} Anda sebesar {{.Amount}} telah ditransfer ke rekening bank Anda."),
+		domain.InstalmentDueEvent: newNotificationTemplate(
+			"Cicilan pinjaman {{.LoanID}} Anda jatuh tempo pada {{date .DueDate}}",
+			"Halo {{.Name}}, cicilan pinjaman {{.LoanID}} Anda sebesar {{.Amount}} jatuh tempo pada {{date .DueDate}}. Silakan bayar ke virtual account {{.VirtualAccount}}."),
+		domain.PaymentOverdueEvent: newNotificationTemplate(
+			"Cicilan pinjaman {{.LoanID}} Anda terlambat",
+			"Halo {{.Name}}, cicilan pinjaman {{.LoanID}} Anda sebesar {{.Amount}} jatuh tempo pada {{date .DueDate}} dan belum dibayar. Silakan bayar ke virtual account {{.VirtualAccount}}."),
+		domain.LoanDefaultedEvent: newNotificationTemplate(
+			"Pinjaman {{.LoanID}} Anda diserahkan ke penagihan",
+			"Halo {{.Name}}, pinjaman {{.LoanID}} Anda telah diserahkan ke penagihan. Sisa pinjaman Anda {{.Remaining}}, silakan bayar ke virtual account {{.VirtualAccount}}."),
+		domain.RepaymentReceivedEvent: newNotificationTemplate(
+			"Pembayaran pinjaman {{.LoanID}} Anda diterima",
+			"Halo {{.Name}}, pembayaran Anda sebesar {{.Amount}} telah kami terima. Sisa pinjaman {{.LoanID}} Anda {{.Remaining}}."),
+	},
+}
+
+// notify renders the event for every channel the client can be reached by and queues it, so the use case is not held
+// up by slow channels. Queued notifications are delivered by RetryNotifications. The use case which notifies has
+// already succeeded, so problems are only logged. The number of new notifications is returned.
+func (cola *cola) notify(client domain.Client, event domain.NotificationEvent, reference string, data notificationData) int {
+	if cola.notifications == nil {
+		return 0
+	}
+	created, err := cola.notifications.queue(client, event, reference, data, cola.now())
+	if err != nil {
+		log.Printf("[WARN] problem notifying client %s of %s %s: %v", client.KTPNumber(), event, reference, err)
+	}
+	return created
+}
+
+func (notifications *notifications) queue(client domain.Client, event domain.NotificationEvent, reference string, data notificationData, now time.Time) (created int, err error) {
+	sent, err := notifications.repo.ByKTPNumber(client.KTPNumber())
+	if err != nil {
+		return 0, err
+	}
+	data.Name = client.Name()
+	data.VirtualAccount = client.VirtualAccount()
+	templates, found := notificationTemplates[notifications.language]
+	if !found {
+		templates = notificationTemplates[English]
+	}
+	var subject, body bytes.Buffer
+	if err = templates[event].subject.Execute(&subject, data); err != nil {
+		return 0, err
+	}
+	if err = templates[event].body.Execute(&body, data); err != nil {
+		return 0, err
+	}
+	for _, channel := range notifications.channels {
+		recipient := ""
+		switch channel.Kind() {
+		case domain.EmailChannel:
+			recipient = client.Email()
+		case domain.SMSChannel:
+			recipient = client.Phone()
+		}
+		if recipient == "" && channel.Kind() != domain.WebhookChannel {
+			continue
+		}
+		if isSent(sent, event, reference, channel.Kind()) {
+			continue
+		}
+		sequence, err := notifications.repo.NextNotificationSequence()
+		if err != nil {
+			return created, err
+		}
+		notification := domain.NewNotification(sequence, client.KTPNumber(), event, reference, channel.Kind(), recipient, subject.String(), body.String(), now)
+		if err = notifications.repo.Save(notification); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+func isSent(sent []domain.Notification, event domain.NotificationEvent, reference string, channel domain.NotificationChannel) bool {
+	for _, notification := range sent {
+		if notification.Event() == event && notification.Reference() == reference && notification.Channel() == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver attempts to deliver the notification and saves the outcome, only an error of the repository is returned
+func (notifications *notifications) deliver(channel Channel, notification domain.Notification, now time.Time) error {
+	err := channel.Deliver(Message{
+		ID:        notification.ID(),
+		KTPNumber: notification.KTPNumber(),
+		Event:     notification.Event(),
+		Recipient: notification.Recipient(),
+		Subject:   notification.Subject(),
+		Body:      notification.Body(),
+	})
+	if err != nil {
+		err = notification.RecordFailure(err.Error(), now)
+	} else {
+		err = notification.RecordDelivery(now)
+	}
+	if err != nil {
+		return err
+	}
+	return notifications.repo.Save(notification)
+}
+
+func (notifications *notifications) channel(kind domain.NotificationChannel) (Channel, bool) {
+	for _, channel := range notifications.channels {
+		if channel.Kind() == kind {
+			return channel, true
+		}
+	}
+	return nil, false
+}
+
+// notifyApproval is sent when the loan is approved, the loan may still wait for its agreement to be accepted
+func (cola *cola) notifyApproval(client domain.Client) {
+	loan := client.ActiveLoan()
+	cola.notify(client, domain.LoanApprovedEvent, loan.ID(), notificationData{
+		LoanID:       loan.ID(),
+		Amount:       loan.Amount(),
+		TotalPayable: loan.TotalPayable(),
+	})
+}
+
+// notifyDunning queues the notification of the dunning stage the loan reached. The client is reminded of an
+// instalment once, but notified of every overdue stage.
+func (cola *cola) notifyDunning(client domain.Client, stage domain.DunningStage, notice Notice) {
+	instalment := notice.LoanID + "/" + notice.DueDate.Format("2006-01-02")
+	event, reference := domain.PaymentOverdueEvent, instalment+"/"+stage.Name
+	switch stage.Action {
+	case domain.SendReminder:
+		event, reference = domain.InstalmentDueEvent, instalment
+	case domain.HandOverToCollections:
+		event, reference = domain.LoanDefaultedEvent, notice.LoanID
+	}
+	cola.notify(client, event, reference, notificationData{
+		LoanID:    notice.LoanID,
+		Amount:    notice.AmountDue,
+		Remaining: notice.Remaining,
+		DueDate:   notice.DueDate,
+	})
+}
+
+func (cola *cola) RetryNotifications(asOf time.Time) (lms.NotificationRun, error) {
+	run := lms.NotificationRun{AsOf: asOf}
+	if cola.notifications == nil {
+		return run, nil
+	}
+	pending, err := cola.notifications.repo.ByStatus(domain.DeliveryPending)
+	if err != nil {
+		return run, fmt.Errorf("retrying notifications as of %s: %v", asOf.Format(time.RFC3339), err)
+	}
+	for _, notification := range pending {
+		if !notification.IsDue(asOf) {
+			continue
+		}
+		channel, found := cola.notifications.channel(notification.Channel())
+		if !found {
+			continue
+		}
+		if err = cola.notifications.deliver(channel, notification, asOf); err != nil {
+			return run, fmt.Errorf("retrying notification %s: %v", notification.ID(), err)
+		}
+		switch notification.Status() {
+		case domain.DeliveryDelivered:
+			run.Delivered++
+		case domain.DeliveryFailed:
+			run.Failed++
+		default:
+			run.Pending++
+		}
+	}
+	return run, nil
+}
+
+// eraseNotifications removes personal data from notifications of the erased client
+func (cola *cola) eraseNotifications(ktpNumber string) error {
+	if cola.notifications == nil {
+		return nil
+	}
+	notifications, err := cola.notifications.repo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return err
+	}
+	for _, notification := range notifications {
+		notification.Erase()
+		if err = cola.notifications.repo.Save(notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cola *cola) NotificationsOfClient(ktpNumber string) ([]lms.Notification, error) {
+	dtos := []lms.Notification{}
+	if cola.notifications == nil {
+		return dtos, nil
+	}
+	notifications, err := cola.notifications.repo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return nil, fmt.Errorf("listing notifications of client %s: %v", ktpNumber, err)
+	}
+	for _, notification := range notifications {
+		dtos = append(dtos, lms.Notification{
+			ID:            notification.ID(),
+			KTPNumber:     notification.KTPNumber(),
+			Event:         string(notification.Event()),
+			Reference:     notification.Reference(),
+			Channel:       string(notification.Channel()),
+			Recipient:     notification.Recipient(),
+			Subject:       notification.Subject(),
+			Body:          notification.Body(),
+			CreatedAt:     notification.CreatedAt(),
+			Status:        string(notification.Status()),
+			Attempts:      notification.Attempts(),
+			LastError:     notification.LastError(),
+			DeliveredAt:   notification.DeliveredAt(),
+			NextAttemptAt: notification.NextAttemptAt(),
+		})
+	}
+	return dtos, nil
+}
+
+// repaymentReference is unique for every repayment of the loan
+func repaymentReference(loan domain.Loan) string {
+	return loan.ID() + "/" + strconv.Itoa(len(loan.Repayments()))
+}
@@ -0,0 +1,156 @@
+package cola
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// SMSSender delivers text messages to phone numbers
+type SMSSender interface {
+	Send(phone string, message string) error
+}
+
+// WithOTP requires clients to verify their phone number by a one-time code sent by sender before they register or
+// accept a loan agreement. Without it neither needs the code.
+func WithOTP(sender SMSSender) Option {
+	return func(cola *cola) {
+		cola.otp = &otpChallenges{
+			sender:     sender,
+			challenges: make(map[string]domain.OTPChallenge),
+			latest:     make(map[string]domain.OTPChallenge),
+		}
+	}
+}
+
+// otpChallenges are short-lived, so they are kept in memory. Obsolete challenges are removed when a new one is sent.
+type otpChallenges struct {
+	sender     SMSSender
+	mutex      sync.Mutex
+	challenges map[string]domain.OTPChallenge
+	// latest challenge by phone number and purpose is used for resend throttling
+	latest map[string]domain.OTPChallenge
+}
+
+func (cola *cola) ChallengeOTP(phone string, purpose string) (lms.OTPChallenge, error) {
+	if cola.otp == nil {
+		return lms.OTPChallenge{}, lms.ErrOTPDisabled
+	}
+	id, code, err := newOTPCode()
+	if err != nil {
+		return lms.OTPChallenge{}, fmt.Errorf("sending otp to %s: %v", phone, err)
+	}
+	now := cola.now()
+	challenge, err := domain.NewOTPChallenge(id, phone, domain.OTPPurpose(purpose), code, now)
+	if err != nil {
+		return lms.OTPChallenge{}, err
+	}
+	if err = cola.otp.reserve(challenge, now); err != nil {
+		return lms.OTPChallenge{}, err
+	}
+	message := fmt.Sprintf("Your goLoans verification code is %s. It expires in %d minutes, do not share it with anyone.", code, domain.OTPValidity/time.Minute)
+	if err = cola.otp.sender.Send(challenge.Phone(), message); err != nil {
+		cola.otp.cancel(challenge)
+		return lms.OTPChallenge{}, fmt.Errorf("sending otp to %s: %v", phone, err)
+	}
+	return lms.OTPChallenge{
+		ID:        challenge.ID(),
+		Phone:     challenge.Phone(),
+		Purpose:   string(challenge.Purpose()),
+		ExpiresAt: challenge.ExpiresAt(),
+	}, nil
+}
+
+// reserve keeps the challenge before its code is sent, so the gateway is not called with the mutex held and another
+// code for the same phone and purpose is throttled while this one is being sent
+func (otp *otpChallenges) reserve(challenge domain.OTPChallenge, now time.Time) error {
+	otp.mutex.Lock()
+	defer otp.mutex.Unlock()
+	for id, obsolete := range otp.challenges {
+		if obsolete.IsObsolete(now) && now.Sub(obsolete.SentAt()) >= domain.OTPResendInterval {
+			delete(otp.challenges, id)
+			delete(otp.latest, otpKey(obsolete))
+		}
+	}
+	key := otpKey(challenge)
+	if latest, found := otp.latest[key]; found && now.Before(latest.SentAt().Add(domain.OTPResendInterval)) {
+		return lms.ErrOTPResendTooSoon
+	}
+	otp.challenges[challenge.ID()] = challenge
+	otp.latest[key] = challenge
+	return nil
+}
+
+// cancel removes the reserved challenge whose code could not be sent, so another one can be sent right away
+func (otp *otpChallenges) cancel(challenge domain.OTPChallenge) {
+	otp.mutex.Lock()
+	defer otp.mutex.Unlock()
+	delete(otp.challenges, challenge.ID())
+	key := otpKey(challenge)
+	if otp.latest[key] == challenge {
+		delete(otp.latest, key)
+	}
+}
+
+func otpKey(challenge domain.OTPChallenge) string {
+	return challenge.Phone() + "/" + string(challenge.Purpose())
+}
+
+// newOTPCode returns random challenge ID and 6-digit code
+func newOTPCode() (id string, code string, err error) {
+	random := make([]byte, 16)
+	if _, err = rand.Read(random); err != nil {
+		return "", "", err
+	}
+	number, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(random), fmt.Sprintf("%06d", number.Int64()), nil
+}
+
+func (cola *cola) VerifyOTP(challengeID string, code string) error {
+	if cola.otp == nil {
+		return lms.ErrOTPDisabled
+	}
+	cola.otp.mutex.Lock()
+	defer cola.otp.mutex.Unlock()
+	challenge, found := cola.otp.challenges[challengeID]
+	if !found {
+		return lms.ErrOTPChallengeDoesNotExist
+	}
+	return challenge.Verify(code, cola.now())
+}
+
+// useOTP checks the challenge the action is allowed by and marks it used while holding the mutex, so two actions
+// running at the same time can't both be allowed by it. The action releases it with releaseOTP when it fails. Nil is
+// returned without error when OTP verification is not enabled.
+func (cola *cola) useOTP(challengeID string, phone string, purpose domain.OTPPurpose) (domain.OTPChallenge, error) {
+	if cola.otp == nil {
+		return nil, nil
+	}
+	cola.otp.mutex.Lock()
+	defer cola.otp.mutex.Unlock()
+	challenge, found := cola.otp.challenges[challengeID]
+	if !found || !challenge.IsVerifiedFor(phone, purpose, cola.now()) {
+		return nil, lms.ErrOTPVerificationRequired
+	}
+	challenge.Use()
+	return challenge, nil
+}
+
+// releaseOTP lets the challenge returned by useOTP allow another attempt of the action which failed
+func (cola *cola) releaseOTP(challenge domain.OTPChallenge) {
+	if challenge == nil {
+		return
+	}
+	cola.otp.mutex.Lock()
+	defer cola.otp.mutex.Unlock()
+	challenge.Release()
+}
@@ -0,0 +1,80 @@
+package cola
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// ClientQuery is used by ClientRepo.Search for filtering and paginating clients. Empty fields match all clients.
+type ClientQuery struct {
+	NamePrefix    string
+	BirthDate     string
+	HasActiveLoan *bool
+	Overdue       *bool
+	// AsOf is the date for which overdue status is evaluated
+	AsOf time.Time
+	// After returns clients with KTP number greater than After
+	After string
+	// Before returns clients with KTP number lower than Before
+	Before string
+	Limit  int
+}
+
+// ClientPage is a result of ClientRepo.Search. Clients are ordered by KTP number.
+type ClientPage struct {
+	Clients     []domain.Client
+	HasNext     bool
+	HasPrevious bool
+}
+
+// Matches tells whether client satisfies all filters of the query. Pagination fields are not taken into account.
+func (query ClientQuery) Matches(client domain.Client) bool {
+	if !strings.HasPrefix(strings.ToLower(client.Name()), strings.ToLower(query.NamePrefix)) {
+		return false
+	}
+	if query.BirthDate != "" && client.BirthDate() != query.BirthDate {
+		return false
+	}
+	if query.HasActiveLoan != nil && client.HasActiveLoan() != *query.HasActiveLoan {
+		return false
+	}
+	if query.Overdue != nil && (client.DaysPastDue(query.AsOf) > 0) != *query.Overdue {
+		return false
+	}
+	return true
+}
+
+// SearchClients filters and paginates clients in memory. It can be used by ClientRepo implementations which are
+// not able to run the query in the database.
+func SearchClients(clients []domain.Client, query ClientQuery) ClientPage {
+	var matching []domain.Client
+	for _, client := range clients {
+		if query.Matches(client) {
+			matching = append(matching, client)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].KTPNumber() < matching[j].KTPNumber()
+	})
+	from, to := 0, len(matching)
+	if query.After != "" {
+		from = sort.Search(len(matching), func(i int) bool { return matching[i].KTPNumber() > query.After })
+	}
+	if query.Before != "" {
+		to = sort.Search(len(matching), func(i int) bool { return matching[i].KTPNumber() >= query.Before })
+	}
+	if query.Limit > 0 && to-from > query.Limit {
+		if query.Before != "" {
+			from = to - query.Limit
+		} else {
+			to = from + query.Limit
+		}
+	}
+	if from > to {
+		from = to
+	}
+	return ClientPage{Clients: matching[from:to], HasPrevious: from > 0, HasNext: to < len(matching)}
+}
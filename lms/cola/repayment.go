@@ -0,0 +1,125 @@
+package cola
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// Repay runs again when the client was saved by another use case, e.g. the end of day job, in the meantime
+func (cola *cola) Repay(ktpNumber string, amount lms.Money) error {
+	return retryWhenClientChanged(func() error {
+		client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+		if err != nil {
+			return fmt.Errorf("client %s is repaying %s: %v", ktpNumber, amount, err)
+		}
+		if !found {
+			return lms.ErrClientDoesNotExist
+		}
+		return cola.repay(client, amount, cola.now(), "")
+	})
+}
+
+// repay books the repayment, bankReference of the statement line it comes from is saved together with it, so the line
+// is never booked twice. Only money seen on a bank statement is kept as credit.
+func (cola *cola) repay(client domain.Client, amount lms.Money, paidAt time.Time, bankReference string) error {
+	repay := client.Repay
+	if cola.overpaymentMode == KeepOverpaymentAsCredit && bankReference != "" {
+		repay = client.RepayWithCredit
+	}
+	loan := loanToRepay(client)
+	if err := repay(amount, paidAt); err != nil {
+		return err
+	}
+	if bankReference != "" {
+		client.RecordBankReference(bankReference)
+	}
+	if err := cola.ClientRepo.Save(client); err != nil {
+		return fmt.Errorf("client %s is repaying %s: %w", client.KTPNumber(), amount, err)
+	}
+	if loan != nil {
+		cola.notify(client, domain.RepaymentReceivedEvent, repaymentReference(loan), notificationData{
+			LoanID:    loan.ID(),
+			Amount:    amount,
+			Remaining: loan.Remaining(),
+		})
+	}
+	return nil
+}
+
+// ktpNumberPattern and virtualAccountPattern find identifiers of clients in free text references of bank transfers
+var (
+	ktpNumberPattern      = regexp.MustCompile(`\b[0-9]{16}\b`)
+	virtualAccountPattern = regexp.MustCompile(`\b[0-9]{15}\b`)
+)
+
+func (cola *cola) Reconcile(lines []lms.StatementLine) (lms.ReconciliationReport, error) {
+	var report lms.ReconciliationReport
+	for _, line := range lines {
+		if line.BankReference == "" {
+			report.Unmatched = append(report.Unmatched, lms.ReconciledLine{StatementLine: line, Reason: lms.ErrBankReferenceMissing})
+			continue
+		}
+		client, found, err := cola.matchClient(line)
+		if err != nil {
+			return report, fmt.Errorf("reconciling statement line %s: %v", line.BankReference, err)
+		}
+		if !found {
+			report.Unmatched = append(report.Unmatched, lms.ReconciledLine{StatementLine: line, Reason: lms.ErrClientDoesNotExist})
+			continue
+		}
+		reconciled := lms.ReconciledLine{StatementLine: line, KTPNumber: client.KTPNumber()}
+		if client.HasBankReference(line.BankReference) {
+			reconciled.Reason = lms.ErrStatementLineAlreadyProcessed
+			report.Duplicates = append(report.Duplicates, reconciled)
+			continue
+		}
+		loan := loanToRepay(client)
+		if loan != nil && !line.Amount.SameCurrency(loan.Remaining()) {
+			reconciled.Reason = domain.ErrCurrencyMismatch
+			report.Unmatched = append(report.Unmatched, reconciled)
+			continue
+		}
+		reconciled.Recovery = loan != nil && loan.IsWrittenOff()
+		overpaid := loan == nil || line.Amount.Cmp(loan.Remaining()) > 0
+		if overpaid && cola.overpaymentMode == RejectOverpayment {
+			reconciled.Reason = domain.ErrRepaymentAmountTooHigh
+			report.Overpaid = append(report.Overpaid, reconciled)
+			continue
+		}
+		if err = cola.repay(client, line.Amount, line.Date, line.BankReference); err != nil {
+			return report, fmt.Errorf("reconciling statement line %s: %v", line.BankReference, err)
+		}
+		reconciled.Applied = true
+		if overpaid {
+			report.Overpaid = append(report.Overpaid, reconciled)
+		} else {
+			report.Matched = append(report.Matched, reconciled)
+		}
+	}
+	return report, nil
+}
+
+// matchClient looks for virtual account or KTP number of an existing client in payment reference. Virtual account
+// is preferred, because it has a check digit.
+func (cola *cola) matchClient(line lms.StatementLine) (client domain.Client, found bool, err error) {
+	for _, number := range virtualAccountPattern.FindAllString(line.Reference, -1) {
+		if !domain.IsValidVirtualAccount(number) {
+			continue
+		}
+		client, found, err = cola.ClientRepo.ByVirtualAccount(number)
+		if err != nil || found {
+			return
+		}
+	}
+	for _, ktpNumber := range ktpNumberPattern.FindAllString(line.Reference, -1) {
+		client, found, err = cola.ClientRepo.ByKTPNumber(ktpNumber)
+		if err != nil || found {
+			return
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,33 @@
+package cola
+
+import (
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// WithScorecards scores clients applying for loans. The first scorecard applying to the product is used, its score band
+// sets maximum amount and price of the loan. Products without scorecard are not scored.
+func WithScorecards(scorecards ...domain.Scorecard) Option {
+	return func(cola *cola) {
+		cola.scorecards = scorecards
+	}
+}
+
+// score returns the product adjusted by score band of the client, it is the product itself when no scorecard applies.
+// Score below all bands is returned as domain.ScoreTooLowStruct. The score is returned with the error too, so that
+// the rejected application keeps it. Applications are the earlier applications of the client.
+func (cola *cola) score(client domain.Client, applications []domain.LoanApplication, product domain.Product, asOf time.Time) (domain.Product, *domain.Score, error) {
+	for _, scorecard := range cola.scorecards {
+		if !scorecard.AppliesTo(product) {
+			continue
+		}
+		score := scorecard.Score(client.ScoringAttributes(asOf, applications), asOf)
+		banded, err := scorecard.Apply(product, score)
+		if err != nil {
+			return nil, &score, err
+		}
+		return banded, &score, nil
+	}
+	return product, nil, nil
+}
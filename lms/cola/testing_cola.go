@@ -1,9 +1,14 @@
 package cola
 
-import "github.com/briyanadityatama/goLoans/lms/cola/domain"
+import (
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
 
 type fakeClientRepo struct {
-	clientsByKTPNumber map[string]domain.Client
+	clientsByKTPNumber     map[string]domain.Client
+	virtualAccountSequence uint64
 }
 
 // NewFakeClientRepo returns ClientRepo fake implementation storing everything in memory which is useful for testing lms.Lms without real database
@@ -21,3 +26,344 @@ func (repo *fakeClientRepo) Save(client domain.Client) error {
 	repo.clientsByKTPNumber[ktpNumber] = client
 	return nil
 }
+
+func (repo *fakeClientRepo) Search(query ClientQuery) (ClientPage, error) {
+	var clients []domain.Client
+	for _, client := range repo.clientsByKTPNumber {
+		clients = append(clients, client)
+	}
+	return SearchClients(clients, query), nil
+}
+
+// FakeDisburser records transfers instead of sending money. Err is returned by every Disburse call when set.
+type FakeDisburser struct {
+	Transfers []Transfer
+	Err       error
+}
+
+// NewFakeDisburser returns Disburser fake implementation which is useful for testing lms.Lms without real bank
+func NewFakeDisburser() *FakeDisburser {
+	return &FakeDisburser{}
+}
+
+// Disburse records the transfer and returns its idempotency key as reference
+func (disburser *FakeDisburser) Disburse(transfer Transfer) (string, error) {
+	disburser.Transfers = append(disburser.Transfers, transfer)
+	return transfer.IdempotencyKey, disburser.Err
+}
+
+// FakeExchangeRates returns the same rate for a currency pair regardless of date
+type FakeExchangeRates []domain.ExchangeRate
+
+// Rate returns the first rate of the currency pair
+func (rates FakeExchangeRates) Rate(from, to domain.Currency, asOf time.Time) (domain.ExchangeRate, error) {
+	for _, rate := range rates {
+		if rate.From == from && rate.To == to {
+			return rate, nil
+		}
+	}
+	return domain.ExchangeRate{}, ErrExchangeRateNotFound
+}
+
+func (repo *fakeClientRepo) ByVirtualAccount(number string) (domain.Client, bool, error) {
+	for _, client := range repo.clientsByKTPNumber {
+		if client.VirtualAccount() == number {
+			return client, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (repo *fakeClientRepo) NextVirtualAccountSequence() (uint64, error) {
+	repo.virtualAccountSequence++
+	return repo.virtualAccountSequence, nil
+}
+
+// FakeNotifier records notices instead of sending them. Err is returned by every Notify call when set.
+type FakeNotifier struct {
+	Notices []Notice
+	Err     error
+}
+
+// Notify records the notice
+func (notifier *FakeNotifier) Notify(notice Notice) error {
+	notifier.Notices = append(notifier.Notices, notice)
+	return notifier.Err
+}
+
+// FakeCreditBureau returns reports by KTP number and counts requests, client without report has no debts. Err is
+// returned by every Report call when set.
+type FakeCreditBureau struct {
+	Reports  map[string]domain.CreditBureauReport
+	Requests int
+	Err      error
+}
+
+// Report returns the report of the client or Err
+func (bureau *FakeCreditBureau) Report(ktpNumber string) (domain.CreditBureauReport, error) {
+	bureau.Requests++
+	if bureau.Err != nil {
+		return domain.CreditBureauReport{}, bureau.Err
+	}
+	return bureau.Reports[ktpNumber], nil
+}
+
+type fakeApplicationRepo struct {
+	applications        []domain.LoanApplication
+	applicationSequence uint64
+}
+
+// NewFakeApplicationRepo returns ApplicationRepo fake implementation storing everything in memory which is useful for testing lms.Lms without real database
+func NewFakeApplicationRepo() ApplicationRepo {
+	return &fakeApplicationRepo{}
+}
+
+func (repo *fakeApplicationRepo) ByID(id string) (domain.LoanApplication, bool, error) {
+	for _, application := range repo.applications {
+		if application.ID() == id {
+			return application, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (repo *fakeApplicationRepo) ByKTPNumber(ktpNumber string) ([]domain.LoanApplication, error) {
+	var applications []domain.LoanApplication
+	for _, application := range repo.applications {
+		if application.KTPNumber() == ktpNumber {
+			applications = append(applications, application)
+		}
+	}
+	return applications, nil
+}
+
+func (repo *fakeApplicationRepo) ByStatus(status domain.ApplicationStatus) ([]domain.LoanApplication, error) {
+	var applications []domain.LoanApplication
+	for _, application := range repo.applications {
+		if application.Status() == status {
+			applications = append(applications, application)
+		}
+	}
+	return applications, nil
+}
+
+func (repo *fakeApplicationRepo) NextApplicationSequence() (uint64, error) {
+	repo.applicationSequence++
+	return repo.applicationSequence, nil
+}
+
+func (repo *fakeApplicationRepo) Save(application domain.LoanApplication) error {
+	for i, saved := range repo.applications {
+		if saved.ID() == application.ID() {
+			repo.applications[i] = application
+			return nil
+		}
+	}
+	repo.applications = append(repo.applications, application)
+	return nil
+}
+
+// FakeSMSSender records messages instead of sending them. Err is returned by every Send call when set.
+type FakeSMSSender struct {
+	Messages []SMS
+	Err      error
+}
+
+// SMS is a text message recorded by FakeSMSSender
+type SMS struct {
+	Phone   string
+	Message string
+}
+
+// Send records the message
+func (sender *FakeSMSSender) Send(phone string, message string) error {
+	if sender.Err != nil {
+		return sender.Err
+	}
+	sender.Messages = append(sender.Messages, SMS{Phone: phone, Message: message})
+	return nil
+}
+
+type fakeNotificationRepo struct {
+	notifications        []domain.Notification
+	notificationSequence uint64
+}
+
+// NewFakeNotificationRepo returns NotificationRepo fake implementation storing everything in memory which is useful for testing lms.Lms without real database
+func NewFakeNotificationRepo() NotificationRepo {
+	return &fakeNotificationRepo{}
+}
+
+func (repo *fakeNotificationRepo) ByKTPNumber(ktpNumber string) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	for _, notification := range repo.notifications {
+		if notification.KTPNumber() == ktpNumber {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications, nil
+}
+
+func (repo *fakeNotificationRepo) ByStatus(status domain.DeliveryStatus) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	for _, notification := range repo.notifications {
+		if notification.Status() == status {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications, nil
+}
+
+func (repo *fakeNotificationRepo) NextNotificationSequence() (uint64, error) {
+	repo.notificationSequence++
+	return repo.notificationSequence, nil
+}
+
+func (repo *fakeNotificationRepo) Save(notification domain.Notification) error {
+	for i, saved := range repo.notifications {
+		if saved.ID() == notification.ID() {
+			repo.notifications[i] = notification
+			return nil
+		}
+	}
+	repo.notifications = append(repo.notifications, notification)
+	return nil
+}
+
+// FakeChannel records messages instead of delivering them. Err is returned by every Deliver call when set.
+type FakeChannel struct {
+	Channel  domain.NotificationChannel
+	Messages []Message
+	Err      error
+}
+
+// Kind returns Channel
+func (channel *FakeChannel) Kind() domain.NotificationChannel {
+	return channel.Channel
+}
+
+// Deliver records the message
+func (channel *FakeChannel) Deliver(message Message) error {
+	if channel.Err != nil {
+		return channel.Err
+	}
+	channel.Messages = append(channel.Messages, message)
+	return nil
+}
+
+type fakeWebhookRepo struct {
+	subscriptions        []domain.WebhookSubscription
+	subscriptionSequence uint64
+	deliveries           []domain.WebhookDelivery
+	deliverySequence     uint64
+}
+
+// NewFakeWebhookRepo returns WebhookRepo fake implementation storing everything in memory which is useful for testing lms.Lms without real database
+func NewFakeWebhookRepo() WebhookRepo {
+	return &fakeWebhookRepo{}
+}
+
+func (repo *fakeWebhookRepo) Subscriptions() ([]domain.WebhookSubscription, error) {
+	return repo.subscriptions, nil
+}
+
+func (repo *fakeWebhookRepo) SubscriptionByID(id string) (domain.WebhookSubscription, bool, error) {
+	for _, subscription := range repo.subscriptions {
+		if subscription.ID() == id {
+			return subscription, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (repo *fakeWebhookRepo) NextSubscriptionSequence() (uint64, error) {
+	repo.subscriptionSequence++
+	return repo.subscriptionSequence, nil
+}
+
+func (repo *fakeWebhookRepo) SaveSubscription(subscription domain.WebhookSubscription) error {
+	for i, saved := range repo.subscriptions {
+		if saved.ID() == subscription.ID() {
+			repo.subscriptions[i] = subscription
+			return nil
+		}
+	}
+	repo.subscriptions = append(repo.subscriptions, subscription)
+	return nil
+}
+
+func (repo *fakeWebhookRepo) DeleteSubscription(id string) error {
+	var subscriptions []domain.WebhookSubscription
+	for _, subscription := range repo.subscriptions {
+		if subscription.ID() != id {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	repo.subscriptions = subscriptions
+	return nil
+}
+
+func (repo *fakeWebhookRepo) DeliveryByID(id string) (domain.WebhookDelivery, bool, error) {
+	for _, delivery := range repo.deliveries {
+		if delivery.ID() == id {
+			return delivery, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (repo *fakeWebhookRepo) DeliveriesOfSubscription(subscriptionID string) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range repo.deliveries {
+		if delivery.SubscriptionID() == subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (repo *fakeWebhookRepo) DeliveriesByStatus(status domain.DeliveryStatus) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range repo.deliveries {
+		if delivery.Status() == status {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (repo *fakeWebhookRepo) NextDeliverySequence() (uint64, error) {
+	repo.deliverySequence++
+	return repo.deliverySequence, nil
+}
+
+func (repo *fakeWebhookRepo) SaveDelivery(delivery domain.WebhookDelivery) error {
+	for i, saved := range repo.deliveries {
+		if saved.ID() == delivery.ID() {
+			repo.deliveries[i] = delivery
+			return nil
+		}
+	}
+	repo.deliveries = append(repo.deliveries, delivery)
+	return nil
+}
+
+// FakeWebhookPoster records requests instead of posting them. StatusCode and Err are returned by every Post call,
+// zero StatusCode means 200.
+type FakeWebhookPoster struct {
+	Requests   []WebhookRequest
+	StatusCode int
+	Err        error
+}
+
+// Post records the request
+func (poster *FakeWebhookPoster) Post(request WebhookRequest) (int, error) {
+	poster.Requests = append(poster.Requests, request)
+	if poster.Err != nil {
+		return 0, poster.Err
+	}
+	if poster.StatusCode == 0 {
+		return 200, nil
+	}
+	return poster.StatusCode, nil
+}
@@ -0,0 +1,397 @@
+package cola
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// WebhookRepo is used internally by lms package for loading/storing webhook subscriptions and logs of their deliveries
+type WebhookRepo interface {
+	// Subscriptions lists subscriptions in the order they were created
+	Subscriptions() ([]domain.WebhookSubscription, error)
+	SubscriptionByID(id string) (subscription domain.WebhookSubscription, found bool, err error)
+	// NextSubscriptionSequence never returns the same sequence twice
+	NextSubscriptionSequence() (uint64, error)
+	SaveSubscription(subscription domain.WebhookSubscription) error
+	DeleteSubscription(id string) error
+	DeliveryByID(id string) (delivery domain.WebhookDelivery, found bool, err error)
+	// DeliveriesOfSubscription lists deliveries to the subscription in the order they were created
+	DeliveriesOfSubscription(subscriptionID string) ([]domain.WebhookDelivery, error)
+	// DeliveriesByStatus lists deliveries in the status in the order they were created
+	DeliveriesByStatus(status domain.DeliveryStatus) ([]domain.WebhookDelivery, error)
+	// NextDeliverySequence never returns the same sequence twice
+	NextDeliverySequence() (uint64, error)
+	SaveDelivery(delivery domain.WebhookDelivery) error
+}
+
+// WebhookPoster posts signed payloads to subscribers. The status code is zero when the subscriber did not respond.
+type WebhookPoster interface {
+	Post(request WebhookRequest) (statusCode int, err error)
+}
+
+// WebhookRequest is a signed payload posted to WebhookPoster
+type WebhookRequest struct {
+	URL        string
+	DeliveryID string
+	EventType  domain.WebhookEventType
+	// Timestamp is Unix time of the attempt in seconds, it is signed together with the payload
+	Timestamp string
+	// Signature is hex encoded HMAC-SHA256 of the timestamp and the payload made by domain.SignWebhook
+	Signature string
+	Payload   []byte
+}
+
+// WithWebhooks posts client registrations and every change of loan state to subscribed partners. Without it webhooks
+// can't be subscribed to.
+func WithWebhooks(repo WebhookRepo, poster WebhookPoster) Option {
+	return func(cola *cola) {
+		cola.webhooks = &webhooks{repo: repo, poster: poster}
+		cola.ClientRepo = &publishingClientRepo{ClientRepo: cola.ClientRepo, cola: cola}
+	}
+}
+
+type webhooks struct {
+	repo   WebhookRepo
+	poster WebhookPoster
+}
+
+// webhookEvent is the payload posted to subscribers
+type webhookEvent struct {
+	ID        string                  `json:"id"`
+	Type      domain.WebhookEventType `json:"type"`
+	CreatedAt time.Time               `json:"createdAt"`
+	Data      interface{}             `json:"data"`
+}
+
+// clientRegisteredData holds no personal data beyond the KTP number, payloads are kept in the delivery log after
+// the client is erased
+type clientRegisteredData struct {
+	KTPNumber      string `json:"ktpNumber"`
+	VirtualAccount string `json:"virtualAccount"`
+}
+
+type loanStateChangedData struct {
+	KTPNumber string `json:"ktpNumber"`
+	LoanID    string `json:"loanId"`
+	State     string `json:"state"`
+	// PreviousState is empty for a new loan
+	PreviousState string       `json:"previousState,omitempty"`
+	Amount        domain.Money `json:"amount"`
+	Remaining     domain.Money `json:"remaining"`
+}
+
+// publishingClientRepo publishes loan.state_changed for every change of a loan state the client recorded once the
+// client is saved, so use cases don't have to publish changes
+type publishingClientRepo struct {
+	ClientRepo
+	cola *cola
+}
+
+func (repo *publishingClientRepo) Save(client domain.Client) error {
+	if err := repo.ClientRepo.Save(client); err != nil {
+		return err
+	}
+	for _, change := range client.LoanStateChanges() {
+		repo.cola.publish(domain.LoanStateChangedWebhook, loanStateChangedData{
+			KTPNumber:     client.KTPNumber(),
+			LoanID:        change.LoanID,
+			State:         change.State,
+			PreviousState: change.PreviousState,
+			Amount:        change.Amount,
+			Remaining:     change.Remaining,
+		})
+	}
+	client.ClearLoanStateChanges()
+	return nil
+}
+
+// publish creates a pending delivery of the event for every subscription, so saving a client never waits for partners.
+// Deliveries are posted by RetryWebhookDeliveries. Failures are logged.
+func (cola *cola) publish(eventType domain.WebhookEventType, data interface{}) {
+	if cola.webhooks == nil {
+		return
+	}
+	if err := cola.webhooks.publish(eventType, data, cola.now()); err != nil {
+		log.Printf("[WARN] problem publishing %s webhook: %v", eventType, err)
+	}
+}
+
+func (webhooks *webhooks) publish(eventType domain.WebhookEventType, data interface{}, now time.Time) error {
+	subscriptions, err := webhooks.repo.Subscriptions()
+	if err != nil {
+		return err
+	}
+	eventID, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(webhookEvent{ID: eventID, Type: eventType, CreatedAt: now, Data: data})
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(eventType) {
+			continue
+		}
+		sequence, err := webhooks.repo.NextDeliverySequence()
+		if err != nil {
+			return err
+		}
+		delivery := domain.NewWebhookDelivery(sequence, subscription.ID(), eventID, eventType, payload, now, "")
+		if err = webhooks.repo.SaveDelivery(delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt posts the signed payload and saves the outcome, only an error of the repository is returned
+func (webhooks *webhooks) attempt(subscription domain.WebhookSubscription, delivery domain.WebhookDelivery, now time.Time) error {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	statusCode, err := webhooks.poster.Post(WebhookRequest{
+		URL:        subscription.URL(),
+		DeliveryID: delivery.ID(),
+		EventType:  delivery.EventType(),
+		Timestamp:  timestamp,
+		Signature:  domain.SignWebhook(subscription.Secret(), timestamp, delivery.Payload()),
+		Payload:    delivery.Payload(),
+	})
+	attempt := domain.WebhookAttempt{At: now, StatusCode: statusCode}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if err = delivery.RecordAttempt(attempt); err != nil {
+		return err
+	}
+	return webhooks.repo.SaveDelivery(delivery)
+}
+
+func (cola *cola) SubscribeWebhook(data lms.WebhookSubscriptionData) (lms.WebhookSubscription, error) {
+	if cola.webhooks == nil {
+		return lms.WebhookSubscription{}, lms.ErrWebhooksDisabled
+	}
+	secret := data.Secret
+	if secret == "" {
+		generated, err := randomHex(domain.WebhookMinSecretLength)
+		if err != nil {
+			return lms.WebhookSubscription{}, fmt.Errorf("subscribing webhook %s: %v", data.URL, err)
+		}
+		secret = generated
+	}
+	var eventTypes []domain.WebhookEventType
+	for _, eventType := range data.EventTypes {
+		eventTypes = append(eventTypes, domain.WebhookEventType(eventType))
+	}
+	sequence, err := cola.webhooks.repo.NextSubscriptionSequence()
+	if err != nil {
+		return lms.WebhookSubscription{}, fmt.Errorf("subscribing webhook %s: %v", data.URL, err)
+	}
+	subscription, err := domain.NewWebhookSubscription(sequence, data.URL, eventTypes, secret, cola.now())
+	if err != nil {
+		return lms.WebhookSubscription{}, err
+	}
+	if err = cola.webhooks.repo.SaveSubscription(subscription); err != nil {
+		return lms.WebhookSubscription{}, fmt.Errorf("subscribing webhook %s: %v", data.URL, err)
+	}
+	return webhookSubscriptionDto(subscription), nil
+}
+
+func (cola *cola) WebhookSubscriptions() ([]lms.WebhookSubscription, error) {
+	if cola.webhooks == nil {
+		return nil, lms.ErrWebhooksDisabled
+	}
+	subscriptions, err := cola.webhooks.repo.Subscriptions()
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook subscriptions: %v", err)
+	}
+	dtos := []lms.WebhookSubscription{}
+	for _, subscription := range subscriptions {
+		dtos = append(dtos, webhookSubscriptionDto(subscription))
+	}
+	return dtos, nil
+}
+
+func (cola *cola) WebhookSubscriptionByID(id string) (lms.WebhookSubscription, bool, error) {
+	if cola.webhooks == nil {
+		return lms.WebhookSubscription{}, false, lms.ErrWebhooksDisabled
+	}
+	subscription, found, err := cola.webhooks.repo.SubscriptionByID(id)
+	if err != nil {
+		return lms.WebhookSubscription{}, false, fmt.Errorf("loading webhook subscription %s: %v", id, err)
+	}
+	if !found {
+		return lms.WebhookSubscription{}, false, nil
+	}
+	return webhookSubscriptionDto(subscription), true, nil
+}
+
+func (cola *cola) UnsubscribeWebhook(id string) error {
+	if cola.webhooks == nil {
+		return lms.ErrWebhooksDisabled
+	}
+	_, found, err := cola.webhooks.repo.SubscriptionByID(id)
+	if err != nil {
+		return fmt.Errorf("unsubscribing webhook %s: %v", id, err)
+	}
+	if !found {
+		return lms.ErrWebhookSubscriptionDoesNotExist
+	}
+	deliveries, err := cola.webhooks.repo.DeliveriesOfSubscription(id)
+	if err != nil {
+		return fmt.Errorf("unsubscribing webhook %s: %v", id, err)
+	}
+	for _, delivery := range deliveries {
+		if delivery.Status() != domain.DeliveryPending {
+			continue
+		}
+		if err = delivery.Abandon("unsubscribed", cola.now()); err != nil {
+			return err
+		}
+		if err = cola.webhooks.repo.SaveDelivery(delivery); err != nil {
+			return fmt.Errorf("unsubscribing webhook %s: %v", id, err)
+		}
+	}
+	if err = cola.webhooks.repo.DeleteSubscription(id); err != nil {
+		return fmt.Errorf("unsubscribing webhook %s: %v", id, err)
+	}
+	return nil
+}
+
+func (cola *cola) WebhookDeliveries(subscriptionID string) ([]lms.WebhookDelivery, error) {
+	if cola.webhooks == nil {
+		return nil, lms.ErrWebhooksDisabled
+	}
+	_, found, err := cola.webhooks.repo.SubscriptionByID(subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("listing deliveries of webhook %s: %v", subscriptionID, err)
+	}
+	if !found {
+		return nil, lms.ErrWebhookSubscriptionDoesNotExist
+	}
+	deliveries, err := cola.webhooks.repo.DeliveriesOfSubscription(subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("listing deliveries of webhook %s: %v", subscriptionID, err)
+	}
+	dtos := []lms.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		dtos = append(dtos, webhookDeliveryDto(delivery))
+	}
+	return dtos, nil
+}
+
+func (cola *cola) ReplayWebhookDelivery(id string) (lms.WebhookDelivery, error) {
+	if cola.webhooks == nil {
+		return lms.WebhookDelivery{}, lms.ErrWebhooksDisabled
+	}
+	replayed, found, err := cola.webhooks.repo.DeliveryByID(id)
+	if err != nil {
+		return lms.WebhookDelivery{}, fmt.Errorf("replaying webhook delivery %s: %v", id, err)
+	}
+	if !found {
+		return lms.WebhookDelivery{}, lms.ErrWebhookDeliveryDoesNotExist
+	}
+	subscription, found, err := cola.webhooks.repo.SubscriptionByID(replayed.SubscriptionID())
+	if err != nil {
+		return lms.WebhookDelivery{}, fmt.Errorf("replaying webhook delivery %s: %v", id, err)
+	}
+	if !found {
+		return lms.WebhookDelivery{}, lms.ErrWebhookSubscriptionDoesNotExist
+	}
+	sequence, err := cola.webhooks.repo.NextDeliverySequence()
+	if err != nil {
+		return lms.WebhookDelivery{}, fmt.Errorf("replaying webhook delivery %s: %v", id, err)
+	}
+	now := cola.now()
+	delivery := domain.NewWebhookDelivery(sequence, subscription.ID(), replayed.EventID(), replayed.EventType(), replayed.Payload(), now, replayed.ID())
+	if err = cola.webhooks.attempt(subscription, delivery, now); err != nil {
+		return lms.WebhookDelivery{}, fmt.Errorf("replaying webhook delivery %s: %v", id, err)
+	}
+	return webhookDeliveryDto(delivery), nil
+}
+
+func (cola *cola) RetryWebhookDeliveries(asOf time.Time) (lms.WebhookRun, error) {
+	run := lms.WebhookRun{AsOf: asOf}
+	if cola.webhooks == nil {
+		return run, nil
+	}
+	pending, err := cola.webhooks.repo.DeliveriesByStatus(domain.DeliveryPending)
+	if err != nil {
+		return run, fmt.Errorf("retrying webhook deliveries as of %s: %v", asOf.Format(time.RFC3339), err)
+	}
+	for _, delivery := range pending {
+		if !delivery.IsDue(asOf) {
+			continue
+		}
+		subscription, found, err := cola.webhooks.repo.SubscriptionByID(delivery.SubscriptionID())
+		if err != nil {
+			return run, fmt.Errorf("retrying webhook delivery %s: %v", delivery.ID(), err)
+		}
+		if found {
+			err = cola.webhooks.attempt(subscription, delivery, asOf)
+		} else if err = delivery.Abandon("unsubscribed", asOf); err == nil {
+			err = cola.webhooks.repo.SaveDelivery(delivery)
+		}
+		if err != nil {
+			return run, fmt.Errorf("retrying webhook delivery %s: %v", delivery.ID(), err)
+		}
+		switch delivery.Status() {
+		case domain.DeliveryDelivered:
+			run.Delivered++
+		case domain.DeliveryFailed:
+			run.Failed++
+		default:
+			run.Pending++
+		}
+	}
+	return run, nil
+}
+
+func webhookSubscriptionDto(subscription domain.WebhookSubscription) lms.WebhookSubscription {
+	var eventTypes []string
+	for _, eventType := range subscription.EventTypes() {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	return lms.WebhookSubscription{
+		ID:         subscription.ID(),
+		URL:        subscription.URL(),
+		EventTypes: eventTypes,
+		Secret:     subscription.Secret(),
+		CreatedAt:  subscription.CreatedAt(),
+	}
+}
+
+func webhookDeliveryDto(delivery domain.WebhookDelivery) lms.WebhookDelivery {
+	attempts := []lms.WebhookAttempt{}
+	for _, attempt := range delivery.Attempts() {
+		attempts = append(attempts, lms.WebhookAttempt{At: attempt.At, StatusCode: attempt.StatusCode, Error: attempt.Error})
+	}
+	return lms.WebhookDelivery{
+		ID:             delivery.ID(),
+		SubscriptionID: delivery.SubscriptionID(),
+		EventID:        delivery.EventID(),
+		EventType:      string(delivery.EventType()),
+		Payload:        string(delivery.Payload()),
+		CreatedAt:      delivery.CreatedAt(),
+		ReplayOf:       delivery.ReplayOf(),
+		Status:         string(delivery.Status()),
+		Attempts:       attempts,
+		NextAttemptAt:  delivery.NextAttemptAt(),
+	}
+}
+
+// randomHex returns twice as many hex digits as there are random bytes
+func randomHex(bytes int) (string, error) {
+	random := make([]byte, bytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
@@ -0,0 +1,59 @@
+package cola
+
+import (
+	"fmt"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// WithOfficers lets the officers with the given IDs run back-office use cases, e.g. review and write-off of loans.
+// Without it every officer is refused.
+func WithOfficers(officers ...string) Option {
+	return func(cola *cola) {
+		cola.officers = make(map[string]bool, len(officers))
+		for _, officer := range officers {
+			cola.officers[officer] = true
+		}
+	}
+}
+
+// AuthorizeOfficer returns error unless the officer was configured by WithOfficers
+func (cola *cola) AuthorizeOfficer(officer string) error {
+	if officer == "" {
+		return lms.ErrOfficerRequired
+	}
+	if !cola.officers[officer] {
+		return lms.ErrOfficerNotAuthorized
+	}
+	return nil
+}
+
+func (cola *cola) WriteOffLoan(ktpNumber string, officer string) error {
+	if err := cola.AuthorizeOfficer(officer); err != nil {
+		return err
+	}
+	client, found, err := cola.ClientRepo.ByKTPNumber(ktpNumber)
+	if err != nil {
+		return fmt.Errorf("officer %s is writing off loan of client %s: %v", officer, ktpNumber, err)
+	}
+	if !found {
+		return lms.ErrClientDoesNotExist
+	}
+	if err = client.WriteOff(officer, cola.now()); err != nil {
+		return err
+	}
+	if err = cola.ClientRepo.Save(client); err != nil {
+		return fmt.Errorf("officer %s is writing off loan of client %s: %v", officer, ktpNumber, err)
+	}
+	return nil
+}
+
+// loanToRepay returns the loan money received from the client is applied to, it is either the active loan or
+// the written-off loan which is being recovered
+func loanToRepay(client domain.Client) domain.Loan {
+	if client.HasActiveLoan() {
+		return client.ActiveLoan()
+	}
+	return client.WrittenOffLoan()
+}
@@ -0,0 +1,165 @@
+package lms
+
+import "time"
+
+// ClientDataExport is everything the system holds about a client. It is a data transfer object DTO which can be
+// handed over to the client as JSON bundle.
+type ClientDataExport struct {
+	KTPNumber      string                `json:"ktpNumber"`
+	Profile        ProfileExport         `json:"profile"`
+	Loans          []LoanExport          `json:"loans"`
+	Applications   []ApplicationExport   `json:"applications"`
+	ProfileChanges []ProfileChangeExport `json:"profileChanges"`
+	CreditBalance  Money                 `json:"creditBalance"`
+	Refunds        []RefundExport        `json:"refunds"`
+	Notifications  []NotificationExport  `json:"notifications"`
+	Erased         bool                  `json:"erased"`
+	ExportedAt     time.Time             `json:"exportedAt"`
+}
+
+// ProfileExport is a part of ClientDataExport
+type ProfileExport struct {
+	Gender            string `json:"gender"`
+	BirthDate         string `json:"birthDate"`
+	Name              string `json:"name"`
+	Phone             string `json:"phone"`
+	Email             string `json:"email"`
+	Address           string `json:"address"`
+	BankCode          string `json:"bankCode"`
+	BankAccountNumber string `json:"bankAccountNumber"`
+	BankAccountHolder string `json:"bankAccountHolder"`
+}
+
+// LoanExport is a part of ClientDataExport
+type LoanExport struct {
+	Product          string            `json:"product"`
+	Amount           Money             `json:"amount"`
+	Term             uint              `json:"term"`
+	Fee              Money             `json:"fee"`
+	Interest         Money             `json:"interest"`
+	TotalPayable     Money             `json:"totalPayable"`
+	LateCharges      Money             `json:"lateCharges"`
+	Remaining        Money             `json:"remaining"`
+	StartDate        time.Time         `json:"startDate"`
+	DueDate          time.Time         `json:"dueDate"`
+	Repayments       []RepaymentExport `json:"repayments"`
+	Accruals         []AccrualExport   `json:"accruals"`
+	DunningStage     string            `json:"dunningStage"`
+	Defaulted        bool              `json:"defaulted"`
+	WrittenOff       bool              `json:"writtenOff"`
+	WrittenOffAmount Money             `json:"writtenOffAmount"`
+	Recovered        Money             `json:"recovered"`
+	// Score is missing for loans approved without scorecard
+	Score *ScoreExport `json:"score,omitempty"`
+	// Affordability is missing for loans applied for before income was declared
+	Affordability *AffordabilityExport `json:"affordability,omitempty"`
+	// CreditBureauReport is missing when the credit bureau was not consulted or did not answer
+	CreditBureauReport *CreditBureauReportExport `json:"creditBureauReport,omitempty"`
+	Status             string                    `json:"status"`
+	ReviewReason       string                    `json:"reviewReason,omitempty"`
+	ReviewedBy         string                    `json:"reviewedBy,omitempty"`
+	// Agreement is missing for loans disbursed without accepting an agreement
+	Agreement *AgreementExport `json:"agreement,omitempty"`
+}
+
+// AgreementExport is a part of ClientDataExport
+type AgreementExport struct {
+	Hash         string     `json:"hash,omitempty"`
+	AcceptedFrom string     `json:"acceptedFrom,omitempty"`
+	AcceptedAt   *time.Time `json:"acceptedAt,omitempty"`
+}
+
+// ApplicationExport is a part of ClientDataExport
+type ApplicationExport struct {
+	ID            string              `json:"id"`
+	Product       string              `json:"product"`
+	Amount        Money               `json:"amount"`
+	Term          uint                `json:"term"`
+	Affordability AffordabilityExport `json:"affordability"`
+	Status        string              `json:"status"`
+	Reason        string              `json:"reason,omitempty"`
+	LoanID        string              `json:"loanId,omitempty"`
+	SubmittedAt   time.Time           `json:"submittedAt"`
+	DecidedAt     *time.Time          `json:"decidedAt,omitempty"`
+	Channel       string              `json:"channel,omitempty"`
+	IPAddress     string              `json:"ipAddress,omitempty"`
+	Score         *ScoreExport        `json:"score,omitempty"`
+	// CreditBureauReport is the report the application was assessed with, also when it was rejected for it
+	CreditBureauReport *CreditBureauReportExport `json:"creditBureauReport,omitempty"`
+}
+
+// AffordabilityExport is a part of ClientDataExport
+type AffordabilityExport struct {
+	MonthlyIncome      Money  `json:"monthlyIncome"`
+	EmploymentType     string `json:"employmentType"`
+	MonthlyObligations Money  `json:"monthlyObligations"`
+}
+
+// CreditBureauReportExport is a part of ClientDataExport
+type CreditBureauReportExport struct {
+	Debts      []BureauDebtExport `json:"debts"`
+	ReportedAt time.Time          `json:"reportedAt"`
+}
+
+// BureauDebtExport is a part of ClientDataExport
+type BureauDebtExport struct {
+	Lender      string `json:"lender"`
+	Outstanding Money  `json:"outstanding"`
+	DaysPastDue uint   `json:"daysPastDue"`
+}
+
+// ScoreExport is a part of ClientDataExport
+type ScoreExport struct {
+	Scorecard string    `json:"scorecard"`
+	Points    int       `json:"points"`
+	Band      string    `json:"band"`
+	Reasons   []string  `json:"reasons"`
+	ScoredAt  time.Time `json:"scoredAt"`
+}
+
+// RepaymentExport is a part of ClientDataExport
+type RepaymentExport struct {
+	Amount     Money     `json:"amount"`
+	PaidAt     time.Time `json:"paidAt"`
+	FromCredit bool      `json:"fromCredit"`
+	Recovery   bool      `json:"recovery"`
+}
+
+// AccrualExport is a part of ClientDataExport
+type AccrualExport struct {
+	Date   time.Time `json:"date"`
+	Kind   string    `json:"kind"`
+	Base   Money     `json:"base"`
+	Amount Money     `json:"amount"`
+}
+
+// RefundExport is a part of ClientDataExport
+type RefundExport struct {
+	ID          string    `json:"id"`
+	Amount      Money     `json:"amount"`
+	RequestedAt time.Time `json:"requestedAt"`
+	Status      string    `json:"status"`
+	Reference   string    `json:"reference"`
+}
+
+// NotificationExport is a part of ClientDataExport
+type NotificationExport struct {
+	ID          string     `json:"id"`
+	Event       string     `json:"event"`
+	Reference   string     `json:"reference"`
+	Channel     string     `json:"channel"`
+	Recipient   string     `json:"recipient,omitempty"`
+	Subject     string     `json:"subject"`
+	Body        string     `json:"body"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	Status      string     `json:"status"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// ProfileChangeExport is a part of ClientDataExport
+type ProfileChangeExport struct {
+	Field     string    `json:"field"`
+	OldValue  string    `json:"oldValue"`
+	NewValue  string    `json:"newValue"`
+	ChangedAt time.Time `json:"changedAt"`
+}
@@ -0,0 +1,27 @@
+package lms
+
+// TrialBalance lists balances of all ledger accounts and is used as data transfer object DTO. Total debits equal total
+// credits in every currency.
+type TrialBalance struct {
+	Lines []TrialBalanceLine
+	// Mismatches are loans and credit balances whose ledger balance differs from the amount held by the client, empty
+	// when the ledger is consistent
+	Mismatches []LedgerMismatch
+}
+
+// TrialBalanceLine is a balance of an account in one currency. Only one of Debit and Credit is non-zero.
+type TrialBalanceLine struct {
+	Account  string
+	Currency Currency
+	Debit    Money
+	Credit   Money
+}
+
+// LedgerMismatch is a loan or credit balance whose ledger balance does not match and is used as data transfer object DTO
+type LedgerMismatch struct {
+	KTPNumber string
+	// LoanID is empty when credit balance does not match
+	LoanID   string
+	Ledger   Money
+	Expected Money
+}
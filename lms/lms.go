@@ -6,22 +6,176 @@ package lms
 
 import (
 	"errors"
+	"time"
 )
 
 // Lms provides methods for all use cases in the system
 type Lms interface {
+	// RegisterClient creates a client. When OTP verification is enabled the phone number must be verified first.
 	RegisterClient(clientData ClientData) (Client, error)
+	// ChallengeOTP sends a one-time code to the phone number for the purpose (registration, agreement_acceptance or
+	// phone_change).
+	// Another code for the same phone number and purpose is sent after a minute at the earliest.
+	ChallengeOTP(phone string, purpose string) (OTPChallenge, error)
+	// VerifyOTP checks the code sent by ChallengeOTP. Verified challenge is used once by RegisterClient,
+	// AcceptAgreement or UpdateClient.
+	VerifyOTP(challengeID string, code string) error
+	// UpdateClient applies patch to the client profile. KTP number can't be changed. When OTP verification is enabled
+	// a new phone number must be verified first.
+	UpdateClient(ktpNumber string, patch ClientPatch) (Client, error)
 	ClientByKTPNumber(ktpNumber string) (client Client, found bool, error error)
-	ApplyForLoan(ktpNumber string, amount uint, term uint) (error error)
+	// ClientByVirtualAccount finds the client who was assigned the virtual account during registration
+	ClientByVirtualAccount(number string) (client Client, found bool, error error)
+	// ExportClientData returns everything held about the client
+	ExportClientData(ktpNumber string) (ClientDataExport, error)
+	// EraseClient pseudonymizes personal data of the client, loans are retained. Recipients and texts of client's
+	// notifications are removed. Client with active loan or credit balance can't be erased.
+	EraseClient(ktpNumber string) error
+	// Clients searches for clients matching the query. Results are paginated, use cursors from ClientsPage to get
+	// next or previous page.
+	Clients(query ClientQuery) (ClientsPage, error)
+	// ApplyForLoan creates a loan and disburses it to client's bank account. When the bank rejects the transfer the loan
+	// is cancelled and ErrDisbursementFailed is returned. When the credit bureau does not answer in time the loan is
+	// created, but it is not disbursed until an officer reviews it and ErrLoanReferredForReview is returned.
+	// Loan whose repayments together with declared obligations exceed the allowed share of declared income is rejected.
+	// When loan agreements are required the loan is disbursed once the client accepts its agreement.
+	// The application is recorded as SubmitApplication does.
+	ApplyForLoan(application ApplicationData) (error error)
+	// SubmitApplication applies for a loan like ApplyForLoan and returns the recorded application together with the
+	// error it was rejected with. Application for a product or client which does not exist is not recorded.
+	SubmitApplication(application ApplicationData) (LoanApplication, error)
+	ApplicationByID(id string) (application LoanApplication, found bool, error error)
+	// ApplicationsOfClient lists applications of the client in the order they were submitted
+	ApplicationsOfClient(ktpNumber string) ([]LoanApplication, error)
+	// CancelApplication withdraws the application which was not decided yet, its loan in review is cancelled
+	CancelApplication(id string) error
+	// ExpireApplications expires applications which were not decided in time, their loans in review are cancelled. It
+	// is run every business day by the end of day job.
+	ExpireApplications(asOf time.Time) (expired int, error error)
+	// LoanAgreement returns terms of the active loan of the client which are rendered into the loan agreement
+	LoanAgreement(ktpNumber string) (LoanAgreement, error)
+	// AcceptAgreement records that the client accepted the agreement of the active loan and disburses the loan unless it
+	// waits for review
+	AcceptAgreement(ktpNumber string, acceptance AgreementAcceptance) error
+	// ReviewLoan approves and disburses or declines and cancels the loan which was referred for review. Only an officer
+	// can review, the officer is recorded on the loan.
+	ReviewLoan(ktpNumber string, officer string, approve bool) error
+	// LoansInReview lists loans waiting for review in the order of KTP numbers of their clients
+	LoansInReview() ([]LoanInReview, error)
+	// Repay applies money received from the client to the active loan. The amount exceeding what the client owes is
+	// rejected, only Reconcile can keep it as client's credit balance.
+	Repay(ktpNumber string, amount Money) error
+	// WriteOffLoan takes the defaulted loan of the client off the books. Only an officer can write off, the officer is
+	// recorded on the loan. Later repayments of the loan are recoveries.
+	WriteOffLoan(ktpNumber string, officer string) error
+	// AuthorizeOfficer returns ErrOfficerRequired without an officer and ErrOfficerNotAuthorized unless the officer may
+	// run back-office use cases, such as booking repayments and reconciling bank statements
+	AuthorizeOfficer(officer string) error
+	// RequestRefund transfers amount from client's credit balance to client's bank account. When the outcome of the
+	// transfer is unknown the refund stays pending and ErrRefundPending is returned.
+	RequestRefund(ktpNumber string, amount Money) error
+	// RetryRefunds transfers pending refunds again with the same idempotency key. It is run every business day by
+	// the end of day job.
+	RetryRefunds(asOf time.Time) (RefundRun, error)
+	// Reconcile matches incoming payments from a bank statement with clients and applies them as repayments. Lines
+	// already booked are reported as duplicates, so the statement can be imported again after a failure. Depending on
+	// configuration the amount exceeding what the client owes is either rejected or kept as client's credit balance.
+	Reconcile(lines []StatementLine) (ReconciliationReport, error)
+	// DisburseLoan retries disbursement of the active loan when the outcome of the previous attempt is unknown
+	DisburseLoan(ktpNumber string) error
+	// AccrueLateCharges charges late fees and penalty interest of overdue loans for every day until asOf. It is run
+	// every business day by the accrual job, running it again for the same day does not charge anything twice.
+	AccrueLateCharges(asOf time.Time) (AccrualRun, error)
+	// RunDunning moves loans through dunning stages and sends notices of the stages reached, clients are notified of
+	// them too. It is run every business day after AccrueLateCharges. Loans handed over to collections are marked
+	// defaulted.
+	RunDunning(asOf time.Time) (DunningRun, error)
+	// LoansByDunningStage lists active loans by the dunning stage they reached, in the order of the stages
+	LoansByDunningStage() ([]DunningStageLoans, error)
+	// RetryNotifications delivers pending notifications whose next attempt is due, both the queued ones and those
+	// whose previous delivery failed
+	RetryNotifications(asOf time.Time) (NotificationRun, error)
+	// NotificationsOfClient lists notifications sent to the client with their delivery status in the order they were
+	// created
+	NotificationsOfClient(ktpNumber string) ([]Notification, error)
+	// SubscribeWebhook posts payloads of the event types to the URL of a partner, signed by the secret. The secret is
+	// generated when none is given.
+	SubscribeWebhook(subscriptionData WebhookSubscriptionData) (WebhookSubscription, error)
+	// WebhookSubscriptions lists subscriptions in the order they were created
+	WebhookSubscriptions() ([]WebhookSubscription, error)
+	WebhookSubscriptionByID(id string) (subscription WebhookSubscription, found bool, err error)
+	// UnsubscribeWebhook deletes the subscription, its pending deliveries fail
+	UnsubscribeWebhook(id string) error
+	// WebhookDeliveries lists deliveries to the subscription with their attempts in the order they were created
+	WebhookDeliveries(subscriptionID string) ([]WebhookDelivery, error)
+	// ReplayWebhookDelivery posts the payload of a delivery again as a new delivery, e.g. after the partner fixed
+	// their endpoint
+	ReplayWebhookDelivery(id string) (WebhookDelivery, error)
+	// RetryWebhookDeliveries posts pending payloads whose next attempt is due, both the queued ones and those whose
+	// previous attempt failed
+	RetryWebhookDeliveries(asOf time.Time) (WebhookRun, error)
+	// TrialBalance sums ledger accounts of all clients and checks that ledger balances match remaining amounts of loans
+	// and credit balances
+	TrialBalance() (TrialBalance, error)
+	Products() []Product
+	// Quote calculates the price of a loan without applying for it. ktpNumber is optional, when given the quote
+	// also tells whether the client is currently eligible for the loan.
+	Quote(ktpNumber string, productCode string, amount Money, term uint) (Quote, error)
 }
 
 // Client is someone who wants to take a loan
 type Client interface {
 	Gender() string
 	KTPNumber() string
+	VirtualAccount() string
 	BirthDate() string
 	Name() string
+	Phone() string
+	Email() string
+	Address() string
+	BankCode() string
+	BankAccountNumber() string
+	BankAccountHolder() string
 	HasActiveLoan() bool
+	DaysPastDue(asOf time.Time) uint
+	// CreditBalance is money received from the client which was not needed for repaying a loan
+	CreditBalance() Money
+}
+
+// Product is a kind of loan offered to clients with its own limits and pricing
+type Product interface {
+	Code() string
+	Name() string
+	// Currency of the loan, amount limits and pricing
+	Currency() Currency
+	MinAmount() Money
+	MaxAmount() Money
+	MinTerm() uint
+	MaxTerm() uint
+	InstalmentPeriod() uint
+	FeeBasisPoints() uint
+	DailyInterestBasisPoints() uint
+}
+
+// Quote is a price of a loan and is used as data transfer object DTO
+type Quote struct {
+	ProductCode  string
+	Amount       Money
+	Term         uint
+	Fee          Money
+	Interest     Money
+	TotalPayable Money
+	Instalments  []Instalment
+	// Eligible is false when the client identified by ktpNumber can't currently take the loan
+	Eligible bool
+	// IneligibilityReason is the error the client would get when applying for the loan
+	IneligibilityReason error
+}
+
+// Instalment is a part of a loan to be repaid until due date and is used as data transfer object DTO
+type Instalment struct {
+	DueDate time.Time
+	Amount  Money
 }
 
 // ClientData stores personal information about client and is used as data transfer object DTO
@@ -30,6 +184,284 @@ type ClientData struct {
 	KTPNumber string
 	BirthDate string
 	Name      string
+	Phone     string
+	// OTPChallengeID is the verified challenge of the phone number, it is needed when OTP verification is enabled
+	OTPChallengeID string
+}
+
+// OTPChallenge is a one-time code sent to a phone number and is used as data transfer object DTO
+type OTPChallenge struct {
+	ID        string
+	Phone     string
+	Purpose   string
+	ExpiresAt time.Time
+}
+
+// ApplicationData is a request of a client for a loan and is used as data transfer object DTO. Income and obligations
+// are declared by the client in the currency of the product.
+type ApplicationData struct {
+	KTPNumber          string
+	ProductCode        string
+	Amount             Money
+	Term               uint
+	MonthlyIncome      Money
+	EmploymentType     string
+	MonthlyObligations Money
+	// Channel is the application the client applied through, e.g. web or android
+	Channel   string
+	IPAddress string
+}
+
+// ClientPatch lists the profile fields to change and is used as data transfer object DTO. Nil field is left unchanged,
+// pointer to empty string clears the field.
+type ClientPatch struct {
+	KTPNumber         *string
+	Gender            *string
+	BirthDate         *string
+	Name              *string
+	Phone             *string
+	Email             *string
+	Address           *string
+	BankCode          *string
+	BankAccountNumber *string
+	BankAccountHolder *string
+	// OTPChallengeID is the verified challenge of the new phone number, it is needed when the phone number is changed
+	// and OTP verification is enabled
+	OTPChallengeID string
+}
+
+// ClientQuery filters clients and is used as data transfer object DTO. Empty fields match all clients.
+type ClientQuery struct {
+	NamePrefix    string
+	BirthDate     string
+	HasActiveLoan *bool
+	Overdue       *bool
+	// Cursor is NextCursor or PreviousCursor from ClientsPage. Empty cursor means the first page.
+	Cursor string
+	Limit  int
+}
+
+// ClientsPage is one page of clients matching ClientQuery and is used as data transfer object DTO
+type ClientsPage struct {
+	Clients []Client
+	// AsOf is the time clients were searched at, overdue clients and their days past due are as of it
+	AsOf time.Time
+	// NextCursor is empty on the last page
+	NextCursor string
+	// PreviousCursor is empty on the first page
+	PreviousCursor string
+}
+
+// AccrualRun summarizes what AccrueLateCharges did and is used as data transfer object DTO
+type AccrualRun struct {
+	AsOf time.Time
+	// Loans is a number of loans which were charged
+	Loans int
+	// Accruals is a number of late fees and daily penalty interests charged
+	Accruals int
+}
+
+// DunningRun summarizes what RunDunning did and is used as data transfer object DTO
+type DunningRun struct {
+	AsOf    time.Time
+	Notices int
+	// Defaulted is a number of loans handed over to collections
+	Defaulted int
+}
+
+// NotificationRun summarizes what RetryNotifications did and is used as data transfer object DTO
+type NotificationRun struct {
+	AsOf      time.Time
+	Delivered int
+	// Failed is a number of notifications which failed their last attempt and are not retried any more
+	Failed int
+	// Pending is a number of notifications which failed and will be retried
+	Pending int
+}
+
+// RefundRun summarizes what RetryRefunds did and is used as data transfer object DTO
+type RefundRun struct {
+	AsOf      time.Time
+	Completed int
+	// Failed is a number of refunds rejected by the bank, their amounts were returned to credit balance
+	Failed int
+	// Pending is a number of refunds whose transfer has unknown outcome again
+	Pending int
+}
+
+// Notification is a message sent to a client and is used as data transfer object DTO
+type Notification struct {
+	ID        string
+	KTPNumber string
+	Event     string
+	Reference string
+	Channel   string
+	Recipient string
+	Subject   string
+	Body      string
+	CreatedAt time.Time
+	// Status is pending, delivered or failed
+	Status    string
+	Attempts  uint
+	LastError string
+	// DeliveredAt is zero until the notification is delivered
+	DeliveredAt time.Time
+	// NextAttemptAt is zero when the notification is not pending
+	NextAttemptAt time.Time
+}
+
+// WebhookSubscriptionData is used to subscribe a webhook and is used as data transfer object DTO
+type WebhookSubscriptionData struct {
+	URL string
+	// EventTypes are client.registered or loan.state_changed
+	EventTypes []string
+	// Secret is optional, it is generated when empty
+	Secret string
+}
+
+// WebhookSubscription is a URL of a partner which subscribed events are posted to and is used as data transfer
+// object DTO
+type WebhookSubscription struct {
+	ID         string
+	URL        string
+	EventTypes []string
+	Secret     string
+	CreatedAt  time.Time
+}
+
+// WebhookDelivery is the payload of an event posted to a subscription and is used as data transfer object DTO
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Payload        string
+	CreatedAt      time.Time
+	// ReplayOf is the replayed delivery, empty for the original delivery of the event
+	ReplayOf string
+	// Status is pending, delivered or failed
+	Status   string
+	Attempts []WebhookAttempt
+	// NextAttemptAt is zero when the delivery is not pending
+	NextAttemptAt time.Time
+}
+
+// WebhookAttempt is one post of a webhook payload and is used as data transfer object DTO
+type WebhookAttempt struct {
+	At time.Time
+	// StatusCode is zero when the subscriber did not respond
+	StatusCode int
+	Error      string
+}
+
+// WebhookRun summarizes what RetryWebhookDeliveries did and is used as data transfer object DTO
+type WebhookRun struct {
+	AsOf      time.Time
+	Delivered int
+	// Failed is a number of deliveries which failed their last attempt and are not retried any more
+	Failed int
+	// Pending is a number of deliveries which failed and will be retried
+	Pending int
+}
+
+// DunningStageLoans lists loans in a dunning stage and is used as data transfer object DTO
+type DunningStageLoans struct {
+	Stage  string
+	Action string
+	Loans  []DunningLoan
+}
+
+// DunningLoan is an active loan in dunning and is used as data transfer object DTO
+type DunningLoan struct {
+	KTPNumber string
+	Name      string
+	LoanID    string
+	// DueDate of the oldest unpaid instalment
+	DueDate       time.Time
+	DaysPastDue   uint
+	AmountPastDue Money
+	Remaining     Money
+	Defaulted     bool
+}
+
+// LoanInReview is a loan waiting for an officer to approve or decline it and is used as data transfer object DTO
+type LoanInReview struct {
+	KTPNumber string
+	Name      string
+	LoanID    string
+	Product   string
+	Amount    Money
+	Term      uint
+	AppliedAt time.Time
+	// Reason why the loan could not be approved automatically
+	Reason string
+}
+
+// LoanApplication is a request of a client for a loan with its outcome and is used as data transfer object DTO
+type LoanApplication struct {
+	ID                 string
+	KTPNumber          string
+	ProductCode        string
+	Amount             Money
+	Term               uint
+	MonthlyIncome      Money
+	EmploymentType     string
+	MonthlyObligations Money
+	// Status is submitted, under_review, approved, rejected, cancelled or expired
+	Status string
+	// Reason is the error the application was rejected with or why it was referred for review
+	Reason string
+	// LoanID is empty when no loan was created for the application
+	LoanID      string
+	SubmittedAt time.Time
+	// DecidedAt is zero while the application is submitted or under review
+	DecidedAt time.Time
+	Channel   string
+	IPAddress string
+	// Scorecard is empty when no scorecard applied to the product
+	Scorecard   string
+	ScorePoints int
+	ScoreBand   string
+	// ReasonCodes are characteristics which lost the most points, starting with the biggest loss
+	ReasonCodes []string
+}
+
+// LoanAgreement is what the client agrees to when accepting the loan and is used as data transfer object DTO
+type LoanAgreement struct {
+	LoanID            string
+	KTPNumber         string
+	Name              string
+	BirthDate         string
+	Address           string
+	BankCode          string
+	BankAccountNumber string
+	BankAccountHolder string
+	ProductName       string
+	Amount            Money
+	Term              uint
+	FeeBasisPoints    uint
+	// DailyInterestBasisPoints is interest charged for every day of the term
+	DailyInterestBasisPoints uint
+	Fee                      Money
+	Interest                 Money
+	TotalPayable             Money
+	Instalments              []Instalment
+	StartDate                time.Time
+	// Required is false when the loan is disbursed without accepting the agreement
+	Required bool
+	// Hash of the accepted document, empty until the client accepts it
+	Hash         string
+	AcceptedFrom string
+	AcceptedAt   time.Time
+}
+
+// AgreementAcceptance is an electronic signature of the loan agreement and is used as data transfer object DTO
+type AgreementAcceptance struct {
+	// Hash identifies the document the client accepted
+	Hash      string
+	IPAddress string
+	// OTPChallengeID is the verified challenge of client's phone number, it is needed when OTP verification is enabled
+	OTPChallengeID string
 }
 
 // ErrClientAlreadyExists is an error returned when Client already exists
@@ -37,3 +469,72 @@ var ErrClientAlreadyExists = errors.New("client_already_exists")
 
 // ErrClientDoesNotExist is an error return when Client does not exist
 var ErrClientDoesNotExist = errors.New("client_does_not_exist")
+
+// ErrApplicationDoesNotExist is an error returned when loan application does not exist
+var ErrApplicationDoesNotExist = errors.New("application_does_not_exist")
+
+// ErrNoActiveLoan is an error returned when a use case needs the active loan of a client who has none
+var ErrNoActiveLoan = errors.New("no_active_loan")
+
+// ErrStatementLineAlreadyProcessed is a reason of statement line whose bank reference was already booked
+var ErrStatementLineAlreadyProcessed = errors.New("statement_line_already_processed")
+
+// ErrBankReferenceMissing is a reason of statement line without bank reference, such line can't be told apart from
+// a line already booked
+var ErrBankReferenceMissing = errors.New("bank_reference_missing")
+
+// ErrWebhooksDisabled is an error returned when webhooks are managed while they are not enabled
+var ErrWebhooksDisabled = errors.New("webhooks_disabled")
+
+// ErrWebhookSubscriptionDoesNotExist is an error returned when webhook subscription does not exist
+var ErrWebhookSubscriptionDoesNotExist = errors.New("webhook_subscription_does_not_exist")
+
+// ErrWebhookDeliveryDoesNotExist is an error returned when webhook delivery does not exist
+var ErrWebhookDeliveryDoesNotExist = errors.New("webhook_delivery_does_not_exist")
+
+// ErrOTPDisabled is an error returned when a one-time code is requested while OTP verification is not enabled
+var ErrOTPDisabled = errors.New("otp_disabled")
+
+// ErrOTPResendTooSoon is an error returned when another code is requested within a minute
+var ErrOTPResendTooSoon = errors.New("otp_resend_too_soon")
+
+// ErrOTPChallengeDoesNotExist is an error returned when a code is verified for unknown or obsolete challenge
+var ErrOTPChallengeDoesNotExist = errors.New("otp_challenge_does_not_exist")
+
+// ErrOTPVerificationRequired is an error returned when an action is run without a verified challenge of the phone
+// number
+var ErrOTPVerificationRequired = errors.New("otp_verification_required")
+
+// ErrProductDoesNotExist is an error returned when client applies for a product which is not in the catalog
+var ErrProductDoesNotExist = errors.New("product_does_not_exist")
+
+// ErrKTPNumberImmutable is an error returned when client tries to change KTP number
+var ErrKTPNumberImmutable = errors.New("ktp_number_immutable")
+
+// ErrInvalidCursor is an error returned when pagination cursor is malformed
+var ErrInvalidCursor = errors.New("invalid_cursor")
+
+// ErrBankAccountMissing is an error returned when client without bank account applies for a loan
+var ErrBankAccountMissing = errors.New("bank_account_missing")
+
+// ErrDisbursementFailed is an error returned when the bank rejected the transfer and the loan was cancelled
+var ErrDisbursementFailed = errors.New("disbursement_failed")
+
+// ErrLoanReferredForReview is returned when the loan was created, but it waits for an officer to review it
+var ErrLoanReferredForReview = errors.New("loan_referred_for_review")
+
+// ErrOfficerRequired is an error returned when use case for officers is run without officer
+var ErrOfficerRequired = errors.New("officer_required")
+
+// ErrOfficerNotAuthorized is an error returned when use case for officers is run by someone who is not a known officer
+var ErrOfficerNotAuthorized = errors.New("officer_not_authorized")
+
+// ErrRefundFailed is an error returned when the bank rejected the transfer and the amount was returned to credit balance
+var ErrRefundFailed = errors.New("refund_failed")
+
+// ErrRefundPending is an error returned when the outcome of the refund transfer is unknown, the refund is transferred
+// again by RetryRefunds
+var ErrRefundPending = errors.New("refund_pending")
+
+// ErrAgreementChanged is returned when the accepted hash is not the hash of the current agreement
+var ErrAgreementChanged = errors.New("agreement_changed")
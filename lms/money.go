@@ -0,0 +1,20 @@
+package lms
+
+import "github.com/briyanadityatama/goLoans/money"
+
+// Money is an amount in integer minor units of a currency. It is shared with the domain, so amounts keep their
+// currency and rounding rules on the way from the API to the loans. In JSON it is an object with amount and currency.
+type Money = money.Money
+
+// Currency is an ISO 4217 currency code
+type Currency = money.Currency
+
+// Rupiah returns amount given in whole Indonesian rupiah
+func Rupiah(rupiah int64) Money {
+	return money.Rupiah(rupiah)
+}
+
+// ParseRupiah parses decimal amount of Indonesian rupiah, e.g. "1500.50"
+func ParseRupiah(amount string) (Money, error) {
+	return money.Parse(amount, money.IDR)
+}
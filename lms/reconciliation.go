@@ -0,0 +1,39 @@
+package lms
+
+import "time"
+
+// StatementLine is an incoming payment read from a bank statement and is used as data transfer object DTO
+type StatementLine struct {
+	// BankReference identifies the line in the bank statement
+	BankReference string
+	Date          time.Time
+	Amount        Money
+	// Reference is free text entered by the payer which is used for matching the payment with a client
+	Reference string
+}
+
+// ReconciliationReport lists statement lines by the outcome of matching and is used as data transfer object DTO
+type ReconciliationReport struct {
+	// Matched lines were applied as repayments
+	Matched []ReconciledLine
+	// Unmatched lines could not be assigned to any client and need manual processing
+	Unmatched []ReconciledLine
+	// Overpaid lines were assigned to a client, but the amount exceeds what the client owes. Depending on configuration
+	// they were either not applied at all or the excess was kept as client's credit balance, see ReconciledLine.Applied.
+	Overpaid []ReconciledLine
+	// Duplicates were already booked from an earlier import of the statement, they are skipped
+	Duplicates []ReconciledLine
+}
+
+// ReconciledLine is a StatementLine with the outcome of matching
+type ReconciledLine struct {
+	StatementLine
+	// KTPNumber of the matched client, empty for unmatched lines
+	KTPNumber string
+	// Reason is the error which prevented the line from being applied, nil for matched lines
+	Reason error
+	// Applied tells whether the money was booked to the client
+	Applied bool
+	// Recovery line repays a written-off loan
+	Recovery bool
+}
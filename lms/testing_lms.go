@@ -1,5 +1,7 @@
 package lms
 
+import "time"
+
 type fakeLms struct {
 	clientsByKTPNumber map[string]Client
 }
@@ -15,15 +17,217 @@ func (lms *fakeLms) RegisterClient(clientData ClientData) (Client, error) {
 	return newClient, nil
 }
 
+func (lms *fakeLms) UpdateClient(ktpNumber string, patch ClientPatch) (Client, error) {
+	panic("implement me")
+}
+
 func (lms *fakeLms) ClientByKTPNumber(ktpNumber string) (client Client, found bool, err error) {
 	client, ok := lms.clientsByKTPNumber[ktpNumber]
 	return client, ok, nil
 }
 
-func (lms *fakeLms) ApplyForLoan(ktpNumber string, amount uint, term uint) error {
+func (lms *fakeLms) ClientByVirtualAccount(number string) (client Client, found bool, err error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) ExportClientData(ktpNumber string) (ClientDataExport, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) EraseClient(ktpNumber string) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) Clients(query ClientQuery) (ClientsPage, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) ApplyForLoan(application ApplicationData) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) SubmitApplication(application ApplicationData) (LoanApplication, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) ApplicationByID(id string) (LoanApplication, bool, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) ApplicationsOfClient(ktpNumber string) ([]LoanApplication, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) CancelApplication(id string) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) ExpireApplications(asOf time.Time) (int, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) LoanAgreement(ktpNumber string) (LoanAgreement, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) AcceptAgreement(ktpNumber string, acceptance AgreementAcceptance) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) ChallengeOTP(phone string, purpose string) (OTPChallenge, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) VerifyOTP(challengeID string, code string) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) Repay(ktpNumber string, amount Money) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) WriteOffLoan(ktpNumber string, officer string) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) RequestRefund(ktpNumber string, amount Money) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) Reconcile(lines []StatementLine) (ReconciliationReport, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) AccrueLateCharges(asOf time.Time) (AccrualRun, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) RunDunning(asOf time.Time) (DunningRun, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) RetryRefunds(asOf time.Time) (RefundRun, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) RetryNotifications(asOf time.Time) (NotificationRun, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) NotificationsOfClient(ktpNumber string) ([]Notification, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) SubscribeWebhook(subscriptionData WebhookSubscriptionData) (WebhookSubscription, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) WebhookSubscriptions() ([]WebhookSubscription, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) WebhookSubscriptionByID(id string) (WebhookSubscription, bool, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) UnsubscribeWebhook(id string) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) WebhookDeliveries(subscriptionID string) ([]WebhookDelivery, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) ReplayWebhookDelivery(id string) (WebhookDelivery, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) RetryWebhookDeliveries(asOf time.Time) (WebhookRun, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) AuthorizeOfficer(officer string) error {
+	if officer == "" {
+		return ErrOfficerRequired
+	}
+	return nil
+}
+
+func (lms *fakeLms) ReviewLoan(ktpNumber string, officer string, approve bool) error {
 	panic("implement me")
 }
 
+func (lms *fakeLms) LoansInReview() ([]LoanInReview, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) LoansByDunningStage() ([]DunningStageLoans, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) TrialBalance() (TrialBalance, error) {
+	panic("implement me")
+}
+
+func (lms *fakeLms) DisburseLoan(ktpNumber string) error {
+	panic("implement me")
+}
+
+func (lms *fakeLms) Products() []Product {
+	return []Product{fakeProduct{code: "payday", name: "Payday loan", minAmount: Rupiah(500000), maxAmount: Rupiah(50000000), minTerm: 7, maxTerm: 30}}
+}
+
+func (lms *fakeLms) Quote(ktpNumber string, productCode string, amount Money, term uint) (Quote, error) {
+	panic("implement me")
+}
+
+type fakeProduct struct {
+	code, name                               string
+	minAmount, maxAmount                     Money
+	minTerm, maxTerm, period                 uint
+	feeBasisPoints, dailyInterestBasisPoints uint
+}
+
+func (product fakeProduct) Code() string {
+	return product.code
+}
+
+func (product fakeProduct) Name() string {
+	return product.name
+}
+
+func (product fakeProduct) Currency() Currency {
+	return product.minAmount.Currency()
+}
+
+func (product fakeProduct) MinAmount() Money {
+	return product.minAmount
+}
+
+func (product fakeProduct) MaxAmount() Money {
+	return product.maxAmount
+}
+
+func (product fakeProduct) MinTerm() uint {
+	return product.minTerm
+}
+
+func (product fakeProduct) MaxTerm() uint {
+	return product.maxTerm
+}
+
+func (product fakeProduct) InstalmentPeriod() uint {
+	return product.period
+}
+
+func (product fakeProduct) FeeBasisPoints() uint {
+	return product.feeBasisPoints
+}
+
+func (product fakeProduct) DailyInterestBasisPoints() uint {
+	return product.dailyInterestBasisPoints
+}
+
 type fakeClient struct {
 	gender, ktpNumber, birthDate, name string
 }
@@ -36,6 +240,10 @@ func (client fakeClient) KTPNumber() string {
 	return client.ktpNumber
 }
 
+func (client fakeClient) VirtualAccount() string {
+	return ""
+}
+
 func (client fakeClient) BirthDate() string {
 	return client.birthDate
 }
@@ -44,6 +252,38 @@ func (client fakeClient) Name() string {
 	return client.name
 }
 
+func (client fakeClient) Phone() string {
+	return ""
+}
+
+func (client fakeClient) Email() string {
+	return ""
+}
+
+func (client fakeClient) Address() string {
+	return ""
+}
+
+func (client fakeClient) BankCode() string {
+	return ""
+}
+
+func (client fakeClient) BankAccountNumber() string {
+	return ""
+}
+
+func (client fakeClient) BankAccountHolder() string {
+	return ""
+}
+
+func (client fakeClient) DaysPastDue(asOf time.Time) uint {
+	return 0
+}
+
+func (client fakeClient) CreditBalance() Money {
+	return Rupiah(0)
+}
+
 func (client fakeClient) HasActiveLoan() bool {
-	panic("implement me")
+	return false
 }
@@ -2,13 +2,202 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/job"
 	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+	"github.com/briyanadityatama/goLoans/lms/cola/infra/bank"
+	"github.com/briyanadityatama/goLoans/lms/cola/infra/bureau"
+	"github.com/briyanadityatama/goLoans/lms/cola/infra/email"
+	"github.com/briyanadityatama/goLoans/lms/cola/infra/fx"
+	"github.com/briyanadityatama/goLoans/lms/cola/infra/notify"
 	"github.com/briyanadityatama/goLoans/lms/cola/infra/repo"
+	"github.com/briyanadityatama/goLoans/lms/cola/infra/sms"
+	"github.com/briyanadityatama/goLoans/lms/cola/infra/webhook"
+	"github.com/briyanadityatama/goLoans/report"
 	"github.com/briyanadityatama/goLoans/rest"
 )
 
 func main() {
-	lms := cola.New(repo.NewMemoryClientRepo())
-	server := rest.NewLoansServer("localhost:8080", "http://localhost:8080", lms)
+	overpayment := flag.String("overpayment", "reject", "what to do with repayments exceeding the loan: reject or credit")
+	ratesFile := flag.String("rates", "exchange_rates.csv", "CSV file with exchange rates to IDR used for reporting, only loans in IDR can be reported when it does not exist")
+	lateFee := flag.Uint("late-fee", domain.DefaultPenaltyPolicy.LateFeeBasisPoints, "late fee charged on overdue instalment in basis points")
+	penalty := flag.Uint("penalty", domain.DefaultPenaltyPolicy.DailyPenaltyBasisPoints, "daily penalty interest on amount past due in basis points")
+	maxCost := flag.Uint("max-total-cost", domain.DefaultPenaltyPolicy.MaxTotalCostBasisPoints, "cap of fee, interest and late charges of a loan in basis points of its amount")
+	endOfDayAt := flag.Duration("end-of-day-at", time.Hour, "time of day when late charges are accrued and dunning notices sent on business days")
+	dunningFile := flag.String("dunning", "", "JSON file with dunning stages, default stages are used when empty")
+	noticesFile := flag.String("notices", "", "file where dunning notices are written, standard error when empty")
+	lenderCode := flag.String("lender-code", "", "code assigned to the lender by the credit bureau, required for credit reporting files")
+	scorecardsFile := flag.String("scorecards", "", "JSON file with scorecards of loan applications, applications are not scored when empty")
+	creditBureauURL := flag.String("credit-bureau", "", "URL of the credit bureau API consulted before loans are approved, stub starts a local stub, the bureau is not consulted when empty")
+	creditBureauTimeout := flag.Duration("credit-bureau-timeout", 5*time.Second, "time to wait for the credit bureau before the loan is referred for manual review")
+	maxDebtToIncome := flag.Uint("max-dti", domain.DefaultMaxDebtToIncomeBasisPoints, "cap of monthly repayments of all debts of a client in basis points of declared monthly income")
+	agreements := flag.Bool("agreements", true, "hold approved loans until the client accepts the loan agreement")
+	otp := flag.Bool("otp", true, "require phone numbers verified by one-time codes before registration and agreement acceptance, codes are written to standard error")
+	notificationLanguage := flag.String("notification-language", "id", "language of notifications sent to clients: en or id")
+	smtpAddr := flag.String("smtp", "stub", "host:port of SMTP server notifications are emailed through, stub starts a local stand-in server, no emails are sent when empty")
+	mailFrom := flag.String("mail-from", "noreply@goloans.local", "sender address of notification emails")
+	notificationWebhook := flag.String("notification-webhook", "", "URL every notification is posted to, notifications are not posted when empty")
+	notificationRetry := flag.Duration("notification-retry-every", 10*time.Second, "how often queued notifications are delivered and those whose delivery failed are retried")
+	webhooks := flag.Bool("webhooks", true, "let partners subscribe to client registrations and loan state changes through /webhooks/subscriptions")
+	webhookTimeout := flag.Duration("webhook-timeout", 10*time.Second, "time to wait for a partner to accept a webhook before the attempt fails")
+	webhookRetry := flag.Duration("webhook-retry-every", 10*time.Second, "how often queued webhook deliveries are posted and those whose next attempt is due are retried")
+	officers := flag.String("officers", "", "comma separated IDs of officers allowed to review and write off loans, X-Officer-ID header must be set by an authenticating proxy")
+	flag.Parse()
+	var options []cola.Option
+	switch *overpayment {
+	case "reject":
+	case "credit":
+		options = append(options, cola.WithOverpaymentMode(cola.KeepOverpaymentAsCredit))
+	default:
+		log.Fatalf("unknown overpayment mode %s, use reject or credit", *overpayment)
+	}
+	rates, err := fx.NewFileExchangeRates(*ratesFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		log.Printf("[WARN] exchange rates file %s not found, only loans in IDR can be reported", *ratesFile)
+	case err != nil:
+		log.Fatalf("loading exchange rates: %v", err)
+	default:
+		options = append(options, cola.WithExchangeRates(rates))
+	}
+	options = append(options, cola.WithPenaltyPolicy(domain.PenaltyPolicy{
+		LateFeeBasisPoints:      *lateFee,
+		DailyPenaltyBasisPoints: *penalty,
+		MaxTotalCostBasisPoints: *maxCost,
+	}))
+	schedule, err := loadDunningSchedule(*dunningFile)
+	if err != nil {
+		log.Fatalf("loading dunning stages: %v", err)
+	}
+	notices := os.Stderr
+	if *noticesFile != "" {
+		notices, err = os.OpenFile(*noticesFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatalf("opening notices file: %v", err)
+		}
+		defer notices.Close()
+	}
+	options = append(options, cola.WithMaxDebtToIncome(*maxDebtToIncome))
+	if *officers != "" {
+		options = append(options, cola.WithOfficers(strings.Split(*officers, ",")...))
+	}
+	if *agreements {
+		options = append(options, cola.WithLoanAgreements())
+	}
+	smsSender := sms.NewLogSMSSender(log.New(os.Stderr, "", log.LstdFlags))
+	if *otp {
+		options = append(options, cola.WithOTP(smsSender))
+	}
+	channels := []cola.Channel{sms.NewChannel(smsSender)}
+	switch *smtpAddr {
+	case "":
+	case "stub":
+		listener, err := net.Listen("tcp", smtpStubAddr)
+		if err != nil {
+			log.Fatalf("starting SMTP stub: %v", err)
+		}
+		go func() {
+			log.Printf("[INFO] SMTP stub listening on %s", smtpStubAddr)
+			log.Fatal(email.NewStub(log.New(os.Stderr, "", log.LstdFlags)).Serve(listener))
+		}()
+		channels = append(channels, email.NewSMTPChannel(smtpStubAddr, *mailFrom))
+	default:
+		channels = append(channels, email.NewSMTPChannel(*smtpAddr, *mailFrom))
+	}
+	if *notificationWebhook != "" {
+		channels = append(channels, webhook.NewChannel(*notificationWebhook, 10*time.Second))
+	}
+	language := cola.Language(*notificationLanguage)
+	if language != cola.English && language != cola.Indonesian {
+		log.Fatalf("unknown notification language %s", *notificationLanguage)
+	}
+	scorecards, err := loadScorecards(*scorecardsFile)
+	if err != nil {
+		log.Fatalf("loading scorecards: %v", err)
+	}
+	options = append(options, cola.WithScorecards(scorecards...))
+	switch *creditBureauURL {
+	case "":
+	case "stub":
+		go func() {
+			log.Printf("[INFO] Credit bureau stub listening on %s", creditBureauStubAddr)
+			log.Fatal(http.ListenAndServe(creditBureauStubAddr, bureau.NewStub(2**creditBureauTimeout)))
+		}()
+		options = append(options, cola.WithCreditBureau(bureau.NewHTTPCreditBureau("http://"+creditBureauStubAddr, *creditBureauTimeout)))
+	default:
+		options = append(options, cola.WithCreditBureau(bureau.NewHTTPCreditBureau(*creditBureauURL, *creditBureauTimeout)))
+	}
+	options = append(options, cola.WithDunning(schedule, notify.NewLogNotifier(log.New(notices, "", log.LstdFlags))))
+	options = append(options, cola.WithNotifications(repo.NewMemoryNotificationRepo(), language, channels...))
+	if *webhooks {
+		options = append(options, cola.WithWebhooks(repo.NewMemoryWebhookRepo(), webhook.NewHTTPPoster(*webhookTimeout)))
+	}
+	clientRepo := repo.NewMemoryClientRepo()
+	lms := cola.New(clientRepo, repo.NewMemoryApplicationRepo(), bank.NewFakeBankTransfer(), options...)
+	endOfDay := job.NewEndOfDay(lms, *endOfDayAt)
+	endOfDay.Start()
+	defer endOfDay.Stop()
+	retry := job.NewNotificationRetry(lms, *notificationRetry)
+	retry.Start()
+	defer retry.Stop()
+	webhookRetryJob := job.NewWebhookRetry(lms, *webhookRetry)
+	webhookRetryJob.Start()
+	defer webhookRetryJob.Stop()
+	server := rest.NewLoansServer("localhost:8080", "http://localhost:8080", lms, report.New(clientRepo, report.WithLenderCode(*lenderCode)))
 	server.Start()
 }
+
+// creditBureauStubAddr is where the credit bureau stub listens when started with -credit-bureau=stub
+const creditBureauStubAddr = "localhost:8081"
+
+// smtpStubAddr is where the SMTP stub listens when started with -smtp=stub
+const smtpStubAddr = "localhost:2525"
+
+// loadDunningSchedule reads JSON array of stages, e.g. [{"Name": "reminder", "DaysFromDueDate": -3, "Action": "reminder"}]
+func loadDunningSchedule(path string) (domain.DunningSchedule, error) {
+	if path == "" {
+		return domain.DefaultDunningSchedule, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stages []domain.DunningStage
+	if err := json.Unmarshal(content, &stages); err != nil {
+		return nil, err
+	}
+	return domain.NewDunningSchedule(stages...)
+}
+
+// loadScorecards reads JSON array of scorecards, see scorecards.json
+func loadScorecards(path string) ([]domain.Scorecard, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scorecards []domain.Scorecard
+	if err := json.Unmarshal(content, &scorecards); err != nil {
+		return nil, err
+	}
+	for i, scorecard := range scorecards {
+		if scorecards[i], err = domain.NewScorecard(scorecard); err != nil {
+			return nil, fmt.Errorf("scorecard %s: %v", scorecard.Name, err)
+		}
+	}
+	return scorecards, nil
+}
@@ -0,0 +1,251 @@
+// Package money provides amounts in minor units of a currency shared by the API and the domain
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+// Supported currencies
+const (
+	// IDR is Indonesian rupiah
+	IDR Currency = "IDR"
+	// SGD is Singapore dollar
+	SGD Currency = "SGD"
+	// MYR is Malaysian ringgit
+	MYR Currency = "MYR"
+)
+
+// DefaultCurrency is used when amount is given without currency
+const DefaultCurrency = IDR
+
+// minorUnitDigits is a number of decimal digits of the minor unit of every supported currency
+var minorUnitDigits = map[Currency]int{
+	IDR: 2,
+	SGD: 2,
+	MYR: 2,
+}
+
+// MinorUnitDigits is the number of decimal digits of the minor unit of the currency
+func (currency Currency) MinorUnitDigits() int {
+	return minorUnitDigits[currency]
+}
+
+// IsSupported tells whether amounts can be held in the currency
+func (currency Currency) IsSupported() bool {
+	_, supported := minorUnitDigits[currency]
+	return supported
+}
+
+// Money is an amount in integer minor units of a currency. Zero value is zero amount in DefaultCurrency.
+// Arithmetic never wraps around, it returns ErrMoneyOverflow instead.
+type Money struct {
+	minorUnits int64
+	currency   Currency
+}
+
+// New returns amount given in minor units, e.g. sen for IDR
+func New(minorUnits int64, currency Currency) Money {
+	return Money{minorUnits: minorUnits, currency: currency}
+}
+
+// Rupiah returns amount given in whole rupiah. It panics when the amount does not fit into minor units, so it
+// should be used for constants only.
+func Rupiah(rupiah int64) Money {
+	money, err := New(rupiah, IDR).MulDiv(100, 1)
+	if err != nil {
+		panic(err)
+	}
+	return money
+}
+
+var amountPattern = regexp.MustCompile(`^(-?)([0-9]+)(?:\.([0-9]+))?$`)
+
+// Parse parses decimal amount in major units, e.g. "1500.50"
+func Parse(amount string, currency Currency) (Money, error) {
+	digits, known := minorUnitDigits[currency]
+	if !known {
+		return Money{}, ErrUnknownCurrency
+	}
+	parts := amountPattern.FindStringSubmatch(amount)
+	if parts == nil || len(parts[3]) > digits {
+		return Money{}, ErrInvalidMoney
+	}
+	minorUnits, err := strconv.ParseInt(parts[1]+parts[2]+parts[3]+strings.Repeat("0", digits-len(parts[3])), 10, 64)
+	if err != nil {
+		return Money{}, ErrMoneyOverflow
+	}
+	return New(minorUnits, currency), nil
+}
+
+// MinorUnits returns the amount in minor units of the currency
+func (money Money) MinorUnits() int64 {
+	return money.minorUnits
+}
+
+func (money Money) Currency() Currency {
+	if money.currency == "" {
+		return DefaultCurrency
+	}
+	return money.currency
+}
+
+func (money Money) IsZero() bool {
+	return money.minorUnits == 0
+}
+
+func (money Money) IsPositive() bool {
+	return money.minorUnits > 0
+}
+
+func (money Money) IsNegative() bool {
+	return money.minorUnits < 0
+}
+
+// SameCurrency tells whether the amounts can be added, subtracted and compared
+func (money Money) SameCurrency(other Money) bool {
+	return money.Currency() == other.Currency()
+}
+
+func (money Money) Add(other Money) (Money, error) {
+	if !money.SameCurrency(other) {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := money.minorUnits + other.minorUnits
+	if (other.minorUnits > 0 && sum < money.minorUnits) || (other.minorUnits < 0 && sum > money.minorUnits) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return New(sum, money.Currency()), nil
+}
+
+func (money Money) Sub(other Money) (Money, error) {
+	if other.minorUnits == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return money.Add(New(-other.minorUnits, other.Currency()))
+}
+
+// Cmp returns -1, 0 or +1 when money is less than, equal to or greater than other. Amounts in different currencies
+// can't be compared, check SameCurrency first, otherwise Cmp panics.
+func (money Money) Cmp(other Money) int {
+	if !money.SameCurrency(other) {
+		panic(ErrCurrencyMismatch)
+	}
+	switch {
+	case money.minorUnits < other.minorUnits:
+		return -1
+	case money.minorUnits > other.minorUnits:
+		return 1
+	}
+	return 0
+}
+
+// MulDiv multiplies the amount by numerator/denominator and rounds the result to minor units half to even
+// (banker's rounding), so rounding errors do not add up in favour of anybody.
+func (money Money) MulDiv(numerator, denominator uint64) (Money, error) {
+	if denominator == 0 {
+		return Money{}, ErrMoneyOverflow
+	}
+	product := new(big.Int).Mul(big.NewInt(money.minorUnits), new(big.Int).SetUint64(numerator))
+	divisor := new(big.Int).SetUint64(denominator)
+	quotient, remainder := new(big.Int).QuoRem(product, divisor, new(big.Int))
+	if remainder.Sign() != 0 {
+		half := new(big.Int).Abs(remainder)
+		half.Lsh(half, 1)
+		if cmp := half.Cmp(divisor); cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+			quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+		}
+	}
+	if !quotient.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return New(quotient.Int64(), money.Currency()), nil
+}
+
+// Split divides the amount into equal parts, the last part takes the remainder
+func (money Money) Split(parts int) []Money {
+	split := make([]Money, parts)
+	for i := range split {
+		split[i] = New(money.minorUnits/int64(parts), money.Currency())
+	}
+	split[parts-1].minorUnits += money.minorUnits % int64(parts)
+	return split
+}
+
+// Amount formats the amount in major units, e.g. "1500.50"
+func (money Money) Amount() string {
+	digits := minorUnitDigits[money.Currency()]
+	sign := ""
+	minorUnits := strconv.FormatInt(money.minorUnits, 10)
+	if money.minorUnits < 0 {
+		sign, minorUnits = "-", minorUnits[1:]
+	}
+	if len(minorUnits) <= digits {
+		minorUnits = strings.Repeat("0", digits-len(minorUnits)+1) + minorUnits
+	}
+	if digits == 0 {
+		return sign + minorUnits
+	}
+	point := len(minorUnits) - digits
+	return sign + minorUnits[:point] + "." + minorUnits[point:]
+}
+
+func (money Money) String() string {
+	return money.Amount() + " " + string(money.Currency())
+}
+
+// moneyJSON is how Money is serialized in JSON. Amount is a string, so no precision is lost by JSON parsers using
+// floating point numbers.
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency Currency    `json:"currency"`
+}
+
+func (money Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string   `json:"amount"`
+		Currency Currency `json:"currency"`
+	}{money.Amount(), money.Currency()})
+}
+
+// UnmarshalJSON accepts an object with amount and currency, or just the amount as number or string in DefaultCurrency
+func (money *Money) UnmarshalJSON(data []byte) error {
+	var parsed moneyJSON
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(data, &parsed.Amount); err != nil {
+		return ErrInvalidMoney
+	}
+	if parsed.Currency == "" {
+		parsed.Currency = DefaultCurrency
+	}
+	result, err := Parse(string(parsed.Amount), parsed.Currency)
+	if err != nil {
+		return err
+	}
+	*money = result
+	return nil
+}
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are added, subtracted or compared
+var ErrCurrencyMismatch = errors.New("currency_mismatch")
+
+// ErrMoneyOverflow is returned when result of arithmetic does not fit into minor units
+var ErrMoneyOverflow = errors.New("money_overflow")
+
+// ErrInvalidMoney is returned when amount is not a decimal number or has more decimal places than the currency
+var ErrInvalidMoney = errors.New("invalid_money")
+
+// ErrUnknownCurrency is returned for currency which is not supported
+var ErrUnknownCurrency = errors.New("unknown_currency")
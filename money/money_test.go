@@ -0,0 +1,67 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArithmetic(t *testing.T) {
+	t.Run("should add and subtract", func(t *testing.T) {
+		sum, err := Rupiah(10).Add(New(50, IDR))
+		assert.Nil(t, err)
+		assert.Equal(t, New(1050, IDR), sum)
+		difference, err := Rupiah(10).Sub(Rupiah(11))
+		assert.Nil(t, err)
+		assert.Equal(t, Rupiah(-1), difference)
+	})
+	t.Run("should not wrap around", func(t *testing.T) {
+		_, err := New(math.MaxInt64, IDR).Add(New(1, IDR))
+		assert.Equal(t, ErrMoneyOverflow, err)
+		_, err = New(math.MinInt64, IDR).Sub(New(1, IDR))
+		assert.Equal(t, ErrMoneyOverflow, err)
+		_, err = New(math.MaxInt64, IDR).MulDiv(2, 1)
+		assert.Equal(t, ErrMoneyOverflow, err)
+	})
+	t.Run("should not mix currencies", func(t *testing.T) {
+		_, err := Rupiah(10).Add(New(10, "USD"))
+		assert.Equal(t, ErrCurrencyMismatch, err)
+	})
+	t.Run("should round half to even", func(t *testing.T) {
+		for minorUnits, expected := range map[int64]int64{25: 2, 35: 4, 36: 4, -25: -2, -35: -4} {
+			rounded, _ := New(minorUnits, IDR).MulDiv(1, 10)
+			assert.Equal(t, New(expected, IDR), rounded, minorUnits)
+		}
+	})
+	t.Run("should split into equal parts", func(t *testing.T) {
+		assert.Equal(t, []Money{New(33, IDR), New(33, IDR), New(34, IDR)}, Rupiah(1).Split(3))
+	})
+}
+
+func TestParse(t *testing.T) {
+	for amount, expected := range map[string]Money{"1500": Rupiah(1500), "1500.5": New(150050, IDR), "-0.05": New(-5, IDR)} {
+		parsed, err := Parse(amount, IDR)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, parsed, amount)
+	}
+	for amount, expected := range map[string]error{"1.005": ErrInvalidMoney, "1,5": ErrInvalidMoney, "": ErrInvalidMoney, "99999999999999999999": ErrMoneyOverflow} {
+		_, err := Parse(amount, IDR)
+		assert.Equal(t, expected, err, amount)
+	}
+	_, err := Parse("1", "XXX")
+	assert.Equal(t, ErrUnknownCurrency, err)
+}
+
+func TestJSON(t *testing.T) {
+	marshaled, _ := json.Marshal(New(150050, IDR))
+	assert.Equal(t, `{"amount":"1500.50","currency":"IDR"}`, string(marshaled))
+	for data, expected := range map[string]Money{`{"amount":"1500.50","currency":"IDR"}`: New(150050, IDR), `1500`: Rupiah(1500), `"0.5"`: New(50, IDR)} {
+		var parsed Money
+		assert.Nil(t, json.Unmarshal([]byte(data), &parsed), data)
+		assert.Equal(t, expected, parsed, data)
+	}
+	var parsed Money
+	assert.Equal(t, ErrInvalidMoney, json.Unmarshal([]byte(`1e3`), &parsed))
+}
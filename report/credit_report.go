@@ -0,0 +1,285 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// LoanCondition tells the credit bureau whether a reported loan is still owed
+type LoanCondition string
+
+const (
+	// Active loan has outstanding balance at the end of the month
+	Active LoanCondition = "A"
+	// PaidOff loan was fully repaid, it is reported in the month it was closed
+	PaidOff LoanCondition = "L"
+	// WrittenOff loan was taken off the books, it is reported until it is recovered
+	WrittenOff LoanCondition = "W"
+)
+
+// CreditRecord is the state of a loan at the end of a reporting month
+type CreditRecord struct {
+	KTPNumber   string
+	Name        string
+	Gender      string
+	BirthDate   string
+	LoanID      string
+	StartDate   time.Time
+	DueDate     time.Time
+	Amount      domain.Money
+	Outstanding domain.Money
+	DaysPastDue uint
+	// Collectibility is the class of the loan from 1 (current) to 5 (loss)
+	Collectibility int
+	Condition      LoanCondition
+}
+
+// Collectibility classes loans by days past due, written-off loans are always loss
+func Collectibility(daysPastDue uint, writtenOff bool) int {
+	switch {
+	case writtenOff || daysPastDue > 180:
+		return 5
+	case daysPastDue > 120:
+		return 4
+	case daysPastDue > 90:
+		return 3
+	case daysPastDue > 0:
+		return 2
+	}
+	return 1
+}
+
+// Option configures Reporter
+type Option func(reporter *reporter)
+
+// WithLenderCode identifies the lender in credit reporting files, the code is assigned by the credit bureau
+func WithLenderCode(code string) Option {
+	return func(reporter *reporter) {
+		reporter.lenderCode = code
+	}
+}
+
+// CreditRecords lists every loan which was owed during the month. Erased clients are left out, because they can only
+// be erased after all their loans were repaid.
+func (reporter *reporter) CreditRecords(month time.Time) ([]CreditRecord, error) {
+	start := monthOf(month)
+	end := start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	var records []CreditRecord
+	err := reporter.forEachClient(func(client domain.Client) error {
+		if client.IsErased() {
+			return nil
+		}
+		for _, loan := range client.Loans() {
+			if loan.DisbursementStatus() != domain.Disbursed || loan.StartDate().After(end) {
+				continue
+			}
+			opening, outstanding := outstandingAsOf(loan, start), outstandingAsOf(loan, end)
+			if opening.IsZero() && outstanding.IsZero() && loan.StartDate().Before(start) {
+				continue
+			}
+			writtenOff := loan.IsWrittenOff() && !loan.WrittenOffAt().After(end)
+			record := CreditRecord{
+				KTPNumber:   client.KTPNumber(),
+				Name:        client.Name(),
+				Gender:      client.Gender(),
+				BirthDate:   client.BirthDate(),
+				LoanID:      loan.ID(),
+				StartDate:   loan.StartDate(),
+				DueDate:     loan.DueDate(),
+				Amount:      loan.Amount(),
+				Outstanding: outstanding,
+				DaysPastDue: daysPastDueAsOf(loan, end),
+				Condition:   Active,
+			}
+			switch {
+			case writtenOff:
+				record.Condition = WrittenOff
+			case outstanding.IsZero():
+				record.Condition = PaidOff
+				record.DaysPastDue = 0
+			}
+			record.Collectibility = Collectibility(record.DaysPastDue, writtenOff)
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing credit records: %v", err)
+	}
+	return records, nil
+}
+
+// outstandingAsOf takes back repayments and accruals made after the date
+func outstandingAsOf(loan domain.Loan, asOf time.Time) domain.Money {
+	outstanding := loan.Remaining()
+	if loan.StartDate().After(asOf) {
+		return domain.NewMoney(0, outstanding.Currency())
+	}
+	for _, repayment := range loan.Repayments() {
+		if repayment.PaidAt.After(asOf) {
+			outstanding, _ = outstanding.Add(repayment.Amount)
+		}
+	}
+	for _, accrual := range loan.Accruals() {
+		if accrual.Date.After(asOf) {
+			outstanding, _ = outstanding.Sub(accrual.Amount)
+		}
+	}
+	return outstanding
+}
+
+// daysPastDueAsOf allocates repayments made until the date to instalments in order of their due dates
+func daysPastDueAsOf(loan domain.Loan, asOf time.Time) uint {
+	paid := domain.NewMoney(0, loan.Amount().Currency())
+	for _, repayment := range loan.Repayments() {
+		if !repayment.PaidAt.After(asOf) {
+			paid, _ = paid.Add(repayment.Amount)
+		}
+	}
+	for _, instalment := range loan.Instalments() {
+		if paid.Cmp(instalment.Amount) < 0 {
+			if !asOf.After(instalment.DueDate) {
+				return 0
+			}
+			return uint(asOf.Sub(instalment.DueDate) / (24 * time.Hour))
+		}
+		paid, _ = paid.Sub(instalment.Amount)
+	}
+	return 0
+}
+
+// creditRecordLength is the length of every line of credit reporting file without line break
+const creditRecordLength = 165
+
+// WriteCreditReport writes fixed-width file with a header line followed by a line for every loan. Nothing is written
+// when any record is invalid, InvalidCreditRecordsStruct lists all problems instead.
+//
+// Header: H, lender code (10), month YYYYMM, generation date YYYYMMDD, number of records (9), padded with spaces.
+//
+// Record: D, KTP number (16), name (50), gender L/P (1), birth date YYYYMMDD, loan ID (30), currency (3), start date
+// YYYYMMDD, due date YYYYMMDD, amount (17) and outstanding (17) in minor units, days past due (4), collectibility (1) and
+// condition A/L/W (1). Text is left-aligned and padded with spaces, numbers are right-aligned and padded with zeros.
+func (reporter *reporter) WriteCreditReport(writer io.Writer, month time.Time, generatedAt time.Time) error {
+	if reporter.lenderCode == "" {
+		return ErrLenderCodeMissing
+	}
+	records, err := reporter.CreditRecords(month)
+	if err != nil {
+		return err
+	}
+	lines := []string{pad(fmt.Sprintf("H%-10.10s%s%s%09d", reporter.lenderCode, monthOf(month).Format("200601"), generatedAt.Format("20060102"), len(records)))}
+	var problems []CreditRecordProblem
+	for _, record := range records {
+		line, recordProblems := creditRecordLine(record)
+		problems = append(problems, recordProblems...)
+		lines = append(lines, line)
+	}
+	if len(problems) > 0 {
+		return InvalidCreditRecordsStruct{ErrInvalidCreditRecords, problems}
+	}
+	_, err = io.WriteString(writer, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+var ktpNumberPattern = regexp.MustCompile(`^[0-9]{16}$`)
+
+func creditRecordLine(record CreditRecord) (string, []CreditRecordProblem) {
+	var problems []CreditRecordProblem
+	problem := func(field string) {
+		problems = append(problems, CreditRecordProblem{KTPNumber: record.KTPNumber, LoanID: record.LoanID, Field: field})
+	}
+	if !ktpNumberPattern.MatchString(record.KTPNumber) {
+		problem("ktpNumber")
+	}
+	name := strings.ToUpper(strings.TrimSpace(record.Name))
+	if name == "" || !isPrintableASCII(name) {
+		problem("name")
+	}
+	gender := genderCode(record.Gender)
+	if gender == "" {
+		problem("gender")
+	}
+	birthDate, valid := parseBirthDate(record.BirthDate)
+	if !valid {
+		problem("birthDate")
+	}
+	if len(record.LoanID) > 30 {
+		problem("loanId")
+	}
+	daysPastDue := record.DaysPastDue
+	if daysPastDue > 9999 {
+		daysPastDue = 9999
+	}
+	line := fmt.Sprintf("D%-16.16s%-50.50s%1.1s%8.8s%-30.30s%-3.3s%s%s%017d%017d%04d%d%s",
+		record.KTPNumber,
+		name,
+		gender,
+		birthDate,
+		record.LoanID,
+		record.Amount.Currency(),
+		record.StartDate.Format("20060102"),
+		record.DueDate.Format("20060102"),
+		record.Amount.MinorUnits(),
+		record.Outstanding.MinorUnits(),
+		daysPastDue,
+		record.Collectibility,
+		record.Condition,
+	)
+	return line, problems
+}
+
+func pad(line string) string {
+	return fmt.Sprintf("%-*s", creditRecordLength, line)
+}
+
+func genderCode(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "m", "male", "l", "laki-laki":
+		return "L"
+	case "f", "female", "p", "perempuan":
+		return "P"
+	}
+	return ""
+}
+
+func parseBirthDate(birthDate string) (string, bool) {
+	date, err := domain.ParseBirthDate(birthDate)
+	if err != nil {
+		return "", false
+	}
+	return date.Format("20060102"), true
+}
+
+func isPrintableASCII(text string) bool {
+	for _, char := range text {
+		if char < ' ' || char > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrLenderCodeMissing is returned when credit reporting file is written by Reporter without lender code
+var ErrLenderCodeMissing = errors.New("lender_code_missing")
+
+// ErrInvalidCreditRecords is returned when mandatory fields of credit records are missing or malformed
+var ErrInvalidCreditRecords = errors.New("invalid_credit_records")
+
+// InvalidCreditRecordsStruct is an error struct wrapping ErrInvalidCreditRecords with all problems found
+type InvalidCreditRecordsStruct struct {
+	error
+	Problems []CreditRecordProblem
+}
+
+// CreditRecordProblem is a mandatory field of a credit record which is missing or malformed
+type CreditRecordProblem struct {
+	KTPNumber string
+	LoanID    string
+	Field     string
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+const dateFormat = "2006-01-02"
+
+// WritePortfolioCSV writes one line with total outstanding balance and a line for every PAR bucket
+func WritePortfolioCSV(writer io.Writer, portfolio Portfolio) error {
+	records := [][]string{
+		{"asOf", "bucket", "minDaysPastDue", "loans", "outstanding", "currency", "basisPoints"},
+		{portfolio.AsOf.Format(dateFormat), "total", "0", strconv.Itoa(portfolio.Loans), portfolio.Outstanding.Amount(), string(portfolio.Outstanding.Currency()), "10000"},
+	}
+	for _, bucket := range portfolio.PAR {
+		records = append(records, []string{
+			portfolio.AsOf.Format(dateFormat),
+			bucket.Name,
+			strconv.FormatUint(uint64(bucket.MinDaysPastDue), 10),
+			strconv.Itoa(bucket.Loans),
+			bucket.Outstanding.Amount(),
+			string(bucket.Outstanding.Currency()),
+			strconv.FormatInt(bucket.BasisPoints, 10),
+		})
+	}
+	return writeCSV(writer, records)
+}
+
+// WriteVolumesCSV writes a line for every day
+func WriteVolumesCSV(writer io.Writer, volumes []DailyVolume) error {
+	records := [][]string{{"date", "disbursements", "disbursed", "repayments", "repaid", "currency"}}
+	for _, volume := range volumes {
+		records = append(records, []string{
+			volume.Date.Format(dateFormat),
+			strconv.Itoa(volume.Disbursements),
+			volume.Disbursed.Amount(),
+			strconv.Itoa(volume.Repayments),
+			volume.Repaid.Amount(),
+			string(volume.Disbursed.Currency()),
+		})
+	}
+	return writeCSV(writer, records)
+}
+
+// WriteVintagesCSV writes a line for every point of every vintage curve, so the file can be pivoted by month on book
+func WriteVintagesCSV(writer io.Writer, vintages []Vintage) error {
+	records := [][]string{{"month", "loans", "disbursed", "totalPayable", "monthsOnBook", "repaid", "repaidBasisPoints", "defaulted", "defaultedBasisPoints", "currency"}}
+	for _, vintage := range vintages {
+		for _, point := range vintage.Curve {
+			records = append(records, []string{
+				vintage.Month,
+				strconv.Itoa(vintage.Loans),
+				vintage.Disbursed.Amount(),
+				vintage.TotalPayable.Amount(),
+				strconv.Itoa(point.MonthsOnBook),
+				point.Repaid.Amount(),
+				strconv.FormatInt(point.RepaidBasisPoints, 10),
+				point.Defaulted.Amount(),
+				strconv.FormatInt(point.DefaultedBasisPoints, 10),
+				string(vintage.Disbursed.Currency()),
+			})
+		}
+	}
+	return writeCSV(writer, records)
+}
+
+func writeCSV(writer io.Writer, records [][]string) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.WriteAll(records); err != nil {
+		return err
+	}
+	return csvWriter.Error()
+}
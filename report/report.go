@@ -0,0 +1,324 @@
+// Package report computes portfolio reports of the loan book for management. It scans all clients in cola.ClientRepo,
+// amounts of loans in other currencies are converted to cola.ReportingCurrency by exchange rates snapshotted on the
+// loans at origination.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+)
+
+// Reporter provides reports of the loan book
+type Reporter interface {
+	// Portfolio is outstanding balance of disbursed loans and portfolio at risk as of the date
+	Portfolio(asOf time.Time) (Portfolio, error)
+	// Volumes lists disbursements and repayments for every day between from and to inclusive
+	Volumes(from, to time.Time) ([]DailyVolume, error)
+	// Vintages lists repayment and default curves of loans grouped by origination month
+	Vintages(asOf time.Time) ([]Vintage, error)
+	// CreditRecords lists loans which were owed during the month as of the end of the month
+	CreditRecords(month time.Time) ([]CreditRecord, error)
+	// WriteCreditReport writes monthly credit reporting file for the credit bureau
+	WriteCreditReport(writer io.Writer, month time.Time, generatedAt time.Time) error
+}
+
+// Portfolio is outstanding balance of active disbursed loans. Written-off loans are off the books and not included.
+type Portfolio struct {
+	AsOf        time.Time
+	Loans       int
+	Outstanding domain.Money
+	PAR         []PARBucket
+}
+
+// PARBucket is portfolio at risk, remaining amount of loans at least MinDaysPastDue days past due
+type PARBucket struct {
+	Name           string
+	MinDaysPastDue uint
+	Loans          int
+	Outstanding    domain.Money
+	// BasisPoints is the share of total outstanding balance rounded down
+	BasisPoints int64
+}
+
+// parBuckets are cumulative, a loan 90 days past due is in every bucket
+var parBuckets = []PARBucket{
+	{Name: "PAR1", MinDaysPastDue: 1},
+	{Name: "PAR30", MinDaysPastDue: 30},
+	{Name: "PAR60", MinDaysPastDue: 60},
+	{Name: "PAR90", MinDaysPastDue: 90},
+}
+
+// DailyVolume is money which left and came back on a day. Loans are disbursed on the day they start, repayments from
+// credit balance are not counted as the money was received before.
+type DailyVolume struct {
+	Date          time.Time
+	Disbursements int
+	Disbursed     domain.Money
+	Repayments    int
+	Repaid        domain.Money
+}
+
+// Vintage is a cohort of loans disbursed in the same month
+type Vintage struct {
+	// Month of origination formatted as YYYY-MM
+	Month        string
+	Loans        int
+	Disbursed    domain.Money
+	TotalPayable domain.Money
+	Curve        []VintagePoint
+}
+
+// VintagePoint is the state of a vintage at the end of a month on book or as of the report date for the current month.
+// Month of origination is month 0.
+type VintagePoint struct {
+	MonthsOnBook int
+	// Repaid is cumulative, RepaidBasisPoints is its share of total payable
+	Repaid            domain.Money
+	RepaidBasisPoints int64
+	// Defaulted is the amount of loans handed over to collections, DefaultedBasisPoints is its share of disbursed amount
+	Defaulted            domain.Money
+	DefaultedBasisPoints int64
+}
+
+// batchSize is a number of clients loaded from ClientRepo at once
+const batchSize = 100
+
+type reporter struct {
+	repo       cola.ClientRepo
+	lenderCode string
+}
+
+// New returns Reporter scanning all clients of the repo on every call
+func New(repo cola.ClientRepo, options ...Option) Reporter {
+	reporter := &reporter{repo: repo}
+	for _, option := range options {
+		option(reporter)
+	}
+	return reporter
+}
+
+func (reporter *reporter) Portfolio(asOf time.Time) (Portfolio, error) {
+	portfolio := Portfolio{AsOf: asOf, Outstanding: zero(), PAR: make([]PARBucket, len(parBuckets))}
+	copy(portfolio.PAR, parBuckets)
+	for i := range portfolio.PAR {
+		portfolio.PAR[i].Outstanding = zero()
+	}
+	err := reporter.forEachLoan(func(loan domain.Loan) error {
+		if loan.DisbursementStatus() != domain.Disbursed || loan.IsWrittenOff() || loan.Remaining().IsZero() {
+			return nil
+		}
+		remaining, err := add(portfolio.Outstanding, loan, loan.Remaining())
+		if err != nil {
+			return err
+		}
+		portfolio.Loans++
+		portfolio.Outstanding = remaining
+		daysPastDue := loan.DaysPastDue(asOf)
+		for i, bucket := range portfolio.PAR {
+			if daysPastDue < bucket.MinDaysPastDue {
+				continue
+			}
+			if portfolio.PAR[i].Outstanding, err = add(bucket.Outstanding, loan, loan.Remaining()); err != nil {
+				return err
+			}
+			portfolio.PAR[i].Loans++
+		}
+		return nil
+	})
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("reporting portfolio: %v", err)
+	}
+	for i, bucket := range portfolio.PAR {
+		portfolio.PAR[i].BasisPoints = basisPoints(bucket.Outstanding, portfolio.Outstanding)
+	}
+	return portfolio, nil
+}
+
+func (reporter *reporter) Volumes(from, to time.Time) ([]DailyVolume, error) {
+	from, to = day(from), day(to)
+	if to.Before(from) {
+		return nil, ErrInvalidPeriod
+	}
+	var volumes []DailyVolume
+	index := make(map[time.Time]int)
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		index[date] = len(volumes)
+		volumes = append(volumes, DailyVolume{Date: date, Disbursed: zero(), Repaid: zero()})
+	}
+	err := reporter.forEachLoan(func(loan domain.Loan) error {
+		if loan.DisbursementStatus() != domain.Disbursed {
+			return nil
+		}
+		if i, found := index[day(loan.StartDate())]; found {
+			disbursed, err := add(volumes[i].Disbursed, loan, loan.Amount())
+			if err != nil {
+				return err
+			}
+			volumes[i].Disbursements++
+			volumes[i].Disbursed = disbursed
+		}
+		for _, repayment := range loan.Repayments() {
+			i, found := index[day(repayment.PaidAt)]
+			if !found || repayment.FromCredit {
+				continue
+			}
+			repaid, err := add(volumes[i].Repaid, loan, repayment.Amount)
+			if err != nil {
+				return err
+			}
+			volumes[i].Repayments++
+			volumes[i].Repaid = repaid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reporting volumes: %v", err)
+	}
+	return volumes, nil
+}
+
+func (reporter *reporter) Vintages(asOf time.Time) ([]Vintage, error) {
+	var loans []domain.Loan
+	err := reporter.forEachLoan(func(loan domain.Loan) error {
+		if loan.DisbursementStatus() == domain.Disbursed && !loan.StartDate().After(asOf) {
+			loans = append(loans, loan)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reporting vintages: %v", err)
+	}
+	byMonth := make(map[time.Time]*Vintage)
+	var months []time.Time
+	for _, loan := range loans {
+		origination := monthOf(loan.StartDate())
+		vintage, found := byMonth[origination]
+		if !found {
+			vintage = &Vintage{Month: origination.Format("2006-01"), Disbursed: zero(), TotalPayable: zero()}
+			byMonth[origination] = vintage
+			months = append(months, origination)
+		}
+		if vintage.Disbursed, err = add(vintage.Disbursed, loan, loan.Amount()); err != nil {
+			return nil, fmt.Errorf("reporting vintages: %v", err)
+		}
+		if vintage.TotalPayable, err = add(vintage.TotalPayable, loan, loan.TotalPayable()); err != nil {
+			return nil, fmt.Errorf("reporting vintages: %v", err)
+		}
+		vintage.Loans++
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+	vintages := make([]Vintage, 0, len(months))
+	for _, origination := range months {
+		vintage := byMonth[origination]
+		for monthsOnBook := 0; !origination.AddDate(0, monthsOnBook, 0).After(asOf); monthsOnBook++ {
+			point, err := vintagePoint(loans, origination, monthsOnBook, asOf)
+			if err != nil {
+				return nil, fmt.Errorf("reporting vintage %s: %v", vintage.Month, err)
+			}
+			point.RepaidBasisPoints = basisPoints(point.Repaid, vintage.TotalPayable)
+			point.DefaultedBasisPoints = basisPoints(point.Defaulted, vintage.Disbursed)
+			vintage.Curve = append(vintage.Curve, point)
+		}
+		vintages = append(vintages, *vintage)
+	}
+	return vintages, nil
+}
+
+// vintagePoint sums repayments and defaults of loans originated in the month until the end of the month on book
+func vintagePoint(loans []domain.Loan, origination time.Time, monthsOnBook int, asOf time.Time) (VintagePoint, error) {
+	until := origination.AddDate(0, monthsOnBook+1, 0).Add(-time.Nanosecond)
+	if until.After(asOf) {
+		until = asOf
+	}
+	point := VintagePoint{MonthsOnBook: monthsOnBook, Repaid: zero(), Defaulted: zero()}
+	var err error
+	for _, loan := range loans {
+		if !monthOf(loan.StartDate()).Equal(origination) {
+			continue
+		}
+		for _, repayment := range loan.Repayments() {
+			if repayment.PaidAt.After(until) {
+				continue
+			}
+			if point.Repaid, err = add(point.Repaid, loan, repayment.Amount); err != nil {
+				return VintagePoint{}, err
+			}
+		}
+		if loan.IsDefaulted() && !loan.DefaultedAt().After(until) {
+			if point.Defaulted, err = add(point.Defaulted, loan, loan.Amount()); err != nil {
+				return VintagePoint{}, err
+			}
+		}
+	}
+	return point, nil
+}
+
+func (reporter *reporter) forEachLoan(process func(loan domain.Loan) error) error {
+	return reporter.forEachClient(func(client domain.Client) error {
+		for _, loan := range client.Loans() {
+			if err := process(loan); err != nil {
+				return fmt.Errorf("loan %s: %v", loan.ID(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// forEachClient pages through clients ordered by KTP number and stops on the first error
+func (reporter *reporter) forEachClient(process func(client domain.Client) error) error {
+	query := cola.ClientQuery{Limit: batchSize}
+	for {
+		page, err := reporter.repo.Search(query)
+		if err != nil {
+			return err
+		}
+		for _, client := range page.Clients {
+			if err := process(client); err != nil {
+				return err
+			}
+		}
+		if !page.HasNext {
+			return nil
+		}
+		query.After = page.Clients[len(page.Clients)-1].KTPNumber()
+	}
+}
+
+// add converts amount of the loan to cola.ReportingCurrency and adds it to total
+func add(total domain.Money, loan domain.Loan, amount domain.Money) (domain.Money, error) {
+	converted, err := loan.ReportingRate().Convert(amount)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return total.Add(converted)
+}
+
+func zero() domain.Money {
+	return domain.NewMoney(0, cola.ReportingCurrency)
+}
+
+func basisPoints(part, total domain.Money) int64 {
+	if total.IsZero() {
+		return 0
+	}
+	return part.MinorUnits() * 10000 / total.MinorUnits()
+}
+
+// ErrInvalidPeriod is returned when volumes are requested for a period ending before it starts
+var ErrInvalidPeriod = errors.New("invalid_period")
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
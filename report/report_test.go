@@ -0,0 +1,221 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms/cola"
+	"github.com/briyanadityatama/goLoans/lms/cola/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+const term = domain.Term(30)
+
+var (
+	january  = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	february = time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// newReporter returns Reporter of three loans:
+//   - 10 000 000 IDR started on 1 January, 2 400 000 repaid on 10 January
+//   - 10 000 000 IDR started on 15 January and defaulted on 15 May
+//   - 1 000 SGD started on 1 February and converted at 12 000 IDR
+func newReporter() Reporter {
+	repo := cola.NewFakeClientRepo()
+	rupiah := domain.IdentityRate(domain.IDR, january)
+	first := disbursedClient("3522582509010001", domain.PaydayLoan, domain.Rupiah(10000000), january, rupiah)
+	first.Repay(domain.Rupiah(2400000), january.AddDate(0, 0, 9))
+	second := disbursedClient("3522582509010002", domain.PaydayLoan, domain.Rupiah(10000000), january.AddDate(0, 0, 14), rupiah)
+	second.Dun(domain.DefaultDunningSchedule, second.ActiveLoan().DueDate().AddDate(0, 0, 90))
+	sgd, _ := domain.ParseMoney("1000", domain.SGD)
+	rate, _ := domain.NewExchangeRate(domain.SGD, domain.IDR, "12000", february)
+	third := disbursedClient("3522582509010003", domain.PaydayLoanSGD, sgd, february, rate)
+	for _, client := range []domain.Client{first, second, third} {
+		repo.Save(client)
+	}
+	return New(repo)
+}
+
+func disbursedClient(ktpNumber string, product domain.Product, amount domain.Money, startDate time.Time, rate domain.ExchangeRate) domain.Client {
+	client := domain.NewClient("", "", "", ktpNumber)
+	client.ApplyForLoan(product, amount, term, startDate, rate)
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", startDate)
+	return client
+}
+
+func TestPortfolio(t *testing.T) {
+	asOf := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	portfolio, err := newReporter().Portfolio(asOf)
+	assert.Nil(t, err)
+	t.Run("should sum remaining amounts in IDR", func(t *testing.T) {
+		assert.Equal(t, 3, portfolio.Loans)
+		assert.Equal(t, domain.Rupiah(10000000+12400000+12468000), portfolio.Outstanding)
+	})
+	t.Run("should bucket loans by days past due", func(t *testing.T) {
+		assert.Equal(t, []PARBucket{
+			{Name: "PAR1", MinDaysPastDue: 1, Loans: 2, Outstanding: domain.Rupiah(22400000), BasisPoints: 6424},
+			{Name: "PAR30", MinDaysPastDue: 30, Loans: 1, Outstanding: domain.Rupiah(10000000), BasisPoints: 2867},
+			{Name: "PAR60", MinDaysPastDue: 60, Outstanding: domain.Rupiah(0)},
+			{Name: "PAR90", MinDaysPastDue: 90, Outstanding: domain.Rupiah(0)},
+		}, portfolio.PAR)
+	})
+}
+
+func TestVolumes(t *testing.T) {
+	volumes, err := newReporter().Volumes(january, january.AddDate(0, 0, 14))
+	assert.Nil(t, err)
+	assert.Len(t, volumes, 15)
+	assert.Equal(t, DailyVolume{Date: january, Disbursements: 1, Disbursed: domain.Rupiah(10000000), Repaid: domain.Rupiah(0)}, volumes[0])
+	assert.Equal(t, DailyVolume{Date: january.AddDate(0, 0, 9), Disbursed: domain.Rupiah(0), Repayments: 1, Repaid: domain.Rupiah(2400000)}, volumes[9])
+	assert.Equal(t, 1, volumes[14].Disbursements)
+	t.Run("period ending before it starts", func(t *testing.T) {
+		_, err := newReporter().Volumes(february, january)
+		assert.Equal(t, ErrInvalidPeriod, err)
+	})
+}
+
+func TestVintages(t *testing.T) {
+	asOf := time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)
+	vintages, err := newReporter().Vintages(asOf)
+	assert.Nil(t, err)
+	assert.Len(t, vintages, 2)
+	t.Run("should group loans by origination month", func(t *testing.T) {
+		assert.Equal(t, "2026-01", vintages[0].Month)
+		assert.Equal(t, 2, vintages[0].Loans)
+		assert.Equal(t, domain.Rupiah(20000000), vintages[0].Disbursed)
+		assert.Equal(t, domain.Rupiah(24800000), vintages[0].TotalPayable)
+		assert.Equal(t, "2026-02", vintages[1].Month)
+		assert.Equal(t, domain.Rupiah(12000000), vintages[1].Disbursed)
+	})
+	t.Run("should trace repayments and defaults by month on book", func(t *testing.T) {
+		curve := vintages[0].Curve
+		assert.Len(t, curve, 5)
+		assert.Equal(t, VintagePoint{MonthsOnBook: 0, Repaid: domain.Rupiah(2400000), RepaidBasisPoints: 967, Defaulted: domain.Rupiah(0)}, curve[0])
+		assert.Equal(t, domain.Rupiah(0), curve[3].Defaulted)
+		assert.Equal(t, VintagePoint{MonthsOnBook: 4, Repaid: domain.Rupiah(2400000), RepaidBasisPoints: 967, Defaulted: domain.Rupiah(10000000), DefaultedBasisPoints: 5000}, curve[4])
+		assert.Len(t, vintages[1].Curve, 4)
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	reporter := newReporter()
+	t.Run("portfolio", func(t *testing.T) {
+		portfolio, _ := reporter.Portfolio(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC))
+		var buffer bytes.Buffer
+		assert.Nil(t, WritePortfolioCSV(&buffer, portfolio))
+		assert.Equal(t, "asOf,bucket,minDaysPastDue,loans,outstanding,currency,basisPoints\n"+
+			"2026-03-03,total,0,3,34868000.00,IDR,10000\n"+
+			"2026-03-03,PAR1,1,2,22400000.00,IDR,6424\n"+
+			"2026-03-03,PAR30,30,1,10000000.00,IDR,2867\n"+
+			"2026-03-03,PAR60,60,0,0.00,IDR,0\n"+
+			"2026-03-03,PAR90,90,0,0.00,IDR,0\n", buffer.String())
+	})
+	t.Run("volumes", func(t *testing.T) {
+		volumes, _ := reporter.Volumes(january, january)
+		var buffer bytes.Buffer
+		assert.Nil(t, WriteVolumesCSV(&buffer, volumes))
+		assert.Equal(t, "date,disbursements,disbursed,repayments,repaid,currency\n"+
+			"2026-01-01,1,10000000.00,0,0.00,IDR\n", buffer.String())
+	})
+	t.Run("vintages", func(t *testing.T) {
+		vintages, _ := reporter.Vintages(february)
+		var buffer bytes.Buffer
+		assert.Nil(t, WriteVintagesCSV(&buffer, vintages))
+		assert.Equal(t, "month,loans,disbursed,totalPayable,monthsOnBook,repaid,repaidBasisPoints,defaulted,defaultedBasisPoints,currency\n"+
+			"2026-01,2,20000000.00,24800000.00,0,2400000.00,967,0.00,0,IDR\n"+
+			"2026-01,2,20000000.00,24800000.00,1,2400000.00,967,0.00,0,IDR\n"+
+			"2026-02,1,12000000.00,12468000.00,0,0.00,0,0.00,0,IDR\n", buffer.String())
+	})
+}
+
+func TestCreditRecords(t *testing.T) {
+	reporter := newReporter()
+	t.Run("should list loans owed during the month", func(t *testing.T) {
+		records, err := reporter.CreditRecords(january)
+		assert.Nil(t, err)
+		assert.Len(t, records, 2)
+		assert.Equal(t, CreditRecord{
+			KTPNumber:      "3522582509010001",
+			LoanID:         "3522582509010001-1",
+			StartDate:      january,
+			DueDate:        january.AddDate(0, 0, 30),
+			Amount:         domain.Rupiah(10000000),
+			Outstanding:    domain.Rupiah(10000000),
+			Collectibility: 1,
+			Condition:      Active,
+		}, records[0])
+	})
+	t.Run("should classify loans by days past due at the end of the month", func(t *testing.T) {
+		records, _ := reporter.CreditRecords(february)
+		assert.Len(t, records, 3)
+		assert.Equal(t, uint(28), records[0].DaysPastDue)
+		assert.Equal(t, 2, records[0].Collectibility)
+		assert.Equal(t, uint(14), records[1].DaysPastDue)
+		assert.Equal(t, uint(0), records[2].DaysPastDue)
+	})
+	t.Run("should restore outstanding balance of past months", func(t *testing.T) {
+		repo := cola.NewFakeClientRepo()
+		client := disbursedClient("3522582509010002", domain.PaydayLoan, domain.Rupiah(10000000), january, domain.IdentityRate(domain.IDR, january))
+		client.Repay(domain.Rupiah(12400000), february.AddDate(0, 0, 2))
+		repo.Save(client)
+		records, _ := New(repo).CreditRecords(january)
+		assert.Equal(t, domain.Rupiah(12400000), records[0].Outstanding)
+		assert.Equal(t, Active, records[0].Condition)
+		records, _ = New(repo).CreditRecords(february)
+		assert.Equal(t, domain.Rupiah(0), records[0].Outstanding)
+		assert.Equal(t, PaidOff, records[0].Condition)
+		records, _ = New(repo).CreditRecords(february.AddDate(0, 1, 0))
+		assert.Empty(t, records)
+	})
+}
+
+func TestCollectibility(t *testing.T) {
+	for daysPastDue, expected := range map[uint]int{0: 1, 1: 2, 90: 2, 91: 3, 120: 3, 121: 4, 180: 4, 181: 5} {
+		assert.Equal(t, expected, Collectibility(daysPastDue, false), daysPastDue)
+	}
+	assert.Equal(t, 5, Collectibility(0, true))
+}
+
+func TestWriteCreditReport(t *testing.T) {
+	repo := cola.NewFakeClientRepo()
+	client := domain.NewClient("female", "1 December 1994", "Doe", "3522582509010002")
+	client.ApplyForLoan(domain.PaydayLoan, domain.Rupiah(10000000), term, january, domain.IdentityRate(domain.IDR, january))
+	client.StartDisbursement()
+	client.CompleteDisbursement("ref", january)
+	repo.Save(client)
+	generatedAt := february.AddDate(0, 0, 1)
+	t.Run("should write fixed-width file", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.Nil(t, New(repo, WithLenderCode("LND001")).WriteCreditReport(&buffer, january, generatedAt))
+		header := "HLND001    20260120260202000000001" + strings.Repeat(" ", 131)
+		record := "D3522582509010002DOE" + strings.Repeat(" ", 47) + "P19941201" + "3522582509010002-1" + strings.Repeat(" ", 12) +
+			"IDR2026010120260131" + "00000001000000000" + "00000001240000000" + "0000" + "1" + "A"
+		assert.Equal(t, header+"\n"+record+"\n", buffer.String())
+		assert.Len(t, record, creditRecordLength)
+	})
+	t.Run("should require lender code", func(t *testing.T) {
+		assert.Equal(t, ErrLenderCodeMissing, New(repo).WriteCreditReport(&bytes.Buffer{}, january, generatedAt))
+	})
+	t.Run("should list all missing mandatory fields", func(t *testing.T) {
+		var buffer bytes.Buffer
+		err := newReporterWithLenderCode().WriteCreditReport(&buffer, january, generatedAt)
+		assert.Equal(t, InvalidCreditRecordsStruct{ErrInvalidCreditRecords, []CreditRecordProblem{
+			{KTPNumber: "3522582509010001", LoanID: "3522582509010001-1", Field: "name"},
+			{KTPNumber: "3522582509010001", LoanID: "3522582509010001-1", Field: "gender"},
+			{KTPNumber: "3522582509010001", LoanID: "3522582509010001-1", Field: "birthDate"},
+			{KTPNumber: "3522582509010002", LoanID: "3522582509010002-1", Field: "name"},
+			{KTPNumber: "3522582509010002", LoanID: "3522582509010002-1", Field: "gender"},
+			{KTPNumber: "3522582509010002", LoanID: "3522582509010002-1", Field: "birthDate"},
+		}}, err)
+		assert.Empty(t, buffer.String())
+	})
+}
+
+func newReporterWithLenderCode() Reporter {
+	reporter := newReporter().(*reporter)
+	WithLenderCode("LND001")(reporter)
+	return reporter
+}
@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/rest/rest"
+)
+
+// agreementHashHeader carries SHA-256 of the HTML agreement, the client accepts the agreement by sending it back
+const agreementHashHeader = "X-Agreement-Hash"
+
+// getAgreement renders the agreement of client's active loan. Optional format query parameter is html (default) or pdf.
+// The hash of the HTML agreement is returned in agreementHashHeader for both formats.
+func (server *LoansServer) getAgreement(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/goLoans/agreement")
+	format := request.URL.Query().Get("format")
+	if format != "" && format != "html" && format != "pdf" {
+		writer.WriteJSONError(errUnknownAgreementFormat, 400)
+		return
+	}
+	agreement, err := server.lms.LoanAgreement(ktpNumber)
+	if err == lms.ErrClientDoesNotExist || err == lms.ErrNoActiveLoan {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem getting loan agreement of client %s: %s", ktpNumber, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	document, hash, err := lms.RenderAgreement(agreement)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem rendering loan agreement of client %s: %s", ktpNumber, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	writer.Header().Add(agreementHashHeader, hash)
+	if format == "pdf" {
+		var text string
+		if text, err = lms.RenderAgreementText(agreement); err != nil {
+			errorDto := fmt.Sprintf("problem rendering loan agreement of client %s: %s", ktpNumber, err.Error())
+			writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+			return
+		}
+		writer.Header().Add("Content-Type", "application/pdf")
+		writer.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "agreement-"+agreement.LoanID+".pdf"))
+		writer.WriteHeader(200)
+		err = writePDF(writer, strings.Split(strings.TrimSuffix(text, "\n"), "\n"))
+	} else {
+		writer.Header().Add("Content-Type", "text/html; charset=utf-8")
+		writer.WriteHeader(200)
+		_, err = writer.Write(document)
+	}
+	if err != nil {
+		log.Printf("[WARN] problem writing loan agreement of client %s: %s", ktpNumber, err.Error())
+	}
+}
+
+// postAgreementAcceptance accepts the agreement of client's active loan. The hash must be the hash of the current
+// agreement, the IP address is taken from the connection.
+func (server *LoansServer) postAgreementAcceptance(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/goLoans/agreement/acceptance")
+	var acceptance postAgreementAcceptanceRequest
+	err := request.ReadJSONBody(&acceptance)
+	if err != nil || acceptance.Hash == "" {
+		writer.WriteJSONError(errHashMissing, 400)
+		return
+	}
+	err = server.lms.AcceptAgreement(ktpNumber, lms.AgreementAcceptance{
+		Hash:           acceptance.Hash,
+		IPAddress:      clientIP(request),
+		OTPChallengeID: acceptance.OTPChallengeID,
+	})
+	if err == lms.ErrClientDoesNotExist || err == lms.ErrNoActiveLoan {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err == lms.ErrOTPVerificationRequired {
+		writer.WriteJSONError(err, 403)
+		return
+	}
+	if err == lms.ErrDisbursementFailed {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 409)
+		return
+	}
+	writer.WriteHeader(200)
+}
+
+// clientIP is the address of the connection without port
+func clientIP(request *rest.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+// postAgreementAcceptanceRequest DTO for JSON unmarshaling
+type postAgreementAcceptanceRequest struct {
+	Hash           string `json:"hash"`
+	OTPChallengeID string `json:"otpChallengeId"`
+}
+
+// errUnknownAgreementFormat is returned when the agreement is requested in other format than html or pdf
+var errUnknownAgreementFormat = errors.New("unknown_agreement_format")
+
+// errHashMissing is returned when the client accepts the agreement without its hash
+var errHashMissing = errors.New("hash_missing")
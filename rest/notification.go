@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/rest/rest"
+)
+
+// getClientNotifications lists notifications sent to the client with their delivery status
+func (server *LoansServer) getClientNotifications(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/notifications")
+	notifications, err := server.lms.NotificationsOfClient(ktpNumber)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem listing notifications of client %s: %s", ktpNumber, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := getNotificationsResponse{Notifications: []notificationResponse{}}
+	for _, notification := range notifications {
+		response.Notifications = append(response.Notifications, notificationResponseOf(notification))
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem listing notifications of client %s: %s", ktpNumber, err.Error())
+	}
+}
+
+func notificationResponseOf(notification lms.Notification) notificationResponse {
+	response := notificationResponse{
+		ID:        notification.ID,
+		Event:     notification.Event,
+		Reference: notification.Reference,
+		Channel:   notification.Channel,
+		Recipient: notification.Recipient,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+		CreatedAt: notification.CreatedAt,
+		Status:    notification.Status,
+		Attempts:  notification.Attempts,
+		LastError: notification.LastError,
+	}
+	if !notification.DeliveredAt.IsZero() {
+		response.DeliveredAt = &notification.DeliveredAt
+	}
+	if !notification.NextAttemptAt.IsZero() {
+		response.NextAttemptAt = &notification.NextAttemptAt
+	}
+	return response
+}
+
+// getNotificationsResponse DTO for JSON marshaling
+type getNotificationsResponse struct {
+	Notifications []notificationResponse `json:"notifications"`
+}
+
+// notificationResponse DTO for JSON marshaling
+type notificationResponse struct {
+	ID            string     `json:"id"`
+	Event         string     `json:"event"`
+	Reference     string     `json:"reference"`
+	Channel       string     `json:"channel"`
+	Recipient     string     `json:"recipient,omitempty"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	Status        string     `json:"status"`
+	Attempts      uint       `json:"attempts"`
+	LastError     string     `json:"lastError,omitempty"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/rest/rest"
+)
+
+// postOTPChallenges sends a one-time code to the phone number, the code is verified by posting it to the challenge
+func (server *LoansServer) postOTPChallenges(writer *rest.ResponseWriter, request *rest.Request) {
+	var challengeRequest postOTPChallengesRequest
+	err := request.ReadJSONBody(&challengeRequest)
+	if err != nil || challengeRequest.Phone == "" {
+		writer.WriteJSONError(errPhoneMissing, 400)
+		return
+	}
+	challenge, err := server.lms.ChallengeOTP(challengeRequest.Phone, challengeRequest.Purpose)
+	if err == lms.ErrOTPDisabled {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err == lms.ErrOTPResendTooSoon {
+		writer.WriteJSONError(err, 429)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	writer.Header().Add("Location", server.publicURL+"/otp/challenges/"+challenge.ID)
+	writer.WriteHeader(201)
+	err = writer.WriteJSON(otpChallengeResponse{
+		ID:        challenge.ID,
+		Phone:     challenge.Phone,
+		Purpose:   challenge.Purpose,
+		ExpiresAt: challenge.ExpiresAt,
+		Links:     []link{{"verify", server.publicURL + "/otp/challenges/" + challenge.ID + "/verify"}},
+	})
+	if err != nil {
+		log.Printf("[WARN] problem sending otp to %s: %s", challengeRequest.Phone, err.Error())
+	}
+}
+
+func (server *LoansServer) postOTPVerify(writer *rest.ResponseWriter, request *rest.Request) {
+	id := strings.TrimSuffix(request.URL.Path[len("/otp/challenges/"):], "/verify")
+	var verifyRequest postOTPVerifyRequest
+	err := request.ReadJSONBody(&verifyRequest)
+	if err != nil || verifyRequest.Code == "" {
+		writer.WriteJSONError(errCodeMissing, 400)
+		return
+	}
+	err = server.lms.VerifyOTP(id, verifyRequest.Code)
+	if err == lms.ErrOTPDisabled || err == lms.ErrOTPChallengeDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	writer.WriteHeader(200)
+}
+
+// postOTPChallengesRequest DTO for JSON unmarshaling
+type postOTPChallengesRequest struct {
+	Phone   string `json:"phone"`
+	Purpose string `json:"purpose"`
+}
+
+// postOTPVerifyRequest DTO for JSON unmarshaling
+type postOTPVerifyRequest struct {
+	Code string `json:"code"`
+}
+
+// otpChallengeResponse DTO for JSON marshaling
+type otpChallengeResponse struct {
+	ID        string    `json:"id"`
+	Phone     string    `json:"phone"`
+	Purpose   string    `json:"purpose"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Links     []link    `json:"links"`
+}
+
+// errPhoneMissing is returned when a one-time code is requested without the phone number
+var errPhoneMissing = errors.New("phone_missing")
+
+// errCodeMissing is returned when a challenge is verified without the code
+var errCodeMissing = errors.New("code_missing")
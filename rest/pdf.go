@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PDF page is A4 in points, text is set in Helvetica which every PDF reader has built in
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 10
+	pdfLeading      = 14
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+	// pdfLineLength is how many characters of Helvetica fit between margins
+	pdfLineLength = 95
+)
+
+// writePDF writes lines of text as a minimal PDF document, lines longer than the page are wrapped on spaces. Only
+// ASCII is printed, other characters are replaced with question marks.
+func writePDF(writer io.Writer, lines []string) error {
+	var wrapped []string
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapLine(line, pdfLineLength)...)
+	}
+	var pages [][]string
+	for len(wrapped) > pdfLinesPerPage {
+		pages = append(pages, wrapped[:pdfLinesPerPage])
+		wrapped = wrapped[pdfLinesPerPage:]
+	}
+	pages = append(pages, wrapped)
+	// objects 1 and 2 are the catalog and the page tree, 3 is the font, then every page is followed by its content
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"}
+	var kids []string
+	for _, page := range pages {
+		pageNumber := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNumber))
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, pageNumber+1))
+		content := pageContent(page)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = document.Len()
+		fmt.Fprintf(&document, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err := document.WriteTo(writer)
+	return err
+}
+
+func pageContent(lines []string) string {
+	var content strings.Builder
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) '\n", escapePDFText(line))
+	}
+	content.WriteString("ET")
+	return content.String()
+}
+
+func escapePDFText(text string) string {
+	var escaped strings.Builder
+	for _, char := range text {
+		switch {
+		case char == '(' || char == ')' || char == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(char)
+		case char < ' ' || char > '~':
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(char)
+		}
+	}
+	return escaped.String()
+}
+
+func wrapLine(line string, length int) []string {
+	var wrapped []string
+	for len(line) > length {
+		cut := strings.LastIndex(line[:length], " ")
+		if cut <= 0 {
+			cut = length
+		}
+		wrapped = append(wrapped, line[:cut])
+		line = strings.TrimLeft(line[cut:], " ")
+	}
+	return append(wrapped, line)
+}
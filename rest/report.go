@@ -0,0 +1,290 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/report"
+	"github.com/briyanadityatama/goLoans/rest/rest"
+)
+
+// getPortfolioReport is used by management identified by officerHeader. Optional asOf query parameter defaults to today, format=csv returns CSV.
+func (server *LoansServer) getPortfolioReport(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	asOf, err := dateParameter(request, "asOf", time.Now())
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	portfolio, err := server.reporter.Portfolio(asOf)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem reporting portfolio: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := portfolioReportDto{
+		AsOf:        portfolio.AsOf.Format(dateFormat),
+		Loans:       portfolio.Loans,
+		Outstanding: portfolio.Outstanding,
+		PAR:         []parBucketDto{},
+	}
+	for _, bucket := range portfolio.PAR {
+		response.PAR = append(response.PAR, parBucketDto{
+			Bucket:         bucket.Name,
+			MinDaysPastDue: bucket.MinDaysPastDue,
+			Loans:          bucket.Loans,
+			Outstanding:    bucket.Outstanding,
+			BasisPoints:    bucket.BasisPoints,
+		})
+	}
+	writeReport(writer, request, "portfolio", response, func(csv io.Writer) error {
+		return report.WritePortfolioCSV(csv, portfolio)
+	})
+}
+
+// getVolumesReport lists every day between from and to query parameters. Without them the last 30 days are listed.
+func (server *LoansServer) getVolumesReport(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	to, err := dateParameter(request, "to", time.Now())
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	from, err := dateParameter(request, "from", to.AddDate(0, 0, -29))
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	volumes, err := server.reporter.Volumes(from, to)
+	if err == report.ErrInvalidPeriod {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem reporting volumes: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := volumesReportDto{Days: []dailyVolumeDto{}}
+	for _, volume := range volumes {
+		response.Days = append(response.Days, dailyVolumeDto{
+			Date:          volume.Date.Format(dateFormat),
+			Disbursements: volume.Disbursements,
+			Disbursed:     volume.Disbursed,
+			Repayments:    volume.Repayments,
+			Repaid:        volume.Repaid,
+		})
+	}
+	writeReport(writer, request, "volumes", response, func(csv io.Writer) error {
+		return report.WriteVolumesCSV(csv, volumes)
+	})
+}
+
+// getVintagesReport returns curves of all origination months until optional asOf query parameter
+func (server *LoansServer) getVintagesReport(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	asOf, err := dateParameter(request, "asOf", time.Now())
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	vintages, err := server.reporter.Vintages(asOf)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem reporting vintages: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := vintagesReportDto{Vintages: []vintageDto{}}
+	for _, vintage := range vintages {
+		vintageDto := vintageDto{
+			Month:        vintage.Month,
+			Loans:        vintage.Loans,
+			Disbursed:    vintage.Disbursed,
+			TotalPayable: vintage.TotalPayable,
+			Curve:        []vintagePointDto{},
+		}
+		for _, point := range vintage.Curve {
+			vintageDto.Curve = append(vintageDto.Curve, vintagePointDto{
+				MonthsOnBook:         point.MonthsOnBook,
+				Repaid:               point.Repaid,
+				RepaidBasisPoints:    point.RepaidBasisPoints,
+				Defaulted:            point.Defaulted,
+				DefaultedBasisPoints: point.DefaultedBasisPoints,
+			})
+		}
+		response.Vintages = append(response.Vintages, vintageDto)
+	}
+	writeReport(writer, request, "vintages", response, func(csv io.Writer) error {
+		return report.WriteVintagesCSV(csv, vintages)
+	})
+}
+
+// getCreditReport returns credit reporting file of the month given by month query parameter formatted as YYYY-MM.
+// The previous month is reported by default. It is downloaded by an officer identified by officerHeader.
+func (server *LoansServer) getCreditReport(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	if value := request.URL.Query().Get("month"); value != "" {
+		var err error
+		if month, err = time.Parse(monthFormat, value); err != nil {
+			writer.WriteJSONError(errInvalidDate, 400)
+			return
+		}
+	}
+	var file bytes.Buffer
+	err := server.reporter.WriteCreditReport(&file, month, now)
+	var invalid report.InvalidCreditRecordsStruct
+	if errors.As(err, &invalid) {
+		response := invalidCreditRecordsDto{Error: report.ErrInvalidCreditRecords.Error(), Problems: []creditRecordProblemDto{}}
+		for _, problem := range invalid.Problems {
+			response.Problems = append(response.Problems, creditRecordProblemDto{
+				KTPNumber: problem.KTPNumber,
+				LoanID:    problem.LoanID,
+				Field:     problem.Field,
+				Links:     []link{{"client", server.publicURL + "/clients/" + problem.KTPNumber}},
+			})
+		}
+		writer.Header().Add("Content-Type", "application/json")
+		writer.WriteHeader(422)
+		if err := writer.WriteJSON(response); err != nil {
+			log.Printf("[WARN] problem writing credit report problems: %s", err.Error())
+		}
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem writing credit report: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	writer.Header().Add("Content-Type", "text/plain")
+	writer.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "SLIK_"+month.Format("200601")+".txt"))
+	writer.WriteHeader(200)
+	if _, err := file.WriteTo(writer); err != nil {
+		log.Printf("[WARN] problem writing credit report: %s", err.Error())
+	}
+}
+
+// writeReport writes JSON response or CSV attachment depending on format query parameter
+func writeReport(writer *rest.ResponseWriter, request *rest.Request, name string, response interface{}, writeCSV func(io.Writer) error) {
+	var err error
+	switch request.URL.Query().Get("format") {
+	case "", "json":
+		writer.Header().Add("Content-Type", "application/json")
+		writer.WriteHeader(200)
+		err = writer.WriteJSON(response)
+	case "csv":
+		writer.Header().Add("Content-Type", "text/csv")
+		writer.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+		writer.WriteHeader(200)
+		err = writeCSV(writer)
+	default:
+		writer.WriteJSONError(errUnknownReportFormat, 400)
+		return
+	}
+	if err != nil {
+		log.Printf("[WARN] problem writing %s report: %s", name, err.Error())
+	}
+}
+
+// dateParameter parses optional query parameter formatted as YYYY-MM-DD
+func dateParameter(request *rest.Request, name string, defaultValue time.Time) (time.Time, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	date, err := time.Parse(dateFormat, value)
+	if err != nil {
+		return time.Time{}, errInvalidDate
+	}
+	return date, nil
+}
+
+// errUnknownReportFormat is returned when format query parameter is neither json nor csv
+var errUnknownReportFormat = errors.New("unknown_report_format")
+
+const monthFormat = "2006-01"
+
+// errInvalidDate is returned when date query parameter is not formatted as YYYY-MM-DD or month as YYYY-MM
+var errInvalidDate = errors.New("invalid_date")
+
+// portfolioReportDto DTO for JSON marshaling
+type portfolioReportDto struct {
+	AsOf        string         `json:"asOf"`
+	Loans       int            `json:"loans"`
+	Outstanding lms.Money      `json:"outstanding"`
+	PAR         []parBucketDto `json:"par"`
+}
+
+// parBucketDto DTO for JSON marshaling
+type parBucketDto struct {
+	Bucket         string    `json:"bucket"`
+	MinDaysPastDue uint      `json:"minDaysPastDue"`
+	Loans          int       `json:"loans"`
+	Outstanding    lms.Money `json:"outstanding"`
+	BasisPoints    int64     `json:"basisPoints"`
+}
+
+// volumesReportDto DTO for JSON marshaling
+type volumesReportDto struct {
+	Days []dailyVolumeDto `json:"days"`
+}
+
+// dailyVolumeDto DTO for JSON marshaling
+type dailyVolumeDto struct {
+	Date          string    `json:"date"`
+	Disbursements int       `json:"disbursements"`
+	Disbursed     lms.Money `json:"disbursed"`
+	Repayments    int       `json:"repayments"`
+	Repaid        lms.Money `json:"repaid"`
+}
+
+// vintagesReportDto DTO for JSON marshaling
+type vintagesReportDto struct {
+	Vintages []vintageDto `json:"vintages"`
+}
+
+// vintageDto DTO for JSON marshaling
+type vintageDto struct {
+	Month        string            `json:"month"`
+	Loans        int               `json:"loans"`
+	Disbursed    lms.Money         `json:"disbursed"`
+	TotalPayable lms.Money         `json:"totalPayable"`
+	Curve        []vintagePointDto `json:"curve"`
+}
+
+// vintagePointDto DTO for JSON marshaling
+type vintagePointDto struct {
+	MonthsOnBook         int       `json:"monthsOnBook"`
+	Repaid               lms.Money `json:"repaid"`
+	RepaidBasisPoints    int64     `json:"repaidBasisPoints"`
+	Defaulted            lms.Money `json:"defaulted"`
+	DefaultedBasisPoints int64     `json:"defaultedBasisPoints"`
+}
+
+// invalidCreditRecordsDto DTO for JSON marshaling
+type invalidCreditRecordsDto struct {
+	Error    string                   `json:"error"`
+	Problems []creditRecordProblemDto `json:"problems"`
+}
+
+// creditRecordProblemDto DTO for JSON marshaling
+type creditRecordProblemDto struct {
+	KTPNumber string `json:"ktpNumber"`
+	LoanID    string `json:"loanId"`
+	Field     string `json:"field"`
+	Links     []link `json:"links"`
+}
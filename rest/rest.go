@@ -2,13 +2,21 @@
 package rest
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/report"
 	"github.com/briyanadityatama/goLoans/rest/rest"
+	"github.com/briyanadityatama/goLoans/statement"
 )
 
 // LoansServer is REST server providing lms functionality
@@ -16,12 +24,13 @@ type LoansServer struct {
 	addr      string
 	publicURL string
 	lms       lms.Lms
+	reporter  report.Reporter
 	server    *http.Server
 }
 
 // NewLoansServer initialize LoansServer
-func NewLoansServer(addr string, publicURL string, lms lms.Lms) *LoansServer {
-	return &LoansServer{addr: addr, publicURL: publicURL, lms: lms}
+func NewLoansServer(addr string, publicURL string, lms lms.Lms, reporter report.Reporter) *LoansServer {
+	return &LoansServer{addr: addr, publicURL: publicURL, lms: lms, reporter: reporter}
 }
 
 // Start blocks current goroutine
@@ -30,7 +39,7 @@ func (server *LoansServer) Start() error {
 	mux := http.NewServeMux()
 	mux.Handle("/", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
 		writer.WriteHeader(404)
-		fmt.Fprintln(writer, "Use /clients")
+		fmt.Fprint(writer, "Use /clients")
 	}))
 	mux.Handle("/clients", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
 		switch request.Method {
@@ -43,9 +52,199 @@ func (server *LoansServer) Start() error {
 		}
 	}))
 	mux.Handle("/clients/", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch {
+		case strings.HasSuffix(request.URL.Path, "/goLoans/disbursement"):
+			switch request.Method {
+			case "POST":
+				server.postDisbursement(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/goLoans/agreement/acceptance"):
+			switch request.Method {
+			case "POST":
+				server.postAgreementAcceptance(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/goLoans/agreement"):
+			switch request.Method {
+			case "GET":
+				server.getAgreement(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/goLoans/review"):
+			switch request.Method {
+			case "POST":
+				server.postReview(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/goLoans/writeOff"):
+			switch request.Method {
+			case "POST":
+				server.postWriteOff(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/goLoans/repayments"):
+			switch request.Method {
+			case "POST":
+				server.postRepayments(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/goLoans"):
+			switch request.Method {
+			case "POST":
+				server.postLoans(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/applications"):
+			switch request.Method {
+			case "GET":
+				server.getClientApplications(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/notifications"):
+			switch request.Method {
+			case "GET":
+				server.getClientNotifications(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/refunds"):
+			switch request.Method {
+			case "POST":
+				server.postRefunds(writer, request)
+			}
+		case strings.HasSuffix(request.URL.Path, "/export"):
+			switch request.Method {
+			case "GET":
+				server.getClientExport(writer, request)
+			}
+		default:
+			switch request.Method {
+			case "GET":
+				server.getClient(writer, request)
+			case "PATCH":
+				server.patchClient(writer, request)
+			case "DELETE":
+				server.deleteClient(writer, request)
+			}
+		}
+	}))
+	mux.Handle("/applications/", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch {
+		case strings.HasSuffix(request.URL.Path, "/cancel"):
+			switch request.Method {
+			case "POST":
+				server.postApplicationCancel(writer, request)
+			}
+		default:
+			switch request.Method {
+			case "GET":
+				server.getApplication(writer, request)
+			}
+		}
+	}))
+	mux.Handle("/otp/challenges", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "POST":
+			server.postOTPChallenges(writer, request)
+		}
+	}))
+	mux.Handle("/otp/challenges/", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch {
+		case strings.HasSuffix(request.URL.Path, "/verify"):
+			switch request.Method {
+			case "POST":
+				server.postOTPVerify(writer, request)
+			}
+		}
+	}))
+	mux.Handle("/quotes", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "POST":
+			server.postQuotes(writer, request)
+		}
+	}))
+	mux.Handle("/statements", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "POST":
+			server.postStatements(writer, request)
+		}
+	}))
+	mux.Handle("/dunning", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getDunning(writer, request)
+		}
+	}))
+	mux.Handle("/reviews", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getReviews(writer, request)
+		}
+	}))
+	mux.Handle("/ledger/trialBalance", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getTrialBalance(writer, request)
+		}
+	}))
+	mux.Handle("/reports/portfolio", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getPortfolioReport(writer, request)
+		}
+	}))
+	mux.Handle("/reports/volumes", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getVolumesReport(writer, request)
+		}
+	}))
+	mux.Handle("/reports/vintages", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getVintagesReport(writer, request)
+		}
+	}))
+	mux.Handle("/reports/slik", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
 		switch request.Method {
 		case "GET":
-			server.getClient(writer, request)
+			server.getCreditReport(writer, request)
+		}
+	}))
+	mux.Handle("/webhooks/subscriptions", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getWebhookSubscriptions(writer, request)
+		case "POST":
+			server.postWebhookSubscriptions(writer, request)
+		}
+	}))
+	mux.Handle("/webhooks/subscriptions/", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch {
+		case strings.HasSuffix(request.URL.Path, "/deliveries"):
+			switch request.Method {
+			case "GET":
+				server.getWebhookDeliveries(writer, request)
+			}
+		default:
+			switch request.Method {
+			case "GET":
+				server.getWebhookSubscription(writer, request)
+			case "DELETE":
+				server.deleteWebhookSubscription(writer, request)
+			}
+		}
+	}))
+	mux.Handle("/webhooks/deliveries/", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch {
+		case strings.HasSuffix(request.URL.Path, "/replay"):
+			switch request.Method {
+			case "POST":
+				server.postWebhookDeliveryReplay(writer, request)
+			}
+		}
+	}))
+	mux.Handle("/virtualAccounts/", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getVirtualAccount(writer, request)
+		}
+	}))
+	mux.Handle("/products", rest.HandlerFunc(func(writer *rest.ResponseWriter, request *rest.Request) {
+		switch request.Method {
+		case "GET":
+			server.getProducts(writer, request)
 		}
 	}))
 	server.server = &http.Server{Addr: server.addr, Handler: mux}
@@ -54,12 +253,85 @@ func (server *LoansServer) Start() error {
 
 // Stop can be executed from a different goroutine to stop the server
 func (server *LoansServer) Stop() error {
-	return server.server.Shutdown(nil)
+	return server.server.Shutdown(context.Background())
 }
 
-func (server *LoansServer) getClients(w *rest.ResponseWriter, r *rest.Request) {
-	w.WriteHeader(405)
-	fmt.Fprintln(w, "POST /clients to register a new client")
+// getClients lists clients for back-office, it is done by an officer identified by officerHeader
+func (server *LoansServer) getClients(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	query, err := clientQuery(request.URL.Query())
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	page, err := server.lms.Clients(query)
+	if err == lms.ErrInvalidCursor {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem searching clients: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := getClientsResponse{Clients: []listedClient{}, Links: []link{}}
+	for _, client := range page.Clients {
+		response.Clients = append(response.Clients, listedClient{
+			getClientResponse: server.clientResponse(client),
+			HasActiveLoan:     client.HasActiveLoan(),
+			DaysPastDue:       client.DaysPastDue(page.AsOf),
+		})
+	}
+	if page.NextCursor != "" {
+		response.Links = append(response.Links, link{"next", server.pageLink(request.URL.Query(), page.NextCursor)})
+	}
+	if page.PreviousCursor != "" {
+		response.Links = append(response.Links, link{"prev", server.pageLink(request.URL.Query(), page.PreviousCursor)})
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem searching clients: %s", err.Error())
+	}
+}
+
+func clientQuery(params url.Values) (query lms.ClientQuery, err error) {
+	query.NamePrefix = params.Get("namePrefix")
+	query.BirthDate = params.Get("birthDate")
+	query.Cursor = params.Get("cursor")
+	if query.HasActiveLoan, err = boolParam(params, "hasActiveLoan"); err != nil {
+		return
+	}
+	if query.Overdue, err = boolParam(params, "overdue"); err != nil {
+		return
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			err = fmt.Errorf("limit query parameter should be a number: %s", limit)
+		}
+	}
+	return
+}
+
+func boolParam(params url.Values, name string) (*bool, error) {
+	value := params.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s query parameter should be true or false: %s", name, value)
+	}
+	return &parsed, nil
+}
+
+func (server *LoansServer) pageLink(params url.Values, cursor string) string {
+	params.Set("cursor", cursor)
+	return server.publicURL + "/clients?" + params.Encode()
 }
 
 func (server *LoansServer) postClients(writer *rest.ResponseWriter, request *rest.Request) {
@@ -71,6 +343,10 @@ func (server *LoansServer) postClients(writer *rest.ResponseWriter, request *res
 		return
 	}
 	client, err := server.lms.RegisterClient(clientData)
+	if err == lms.ErrOTPVerificationRequired {
+		writer.WriteJSONError(err, 403)
+		return
+	}
 	if err != nil {
 		writer.WriteJSONError(err, 400)
 		return
@@ -93,24 +369,954 @@ func (server *LoansServer) getClient(writer *rest.ResponseWriter, request *rest.
 		return
 	}
 	writer.WriteHeader(200)
+	err = writer.WriteJSON(server.clientResponse(client))
+	if err != nil {
+		log.Printf("[WARN] problem getting client with ktpNumber %s: %s", ktpNumber, err.Error())
+	}
+}
+
+// getVirtualAccount redirects to the client who owns the virtual account
+func (server *LoansServer) getVirtualAccount(writer *rest.ResponseWriter, request *rest.Request) {
+	number := request.URL.Path[len("/virtualAccounts/"):]
+	client, found, err := server.lms.ClientByVirtualAccount(number)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem getting client with virtual account %s: %s", number, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	if !found {
+		writer.WriteJSONError(lms.ErrClientDoesNotExist, 404)
+		return
+	}
+	writer.Header().Add("Location", server.publicURL+"/clients/"+client.KTPNumber())
+	writer.WriteHeader(303)
+}
+
+func (server *LoansServer) clientResponse(client lms.Client) getClientResponse {
 	selfLink := fmt.Sprintf("%s/clients/%s/goLoans", server.publicURL, client.KTPNumber())
 	response := getClientResponse{
-		KTPNumber: client.KTPNumber(),
-		BirthDate: client.BirthDate(),
-		Name:      client.Name(),
-		Loans:     goLoans{[]link{{"self", selfLink}}}}
+		KTPNumber:      client.KTPNumber(),
+		VirtualAccount: client.VirtualAccount(),
+		BirthDate:      client.BirthDate(),
+		Name:           client.Name(),
+		Phone:          client.Phone(),
+		Email:          client.Email(),
+		Address:        client.Address(),
+		Loans:          goLoans{[]link{{"self", selfLink}}}}
+	if creditBalance := client.CreditBalance(); !creditBalance.IsZero() {
+		response.CreditBalance = &creditBalance
+	}
+	if client.BankAccountNumber() != "" {
+		response.BankAccount = &bankAccountDto{
+			BankCode:   client.BankCode(),
+			Number:     client.BankAccountNumber(),
+			HolderName: client.BankAccountHolder(),
+		}
+	}
+	return response
+}
+
+// patchClient implements JSON merge patch (RFC 7396) of client profile, otpChallengeId next to the profile fields
+// verifies a new phone number
+func (server *LoansServer) patchClient(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := request.URL.Path[len("/clients/"):]
+	var mergePatch map[string]json.RawMessage
+	err := request.ReadJSONBody(&mergePatch)
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	patch, err := clientPatch(mergePatch)
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	client, err := server.lms.UpdateClient(ktpNumber, patch)
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err == lms.ErrOTPVerificationRequired {
+		writer.WriteJSONError(err, 403)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(server.clientResponse(client))
+	if err != nil {
+		log.Printf("[WARN] problem patching client with ktpNumber %s: %s", ktpNumber, err.Error())
+	}
+}
+
+func (server *LoansServer) getClientExport(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/export")
+	export, err := server.lms.ExportClientData(ktpNumber)
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem exporting data of client with ktpNumber %s: %s", ktpNumber, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.Header().Add("Content-Disposition", fmt.Sprintf(`attachment; filename="client-%s.json"`, ktpNumber))
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(export)
+	if err != nil {
+		log.Printf("[WARN] problem exporting data of client with ktpNumber %s: %s", ktpNumber, err.Error())
+	}
+}
+
+// deleteClient erases personal data of the client, loans are retained
+func (server *LoansServer) deleteClient(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := request.URL.Path[len("/clients/"):]
+	err := server.lms.EraseClient(ktpNumber)
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 409)
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+// clientPatch converts JSON merge patch into lms.ClientPatch. JSON null clears the field.
+func clientPatch(mergePatch map[string]json.RawMessage) (patch lms.ClientPatch, err error) {
+	fields := map[string]**string{
+		"ktpNumber": &patch.KTPNumber,
+		"gender":    &patch.Gender,
+		"birthDate": &patch.BirthDate,
+		"name":      &patch.Name,
+		"phone":     &patch.Phone,
+		"email":     &patch.Email,
+		"address":   &patch.Address,
+	}
+	bankAccountFields := map[string]**string{
+		"bankCode":   &patch.BankCode,
+		"number":     &patch.BankAccountNumber,
+		"holderName": &patch.BankAccountHolder,
+	}
+	for name, value := range mergePatch {
+		if name == "otpChallengeId" {
+			if json.Unmarshal(value, &patch.OTPChallengeID) != nil {
+				err = errors.New("JSON merge patch field otpChallengeId should be a string")
+			}
+		} else if name == "bankAccount" {
+			err = patchBankAccount(value, bankAccountFields)
+		} else if field, ok := fields[name]; ok {
+			*field, err = patchValue(name, value)
+		} else {
+			err = fmt.Errorf("JSON merge patch contains unknown field %s", name)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func patchBankAccount(value json.RawMessage, fields map[string]**string) error {
+	if string(value) == "null" {
+		empty := ""
+		for _, field := range fields {
+			*field = &empty
+		}
+		return nil
+	}
+	var mergePatch map[string]json.RawMessage
+	if err := json.Unmarshal(value, &mergePatch); err != nil {
+		return fmt.Errorf("JSON merge patch of bankAccount should be an object: %s", err.Error())
+	}
+	for name, value := range mergePatch {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("JSON merge patch contains unknown field bankAccount.%s", name)
+		}
+		var err error
+		*field, err = patchValue("bankAccount."+name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func patchValue(name string, value json.RawMessage) (*string, error) {
+	var patched *string
+	if err := json.Unmarshal(value, &patched); err != nil {
+		return nil, fmt.Errorf("JSON merge patch field %s should be a string or null", name)
+	}
+	if patched == nil {
+		empty := ""
+		return &empty, nil
+	}
+	return patched, nil
+}
+
+func (server *LoansServer) postLoans(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/goLoans")
+	var loanData postLoansRequest
+	err := request.ReadJSONBody(&loanData)
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	application, err := server.lms.SubmitApplication(lms.ApplicationData{
+		KTPNumber:          ktpNumber,
+		ProductCode:        loanData.Product,
+		Amount:             loanData.Amount,
+		Term:               loanData.Term,
+		MonthlyIncome:      loanData.MonthlyIncome,
+		EmploymentType:     loanData.EmploymentType,
+		MonthlyObligations: loanData.MonthlyObligations,
+		Channel:            loanData.Channel,
+		IPAddress:          clientIP(request),
+	})
+	if application.ID != "" {
+		writer.Header().Add("Location", server.applicationURL(application.ID))
+	}
+	if err == lms.ErrLoanReferredForReview {
+		writer.WriteHeader(202)
+		return
+	}
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	writer.WriteHeader(201)
+}
+
+func (server *LoansServer) applicationURL(id string) string {
+	return server.publicURL + "/applications/" + id
+}
+
+func (server *LoansServer) getApplication(writer *rest.ResponseWriter, request *rest.Request) {
+	id := request.URL.Path[len("/applications/"):]
+	application, found, err := server.lms.ApplicationByID(id)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem getting application %s: %s", id, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	if !found {
+		writer.WriteJSONError(lms.ErrApplicationDoesNotExist, 404)
+		return
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(server.applicationResponse(application))
+	if err != nil {
+		log.Printf("[WARN] problem getting application %s: %s", id, err.Error())
+	}
+}
+
+func (server *LoansServer) getClientApplications(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/applications")
+	applications, err := server.lms.ApplicationsOfClient(ktpNumber)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem listing applications of client %s: %s", ktpNumber, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := getApplicationsResponse{Applications: []applicationResponse{}}
+	for _, application := range applications {
+		response.Applications = append(response.Applications, server.applicationResponse(application))
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
 	err = writer.WriteJSON(response)
 	if err != nil {
-		log.Printf("[WARN] problem getting client with ktpNumber %s: %s", ktpNumber, err.Error())
+		log.Printf("[WARN] problem listing applications of client %s: %s", ktpNumber, err.Error())
+	}
+}
+
+// postApplicationCancel withdraws the application which was not decided yet
+func (server *LoansServer) postApplicationCancel(writer *rest.ResponseWriter, request *rest.Request) {
+	id := strings.TrimSuffix(request.URL.Path[len("/applications/"):], "/cancel")
+	err := server.lms.CancelApplication(id)
+	if err == lms.ErrApplicationDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 409)
+		return
+	}
+	writer.WriteHeader(200)
+}
+
+func (server *LoansServer) applicationResponse(application lms.LoanApplication) applicationResponse {
+	response := applicationResponse{
+		ID:                 application.ID,
+		KTPNumber:          application.KTPNumber,
+		Product:            application.ProductCode,
+		Amount:             application.Amount,
+		Term:               application.Term,
+		MonthlyIncome:      application.MonthlyIncome,
+		EmploymentType:     application.EmploymentType,
+		MonthlyObligations: application.MonthlyObligations,
+		Status:             application.Status,
+		Reason:             application.Reason,
+		LoanID:             application.LoanID,
+		SubmittedAt:        application.SubmittedAt,
+		Channel:            application.Channel,
+		IPAddress:          application.IPAddress,
+		Links: []link{
+			{"self", server.applicationURL(application.ID)},
+			{"client", server.publicURL + "/clients/" + application.KTPNumber},
+		},
+	}
+	if !application.DecidedAt.IsZero() {
+		response.DecidedAt = &application.DecidedAt
+	}
+	if application.Scorecard != "" {
+		response.Score = &scoreDto{application.Scorecard, application.ScorePoints, application.ScoreBand, application.ReasonCodes}
+	}
+	return response
+}
+
+// postDisbursement retries disbursement of client's active loan
+func (server *LoansServer) postDisbursement(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/goLoans/disbursement")
+	err := server.lms.DisburseLoan(ktpNumber)
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 409)
+		return
+	}
+	writer.WriteHeader(200)
+}
+
+// officerHeader identifies the officer running back-office use cases. It is not authentication, it is trusted to be set
+// by a proxy in front of the server which authenticates officers and strips the header from other requests.
+const officerHeader = "X-Officer-ID"
+
+// authorizeOfficer writes error response and returns false unless officerHeader identifies an officer who may run
+// back-office use cases
+func (server *LoansServer) authorizeOfficer(writer *rest.ResponseWriter, request *rest.Request) bool {
+	err := server.lms.AuthorizeOfficer(request.Header.Get(officerHeader))
+	switch err {
+	case nil:
+		return true
+	case lms.ErrOfficerRequired:
+		writer.WriteJSONError(err, 401)
+	case lms.ErrOfficerNotAuthorized:
+		writer.WriteJSONError(err, 403)
+	default:
+		errorDto := fmt.Sprintf("problem authorizing officer: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+	}
+	return false
+}
+
+// postWriteOff writes off defaulted loan of a client, it is done by an officer identified by officerHeader
+func (server *LoansServer) postWriteOff(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/goLoans/writeOff")
+	err := server.lms.WriteOffLoan(ktpNumber, request.Header.Get(officerHeader))
+	if err == lms.ErrOfficerRequired {
+		writer.WriteJSONError(err, 401)
+		return
+	}
+	if err == lms.ErrOfficerNotAuthorized {
+		writer.WriteJSONError(err, 403)
+		return
+	}
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 409)
+		return
+	}
+	writer.WriteHeader(200)
+}
+
+// postReview approves or declines loan referred for review, it is done by an officer identified by officerHeader
+func (server *LoansServer) postReview(writer *rest.ResponseWriter, request *rest.Request) {
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/goLoans/review")
+	var review postReviewRequest
+	err := request.ReadJSONBody(&review)
+	if err != nil || review.Approve == nil {
+		writer.WriteJSONError(errApproveMissing, 400)
+		return
+	}
+	err = server.lms.ReviewLoan(ktpNumber, request.Header.Get(officerHeader), *review.Approve)
+	if err == lms.ErrOfficerRequired {
+		writer.WriteJSONError(err, 401)
+		return
+	}
+	if err == lms.ErrOfficerNotAuthorized {
+		writer.WriteJSONError(err, 403)
+		return
+	}
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err == lms.ErrDisbursementFailed {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 409)
+		return
+	}
+	writer.WriteHeader(200)
+}
+
+// errApproveMissing is returned when review does not say whether the loan is approved
+var errApproveMissing = errors.New("approve_missing")
+
+// getReviews lists loans waiting for an officer to review them
+func (server *LoansServer) getReviews(writer *rest.ResponseWriter, request *rest.Request) {
+	loans, err := server.lms.LoansInReview()
+	if err != nil {
+		errorDto := fmt.Sprintf("problem listing loans in review: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := getReviewsResponse{Loans: []loanInReviewDto{}}
+	for _, loan := range loans {
+		response.Loans = append(response.Loans, loanInReviewDto{
+			KTPNumber: loan.KTPNumber,
+			Name:      loan.Name,
+			LoanID:    loan.LoanID,
+			Product:   loan.Product,
+			Amount:    loan.Amount,
+			Term:      loan.Term,
+			AppliedAt: loan.AppliedAt.Format(dateFormat),
+			Reason:    loan.Reason,
+			Links: []link{
+				{"client", server.publicURL + "/clients/" + loan.KTPNumber},
+				{"review", server.publicURL + "/clients/" + loan.KTPNumber + "/goLoans/review"},
+			},
+		})
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem listing loans in review: %s", err.Error())
+	}
+}
+
+// postRepayments books money received from the client outside of bank statements, it is done by an officer identified
+// by officerHeader
+func (server *LoansServer) postRepayments(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/goLoans/repayments")
+	var repayment postRepaymentsRequest
+	err := request.ReadJSONBody(&repayment)
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	err = server.lms.Repay(ktpNumber, repayment.Amount)
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	writer.WriteHeader(201)
+}
+
+// postRefunds transfers part of client's credit balance back to client's bank account, it is done by an officer
+// identified by officerHeader
+func (server *LoansServer) postRefunds(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	ktpNumber := strings.TrimSuffix(request.URL.Path[len("/clients/"):], "/refunds")
+	var refund postRefundsRequest
+	err := request.ReadJSONBody(&refund)
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	err = server.lms.RequestRefund(ktpNumber, refund.Amount)
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err == lms.ErrRefundPending {
+		writer.WriteJSONError(err, 202)
+		return
+	}
+	if err == lms.ErrRefundFailed {
+		writer.WriteJSONError(err, 409)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	writer.WriteHeader(201)
+}
+
+// postStatements reconciles bank statement sent in request body. Format is given by format query parameter:
+// csv (default) or fixed-width. It is done by an officer identified by officerHeader.
+func (server *LoansServer) postStatements(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	var lines []lms.StatementLine
+	var err error
+	switch format := request.URL.Query().Get("format"); format {
+	case "", "csv":
+		lines, err = statement.ParseCSV(request.Body)
+	case "fixed-width":
+		lines, err = statement.ParseFixedWidth(request.Body)
+	default:
+		err = fmt.Errorf("unknown statement format %s, use csv or fixed-width", format)
+	}
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	reconciliation, err := server.lms.Reconcile(lines)
+	if err != nil {
+		errorDto := fmt.Sprintf("problem reconciling statement: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := postStatementsResponse{
+		Matched:    reconciledLines(reconciliation.Matched),
+		Unmatched:  reconciledLines(reconciliation.Unmatched),
+		Overpaid:   reconciledLines(reconciliation.Overpaid),
+		Duplicates: reconciledLines(reconciliation.Duplicates),
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem reconciling statement: %s", err.Error())
 	}
 }
 
+func reconciledLines(lines []lms.ReconciledLine) []reconciledLineDto {
+	dtos := []reconciledLineDto{}
+	for _, line := range lines {
+		dto := reconciledLineDto{
+			BankReference: line.BankReference,
+			Date:          line.Date.Format(dateFormat),
+			Amount:        line.Amount,
+			Reference:     line.Reference,
+			KTPNumber:     line.KTPNumber,
+			Applied:       line.Applied,
+			Recovery:      line.Recovery,
+		}
+		if line.Reason != nil {
+			dto.Reason = line.Reason.Error()
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos
+}
+
+// getDunning is used by collection officers identified by officerHeader. Optional stage query parameter returns only
+// the given stage.
+func (server *LoansServer) getDunning(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	stages, err := server.lms.LoansByDunningStage()
+	if err != nil {
+		errorDto := fmt.Sprintf("problem listing loans by dunning stage: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	filter := request.URL.Query().Get("stage")
+	response := getDunningResponse{Stages: []dunningStageDto{}}
+	for _, stage := range stages {
+		if filter != "" && stage.Stage != filter {
+			continue
+		}
+		stageDto := dunningStageDto{Stage: stage.Stage, Action: stage.Action, Loans: []dunningLoanDto{}}
+		for _, loan := range stage.Loans {
+			stageDto.Loans = append(stageDto.Loans, dunningLoanDto{
+				KTPNumber:     loan.KTPNumber,
+				Name:          loan.Name,
+				LoanID:        loan.LoanID,
+				DueDate:       loan.DueDate.Format(dateFormat),
+				DaysPastDue:   loan.DaysPastDue,
+				AmountPastDue: loan.AmountPastDue,
+				Remaining:     loan.Remaining,
+				Defaulted:     loan.Defaulted,
+				Links:         []link{{"client", server.publicURL + "/clients/" + loan.KTPNumber}},
+			})
+		}
+		response.Stages = append(response.Stages, stageDto)
+	}
+	if filter != "" && len(response.Stages) == 0 {
+		writer.WriteJSONError(errUnknownDunningStage, 400)
+		return
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem listing loans by dunning stage: %s", err.Error())
+	}
+}
+
+// errUnknownDunningStage is returned when stage query parameter is not one of the configured stages
+var errUnknownDunningStage = errors.New("unknown_dunning_stage")
+
+// getTrialBalance is used by finance. Mismatches list loans and credit balances whose ledger balance is wrong.
+func (server *LoansServer) getTrialBalance(writer *rest.ResponseWriter, request *rest.Request) {
+	trialBalance, err := server.lms.TrialBalance()
+	if err != nil {
+		errorDto := fmt.Sprintf("problem building trial balance: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := getTrialBalanceResponse{Accounts: []trialBalanceLineDto{}, Mismatches: []ledgerMismatchDto{}}
+	for _, line := range trialBalance.Lines {
+		response.Accounts = append(response.Accounts, trialBalanceLineDto{
+			Account:  line.Account,
+			Currency: line.Currency,
+			Debit:    line.Debit,
+			Credit:   line.Credit,
+		})
+	}
+	for _, mismatch := range trialBalance.Mismatches {
+		response.Mismatches = append(response.Mismatches, ledgerMismatchDto{
+			KTPNumber: mismatch.KTPNumber,
+			LoanID:    mismatch.LoanID,
+			Ledger:    mismatch.Ledger,
+			Expected:  mismatch.Expected,
+			Links:     []link{{"client", server.publicURL + "/clients/" + mismatch.KTPNumber}},
+		})
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem building trial balance: %s", err.Error())
+	}
+}
+
+func (server *LoansServer) getProducts(writer *rest.ResponseWriter, request *rest.Request) {
+	var response getProductsResponse
+	for _, product := range server.lms.Products() {
+		response.Products = append(response.Products, productDto{
+			Code:                     product.Code(),
+			Name:                     product.Name(),
+			Currency:                 product.Currency(),
+			MinAmount:                product.MinAmount(),
+			MaxAmount:                product.MaxAmount(),
+			MinTerm:                  product.MinTerm(),
+			MaxTerm:                  product.MaxTerm(),
+			InstalmentPeriod:         product.InstalmentPeriod(),
+			FeeBasisPoints:           product.FeeBasisPoints(),
+			DailyInterestBasisPoints: product.DailyInterestBasisPoints(),
+		})
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err := writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem getting products: %s", err.Error())
+	}
+}
+
+func (server *LoansServer) postQuotes(writer *rest.ResponseWriter, request *rest.Request) {
+	var quoteData postQuotesRequest
+	err := request.ReadJSONBody(&quoteData)
+	if err != nil {
+		writer.WriteHeader(400)
+		fmt.Fprintln(writer, err.Error())
+		return
+	}
+	quote, err := server.lms.Quote(quoteData.KTPNumber, quoteData.Product, quoteData.Amount, quoteData.Term)
+	if err == lms.ErrClientDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	response := postQuotesResponse{
+		Product:      quote.ProductCode,
+		Amount:       quote.Amount,
+		Term:         quote.Term,
+		Fee:          quote.Fee,
+		Interest:     quote.Interest,
+		TotalPayable: quote.TotalPayable,
+		Eligible:     quote.Eligible,
+	}
+	for _, instalment := range quote.Instalments {
+		response.Instalments = append(response.Instalments, instalmentDto{
+			DueDate: instalment.DueDate.Format(dateFormat),
+			Amount:  instalment.Amount,
+		})
+	}
+	if quote.IneligibilityReason != nil {
+		response.IneligibilityReason = quote.IneligibilityReason.Error()
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem writing quote for %s: %s", quoteData.Product, err.Error())
+	}
+}
+
+// dateFormat is used for all dates in JSON responses
+const dateFormat = "2006-01-02"
+
+// postQuotesRequest DTO for JSON unmarshaling
+type postQuotesRequest struct {
+	KTPNumber string    `json:"ktpNumber"`
+	Product   string    `json:"product"`
+	Amount    lms.Money `json:"amount"`
+	Term      uint      `json:"term"`
+}
+
+// postQuotesResponse DTO for JSON marshaling
+type postQuotesResponse struct {
+	Product             string          `json:"product"`
+	Amount              lms.Money       `json:"amount"`
+	Term                uint            `json:"term"`
+	Fee                 lms.Money       `json:"fee"`
+	Interest            lms.Money       `json:"interest"`
+	TotalPayable        lms.Money       `json:"totalPayable"`
+	Instalments         []instalmentDto `json:"instalments"`
+	Eligible            bool            `json:"eligible"`
+	IneligibilityReason string          `json:"ineligibilityReason,omitempty"`
+}
+
+// instalmentDto DTO for JSON marshaling
+type instalmentDto struct {
+	DueDate string    `json:"dueDate"`
+	Amount  lms.Money `json:"amount"`
+}
+
+// postLoansRequest DTO for JSON unmarshaling
+type postLoansRequest struct {
+	Product            string    `json:"product"`
+	Amount             lms.Money `json:"amount"`
+	Term               uint      `json:"term"`
+	MonthlyIncome      lms.Money `json:"monthlyIncome"`
+	EmploymentType     string    `json:"employmentType"`
+	MonthlyObligations lms.Money `json:"monthlyObligations"`
+	Channel            string    `json:"channel"`
+}
+
+// postRepaymentsRequest DTO for JSON unmarshaling
+type postRepaymentsRequest struct {
+	Amount lms.Money `json:"amount"`
+}
+
+// postReviewRequest DTO for JSON unmarshaling
+type postReviewRequest struct {
+	Approve *bool `json:"approve"`
+}
+
+// getReviewsResponse DTO for JSON marshaling
+type getReviewsResponse struct {
+	Loans []loanInReviewDto `json:"loans"`
+}
+
+// getApplicationsResponse DTO for JSON marshaling
+type getApplicationsResponse struct {
+	Applications []applicationResponse `json:"applications"`
+}
+
+// applicationResponse DTO for JSON marshaling
+type applicationResponse struct {
+	ID                 string     `json:"id"`
+	KTPNumber          string     `json:"ktpNumber"`
+	Product            string     `json:"product"`
+	Amount             lms.Money  `json:"amount"`
+	Term               uint       `json:"term"`
+	MonthlyIncome      lms.Money  `json:"monthlyIncome"`
+	EmploymentType     string     `json:"employmentType"`
+	MonthlyObligations lms.Money  `json:"monthlyObligations"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason,omitempty"`
+	LoanID             string     `json:"loanId,omitempty"`
+	SubmittedAt        time.Time  `json:"submittedAt"`
+	DecidedAt          *time.Time `json:"decidedAt,omitempty"`
+	Channel            string     `json:"channel,omitempty"`
+	IPAddress          string     `json:"ipAddress,omitempty"`
+	Score              *scoreDto  `json:"score,omitempty"`
+	Links              []link     `json:"links"`
+}
+
+// scoreDto DTO for JSON marshaling
+type scoreDto struct {
+	Scorecard   string   `json:"scorecard"`
+	Points      int      `json:"points"`
+	Band        string   `json:"band"`
+	ReasonCodes []string `json:"reasonCodes"`
+}
+
+// loanInReviewDto DTO for JSON marshaling
+type loanInReviewDto struct {
+	KTPNumber string    `json:"ktpNumber"`
+	Name      string    `json:"name"`
+	LoanID    string    `json:"loanId"`
+	Product   string    `json:"product"`
+	Amount    lms.Money `json:"amount"`
+	Term      uint      `json:"term"`
+	AppliedAt string    `json:"appliedAt"`
+	Reason    string    `json:"reason"`
+	Links     []link    `json:"links"`
+}
+
+// postRefundsRequest DTO for JSON unmarshaling
+type postRefundsRequest struct {
+	Amount lms.Money `json:"amount"`
+}
+
+// postStatementsResponse DTO for JSON marshaling
+type postStatementsResponse struct {
+	Matched    []reconciledLineDto `json:"matched"`
+	Unmatched  []reconciledLineDto `json:"unmatched"`
+	Overpaid   []reconciledLineDto `json:"overpaid"`
+	Duplicates []reconciledLineDto `json:"duplicates"`
+}
+
+// reconciledLineDto DTO for JSON marshaling
+type reconciledLineDto struct {
+	BankReference string    `json:"bankReference"`
+	Date          string    `json:"date"`
+	Amount        lms.Money `json:"amount"`
+	Reference     string    `json:"reference"`
+	KTPNumber     string    `json:"ktpNumber,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Applied       bool      `json:"applied"`
+	Recovery      bool      `json:"recovery,omitempty"`
+}
+
+// getDunningResponse DTO for JSON marshaling
+type getDunningResponse struct {
+	Stages []dunningStageDto `json:"stages"`
+}
+
+// dunningStageDto DTO for JSON marshaling
+type dunningStageDto struct {
+	Stage  string           `json:"stage"`
+	Action string           `json:"action"`
+	Loans  []dunningLoanDto `json:"loans"`
+}
+
+// dunningLoanDto DTO for JSON marshaling
+type dunningLoanDto struct {
+	KTPNumber     string    `json:"ktpNumber"`
+	Name          string    `json:"name"`
+	LoanID        string    `json:"loanId"`
+	DueDate       string    `json:"dueDate"`
+	DaysPastDue   uint      `json:"daysPastDue"`
+	AmountPastDue lms.Money `json:"amountPastDue"`
+	Remaining     lms.Money `json:"remaining"`
+	Defaulted     bool      `json:"defaulted"`
+	Links         []link    `json:"links"`
+}
+
+// getTrialBalanceResponse DTO for JSON marshaling
+type getTrialBalanceResponse struct {
+	Accounts   []trialBalanceLineDto `json:"accounts"`
+	Mismatches []ledgerMismatchDto   `json:"mismatches"`
+}
+
+// trialBalanceLineDto DTO for JSON marshaling
+type trialBalanceLineDto struct {
+	Account  string       `json:"account"`
+	Currency lms.Currency `json:"currency"`
+	Debit    lms.Money    `json:"debit"`
+	Credit   lms.Money    `json:"credit"`
+}
+
+// ledgerMismatchDto DTO for JSON marshaling
+type ledgerMismatchDto struct {
+	KTPNumber string    `json:"ktpNumber"`
+	LoanID    string    `json:"loanId,omitempty"`
+	Ledger    lms.Money `json:"ledger"`
+	Expected  lms.Money `json:"expected"`
+	Links     []link    `json:"links"`
+}
+
+// getProductsResponse DTO for JSON marshaling
+type getProductsResponse struct {
+	Products []productDto `json:"products"`
+}
+
+// productDto DTO for JSON marshaling
+type productDto struct {
+	Code                     string       `json:"code"`
+	Name                     string       `json:"name"`
+	Currency                 lms.Currency `json:"currency"`
+	MinAmount                lms.Money    `json:"minAmount"`
+	MaxAmount                lms.Money    `json:"maxAmount"`
+	MinTerm                  uint         `json:"minTerm"`
+	MaxTerm                  uint         `json:"maxTerm"`
+	InstalmentPeriod         uint         `json:"instalmentPeriod"`
+	FeeBasisPoints           uint         `json:"feeBasisPoints"`
+	DailyInterestBasisPoints uint         `json:"dailyInterestBasisPoints"`
+}
+
 // getClientResponse DTO for JSON marshaling
 type getClientResponse struct {
-	KTPNumber string  `json:"ktpNumber"`
-	BirthDate string  `json:"birthDate"`
-	Name      string  `json:"name"`
-	Loans     goLoans `json:"goLoans"`
+	KTPNumber      string          `json:"ktpNumber"`
+	VirtualAccount string          `json:"virtualAccount,omitempty"`
+	BirthDate      string          `json:"birthDate"`
+	Name           string          `json:"name"`
+	Phone          string          `json:"phone,omitempty"`
+	Email          string          `json:"email,omitempty"`
+	Address        string          `json:"address,omitempty"`
+	BankAccount    *bankAccountDto `json:"bankAccount,omitempty"`
+	CreditBalance  *lms.Money      `json:"creditBalance,omitempty"`
+	Loans          goLoans         `json:"goLoans"`
+}
+
+// getClientsResponse DTO for JSON marshaling
+type getClientsResponse struct {
+	Clients []listedClient `json:"clients"`
+	Links   []link         `json:"links"`
+}
+
+// listedClient DTO for JSON marshaling
+type listedClient struct {
+	getClientResponse
+	HasActiveLoan bool `json:"hasActiveLoan"`
+	DaysPastDue   uint `json:"daysPastDue"`
+}
+
+// bankAccountDto DTO for JSON marshaling
+type bankAccountDto struct {
+	BankCode   string `json:"bankCode"`
+	Number     string `json:"number"`
+	HolderName string `json:"holderName"`
 }
 
 // goLoans DTO for JSON marshaling
@@ -132,6 +1338,6 @@ type technicalError struct {
 
 func (server *LoansServer) optionsClients(w *rest.ResponseWriter,
 	r *rest.Request) {
-	w.Header().Add("Allow", "OPTIONS, POST")
+	w.Header().Add("Allow", "OPTIONS, GET, POST")
 	w.WriteHeader(200)
 }
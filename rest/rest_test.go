@@ -2,10 +2,13 @@ package rest
 
 import (
 	"errors"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/report"
 	"github.com/briyanadityatama/goLoans/testing/http"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,7 +31,7 @@ func TestGetSlash(t *testing.T) {
 }
 
 func newServer(lmsImplementation lms.Lms) *LoansServer {
-	return NewLoansServer(http.Address, "http://"+http.Address, lmsImplementation)
+	return NewLoansServer(http.Address, "http://"+http.Address, lmsImplementation, nil)
 }
 
 func TestClients(t *testing.T) {
@@ -36,15 +39,10 @@ func TestClients(t *testing.T) {
 	server := newServer(fakeLms)
 	go server.Start()
 	defer server.Stop()
-	t.Run("GET /clients", func(t *testing.T) {
-		response, status := http.Get("/clients")
-		assert.Equal(t, 405, status)
-		assert.Equal(t, "POST /clients to register a new client", response)
-	})
 	t.Run("OPTIONS /clients", func(t *testing.T) {
 		allowHeader, status := http.Options("/clients")
 		assert.Equal(t, 200, status)
-		assert.Equal(t, "OPTIONS, POST", allowHeader)
+		assert.Equal(t, "OPTIONS, GET, POST", allowHeader)
 	})
 	t.Run("POST /clients", func(t *testing.T) {
 		_, status, headers := http.Post("/clients",
@@ -166,3 +164,1324 @@ func TestGetClientWhenLmsIsFailing(t *testing.T) {
 	}
 	assert.Equal(t, expectedResponse, http.Unmarshal(response))
 }
+
+func TestGetProducts(t *testing.T) {
+	server := newServer(lms.NewFakeLms())
+	go server.Start()
+	defer server.Stop()
+	response, status := http.Get("/products")
+	assert.Equal(t, 200, status)
+	expectedResponse := map[string]interface{}{
+		"products": []interface{}{
+			map[string]interface{}{
+				"code":                     "payday",
+				"name":                     "Payday loan",
+				"currency":                 "IDR",
+				"minAmount":                money("500000.00"),
+				"maxAmount":                money("50000000.00"),
+				"minTerm":                  float64(7),
+				"maxTerm":                  float64(30),
+				"instalmentPeriod":         float64(0),
+				"feeBasisPoints":           float64(0),
+				"dailyInterestBasisPoints": float64(0),
+			},
+		},
+	}
+	assert.Equal(t, expectedResponse, http.Unmarshal(response))
+}
+
+// money is how lms.Money looks in JSON response
+func money(amount string) map[string]interface{} {
+	return map[string]interface{}{"amount": amount, "currency": "IDR"}
+}
+
+type LmsRecordingApplications struct {
+	lms.Lms
+	application lms.ApplicationData
+	err         error
+}
+
+func (recording *LmsRecordingApplications) SubmitApplication(application lms.ApplicationData) (lms.LoanApplication, error) {
+	recording.application = application
+	if recording.err == lms.ErrProductDoesNotExist || recording.err == lms.ErrClientDoesNotExist {
+		return lms.LoanApplication{}, recording.err
+	}
+	return lms.LoanApplication{ID: "APP0000000001"}, recording.err
+}
+
+func TestPostLoans(t *testing.T) {
+	recordingLms := &LmsRecordingApplications{Lms: lms.NewFakeLms()}
+	server := newServer(recordingLms)
+	go server.Start()
+	defer server.Stop()
+	t.Run("should apply for loan", func(t *testing.T) {
+		amount, _ := lms.ParseRupiah("1000000.50")
+		_, status, headers := http.Post("/clients/"+ktpNumber+"/goLoans", `{"product": "payday", "amount": {"amount": "1000000.50", "currency": "IDR"}, "term": 30,
+			"monthlyIncome": 8000000, "employmentType": "employed", "monthlyObligations": 500000, "channel": "android"}`)
+		assert.Equal(t, 201, status)
+		assert.Equal(t, server.publicURL+"/applications/APP0000000001", headers.Get("Location"))
+		assert.Equal(t, lms.ApplicationData{
+			KTPNumber:          ktpNumber,
+			ProductCode:        "payday",
+			Amount:             amount,
+			Term:               30,
+			MonthlyIncome:      lms.Rupiah(8000000),
+			EmploymentType:     "employed",
+			MonthlyObligations: lms.Rupiah(500000),
+			Channel:            "android",
+			IPAddress:          recordingLms.application.IPAddress,
+		}, recordingLms.application)
+		assert.NotEmpty(t, recordingLms.application.IPAddress)
+	})
+	t.Run("should return 400 when product does not exist", func(t *testing.T) {
+		recordingLms.err = lms.ErrProductDoesNotExist
+		response, status, _ := http.Post("/clients/"+ktpNumber+"/goLoans", `{"product": "mortgage", "amount": 1000000, "term": 30}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "product_does_not_exist", http.Unmarshal(response)["error"])
+	})
+	t.Run("should return 404 when client does not exist", func(t *testing.T) {
+		recordingLms.err = lms.ErrClientDoesNotExist
+		_, status, headers := http.Post("/clients/1/goLoans", `{"product": "payday", "amount": 1000000, "term": 30}`)
+		assert.Equal(t, 404, status)
+		assert.Empty(t, headers.Get("Location"))
+	})
+	t.Run("should return 202 when loan is referred for review", func(t *testing.T) {
+		recordingLms.err = lms.ErrLoanReferredForReview
+		_, status, _ := http.Post("/clients/"+ktpNumber+"/goLoans", `{"product": "payday", "amount": 1000000, "term": 30}`)
+		assert.Equal(t, 202, status)
+	})
+	t.Run("should return 400 with Location of rejected application", func(t *testing.T) {
+		recordingLms.err = errors.New("loan_not_affordable")
+		_, status, headers := http.Post("/clients/"+ktpNumber+"/goLoans", `{"product": "payday", "amount": 1000000, "term": 30}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, server.publicURL+"/applications/APP0000000001", headers.Get("Location"))
+	})
+}
+
+type LmsWithApplications struct {
+	lms.Lms
+	cancelled []string
+}
+
+var decidedAt = time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+var rejectedApplication = lms.LoanApplication{
+	ID:                 "APP0000000001",
+	KTPNumber:          ktpNumber,
+	ProductCode:        "payday",
+	Amount:             lms.Rupiah(1000000),
+	Term:               30,
+	MonthlyIncome:      lms.Rupiah(2000000),
+	EmploymentType:     "employed",
+	MonthlyObligations: lms.Rupiah(0),
+	Status:             "rejected",
+	Reason:             "loan_not_affordable",
+	SubmittedAt:        decidedAt,
+	DecidedAt:          decidedAt,
+	Channel:            "android",
+	IPAddress:          "10.0.0.1",
+	Scorecard:          "payday",
+	ScorePoints:        420,
+	ScoreBand:          "C",
+	ReasonCodes:        []string{"REPAID_LOANS"},
+}
+
+func (*LmsWithApplications) ApplicationByID(id string) (lms.LoanApplication, bool, error) {
+	if id != rejectedApplication.ID {
+		return lms.LoanApplication{}, false, nil
+	}
+	return rejectedApplication, true, nil
+}
+
+func (*LmsWithApplications) ApplicationsOfClient(ktpNumber string) ([]lms.LoanApplication, error) {
+	if ktpNumber != rejectedApplication.KTPNumber {
+		return []lms.LoanApplication{}, nil
+	}
+	return []lms.LoanApplication{rejectedApplication}, nil
+}
+
+func (withApplications *LmsWithApplications) CancelApplication(id string) error {
+	if id != rejectedApplication.ID {
+		return lms.ErrApplicationDoesNotExist
+	}
+	withApplications.cancelled = append(withApplications.cancelled, id)
+	return errors.New("application_already_decided")
+}
+
+func TestApplications(t *testing.T) {
+	withApplications := &LmsWithApplications{Lms: lms.NewFakeLms()}
+	server := newServer(withApplications)
+	go server.Start()
+	defer server.Stop()
+	expectedApplication := map[string]interface{}{
+		"id":                 "APP0000000001",
+		"ktpNumber":          ktpNumber,
+		"product":            "payday",
+		"amount":             money("1000000.00"),
+		"term":               float64(30),
+		"monthlyIncome":      money("2000000.00"),
+		"employmentType":     "employed",
+		"monthlyObligations": money("0.00"),
+		"status":             "rejected",
+		"reason":             "loan_not_affordable",
+		"submittedAt":        "2026-01-01T10:00:00Z",
+		"decidedAt":          "2026-01-01T10:00:00Z",
+		"channel":            "android",
+		"ipAddress":          "10.0.0.1",
+		"score": map[string]interface{}{
+			"scorecard":   "payday",
+			"points":      float64(420),
+			"band":        "C",
+			"reasonCodes": []interface{}{"REPAID_LOANS"},
+		},
+		"links": []interface{}{
+			map[string]interface{}{"rel": "self", "href": server.publicURL + "/applications/APP0000000001"},
+			map[string]interface{}{"rel": "client", "href": server.publicURL + "/clients/" + ktpNumber},
+		},
+	}
+	t.Run("should return application", func(t *testing.T) {
+		response, status := http.Get("/applications/APP0000000001")
+		assert.Equal(t, 200, status)
+		assert.Equal(t, expectedApplication, http.Unmarshal(response))
+	})
+	t.Run("should return 404 when application does not exist", func(t *testing.T) {
+		response, status := http.Get("/applications/APP0000000002")
+		assert.Equal(t, 404, status)
+		assert.Equal(t, "application_does_not_exist", http.Unmarshal(response)["error"])
+	})
+	t.Run("should list applications of client", func(t *testing.T) {
+		response, status := http.Get("/clients/" + ktpNumber + "/applications")
+		assert.Equal(t, 200, status)
+		assert.Equal(t, map[string]interface{}{"applications": []interface{}{expectedApplication}}, http.Unmarshal(response))
+	})
+	t.Run("should return 409 when decided application is cancelled", func(t *testing.T) {
+		response, status, _ := http.Post("/applications/APP0000000001/cancel", ``)
+		assert.Equal(t, 409, status)
+		assert.Equal(t, "application_already_decided", http.Unmarshal(response)["error"])
+		assert.Equal(t, []string{"APP0000000001"}, withApplications.cancelled)
+	})
+	t.Run("should return 404 when cancelled application does not exist", func(t *testing.T) {
+		_, status, _ := http.Post("/applications/APP0000000002/cancel", ``)
+		assert.Equal(t, 404, status)
+	})
+}
+
+type LmsWithAgreement struct {
+	lms.Lms
+	acceptances []lms.AgreementAcceptance
+}
+
+func (*LmsWithAgreement) LoanAgreement(ktpNumber string) (lms.LoanAgreement, error) {
+	if ktpNumber != "3522582509010002" {
+		return lms.LoanAgreement{}, lms.ErrClientDoesNotExist
+	}
+	dueDate := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	return lms.LoanAgreement{
+		LoanID:                   ktpNumber + "-1",
+		KTPNumber:                ktpNumber,
+		Name:                     "Budi <Santoso>",
+		BirthDate:                "17 August 1990",
+		BankCode:                 "BCA",
+		BankAccountNumber:        "1234567890",
+		BankAccountHolder:        "Budi Santoso",
+		ProductName:              "Payday loan",
+		Amount:                   lms.Rupiah(1000000),
+		Term:                     30,
+		DailyInterestBasisPoints: 80,
+		Fee:                      lms.Rupiah(0),
+		Interest:                 lms.Rupiah(240000),
+		TotalPayable:             lms.Rupiah(1240000),
+		Instalments:              []lms.Instalment{{DueDate: dueDate, Amount: lms.Rupiah(1240000)}},
+		StartDate:                time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Required:                 true,
+	}, nil
+}
+
+func (withAgreement *LmsWithAgreement) AcceptAgreement(ktpNumber string, acceptance lms.AgreementAcceptance) error {
+	agreement, err := withAgreement.LoanAgreement(ktpNumber)
+	if err != nil {
+		return err
+	}
+	if _, hash, _ := lms.RenderAgreement(agreement); hash != acceptance.Hash {
+		return lms.ErrAgreementChanged
+	}
+	withAgreement.acceptances = append(withAgreement.acceptances, acceptance)
+	return nil
+}
+
+func TestAgreement(t *testing.T) {
+	withAgreement := &LmsWithAgreement{Lms: lms.NewFakeLms()}
+	server := newServer(withAgreement)
+	go server.Start()
+	defer server.Stop()
+	var hash string
+	t.Run("should render HTML agreement with its hash", func(t *testing.T) {
+		response, status, header := http.GetWithResponseHeader("/clients/" + ktpNumber + "/goLoans/agreement")
+		assert.Equal(t, 200, status)
+		assert.Equal(t, "text/html; charset=utf-8", header.Get("Content-Type"))
+		assert.Contains(t, response, "<h1>Loan agreement 3522582509010002-1</h1>")
+		assert.Contains(t, response, "Budi &lt;Santoso&gt;")
+		assert.Contains(t, response, "<tr><th>Interest</th><td>240000.00 IDR (0.80% per day)</td></tr>")
+		assert.Contains(t, response, "<tr><td>2026-01-31</td><td>1240000.00 IDR</td></tr>")
+		hash = header.Get("X-Agreement-Hash")
+		assert.Len(t, hash, 64)
+	})
+	t.Run("should render PDF agreement with hash of HTML agreement", func(t *testing.T) {
+		response, status, header := http.GetWithResponseHeader("/clients/" + ktpNumber + "/goLoans/agreement?format=pdf")
+		assert.Equal(t, 200, status)
+		assert.Equal(t, "application/pdf", header.Get("Content-Type"))
+		assert.Equal(t, hash, header.Get("X-Agreement-Hash"))
+		assert.True(t, strings.HasPrefix(response, "%PDF-1.4\n"))
+		assert.True(t, strings.HasSuffix(response, "%%EOF\n"))
+		assert.Contains(t, response, "(Total payable: 1240000.00 IDR) '")
+		assert.Contains(t, response, "(Name: Budi <Santoso>) '")
+	})
+	t.Run("should return 400 for unknown format", func(t *testing.T) {
+		response, status := http.Get("/clients/" + ktpNumber + "/goLoans/agreement?format=docx")
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "unknown_agreement_format", http.Unmarshal(response)["error"])
+	})
+	t.Run("should return 404 when client does not exist", func(t *testing.T) {
+		_, status := http.Get("/clients/1/goLoans/agreement")
+		assert.Equal(t, 404, status)
+	})
+	t.Run("should require hash of the agreement", func(t *testing.T) {
+		response, status, _ := http.Post("/clients/"+ktpNumber+"/goLoans/agreement/acceptance", `{}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "hash_missing", http.Unmarshal(response)["error"])
+	})
+	t.Run("should return 409 when hash is not the hash of the agreement", func(t *testing.T) {
+		response, status, _ := http.Post("/clients/"+ktpNumber+"/goLoans/agreement/acceptance", `{"hash": "abc"}`)
+		assert.Equal(t, 409, status)
+		assert.Equal(t, "agreement_changed", http.Unmarshal(response)["error"])
+		assert.Empty(t, withAgreement.acceptances)
+	})
+	t.Run("should accept agreement from the client IP address", func(t *testing.T) {
+		_, status, _ := http.Post("/clients/"+ktpNumber+"/goLoans/agreement/acceptance", `{"hash": "`+hash+`"}`)
+		assert.Equal(t, 200, status)
+		assert.Len(t, withAgreement.acceptances, 1)
+		assert.Equal(t, hash, withAgreement.acceptances[0].Hash)
+		assert.NotEmpty(t, withAgreement.acceptances[0].IPAddress)
+	})
+}
+
+type LmsWithOTP struct {
+	lms.Lms
+}
+
+func (*LmsWithOTP) ChallengeOTP(phone string, purpose string) (lms.OTPChallenge, error) {
+	if phone == "081234567891" {
+		return lms.OTPChallenge{}, lms.ErrOTPResendTooSoon
+	}
+	if purpose != "registration" {
+		return lms.OTPChallenge{}, errors.New("invalid_otp_purpose")
+	}
+	return lms.OTPChallenge{ID: "abc", Phone: "+6281234567890", Purpose: purpose, ExpiresAt: time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC)}, nil
+}
+
+func (*LmsWithOTP) VerifyOTP(challengeID string, code string) error {
+	if challengeID != "abc" {
+		return lms.ErrOTPChallengeDoesNotExist
+	}
+	if code != "123456" {
+		return errors.New("otp_invalid")
+	}
+	return nil
+}
+
+func (*LmsWithOTP) RegisterClient(clientData lms.ClientData) (lms.Client, error) {
+	return nil, lms.ErrOTPVerificationRequired
+}
+
+func TestOTP(t *testing.T) {
+	server := newServer(&LmsWithOTP{Lms: lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	t.Run("should send code to the phone number", func(t *testing.T) {
+		response, status, header := http.Post("/otp/challenges", `{"phone": "081234567890", "purpose": "registration"}`)
+		assert.Equal(t, 201, status)
+		assert.True(t, strings.HasSuffix(header.Get("Location"), "/otp/challenges/abc"))
+		challenge := http.Unmarshal(response)
+		assert.Equal(t, "abc", challenge["id"])
+		assert.Equal(t, "+6281234567890", challenge["phone"])
+		assert.Equal(t, "2026-01-01T00:05:00Z", challenge["expiresAt"])
+	})
+	t.Run("should return 400 without phone number or with invalid purpose", func(t *testing.T) {
+		response, status, _ := http.Post("/otp/challenges", `{"purpose": "registration"}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "phone_missing", http.Unmarshal(response)["error"])
+		response, status, _ = http.Post("/otp/challenges", `{"phone": "081234567890", "purpose": "payment"}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "invalid_otp_purpose", http.Unmarshal(response)["error"])
+	})
+	t.Run("should return 429 when code is requested again too soon", func(t *testing.T) {
+		_, status, _ := http.Post("/otp/challenges", `{"phone": "081234567891", "purpose": "registration"}`)
+		assert.Equal(t, 429, status)
+	})
+	t.Run("should verify code", func(t *testing.T) {
+		_, status, _ := http.Post("/otp/challenges/abc/verify", `{"code": "123456"}`)
+		assert.Equal(t, 200, status)
+		response, status, _ := http.Post("/otp/challenges/abc/verify", `{"code": "654321"}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "otp_invalid", http.Unmarshal(response)["error"])
+		_, status, _ = http.Post("/otp/challenges/xyz/verify", `{"code": "123456"}`)
+		assert.Equal(t, 404, status)
+		response, status, _ = http.Post("/otp/challenges/abc/verify", `{}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "code_missing", http.Unmarshal(response)["error"])
+	})
+	t.Run("should return 403 when client registers without verified phone number", func(t *testing.T) {
+		response, status, _ := http.Post("/clients", `{"ktpNumber": "3522582509010002", "phone": "081234567890", "otpChallengeId": "abc"}`)
+		assert.Equal(t, 403, status)
+		assert.Equal(t, "otp_verification_required", http.Unmarshal(response)["error"])
+	})
+}
+
+type LmsWithNotifications struct {
+	lms.Lms
+}
+
+func (*LmsWithNotifications) NotificationsOfClient(ktpNumber string) ([]lms.Notification, error) {
+	if ktpNumber != "3522582509010002" {
+		return []lms.Notification{}, nil
+	}
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []lms.Notification{
+		{
+			ID:          "NTF0000000001",
+			KTPNumber:   ktpNumber,
+			Event:       "loan_approved",
+			Reference:   ktpNumber + "-1",
+			Channel:     "sms",
+			Recipient:   "081234567890",
+			Subject:     "Your loan 3522582509010002-1 is approved",
+			Body:        "Hi Doe, your loan 3522582509010002-1 is approved.",
+			CreatedAt:   createdAt,
+			Status:      "delivered",
+			Attempts:    1,
+			DeliveredAt: createdAt,
+		},
+		{
+			ID:            "NTF0000000002",
+			KTPNumber:     ktpNumber,
+			Event:         "loan_approved",
+			Reference:     ktpNumber + "-1",
+			Channel:       "webhook",
+			Subject:       "Your loan 3522582509010002-1 is approved",
+			Body:          "Hi Doe, your loan 3522582509010002-1 is approved.",
+			CreatedAt:     createdAt,
+			Status:        "pending",
+			Attempts:      1,
+			LastError:     "webhook responded with status 503",
+			NextAttemptAt: createdAt.Add(time.Minute),
+		},
+	}, nil
+}
+
+func TestClientNotifications(t *testing.T) {
+	server := newServer(&LmsWithNotifications{Lms: lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	t.Run("should list notifications with delivery status", func(t *testing.T) {
+		response, status := http.Get("/clients/" + ktpNumber + "/notifications")
+		assert.Equal(t, 200, status)
+		notifications := http.Unmarshal(response)["notifications"].([]interface{})
+		assert.Len(t, notifications, 2)
+		delivered := notifications[0].(map[string]interface{})
+		assert.Equal(t, "delivered", delivered["status"])
+		assert.Equal(t, "081234567890", delivered["recipient"])
+		assert.Equal(t, "2026-01-01T00:00:00Z", delivered["deliveredAt"])
+		assert.NotContains(t, delivered, "nextAttemptAt")
+		pending := notifications[1].(map[string]interface{})
+		assert.Equal(t, "pending", pending["status"])
+		assert.Equal(t, "webhook responded with status 503", pending["lastError"])
+		assert.Equal(t, "2026-01-01T00:01:00Z", pending["nextAttemptAt"])
+		assert.NotContains(t, pending, "recipient")
+	})
+	t.Run("client without notifications should have empty list", func(t *testing.T) {
+		response, status := http.Get("/clients/1/notifications")
+		assert.Equal(t, 200, status)
+		assert.Equal(t, `{"notifications":[]}`, strings.TrimSpace(response))
+	})
+}
+
+type LmsQuoting struct {
+	lms.Lms
+}
+
+func (*LmsQuoting) Quote(ktpNumber string, productCode string, amount lms.Money, term uint) (lms.Quote, error) {
+	if ktpNumber == "1" {
+		return lms.Quote{}, lms.ErrClientDoesNotExist
+	}
+	dueDate := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	interest := lms.Rupiah(240)
+	total, _ := amount.Add(interest)
+	return lms.Quote{
+		ProductCode:         productCode,
+		Amount:              amount,
+		Term:                term,
+		Interest:            interest,
+		TotalPayable:        total,
+		Instalments:         []lms.Instalment{{DueDate: dueDate, Amount: total}},
+		IneligibilityReason: errors.New("client_already_has_loan"),
+	}, nil
+}
+
+func TestPostQuotes(t *testing.T) {
+	server := newServer(&LmsQuoting{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	t.Run("should return quote", func(t *testing.T) {
+		response, status, _ := http.Post("/quotes", `{"ktpNumber": "`+ktpNumber+`", "product": "payday", "amount": 1000, "term": 30}`)
+		assert.Equal(t, 200, status)
+		expectedResponse := map[string]interface{}{
+			"product":      "payday",
+			"amount":       money("1000.00"),
+			"term":         float64(30),
+			"fee":          money("0.00"),
+			"interest":     money("240.00"),
+			"totalPayable": money("1240.00"),
+			"instalments": []interface{}{
+				map[string]interface{}{"dueDate": "2026-01-31", "amount": money("1240.00")},
+			},
+			"eligible":            false,
+			"ineligibilityReason": "client_already_has_loan",
+		}
+		assert.Equal(t, expectedResponse, http.Unmarshal(response))
+	})
+	t.Run("should return 404 when client does not exist", func(t *testing.T) {
+		_, status, _ := http.Post("/quotes", `{"ktpNumber": "1", "product": "payday", "amount": 1000, "term": 30}`)
+		assert.Equal(t, 404, status)
+	})
+}
+
+type LmsRecordingPatches struct {
+	lms.Lms
+	patch lms.ClientPatch
+}
+
+func (lms *LmsRecordingPatches) UpdateClient(ktpNumber string, patch lms.ClientPatch) (lms.Client, error) {
+	lms.patch = patch
+	client, _, _ := lms.ClientByKTPNumber(ktpNumber)
+	return client, nil
+}
+
+func TestPatchClient(t *testing.T) {
+	recordingLms := &LmsRecordingPatches{Lms: lms.NewFakeLms()}
+	recordingLms.RegisterClient(clientData)
+	server := newServer(recordingLms)
+	go server.Start()
+	defer server.Stop()
+	t.Run("should convert merge patch", func(t *testing.T) {
+		_, status := http.Patch("/clients/"+ktpNumber, `{"phone": "081234567890", "otpChallengeId": "abc", "email": null, "bankAccount": {"number": "1234567890"}}`)
+		assert.Equal(t, 200, status)
+		patch := recordingLms.patch
+		assert.Equal(t, "081234567890", *patch.Phone)
+		assert.Equal(t, "abc", patch.OTPChallengeID)
+		assert.Equal(t, "", *patch.Email)
+		assert.Equal(t, "1234567890", *patch.BankAccountNumber)
+		assert.Nil(t, patch.Name)
+		assert.Nil(t, patch.BankCode)
+	})
+	t.Run("null bank account should clear all its fields", func(t *testing.T) {
+		http.Patch("/clients/"+ktpNumber, `{"bankAccount": null}`)
+		patch := recordingLms.patch
+		assert.Equal(t, "", *patch.BankCode)
+		assert.Equal(t, "", *patch.BankAccountNumber)
+		assert.Equal(t, "", *patch.BankAccountHolder)
+	})
+	t.Run("should reject unknown fields", func(t *testing.T) {
+		response, status := http.Patch("/clients/"+ktpNumber, `{"salary": "1000"}`)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "JSON merge patch contains unknown field salary\n", response)
+	})
+	t.Run("should reject non string values", func(t *testing.T) {
+		_, status := http.Patch("/clients/"+ktpNumber, `{"name": 1}`)
+		assert.Equal(t, 400, status)
+	})
+}
+
+type LmsRejectingPatches struct {
+	lms.Lms
+}
+
+func (*LmsRejectingPatches) UpdateClient(ktpNumber string, patch lms.ClientPatch) (lms.Client, error) {
+	if patch.Phone != nil {
+		return nil, lms.ErrOTPVerificationRequired
+	}
+	return nil, lms.ErrKTPNumberImmutable
+}
+
+func TestPatchClientKTPNumber(t *testing.T) {
+	server := newServer(&LmsRejectingPatches{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	response, status := http.Patch("/clients/"+ktpNumber, `{"ktpNumber": "1"}`)
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "ktp_number_immutable", http.Unmarshal(response)["error"])
+}
+
+func TestPatchClientPhoneWithoutOTP(t *testing.T) {
+	server := newServer(&LmsRejectingPatches{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	response, status := http.Patch("/clients/"+ktpNumber, `{"phone": "081234567891"}`)
+	assert.Equal(t, 403, status)
+	assert.Equal(t, "otp_verification_required", http.Unmarshal(response)["error"])
+}
+
+type LmsSearchingClients struct {
+	lms.Lms
+	query lms.ClientQuery
+}
+
+func (searchingLms *LmsSearchingClients) Clients(query lms.ClientQuery) (lms.ClientsPage, error) {
+	searchingLms.query = query
+	client, _, _ := searchingLms.ClientByKTPNumber(ktpNumber)
+	return lms.ClientsPage{Clients: []lms.Client{client}, AsOf: time.Now(), NextCursor: "next", PreviousCursor: "prev"}, nil
+}
+
+func TestGetClients(t *testing.T) {
+	searchingLms := &LmsSearchingClients{Lms: lms.NewFakeLms()}
+	searchingLms.RegisterClient(clientData)
+	server := newServer(searchingLms)
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	t.Run("should return page of clients with links", func(t *testing.T) {
+		response, status := http.GetWithHeader("/clients?namePrefix=Do&overdue=true&limit=1", officer)
+		assert.Equal(t, 200, status)
+		expectedResponse := map[string]interface{}{
+			"clients": []interface{}{
+				map[string]interface{}{
+					"ktpNumber":     ktpNumber,
+					"birthDate":     birthDate,
+					"name":          name,
+					"hasActiveLoan": false,
+					"daysPastDue":   float64(0),
+					"goLoans": map[string]interface{}{
+						"links": []interface{}{
+							map[string]interface{}{"rel": "self", "href": server.publicURL + "/clients/" + ktpNumber + "/goLoans"},
+						},
+					},
+				},
+			},
+			"links": []interface{}{
+				map[string]interface{}{"rel": "next", "href": server.publicURL + "/clients?cursor=next&limit=1&namePrefix=Do&overdue=true"},
+				map[string]interface{}{"rel": "prev", "href": server.publicURL + "/clients?cursor=prev&limit=1&namePrefix=Do&overdue=true"},
+			},
+		}
+		assert.Equal(t, expectedResponse, http.Unmarshal(response))
+	})
+	t.Run("should pass query to lms", func(t *testing.T) {
+		http.GetWithHeader("/clients?birthDate=1+December+1994&hasActiveLoan=false&cursor=abc", officer)
+		query := searchingLms.query
+		assert.Equal(t, birthDate, query.BirthDate)
+		assert.False(t, *query.HasActiveLoan)
+		assert.Nil(t, query.Overdue)
+		assert.Equal(t, "abc", query.Cursor)
+	})
+	t.Run("should reject malformed parameters", func(t *testing.T) {
+		_, status := http.GetWithHeader("/clients?overdue=maybe", officer)
+		assert.Equal(t, 400, status)
+		_, status = http.GetWithHeader("/clients?limit=ten", officer)
+		assert.Equal(t, 400, status)
+	})
+	t.Run("should require officer", func(t *testing.T) {
+		response, status := http.Get("/clients")
+		assert.Equal(t, 401, status)
+		assert.Equal(t, "officer_required", http.Unmarshal(response)["error"])
+	})
+}
+
+type LmsExportingClientData struct {
+	lms.Lms
+}
+
+func (*LmsExportingClientData) ExportClientData(ktpNumber string) (lms.ClientDataExport, error) {
+	if ktpNumber != "3522582509010002" {
+		return lms.ClientDataExport{}, lms.ErrClientDoesNotExist
+	}
+	return lms.ClientDataExport{KTPNumber: ktpNumber, Profile: lms.ProfileExport{Name: "Doe"}}, nil
+}
+
+func (*LmsExportingClientData) EraseClient(ktpNumber string) error {
+	if ktpNumber == "1" {
+		return lms.ErrClientDoesNotExist
+	}
+	if ktpNumber == "2" {
+		return errors.New("client_has_active_loan")
+	}
+	return nil
+}
+
+func TestClientDataExportAndErasure(t *testing.T) {
+	server := newServer(&LmsExportingClientData{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	t.Run("GET /clients/{ktpNumber}/export", func(t *testing.T) {
+		response, status := http.Get("/clients/" + ktpNumber + "/export")
+		assert.Equal(t, 200, status)
+		export := http.Unmarshal(response)
+		assert.Equal(t, ktpNumber, export["ktpNumber"])
+		assert.Equal(t, "Doe", export["profile"].(map[string]interface{})["name"])
+	})
+	t.Run("GET /clients/{unexistingKTPNumber}/export", func(t *testing.T) {
+		_, status := http.Get("/clients/1/export")
+		assert.Equal(t, 404, status)
+	})
+	t.Run("DELETE /clients/{ktpNumber}", func(t *testing.T) {
+		_, status := http.Delete("/clients/" + ktpNumber)
+		assert.Equal(t, 204, status)
+	})
+	t.Run("DELETE /clients/{ktpNumber} with active loan", func(t *testing.T) {
+		response, status := http.Delete("/clients/2")
+		assert.Equal(t, 409, status)
+		assert.Equal(t, "client_has_active_loan", http.Unmarshal(response)["error"])
+	})
+	t.Run("DELETE /clients/{unexistingKTPNumber}", func(t *testing.T) {
+		_, status := http.Delete("/clients/1")
+		assert.Equal(t, 404, status)
+	})
+}
+
+type LmsDisbursing struct {
+	lms.Lms
+}
+
+func (*LmsDisbursing) DisburseLoan(ktpNumber string) error {
+	switch ktpNumber {
+	case "1":
+		return lms.ErrClientDoesNotExist
+	case "2":
+		return lms.ErrDisbursementFailed
+	}
+	return nil
+}
+
+func TestPostDisbursement(t *testing.T) {
+	server := newServer(&LmsDisbursing{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	_, status, _ := http.Post("/clients/"+ktpNumber+"/goLoans/disbursement", "")
+	assert.Equal(t, 200, status)
+	_, status, _ = http.Post("/clients/1/goLoans/disbursement", "")
+	assert.Equal(t, 404, status)
+	response, status, _ := http.Post("/clients/2/goLoans/disbursement", "")
+	assert.Equal(t, 409, status)
+	assert.Equal(t, "disbursement_failed", http.Unmarshal(response)["error"])
+}
+
+type LmsReconciling struct {
+	lms.Lms
+	lines []lms.StatementLine
+}
+
+func (reconcilingLms *LmsReconciling) Reconcile(lines []lms.StatementLine) (lms.ReconciliationReport, error) {
+	reconcilingLms.lines = lines
+	return lms.ReconciliationReport{
+		Matched:   []lms.ReconciledLine{{StatementLine: lines[0], KTPNumber: ktpNumber, Applied: true}},
+		Unmatched: []lms.ReconciledLine{{StatementLine: lines[1], Reason: lms.ErrClientDoesNotExist}},
+	}, nil
+}
+
+func TestPostStatements(t *testing.T) {
+	reconcilingLms := &LmsReconciling{Lms: lms.NewFakeLms()}
+	server := newServer(reconcilingLms)
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	t.Run("csv", func(t *testing.T) {
+		response, status, _ := http.PostWithHeader("/statements", "date,amount,reference,bankReference\n"+
+			"2026-01-05,150000,"+ktpNumber+",B1\n"+
+			"2026-01-05,20000,unknown,B2\n", officer)
+		assert.Equal(t, 200, status)
+		expectedResponse := map[string]interface{}{
+			"matched": []interface{}{map[string]interface{}{
+				"bankReference": "B1", "date": "2026-01-05", "amount": money("150000.00"), "reference": ktpNumber, "ktpNumber": ktpNumber, "applied": true,
+			}},
+			"unmatched": []interface{}{map[string]interface{}{
+				"bankReference": "B2", "date": "2026-01-05", "amount": money("20000.00"), "reference": "unknown", "reason": "client_does_not_exist", "applied": false,
+			}},
+			"overpaid":   []interface{}{},
+			"duplicates": []interface{}{},
+		}
+		assert.Equal(t, expectedResponse, http.Unmarshal(response))
+	})
+	t.Run("fixed-width", func(t *testing.T) {
+		_, status, _ := http.PostWithHeader("/statements?format=fixed-width",
+			"20260105C000000000150000B1              "+ktpNumber+"\n"+
+				"20260105C000000000020000B2              unknown\n", officer)
+		assert.Equal(t, 200, status)
+		assert.Equal(t, lms.Rupiah(150000), reconcilingLms.lines[0].Amount)
+		assert.Equal(t, "B2", reconcilingLms.lines[1].BankReference)
+	})
+	t.Run("malformed statement", func(t *testing.T) {
+		_, status, _ := http.PostWithHeader("/statements?format=fixed-width", "garbage\n", officer)
+		assert.Equal(t, 400, status)
+		_, status, _ = http.PostWithHeader("/statements?format=xml", "", officer)
+		assert.Equal(t, 400, status)
+	})
+	t.Run("without officer", func(t *testing.T) {
+		response, status, _ := http.Post("/statements", "date,amount,reference,bankReference\n")
+		assert.Equal(t, 401, status)
+		assert.Equal(t, "officer_required", http.Unmarshal(response)["error"])
+	})
+}
+
+type LmsRepaying struct {
+	lms.Lms
+}
+
+func (repayingLms *LmsRepaying) AuthorizeOfficer(officer string) error {
+	if officer == "stranger" {
+		return lms.ErrOfficerNotAuthorized
+	}
+	return repayingLms.Lms.AuthorizeOfficer(officer)
+}
+
+func (*LmsRepaying) Repay(ktpNumber string, amount lms.Money) error {
+	if amount.Cmp(lms.Rupiah(100)) > 0 {
+		return errors.New("repayment_amount_too_high")
+	}
+	return nil
+}
+
+func TestPostRepayments(t *testing.T) {
+	server := newServer(&LmsRepaying{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	_, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/repayments", `{"amount": 100}`, officer)
+	assert.Equal(t, 201, status)
+	response, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/repayments", `{"amount": 101}`, officer)
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "repayment_amount_too_high", http.Unmarshal(response)["error"])
+	_, status, _ = http.Post("/clients/"+ktpNumber+"/goLoans/repayments", `{"amount": 100}`)
+	assert.Equal(t, 401, status)
+	_, status, _ = http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/repayments", `{"amount": 100}`, map[string][]string{"X-Officer-Id": {"stranger"}})
+	assert.Equal(t, 403, status)
+}
+
+type LmsRefunding struct {
+	lms.Lms
+}
+
+func (*LmsRefunding) RequestRefund(ktpNumber string, amount lms.Money) error {
+	switch {
+	case ktpNumber != "3522582509010002":
+		return lms.ErrClientDoesNotExist
+	case amount.Cmp(lms.Rupiah(100)) > 0:
+		return errors.New("refund_amount_too_high")
+	case amount == lms.Rupiah(13):
+		return lms.ErrRefundFailed
+	case amount == lms.Rupiah(14):
+		return lms.ErrRefundPending
+	}
+	return nil
+}
+
+func TestPostRefunds(t *testing.T) {
+	server := newServer(&LmsRefunding{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	_, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/refunds", `{"amount": 100}`, officer)
+	assert.Equal(t, 201, status)
+	response, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/refunds", `{"amount": 101}`, officer)
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "refund_amount_too_high", http.Unmarshal(response)["error"])
+	_, status, _ = http.PostWithHeader("/clients/"+ktpNumber+"/refunds", `{"amount": 13}`, officer)
+	assert.Equal(t, 409, status)
+	response, status, _ = http.PostWithHeader("/clients/"+ktpNumber+"/refunds", `{"amount": 14}`, officer)
+	assert.Equal(t, 202, status)
+	assert.Equal(t, "refund_pending", http.Unmarshal(response)["error"])
+	_, status, _ = http.PostWithHeader("/clients/1/refunds", `{"amount": 100}`, officer)
+	assert.Equal(t, 404, status)
+	_, status, _ = http.Post("/clients/"+ktpNumber+"/refunds", `{"amount": 100}`)
+	assert.Equal(t, 401, status)
+}
+
+type LmsWithVirtualAccounts struct {
+	lms.Lms
+}
+
+func (virtualAccountsLms *LmsWithVirtualAccounts) ClientByVirtualAccount(number string) (lms.Client, bool, error) {
+	if number != "880800000000016" {
+		return nil, false, nil
+	}
+	return virtualAccountsLms.ClientByKTPNumber(ktpNumber)
+}
+
+func TestGetVirtualAccount(t *testing.T) {
+	virtualAccountsLms := &LmsWithVirtualAccounts{lms.NewFakeLms()}
+	virtualAccountsLms.RegisterClient(clientData)
+	server := newServer(virtualAccountsLms)
+	go server.Start()
+	defer server.Stop()
+	location, status := http.GetWithoutRedirect("/virtualAccounts/880800000000016")
+	assert.Equal(t, 303, status)
+	assert.Equal(t, server.publicURL+"/clients/"+ktpNumber, location)
+	_, status = http.Get("/virtualAccounts/880800000000017")
+	assert.Equal(t, 404, status)
+}
+
+type LmsDunning struct {
+	lms.Lms
+}
+
+func (*LmsDunning) LoansByDunningStage() ([]lms.DunningStageLoans, error) {
+	return []lms.DunningStageLoans{
+		{Stage: "reminder", Action: "reminder", Loans: []lms.DunningLoan{}},
+		{Stage: "escalation_1", Action: "escalation", Loans: []lms.DunningLoan{{
+			KTPNumber:     ktpNumber,
+			Name:          name,
+			LoanID:        ktpNumber + "-1",
+			DueDate:       time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+			DaysPastDue:   7,
+			AmountPastDue: lms.Rupiah(100),
+			Remaining:     lms.Rupiah(150),
+		}}},
+	}, nil
+}
+
+func TestGetDunning(t *testing.T) {
+	server := newServer(&LmsDunning{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	t.Run("should list loans of given stage", func(t *testing.T) {
+		response, status := http.GetWithHeader("/dunning?stage=escalation_1", officer)
+		assert.Equal(t, 200, status)
+		expectedResponse := map[string]interface{}{
+			"stages": []interface{}{
+				map[string]interface{}{
+					"stage":  "escalation_1",
+					"action": "escalation",
+					"loans": []interface{}{
+						map[string]interface{}{
+							"ktpNumber":     ktpNumber,
+							"name":          name,
+							"loanId":        ktpNumber + "-1",
+							"dueDate":       "2026-01-31",
+							"daysPastDue":   float64(7),
+							"amountPastDue": money("100.00"),
+							"remaining":     money("150.00"),
+							"defaulted":     false,
+							"links": []interface{}{
+								map[string]interface{}{"rel": "client", "href": "http://" + http.Address + "/clients/" + ktpNumber},
+							},
+						},
+					},
+				},
+			},
+		}
+		assert.Equal(t, expectedResponse, http.Unmarshal(response))
+	})
+	t.Run("should list all stages", func(t *testing.T) {
+		response, status := http.GetWithHeader("/dunning", officer)
+		assert.Equal(t, 200, status)
+		assert.Len(t, http.Unmarshal(response)["stages"], 2)
+	})
+	t.Run("unknown stage", func(t *testing.T) {
+		_, status := http.GetWithHeader("/dunning?stage=lawsuit", officer)
+		assert.Equal(t, 400, status)
+	})
+	t.Run("without officer", func(t *testing.T) {
+		_, status := http.Get("/dunning")
+		assert.Equal(t, 401, status)
+	})
+}
+
+type LmsWritingOff struct {
+	lms.Lms
+}
+
+func (*LmsWritingOff) WriteOffLoan(ktpNumber string, officer string) error {
+	switch {
+	case officer == "":
+		return lms.ErrOfficerRequired
+	case officer == "stranger":
+		return lms.ErrOfficerNotAuthorized
+	case ktpNumber != "3522582509010002":
+		return lms.ErrClientDoesNotExist
+	case officer == "hasty":
+		return errors.New("loan_not_defaulted")
+	}
+	return nil
+}
+
+func TestPostWriteOff(t *testing.T) {
+	server := newServer(&LmsWritingOff{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	_, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/writeOff", "", officer)
+	assert.Equal(t, 200, status)
+	response, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/writeOff", "", nil)
+	assert.Equal(t, 401, status)
+	assert.Equal(t, "officer_required", http.Unmarshal(response)["error"])
+	response, status, _ = http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/writeOff", "", map[string][]string{"X-Officer-Id": {"stranger"}})
+	assert.Equal(t, 403, status)
+	assert.Equal(t, "officer_not_authorized", http.Unmarshal(response)["error"])
+	_, status, _ = http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/writeOff", "", map[string][]string{"X-Officer-Id": {"hasty"}})
+	assert.Equal(t, 409, status)
+	_, status, _ = http.PostWithHeader("/clients/1/goLoans/writeOff", "", officer)
+	assert.Equal(t, 404, status)
+}
+
+type LmsReviewing struct {
+	lms.Lms
+	approved bool
+}
+
+func (reviewing *LmsReviewing) ReviewLoan(ktpNumber string, officer string, approve bool) error {
+	switch {
+	case officer == "":
+		return lms.ErrOfficerRequired
+	case officer == "stranger":
+		return lms.ErrOfficerNotAuthorized
+	case ktpNumber != "3522582509010002":
+		return lms.ErrClientDoesNotExist
+	}
+	reviewing.approved = approve
+	return nil
+}
+
+func (*LmsReviewing) LoansInReview() ([]lms.LoanInReview, error) {
+	return []lms.LoanInReview{{
+		KTPNumber: ktpNumber,
+		Name:      name,
+		LoanID:    ktpNumber + "-1",
+		Product:   "payday",
+		Amount:    lms.Rupiah(100),
+		Term:      30,
+		AppliedAt: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+		Reason:    "credit_bureau_timeout",
+	}}, nil
+}
+
+func TestReviews(t *testing.T) {
+	reviewingLms := &LmsReviewing{Lms: lms.NewFakeLms()}
+	server := newServer(reviewingLms)
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	t.Run("should list loans in review", func(t *testing.T) {
+		response, status := http.Get("/reviews")
+		assert.Equal(t, 200, status)
+		expectedResponse := map[string]interface{}{
+			"loans": []interface{}{
+				map[string]interface{}{
+					"ktpNumber": ktpNumber,
+					"name":      name,
+					"loanId":    ktpNumber + "-1",
+					"product":   "payday",
+					"amount":    money("100.00"),
+					"term":      float64(30),
+					"appliedAt": "2026-01-01",
+					"reason":    "credit_bureau_timeout",
+					"links": []interface{}{
+						map[string]interface{}{"rel": "client", "href": "http://" + http.Address + "/clients/" + ktpNumber},
+						map[string]interface{}{"rel": "review", "href": "http://" + http.Address + "/clients/" + ktpNumber + "/goLoans/review"},
+					},
+				},
+			},
+		}
+		assert.Equal(t, expectedResponse, http.Unmarshal(response))
+	})
+	t.Run("should review loan", func(t *testing.T) {
+		_, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/review", `{"approve": true}`, officer)
+		assert.Equal(t, 200, status)
+		assert.True(t, reviewingLms.approved)
+	})
+	t.Run("should require decision", func(t *testing.T) {
+		response, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/review", `{}`, officer)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "approve_missing", http.Unmarshal(response)["error"])
+	})
+	t.Run("should require officer", func(t *testing.T) {
+		_, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/review", `{"approve": false}`, nil)
+		assert.Equal(t, 401, status)
+	})
+	t.Run("should refuse unknown officer", func(t *testing.T) {
+		_, status, _ := http.PostWithHeader("/clients/"+ktpNumber+"/goLoans/review", `{"approve": false}`, map[string][]string{"X-Officer-Id": {"stranger"}})
+		assert.Equal(t, 403, status)
+	})
+	t.Run("should return 404 when client does not exist", func(t *testing.T) {
+		_, status, _ := http.PostWithHeader("/clients/1/goLoans/review", `{"approve": false}`, officer)
+		assert.Equal(t, 404, status)
+	})
+}
+
+type LmsWithLedger struct {
+	lms.Lms
+}
+
+func (*LmsWithLedger) TrialBalance() (lms.TrialBalance, error) {
+	return lms.TrialBalance{
+		Lines: []lms.TrialBalanceLine{
+			{Account: "cash", Currency: "IDR", Debit: lms.Rupiah(100), Credit: lms.Rupiah(0)},
+			{Account: "client_credit", Currency: "IDR", Debit: lms.Rupiah(0), Credit: lms.Rupiah(100)},
+		},
+		Mismatches: []lms.LedgerMismatch{{KTPNumber: ktpNumber, LoanID: ktpNumber + "-1", Ledger: lms.Rupiah(50), Expected: lms.Rupiah(40)}},
+	}, nil
+}
+
+func TestGetTrialBalance(t *testing.T) {
+	server := newServer(&LmsWithLedger{lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	response, status := http.Get("/ledger/trialBalance")
+	assert.Equal(t, 200, status)
+	expectedResponse := map[string]interface{}{
+		"accounts": []interface{}{
+			map[string]interface{}{"account": "cash", "currency": "IDR", "debit": money("100.00"), "credit": money("0.00")},
+			map[string]interface{}{"account": "client_credit", "currency": "IDR", "debit": money("0.00"), "credit": money("100.00")},
+		},
+		"mismatches": []interface{}{
+			map[string]interface{}{
+				"ktpNumber": ktpNumber,
+				"loanId":    ktpNumber + "-1",
+				"ledger":    money("50.00"),
+				"expected":  money("40.00"),
+				"links": []interface{}{
+					map[string]interface{}{"rel": "client", "href": "http://" + http.Address + "/clients/" + ktpNumber},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expectedResponse, http.Unmarshal(response))
+}
+
+type stubReporter struct {
+	report.Reporter
+}
+
+func (stubReporter) Portfolio(asOf time.Time) (report.Portfolio, error) {
+	return report.Portfolio{
+		AsOf:        asOf,
+		Loans:       2,
+		Outstanding: lms.Rupiah(200),
+		PAR:         []report.PARBucket{{Name: "PAR30", MinDaysPastDue: 30, Loans: 1, Outstanding: lms.Rupiah(50), BasisPoints: 2500}},
+	}, nil
+}
+
+func (stubReporter) Volumes(from, to time.Time) ([]report.DailyVolume, error) {
+	if to.Before(from) {
+		return nil, report.ErrInvalidPeriod
+	}
+	return []report.DailyVolume{{Date: from, Disbursements: 1, Disbursed: lms.Rupiah(100), Repaid: lms.Rupiah(0)}}, nil
+}
+
+func (stubReporter) WriteCreditReport(writer io.Writer, month time.Time, generatedAt time.Time) error {
+	if month.Month() == time.February {
+		return report.InvalidCreditRecordsStruct{Problems: []report.CreditRecordProblem{{KTPNumber: ktpNumber, LoanID: ktpNumber + "-1", Field: "gender"}}}
+	}
+	_, err := io.WriteString(writer, "HLND001    "+month.Format("200601")+"\n")
+	return err
+}
+
+func TestGetReports(t *testing.T) {
+	server := NewLoansServer(http.Address, "http://"+http.Address, lms.NewFakeLms(), stubReporter{})
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	t.Run("portfolio as JSON", func(t *testing.T) {
+		response, status := http.GetWithHeader("/reports/portfolio?asOf=2026-03-03", officer)
+		assert.Equal(t, 200, status)
+		assert.Equal(t, map[string]interface{}{
+			"asOf":        "2026-03-03",
+			"loans":       float64(2),
+			"outstanding": money("200.00"),
+			"par": []interface{}{
+				map[string]interface{}{"bucket": "PAR30", "minDaysPastDue": float64(30), "loans": float64(1), "outstanding": money("50.00"), "basisPoints": float64(2500)},
+			},
+		}, http.Unmarshal(response))
+	})
+	t.Run("volumes as CSV", func(t *testing.T) {
+		response, status := http.GetWithHeader("/reports/volumes?from=2026-01-01&to=2026-01-01&format=csv", officer)
+		assert.Equal(t, 200, status)
+		assert.Equal(t, "date,disbursements,disbursed,repayments,repaid,currency\n2026-01-01,1,100.00,0,0.00,IDR\n", response)
+	})
+	t.Run("invalid parameters", func(t *testing.T) {
+		response, status := http.GetWithHeader("/reports/portfolio?asOf=3.3.2026", officer)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "invalid_date", http.Unmarshal(response)["error"])
+		response, status = http.GetWithHeader("/reports/volumes?from=2026-02-01&to=2026-01-01", officer)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "invalid_period", http.Unmarshal(response)["error"])
+		response, status = http.GetWithHeader("/reports/portfolio?format=xml", officer)
+		assert.Equal(t, 400, status)
+		assert.Equal(t, "unknown_report_format", http.Unmarshal(response)["error"])
+	})
+	t.Run("without officer", func(t *testing.T) {
+		_, status := http.Get("/reports/portfolio")
+		assert.Equal(t, 401, status)
+		_, status = http.Get("/reports/volumes")
+		assert.Equal(t, 401, status)
+		_, status = http.Get("/reports/vintages")
+		assert.Equal(t, 401, status)
+		_, status = http.Get("/reports/slik")
+		assert.Equal(t, 401, status)
+	})
+	t.Run("credit report", func(t *testing.T) {
+		response, status := http.GetWithHeader("/reports/slik?month=2026-01", officer)
+		assert.Equal(t, 200, status)
+		assert.Equal(t, "HLND001    202601\n", response)
+		_, status = http.GetWithHeader("/reports/slik?month=2026-01-01", officer)
+		assert.Equal(t, 400, status)
+	})
+	t.Run("credit report with invalid records", func(t *testing.T) {
+		response, status := http.GetWithHeader("/reports/slik?month=2026-02", officer)
+		assert.Equal(t, 422, status)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"ktpNumber": ktpNumber,
+				"loanId":    ktpNumber + "-1",
+				"field":     "gender",
+				"links": []interface{}{
+					map[string]interface{}{"rel": "client", "href": "http://" + http.Address + "/clients/" + ktpNumber},
+				},
+			},
+		}, http.Unmarshal(response)["problems"])
+	})
+}
+
+type LmsWithWebhooks struct {
+	lms.Lms
+}
+
+var webhookSubscription = lms.WebhookSubscription{
+	ID:         "WHS0000000001",
+	URL:        "https://partner.example/hooks",
+	EventTypes: []string{"loan.state_changed"},
+	Secret:     "0123456789abcdef",
+	CreatedAt:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+func (*LmsWithWebhooks) SubscribeWebhook(subscriptionData lms.WebhookSubscriptionData) (lms.WebhookSubscription, error) {
+	if subscriptionData.URL == "" {
+		return lms.WebhookSubscription{}, errors.New("invalid_webhook_url")
+	}
+	return webhookSubscription, nil
+}
+
+func (*LmsWithWebhooks) WebhookSubscriptions() ([]lms.WebhookSubscription, error) {
+	return []lms.WebhookSubscription{webhookSubscription}, nil
+}
+
+func (*LmsWithWebhooks) WebhookSubscriptionByID(id string) (lms.WebhookSubscription, bool, error) {
+	return webhookSubscription, id == webhookSubscription.ID, nil
+}
+
+func (*LmsWithWebhooks) UnsubscribeWebhook(id string) error {
+	if id != webhookSubscription.ID {
+		return lms.ErrWebhookSubscriptionDoesNotExist
+	}
+	return nil
+}
+
+func (*LmsWithWebhooks) WebhookDeliveries(subscriptionID string) ([]lms.WebhookDelivery, error) {
+	if subscriptionID != webhookSubscription.ID {
+		return nil, lms.ErrWebhookSubscriptionDoesNotExist
+	}
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []lms.WebhookDelivery{
+		{
+			ID:             "WHD0000000001",
+			SubscriptionID: subscriptionID,
+			EventID:        "e1",
+			EventType:      "loan.state_changed",
+			Payload:        `{"id":"e1","type":"loan.state_changed"}`,
+			CreatedAt:      createdAt,
+			Status:         "pending",
+			Attempts:       []lms.WebhookAttempt{{At: createdAt, StatusCode: 503}},
+			NextAttemptAt:  createdAt.Add(30 * time.Second),
+		},
+	}, nil
+}
+
+func (*LmsWithWebhooks) ReplayWebhookDelivery(id string) (lms.WebhookDelivery, error) {
+	if id != "WHD0000000001" {
+		return lms.WebhookDelivery{}, lms.ErrWebhookDeliveryDoesNotExist
+	}
+	createdAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	return lms.WebhookDelivery{
+		ID:             "WHD0000000002",
+		SubscriptionID: webhookSubscription.ID,
+		EventID:        "e1",
+		EventType:      "loan.state_changed",
+		Payload:        `{"id":"e1","type":"loan.state_changed"}`,
+		CreatedAt:      createdAt,
+		ReplayOf:       id,
+		Status:         "delivered",
+		Attempts:       []lms.WebhookAttempt{{At: createdAt, StatusCode: 200}},
+	}, nil
+}
+
+func TestWebhooks(t *testing.T) {
+	server := newServer(&LmsWithWebhooks{Lms: lms.NewFakeLms()})
+	go server.Start()
+	defer server.Stop()
+	officer := map[string][]string{"X-Officer-Id": {"officer"}}
+	t.Run("should subscribe and return the secret once", func(t *testing.T) {
+		response, status, headers := http.PostWithHeader("/webhooks/subscriptions", `{"url":"https://partner.example/hooks","eventTypes":["loan.state_changed"]}`, officer)
+		assert.Equal(t, 201, status)
+		assert.Equal(t, server.publicURL+"/webhooks/subscriptions/WHS0000000001", headers.Get("Location"))
+		assert.Equal(t, "0123456789abcdef", http.Unmarshal(response)["secret"])
+		response, status = http.GetWithHeader("/webhooks/subscriptions/WHS0000000001", officer)
+		assert.Equal(t, 200, status)
+		assert.NotContains(t, http.Unmarshal(response), "secret")
+	})
+	t.Run("invalid subscription should be rejected", func(t *testing.T) {
+		_, status, _ := http.PostWithHeader("/webhooks/subscriptions", `{"eventTypes":["loan.state_changed"]}`, officer)
+		assert.Equal(t, 400, status)
+	})
+	t.Run("should list subscriptions", func(t *testing.T) {
+		response, status := http.GetWithHeader("/webhooks/subscriptions", officer)
+		assert.Equal(t, 200, status)
+		assert.Len(t, http.Unmarshal(response)["subscriptions"], 1)
+	})
+	t.Run("should list deliveries with attempts", func(t *testing.T) {
+		response, status := http.GetWithHeader("/webhooks/subscriptions/WHS0000000001/deliveries", officer)
+		assert.Equal(t, 200, status)
+		deliveries := http.Unmarshal(response)["deliveries"].([]interface{})
+		delivery := deliveries[0].(map[string]interface{})
+		assert.Equal(t, "pending", delivery["status"])
+		assert.Equal(t, map[string]interface{}{"id": "e1", "type": "loan.state_changed"}, delivery["payload"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"at": "2026-01-01T00:00:00Z", "statusCode": float64(503)}}, delivery["attempts"])
+		assert.Equal(t, "2026-01-01T00:00:30Z", delivery["nextAttemptAt"])
+		_, status = http.GetWithHeader("/webhooks/subscriptions/WHS0000000002/deliveries", officer)
+		assert.Equal(t, 404, status)
+	})
+	t.Run("should replay delivery", func(t *testing.T) {
+		response, status, _ := http.PostWithHeader("/webhooks/deliveries/WHD0000000001/replay", "", officer)
+		assert.Equal(t, 201, status)
+		replay := http.Unmarshal(response)
+		assert.Equal(t, "WHD0000000001", replay["replayOf"])
+		assert.NotContains(t, replay, "nextAttemptAt")
+		_, status, _ = http.PostWithHeader("/webhooks/deliveries/WHD0000000009/replay", "", officer)
+		assert.Equal(t, 404, status)
+	})
+	t.Run("should unsubscribe", func(t *testing.T) {
+		_, status := http.DeleteWithHeader("/webhooks/subscriptions/WHS0000000001", officer)
+		assert.Equal(t, 204, status)
+		_, status = http.DeleteWithHeader("/webhooks/subscriptions/WHS0000000002", officer)
+		assert.Equal(t, 404, status)
+	})
+	t.Run("should require officer", func(t *testing.T) {
+		_, status, _ := http.Post("/webhooks/subscriptions", `{"url":"https://partner.example/hooks","eventTypes":["loan.state_changed"]}`)
+		assert.Equal(t, 401, status)
+		_, status = http.Get("/webhooks/subscriptions")
+		assert.Equal(t, 401, status)
+		_, status = http.Get("/webhooks/subscriptions/WHS0000000001")
+		assert.Equal(t, 401, status)
+		_, status = http.Get("/webhooks/subscriptions/WHS0000000001/deliveries")
+		assert.Equal(t, 401, status)
+		_, status, _ = http.Post("/webhooks/deliveries/WHD0000000001/replay", "")
+		assert.Equal(t, 401, status)
+		_, status = http.Delete("/webhooks/subscriptions/WHS0000000001")
+		assert.Equal(t, 401, status)
+	})
+}
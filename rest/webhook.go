@@ -0,0 +1,261 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/briyanadityatama/goLoans/rest/rest"
+)
+
+// postWebhookSubscriptions subscribes a partner URL to events, the secret is returned only in this response
+func (server *LoansServer) postWebhookSubscriptions(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	var subscriptionRequest postWebhookSubscriptionsRequest
+	err := request.ReadJSONBody(&subscriptionRequest)
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	subscription, err := server.lms.SubscribeWebhook(lms.WebhookSubscriptionData{
+		URL:        subscriptionRequest.URL,
+		EventTypes: subscriptionRequest.EventTypes,
+		Secret:     subscriptionRequest.Secret,
+	})
+	if err == lms.ErrWebhooksDisabled {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		writer.WriteJSONError(err, 400)
+		return
+	}
+	response := server.webhookSubscriptionResponse(subscription)
+	response.Secret = subscription.Secret
+	writer.Header().Add("Location", server.publicURL+"/webhooks/subscriptions/"+subscription.ID)
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(201)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem subscribing webhook %s: %s", subscriptionRequest.URL, err.Error())
+	}
+}
+
+func (server *LoansServer) getWebhookSubscriptions(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	subscriptions, err := server.lms.WebhookSubscriptions()
+	if err == lms.ErrWebhooksDisabled {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem listing webhook subscriptions: %s", err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := getWebhookSubscriptionsResponse{Subscriptions: []webhookSubscriptionResponse{}}
+	for _, subscription := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, server.webhookSubscriptionResponse(subscription))
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem listing webhook subscriptions: %s", err.Error())
+	}
+}
+
+func (server *LoansServer) getWebhookSubscription(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	id := request.URL.Path[len("/webhooks/subscriptions/"):]
+	subscription, found, err := server.lms.WebhookSubscriptionByID(id)
+	if err == lms.ErrWebhooksDisabled {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem getting webhook subscription %s: %s", id, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	if !found {
+		writer.WriteJSONError(lms.ErrWebhookSubscriptionDoesNotExist, 404)
+		return
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(server.webhookSubscriptionResponse(subscription))
+	if err != nil {
+		log.Printf("[WARN] problem getting webhook subscription %s: %s", id, err.Error())
+	}
+}
+
+func (server *LoansServer) deleteWebhookSubscription(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	id := request.URL.Path[len("/webhooks/subscriptions/"):]
+	err := server.lms.UnsubscribeWebhook(id)
+	if err == lms.ErrWebhooksDisabled || err == lms.ErrWebhookSubscriptionDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem unsubscribing webhook %s: %s", id, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+// getWebhookDeliveries is the delivery log of the subscription with every attempt
+func (server *LoansServer) getWebhookDeliveries(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	id := strings.TrimSuffix(request.URL.Path[len("/webhooks/subscriptions/"):], "/deliveries")
+	deliveries, err := server.lms.WebhookDeliveries(id)
+	if err == lms.ErrWebhooksDisabled || err == lms.ErrWebhookSubscriptionDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem listing deliveries of webhook %s: %s", id, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	response := getWebhookDeliveriesResponse{Deliveries: []webhookDeliveryResponse{}}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, server.webhookDeliveryResponse(delivery))
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	err = writer.WriteJSON(response)
+	if err != nil {
+		log.Printf("[WARN] problem listing deliveries of webhook %s: %s", id, err.Error())
+	}
+}
+
+// postWebhookDeliveryReplay posts the payload of the delivery again, the replay is a new delivery
+func (server *LoansServer) postWebhookDeliveryReplay(writer *rest.ResponseWriter, request *rest.Request) {
+	if !server.authorizeOfficer(writer, request) {
+		return
+	}
+	id := strings.TrimSuffix(request.URL.Path[len("/webhooks/deliveries/"):], "/replay")
+	delivery, err := server.lms.ReplayWebhookDelivery(id)
+	if err == lms.ErrWebhooksDisabled || err == lms.ErrWebhookDeliveryDoesNotExist || err == lms.ErrWebhookSubscriptionDoesNotExist {
+		writer.WriteJSONError(err, 404)
+		return
+	}
+	if err != nil {
+		errorDto := fmt.Sprintf("problem replaying webhook delivery %s: %s", id, err.Error())
+		writer.WriteJSONError(technicalError{errors.New("server_error"), errorDto}, 500)
+		return
+	}
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(201)
+	err = writer.WriteJSON(server.webhookDeliveryResponse(delivery))
+	if err != nil {
+		log.Printf("[WARN] problem replaying webhook delivery %s: %s", id, err.Error())
+	}
+}
+
+func (server *LoansServer) webhookSubscriptionResponse(subscription lms.WebhookSubscription) webhookSubscriptionResponse {
+	subscriptionURL := server.publicURL + "/webhooks/subscriptions/" + subscription.ID
+	return webhookSubscriptionResponse{
+		ID:         subscription.ID,
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt,
+		Links: []link{
+			{"self", subscriptionURL},
+			{"deliveries", subscriptionURL + "/deliveries"},
+		},
+	}
+}
+
+func (server *LoansServer) webhookDeliveryResponse(delivery lms.WebhookDelivery) webhookDeliveryResponse {
+	response := webhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        json.RawMessage(delivery.Payload),
+		CreatedAt:      delivery.CreatedAt,
+		ReplayOf:       delivery.ReplayOf,
+		Status:         delivery.Status,
+		Attempts:       []webhookAttemptResponse{},
+		Links:          []link{{"replay", server.publicURL + "/webhooks/deliveries/" + delivery.ID + "/replay"}},
+	}
+	for _, attempt := range delivery.Attempts {
+		response.Attempts = append(response.Attempts, webhookAttemptResponse{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+		})
+	}
+	if !delivery.NextAttemptAt.IsZero() {
+		nextAttemptAt := delivery.NextAttemptAt
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	return response
+}
+
+// postWebhookSubscriptionsRequest DTO for JSON unmarshaling
+type postWebhookSubscriptionsRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Secret     string   `json:"secret"`
+}
+
+// getWebhookSubscriptionsResponse DTO for JSON marshaling
+type getWebhookSubscriptionsResponse struct {
+	Subscriptions []webhookSubscriptionResponse `json:"subscriptions"`
+}
+
+// webhookSubscriptionResponse DTO for JSON marshaling
+type webhookSubscriptionResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Links      []link    `json:"links"`
+}
+
+// getWebhookDeliveriesResponse DTO for JSON marshaling
+type getWebhookDeliveriesResponse struct {
+	Deliveries []webhookDeliveryResponse `json:"deliveries"`
+}
+
+// webhookDeliveryResponse DTO for JSON marshaling
+type webhookDeliveryResponse struct {
+	ID             string                   `json:"id"`
+	SubscriptionID string                   `json:"subscriptionId"`
+	EventID        string                   `json:"eventId"`
+	EventType      string                   `json:"eventType"`
+	Payload        json.RawMessage          `json:"payload"`
+	CreatedAt      time.Time                `json:"createdAt"`
+	ReplayOf       string                   `json:"replayOf,omitempty"`
+	Status         string                   `json:"status"`
+	Attempts       []webhookAttemptResponse `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"nextAttemptAt,omitempty"`
+	Links          []link                   `json:"links"`
+}
+
+// webhookAttemptResponse DTO for JSON marshaling
+type webhookAttemptResponse struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
@@ -0,0 +1,107 @@
+// Package statement reads incoming payments from bank statement files, so they can be reconciled by lms.Lms
+package statement
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+)
+
+// ParseCSV reads a statement with header line and columns: date (YYYY-MM-DD), amount, reference and bankReference
+func ParseCSV(reader io.Reader) ([]lms.StatementLine, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 4
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV statement: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("reading CSV statement: header is missing")
+	}
+	var lines []lms.StatementLine
+	for i, record := range records[1:] {
+		date, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV statement line %d: invalid date %s", i+2, record[0])
+		}
+		amount, err := parseAmount(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV statement line %d: invalid amount %s", i+2, record[1])
+		}
+		lines = append(lines, lms.StatementLine{
+			Date:          date,
+			Amount:        amount,
+			Reference:     record[2],
+			BankReference: record[3],
+		})
+	}
+	return lines, nil
+}
+
+// Fixed-width record layout inspired by MT940 statement line (:61:) with the payer reference (:86:) appended
+const (
+	dateEnd          = 8  // value date YYYYMMDD
+	markEnd          = 9  // C for credit, D for debit
+	amountEnd        = 24 // 15 digits padded with zeros
+	bankReferenceEnd = 40 // 16 characters padded with spaces
+	recordLength     = 74 // payer reference, 34 characters padded with spaces
+)
+
+// ParseFixedWidth reads a statement of fixed-width records. Debit records are skipped, because only incoming payments
+// can be repayments.
+func ParseFixedWidth(reader io.Reader) ([]lms.StatementLine, error) {
+	var lines []lms.StatementLine
+	scanner := bufio.NewScanner(reader)
+	for number := 1; scanner.Scan(); number++ {
+		record := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		if len(record) < bankReferenceEnd || len(record) > recordLength {
+			return nil, fmt.Errorf("reading fixed-width statement line %d: record length should be between %d and %d", number, bankReferenceEnd, recordLength)
+		}
+		mark := record[dateEnd:markEnd]
+		if mark == "D" {
+			continue
+		}
+		if mark != "C" {
+			return nil, fmt.Errorf("reading fixed-width statement line %d: invalid debit/credit mark %s", number, mark)
+		}
+		date, err := time.Parse("20060102", record[:dateEnd])
+		if err != nil {
+			return nil, fmt.Errorf("reading fixed-width statement line %d: invalid date %s", number, record[:dateEnd])
+		}
+		amount, err := parseAmount(record[markEnd:amountEnd])
+		if err != nil {
+			return nil, fmt.Errorf("reading fixed-width statement line %d: invalid amount %s", number, record[markEnd:amountEnd])
+		}
+		lines = append(lines, lms.StatementLine{
+			Date:          date,
+			Amount:        amount,
+			BankReference: strings.TrimSpace(record[amountEnd:bankReferenceEnd]),
+			Reference:     strings.TrimSpace(record[bankReferenceEnd:]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading fixed-width statement: %v", err)
+	}
+	return lines, nil
+}
+
+// parseAmount reads positive rupiah amount, statements of our bank accounts are always in IDR
+func parseAmount(text string) (lms.Money, error) {
+	amount, err := lms.ParseRupiah(text)
+	if err != nil {
+		return amount, err
+	}
+	if amount.IsNegative() {
+		return amount, fmt.Errorf("negative amount %s", text)
+	}
+	return amount, nil
+}
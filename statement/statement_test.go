@@ -0,0 +1,64 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/briyanadityatama/goLoans/lms"
+	"github.com/stretchr/testify/assert"
+)
+
+var date = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+func TestParseCSV(t *testing.T) {
+	lines, err := ParseCSV(strings.NewReader("date,amount,reference,bankReference\n" +
+		"2026-01-05,150000,LOAN 3522582509010002,B0001\n" +
+		"2026-01-05, 20000,\"Doe, thanks\",B0002\n" +
+		"2026-01-05,0.50,LOAN 3522582509010002,B0003\n"))
+	assert.Nil(t, err)
+	halfRupiah, _ := lms.ParseRupiah("0.5")
+	assert.Equal(t, []lms.StatementLine{
+		{Date: date, Amount: lms.Rupiah(150000), Reference: "LOAN 3522582509010002", BankReference: "B0001"},
+		{Date: date, Amount: lms.Rupiah(20000), Reference: "Doe, thanks", BankReference: "B0002"},
+		{Date: date, Amount: halfRupiah, Reference: "LOAN 3522582509010002", BankReference: "B0003"},
+	}, lines)
+}
+
+func TestParseCSVWithInvalidLine(t *testing.T) {
+	for _, statement := range []string{
+		"",
+		"date,amount,reference,bankReference\n5.1.2026,1,ref,B1\n",
+		"date,amount,reference,bankReference\n2026-01-05,-1,ref,B1\n",
+		"date,amount,reference,bankReference\n2026-01-05,1,ref\n",
+		"date,amount,reference,bankReference\n2026-01-05,1.001,ref,B1\n",
+	} {
+		_, err := ParseCSV(strings.NewReader(statement))
+		assert.NotNil(t, err, statement)
+	}
+}
+
+func TestParseFixedWidth(t *testing.T) {
+	lines, err := ParseFixedWidth(strings.NewReader(
+		"20260105C000000000150000B0001           LOAN 3522582509010002\n" +
+			"20260105D000000000001000B0002           BANK FEE\n" +
+			"\n" +
+			"20260105C000000000020000B0003           \r\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []lms.StatementLine{
+		{Date: date, Amount: lms.Rupiah(150000), Reference: "LOAN 3522582509010002", BankReference: "B0001"},
+		{Date: date, Amount: lms.Rupiah(20000), BankReference: "B0003"},
+	}, lines)
+}
+
+func TestParseFixedWidthWithInvalidLine(t *testing.T) {
+	for _, statement := range []string{
+		"20260105C000000000150000\n",
+		"20260105X000000000150000B0001           REF\n",
+		"2026010XC000000000150000B0001           REF\n",
+		"20260105C0000000001500X0B0001           REF\n",
+	} {
+		_, err := ParseFixedWidth(strings.NewReader(statement))
+		assert.NotNil(t, err, statement)
+	}
+}
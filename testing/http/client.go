@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // Address with available port which can be used for starting a server
@@ -32,6 +33,23 @@ func Get(path string) (responseBody string, status int) {
 	return
 }
 
+// GetWithHeader runs HTTP GET method with additional request headers
+func GetWithHeader(path string, header http.Header) (responseBody string, status int) {
+	response := doWithHeader("GET", path, nil, header)
+	responseBody = readResponseBody(response)
+	status = response.StatusCode
+	return
+}
+
+// GetWithResponseHeader runs HTTP GET method and returns response headers too
+func GetWithResponseHeader(path string) (responseBody string, status int, header http.Header) {
+	response := do("GET", path, nil)
+	responseBody = readResponseBody(response)
+	status = response.StatusCode
+	header = response.Header
+	return
+}
+
 func readResponseBody(response *http.Response) string {
 	bytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
@@ -40,6 +58,21 @@ func readResponseBody(response *http.Response) string {
 	return string(bytes)
 }
 
+// GetWithoutRedirect runs HTTP GET method and returns Location header instead of following the redirect
+func GetWithoutRedirect(path string) (location string, status int) {
+	request, err := http.NewRequest("GET", "http://"+Address+path, nil)
+	if err != nil {
+		log.Panicf("http request creation failed GET %s: %s", path, err)
+	}
+	waitForServer()
+	response, err := client.Transport.RoundTrip(request)
+	if err != nil {
+		log.Panicf("http request failed for GET %s: %s", path, err)
+	}
+	response.Body.Close()
+	return response.Header.Get("Location"), response.StatusCode
+}
+
 // Options runs HTTP OPTIONS method
 func Options(path string) (allowHeader string, status int) {
 	response := do("OPTIONS", path, nil)
@@ -57,19 +90,75 @@ func Post(path string, body string) (responseBody string, status int, header htt
 	return
 }
 
+// PostWithHeader runs HTTP POST method with additional request headers
+func PostWithHeader(path string, body string, header http.Header) (responseBody string, status int, responseHeader http.Header) {
+	response := doWithHeader("POST", path, strings.NewReader(body), header)
+	responseBody = readResponseBody(response)
+	status = response.StatusCode
+	responseHeader = response.Header
+	return
+}
+
+// Patch runs HTTP PATCH method
+func Patch(path string, body string) (responseBody string, status int) {
+	response := do("PATCH", path, strings.NewReader(body))
+	responseBody = readResponseBody(response)
+	status = response.StatusCode
+	return
+}
+
+// Delete runs HTTP DELETE method
+func Delete(path string) (responseBody string, status int) {
+	response := do("DELETE", path, nil)
+	responseBody = readResponseBody(response)
+	status = response.StatusCode
+	return
+}
+
+// DeleteWithHeader runs HTTP DELETE method with additional request headers
+func DeleteWithHeader(path string, header http.Header) (responseBody string, status int) {
+	response := doWithHeader("DELETE", path, nil, header)
+	responseBody = readResponseBody(response)
+	status = response.StatusCode
+	return
+}
+
+// client does not reuse connections, because every test starts and stops its own server on the same Address
+var client = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
 func do(method, path string, body io.Reader) (response *http.Response) {
+	return doWithHeader(method, path, body, nil)
+}
+
+func doWithHeader(method, path string, body io.Reader, header http.Header) (response *http.Response) {
 	url := "http://" + Address + path
 	request, err := http.NewRequest(method, url, body)
 	if err != nil {
 		log.Panicf("http request creation failed %s %s: %s", method, path, err)
 	}
-	response, err = http.DefaultClient.Do(request)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	waitForServer()
+	response, err = client.Do(request)
 	if err != nil {
 		log.Panicf("http request failed for %s %s: %s", method, path, err)
 	}
 	return response
 }
 
+// waitForServer gives server started in a different goroutine a chance to start listening on Address
+func waitForServer() {
+	for i := 0; i < 50; i++ {
+		conn, err := net.Dial("tcp", Address)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 // Unmarshal the string and return map
 func Unmarshal(response string) map[string]interface{} {
 	returnedError := make(map[string]interface{})